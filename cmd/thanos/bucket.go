@@ -15,8 +15,10 @@ import (
 	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
+	"github.com/thanos-io/thanos/pkg/model"
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/client"
+	"github.com/thanos-io/thanos/pkg/replicate"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	"github.com/thanos-io/thanos/pkg/ui"
 	"github.com/thanos-io/thanos/pkg/verifier"
@@ -30,6 +32,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/route"
+	promlabels "github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/tsdb/labels"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
@@ -61,6 +65,7 @@ func registerBucket(m map[string]setupFunc, app *kingpin.Application, name strin
 	registerBucketLs(m, cmd, name, objStoreConfig)
 	registerBucketInspect(m, cmd, name, objStoreConfig)
 	registerBucketWeb(m, cmd, name, objStoreConfig)
+	registerBucketReplicate(m, cmd, name, objStoreConfig)
 }
 
 func registerBucketVerify(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *pathOrContent) {
@@ -190,13 +195,17 @@ func registerBucketLs(m map[string]setupFunc, root *kingpin.CmdClause, name stri
 				if err != nil {
 					return err
 				}
+				size, err := block.Size(ctx, bkt, id)
+				if err != nil {
+					return err
+				}
 
 				minTime := time.Unix(m.MinTime/1000, 0)
 				maxTime := time.Unix(m.MaxTime/1000, 0)
 
-				if _, err = fmt.Fprintf(os.Stdout, "%s -- %s - %s Diff: %s, Compaction: %d, Downsample: %d, Source: %s\n",
+				if _, err = fmt.Fprintf(os.Stdout, "%s -- %s - %s Diff: %s, Compaction: %d, Downsample: %d, Source: %s, Size: %s\n",
 					m.ULID, minTime.Format("2006-01-02 15:04"), maxTime.Format("2006-01-02 15:04"), maxTime.Sub(minTime),
-					m.Compaction.Level, m.Thanos.Downsample.Resolution, m.Thanos.Source); err != nil {
+					m.Compaction.Level, m.Thanos.Downsample.Resolution, m.Thanos.Source, humanizeBytes(size)); err != nil {
 					return err
 				}
 				return nil
@@ -347,6 +356,101 @@ func registerBucketWeb(m map[string]setupFunc, root *kingpin.CmdClause, name str
 	}
 }
 
+// registerBucketReplicate registers a command copying blocks from the bucket to another one.
+func registerBucketReplicate(m map[string]setupFunc, root *kingpin.CmdClause, name string, objStoreConfig *pathOrContent) {
+	cmd := root.Command("replicate", "Replicate blocks from the bucket to another bucket, e.g. to migrate between providers or for disaster recovery")
+	httpAddr := regHTTPAddrFlag(cmd)
+	toObjStoreConfig := regCommonObjStoreFlags(cmd, "-to", true, "The object storage which blocks are replicated to.")
+	matcherStrs := cmd.Flag("matcher", "Only blocks whose external labels match this matcher will be replicated, e.g. 'cluster=~\"eu.*\"'. All matchers must match. Repeated flag.").
+		PlaceHolder("<name><op>\"<value>\"").Strings()
+	resolutions := cmd.Flag("resolution", "Only blocks with these resolutions will be replicated. Repeated flag.").
+		Default("0s", "5m", "1h").DurationList()
+	compactionLevels := cmd.Flag("compaction", "Only blocks with these compaction levels will be replicated. Repeated flag.").
+		Default("1", "2", "3", "4").Ints()
+	minTime := model.TimeOrDuration(cmd.Flag("min-time", "Start of time range limit. Only blocks overlapping the time range are replicated. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
+		Default("0000-01-01T00:00:00Z"))
+	maxTime := model.TimeOrDuration(cmd.Flag("max-time", "End of time range limit. Only blocks overlapping the time range are replicated. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
+		Default("9999-12-31T23:59:59Z"))
+	interval := cmd.Flag("interval", "If set, replication is repeated with this interval and the HTTP server with metrics is started. Otherwise blocks are replicated once.").
+		Default("0s").Duration()
+
+	m[name+" replicate"] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, _ opentracing.Tracer, _ bool) error {
+		matchers, err := parseFlagMatchers(*matcherStrs)
+		if err != nil {
+			return errors.Wrap(err, "parse matchers")
+		}
+
+		filter := replicate.BlockFilter{
+			Matchers:         matchers,
+			CompactionLevels: *compactionLevels,
+			MinTime:          *minTime,
+			MaxTime:          *maxTime,
+		}
+		for _, r := range *resolutions {
+			filter.Resolutions = append(filter.Resolutions, r.Nanoseconds()/int64(time.Millisecond))
+		}
+
+		fromConfContentYaml, err := objStoreConfig.Content()
+		if err != nil {
+			return err
+		}
+		fromBkt, err := client.NewBucket(logger, fromConfContentYaml, reg, name)
+		if err != nil {
+			return err
+		}
+
+		toConfContentYaml, err := toObjStoreConfig.Content()
+		if err != nil {
+			runutil.CloseWithLogOnErr(logger, fromBkt, "source bucket client")
+			return err
+		}
+		// nil Prometheus registerer: don't create conflicting metrics.
+		toBkt, err := client.NewBucket(logger, toConfContentYaml, nil, name)
+		if err != nil {
+			runutil.CloseWithLogOnErr(logger, fromBkt, "source bucket client")
+			return err
+		}
+
+		replicator := replicate.NewReplicator(logger, reg, fromBkt, toBkt, filter, "")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		g.Add(func() error {
+			defer runutil.CloseWithLogOnErr(logger, fromBkt, "source bucket client")
+			defer runutil.CloseWithLogOnErr(logger, toBkt, "destination bucket client")
+
+			if *interval == 0 {
+				return replicator.Replicate(ctx)
+			}
+			return runutil.Repeat(*interval, ctx.Done(), func() error {
+				if err := replicator.Replicate(ctx); err != nil {
+					level.Error(logger).Log("msg", "replication failed", "err", err)
+				}
+				return nil
+			})
+		}, func(error) {
+			cancel()
+		})
+
+		if *interval == 0 {
+			return nil
+		}
+		return metricHTTPListenGroup(g, logger, reg, *httpAddr)
+	}
+}
+
+// parseFlagMatchers parses matchers in the <name><op>"<value>" format, e.g. cluster=~"eu.*".
+func parseFlagMatchers(s []string) ([]*promlabels.Matcher, error) {
+	var matchers []*promlabels.Matcher
+	for _, m := range s {
+		ms, err := promql.ParseMetricSelector("{" + m + "}")
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse matcher %q", m)
+		}
+		matchers = append(matchers, ms...)
+	}
+	return matchers, nil
+}
+
 // refresh metadata from remote storage periodically and update UI.
 func refresh(ctx context.Context, logger log.Logger, bucketUI *ui.Bucket, duration time.Duration, timeout time.Duration, name string, reg *prometheus.Registry, objStoreConfig *pathOrContent) error {
 	confContentYaml, err := objStoreConfig.Content()
@@ -360,12 +464,15 @@ func refresh(ctx context.Context, logger log.Logger, bucketUI *ui.Bucket, durati
 	}
 
 	defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+
+	// Blocks are immutable, so their sizes are only computed once.
+	sizes := map[ulid.ULID]int64{}
 	return runutil.Repeat(duration, ctx.Done(), func() error {
 		return runutil.RetryWithLog(logger, time.Minute, ctx.Done(), func() error {
 			iterCtx, iterCancel := context.WithTimeout(ctx, timeout)
 			defer iterCancel()
 
-			blocks, err := download(iterCtx, logger, bkt)
+			blocks, err := download(iterCtx, logger, bkt, sizes)
 			if err != nil {
 				bucketUI.Set("[]", err)
 				return err
@@ -382,9 +489,18 @@ func refresh(ctx context.Context, logger log.Logger, bucketUI *ui.Bucket, durati
 	})
 }
 
-func download(ctx context.Context, logger log.Logger, bkt objstore.Bucket) (blocks []metadata.Meta, err error) {
+// blockWithSize is a block meta with the total size of the block objects, as shown in the bucket web UI.
+type blockWithSize struct {
+	metadata.Meta
+	Size int64 `json:"size"`
+}
+
+// download returns the metas of all blocks in the bucket with their sizes. Sizes of blocks missing in the sizes
+// cache are computed and added to it, sizes of blocks not in the bucket anymore are removed.
+func download(ctx context.Context, logger log.Logger, bkt objstore.Bucket, sizes map[ulid.ULID]int64) (blocks []blockWithSize, err error) {
 	level.Info(logger).Log("msg", "synchronizing block metadata")
 
+	seen := map[ulid.ULID]struct{}{}
 	if err = bkt.Iter(ctx, "", func(name string) error {
 		id, ok := block.IsBlockDir(name)
 		if !ok {
@@ -396,17 +512,47 @@ func download(ctx context.Context, logger log.Logger, bkt objstore.Bucket) (bloc
 			return err
 		}
 
-		blocks = append(blocks, meta)
+		size, ok := sizes[id]
+		if !ok {
+			size, err = block.Size(ctx, bkt, id)
+			if err != nil {
+				return err
+			}
+			sizes[id] = size
+		}
+		seen[id] = struct{}{}
+
+		blocks = append(blocks, blockWithSize{Meta: meta, Size: size})
 		return nil
 	}); err != nil {
 		level.Error(logger).Log("err", err, "msg", "Failed to downloaded block metadata")
 		return blocks, err
 	}
 
+	for id := range sizes {
+		if _, ok := seen[id]; !ok {
+			delete(sizes, id)
+		}
+	}
+
 	level.Info(logger).Log("msg", "downloaded blocks meta.json", "num", len(blocks))
 	return blocks, nil
 }
 
+// humanizeBytes formats the given number of bytes using binary prefixes, e.g. 1.5 MiB.
+func humanizeBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
 func printTable(blockMetas []*metadata.Meta, selectorLabels labels.Labels, sortBy []string) error {
 	header := inspectColumns
 
@@ -96,7 +96,7 @@ func registerCompact(m map[string]setupFunc, app *kingpin.Application) {
 	wait := cmd.Flag("wait", "Do not exit after all compactions have been processed and wait for new work.").
 		Short('w').Bool()
 
-	generateMissingIndexCacheFiles := cmd.Flag("index.generate-missing-cache-file", "If enabled, on startup compactor runs an on-off job that scans all the blocks to find all blocks with missing index cache file. It generates those if needed and upload.").
+	generateMissingIndexCacheFiles := cmd.Flag("index.generate-missing-cache-file", "If enabled, on startup compactor runs an on-off job that scans all the blocks to find all blocks with missing index cache or binary index header file. It generates those if needed and upload.").
 		Hidden().Default("false").Bool()
 
 	// TODO(bplotka): Remove this flag once https://github.com/thanos-io/thanos/issues/297 is fixed.
@@ -113,6 +113,9 @@ func registerCompact(m map[string]setupFunc, app *kingpin.Application) {
 	compactionConcurrency := cmd.Flag("compact.concurrency", "Number of goroutines to use when compacting groups.").
 		Default("1").Int()
 
+	blockUploadConcurrency := cmd.Flag("block-upload-concurrency", "Number of goroutines to use when uploading files of a compacted block to object storage.").
+		Default("1").Int()
+
 	m[comp.String()] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, _ bool) error {
 		return runCompact(g, logger, reg,
 			*httpAddr,
@@ -133,6 +136,7 @@ func registerCompact(m map[string]setupFunc, app *kingpin.Application) {
 			*maxCompactionLevel,
 			*blockSyncConcurrency,
 			*compactionConcurrency,
+			*blockUploadConcurrency,
 		)
 	}
 }
@@ -155,6 +159,7 @@ func runCompact(
 	maxCompactionLevel int,
 	blockSyncConcurrency int,
 	concurrency int,
+	blockUploadConcurrency int,
 ) error {
 	halted := prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "thanos_compactor_halted",
@@ -195,7 +200,7 @@ func runCompact(
 	}()
 
 	sy, err := compact.NewSyncer(logger, reg, bkt, consistencyDelay,
-		blockSyncConcurrency, acceptMalformedIndex)
+		blockSyncConcurrency, acceptMalformedIndex, blockUploadConcurrency)
 	if err != nil {
 		return errors.Wrap(err, "create syncer")
 	}
@@ -332,7 +337,7 @@ func runCompact(
 	return nil
 }
 
-// genMissingIndexCacheFiles scans over all blocks, generates missing index cache files and uploads them to object storage.
+// genMissingIndexCacheFiles scans over all blocks, generates missing index cache and index header files and uploads them to object storage.
 func genMissingIndexCacheFiles(ctx context.Context, logger log.Logger, bkt objstore.Bucket, dir string) error {
 	if err := os.RemoveAll(dir); err != nil {
 		return errors.Wrap(err, "clean index cache directory")
@@ -422,15 +427,25 @@ func generateIndexCacheFile(
 		}
 	}()
 
-	cachePath := filepath.Join(bdir, block.IndexCacheFilename)
-	cache := path.Join(meta.ULID.String(), block.IndexCacheFilename)
-
-	ok, err := objstore.Exists(ctx, bkt, cache)
-	if ok {
-		return nil
+	type indexFile struct {
+		filename string
+		write    func(logger log.Logger, indexFn string, fn string) error
 	}
-	if err != nil {
-		return errors.Wrapf(err, "attempt to check if a cached index file exists")
+	var missing []indexFile
+	for _, f := range []indexFile{
+		{filename: block.IndexCacheFilename, write: block.WriteIndexCache},
+		{filename: block.IndexHeaderFilename, write: block.WriteIndexHeader},
+	} {
+		ok, err := objstore.Exists(ctx, bkt, path.Join(id.String(), f.filename))
+		if err != nil {
+			return errors.Wrapf(err, "attempt to check if %s file exists", f.filename)
+		}
+		if !ok {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
 	}
 
 	level.Debug(logger).Log("msg", "make index cache", "block", id)
@@ -443,12 +458,14 @@ func generateIndexCacheFile(
 		return errors.Wrap(err, "download index file")
 	}
 
-	if err := block.WriteIndexCache(logger, indexPath, cachePath); err != nil {
-		return errors.Wrap(err, "write index cache")
-	}
-
-	if err := objstore.UploadFile(ctx, logger, bkt, cachePath, cache); err != nil {
-		return errors.Wrap(err, "upload index cache")
+	for _, f := range missing {
+		localPath := filepath.Join(bdir, f.filename)
+		if err := f.write(logger, indexPath, localPath); err != nil {
+			return errors.Wrapf(err, "write %s", f.filename)
+		}
+		if err := objstore.UploadFile(ctx, logger, bkt, localPath, path.Join(id.String(), f.filename)); err != nil {
+			return errors.Wrapf(err, "upload %s", f.filename)
+		}
 	}
 	return nil
 }
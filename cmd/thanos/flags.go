@@ -50,6 +50,11 @@ func regHTTPAddrFlag(cmd *kingpin.CmdClause) *string {
 	return cmd.Flag("http-address", "Listen host:port for HTTP endpoints.").Default("0.0.0.0:10902").String()
 }
 
+func regShipperResumeUploadsFlag(cmd *kingpin.CmdClause) *bool {
+	return cmd.Flag("shipper.resume-uploads", "If true, files of a block left in the bucket by a failed upload are kept and not uploaded again by the next attempt, instead of removing the partial upload. Files are compared by their checksum if the object storage provider exposes one, otherwise by their size.").
+		Default("false").Bool()
+}
+
 func modelDuration(flags *kingpin.FlagClause) *model.Duration {
 	value := new(model.Duration)
 	flags.SetValue(value)
@@ -133,3 +138,44 @@ func regCommonTracingFlags(app *kingpin.Application) *pathOrContent {
 		content: tracingConf,
 	}
 }
+
+func regCacheFlags(cmd *kingpin.CmdClause, name, extraHelp string) *pathOrContent {
+	fileFlagName := fmt.Sprintf("%s.config-file", name)
+	contentFlagName := fmt.Sprintf("%s.config", name)
+	desc := strings.Replace(name, "-", " ", -1)
+
+	help := fmt.Sprintf("Path to YAML file that contains %s configuration. %s", desc, extraHelp)
+	confFile := cmd.Flag(fileFlagName, help).PlaceHolder(fmt.Sprintf("<%s.config-yaml-path>", name)).String()
+
+	help = fmt.Sprintf("Alternative to '%s' flag. %s configuration in YAML.", fileFlagName, strings.ToUpper(desc[:1])+desc[1:])
+	conf := cmd.Flag(contentFlagName, help).PlaceHolder(fmt.Sprintf("<%s.config-yaml>", name)).String()
+
+	return &pathOrContent{
+		fileFlagName:    fileFlagName,
+		contentFlagName: contentFlagName,
+		required:        false,
+
+		path:    confFile,
+		content: conf,
+	}
+}
+
+func regSelectorRelabelFlags(cmd *kingpin.CmdClause) *pathOrContent {
+	fileFlagName := "selector.relabel-config-file"
+	contentFlagName := "selector.relabel-config"
+
+	help := "Path to YAML file that contains relabeling configuration that allows selecting blocks. It follows native Prometheus relabel-config syntax. Blocks are selected by their external labels, blocks dropped by the relabeling are not served."
+	relabelConfFile := cmd.Flag(fileFlagName, help).PlaceHolder("<file-path>").String()
+
+	help = fmt.Sprintf("Alternative to '%s' flag. Relabeling configuration that allows selecting blocks in YAML.", fileFlagName)
+	relabelConf := cmd.Flag(contentFlagName, help).PlaceHolder("<content>").String()
+
+	return &pathOrContent{
+		fileFlagName:    fileFlagName,
+		contentFlagName: contentFlagName,
+		required:        false,
+
+		path:    relabelConfFile,
+		content: relabelConf,
+	}
+}
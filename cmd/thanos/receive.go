@@ -44,6 +44,7 @@ func registerReceive(m map[string]setupFunc, app *kingpin.Application, name stri
 	labelStrs := cmd.Flag("labels", "External labels to announce. This flag will be removed in the future when handling multiple tsdb instances is added.").PlaceHolder("key=\"value\"").Strings()
 
 	objStoreConfig := regCommonObjStoreFlags(cmd, "", false)
+	resumeUploads := regShipperResumeUploadsFlag(cmd)
 
 	retention := modelDuration(cmd.Flag("tsdb.retention", "How long to retain raw samples on local storage. 0d - disables this retention").Default("15d"))
 
@@ -100,6 +101,7 @@ func registerReceive(m map[string]setupFunc, app *kingpin.Application, name stri
 			*remoteWriteAddress,
 			*dataDir,
 			objStoreConfig,
+			*resumeUploads,
 			lset,
 			*retention,
 			cw,
@@ -124,6 +126,7 @@ func runReceive(
 	remoteWriteAddress string,
 	dataDir string,
 	objStoreConfig *pathOrContent,
+	resumeUploads bool,
 	lset labels.Labels,
 	retention model.Duration,
 	cw *receive.ConfigWatcher,
@@ -321,7 +324,7 @@ func runReceive(
 			}
 		}()
 
-		s := shipper.New(logger, reg, dataDir, bkt, func() labels.Labels { return lset }, metadata.ReceiveSource)
+		s := shipper.New(logger, reg, dataDir, bkt, func() labels.Labels { return lset }, metadata.ReceiveSource, resumeUploads)
 
 		ctx, cancel := context.WithCancel(context.Background())
 		g.Add(func() error {
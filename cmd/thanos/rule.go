@@ -89,6 +89,7 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application, name string)
 	webPrefixHeaderName := cmd.Flag("web.prefix-header", "Name of HTTP request header used for dynamic prefixing of UI links and redirects. This option is ignored if web.external-prefix argument is set. Security risk: enable this option only if a reverse proxy in front of thanos is resetting the header. The --web.prefix-header=X-Forwarded-Prefix option can be useful, for example, if Thanos UI is served via Traefik reverse proxy with PathPrefixStrip option enabled, which sends the stripped prefix value in X-Forwarded-Prefix header. This allows thanos UI to be served on a sub-path.").Default("").String()
 
 	objStoreConfig := regCommonObjStoreFlags(cmd, "", false)
+	resumeUploads := regShipperResumeUploadsFlag(cmd)
 
 	queries := cmd.Flag("query", "Addresses of statically configured query API servers (repeatable). The scheme may be prefixed with 'dns+' or 'dnssrv+' to detect query API servers through respective DNS lookups.").
 		PlaceHolder("<query>").Strings()
@@ -163,6 +164,7 @@ func registerRule(m map[string]setupFunc, app *kingpin.Application, name string)
 			*dataDir,
 			*ruleFiles,
 			objStoreConfig,
+			*resumeUploads,
 			tsdbOpts,
 			alertQueryURL,
 			*alertExcludeLabels,
@@ -196,6 +198,7 @@ func runRule(
 	dataDir string,
 	ruleFiles []string,
 	objStoreConfig *pathOrContent,
+	resumeUploads bool,
 	tsdbOpts *tsdb.Options,
 	alertQueryURL *url.URL,
 	alertExcludeLabels []string,
@@ -578,7 +581,7 @@ func runRule(
 			}
 		}()
 
-		s := shipper.New(logger, nil, dataDir, bkt, func() labels.Labels { return lset }, metadata.RulerSource)
+		s := shipper.New(logger, nil, dataDir, bkt, func() labels.Labels { return lset }, metadata.RulerSource, resumeUploads)
 
 		ctx, cancel := context.WithCancel(context.Background())
 
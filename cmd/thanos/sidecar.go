@@ -42,6 +42,9 @@ func registerSidecar(m map[string]setupFunc, app *kingpin.Application, name stri
 	dataDir := cmd.Flag("tsdb.path", "Data directory of TSDB.").
 		Default("./data").String()
 
+	blocksFallback := cmd.Flag("tsdb.blocks-fallback", "If true, the persisted blocks in the TSDB data directory are served read-only while Prometheus is unavailable. Data not persisted in blocks yet is missing from such responses.").
+		Default("false").Bool()
+
 	reloaderCfgFile := cmd.Flag("reloader.config-file", "Config file watched by the reloader.").
 		Default("").String()
 
@@ -51,6 +54,7 @@ func registerSidecar(m map[string]setupFunc, app *kingpin.Application, name stri
 	reloaderRuleDirs := cmd.Flag("reloader.rule-dir", "Rule directories for the reloader to refresh (repeated field).").Strings()
 
 	objStoreConfig := regCommonObjStoreFlags(cmd, "", false)
+	resumeUploads := regShipperResumeUploadsFlag(cmd)
 
 	uploadCompacted := cmd.Flag("shipper.upload-compacted", "[Experimental] If true sidecar will try to upload compacted blocks as well. Useful for migration purposes. Works only if compaction is disabled on Prometheus.").Default("false").Hidden().Bool()
 
@@ -74,7 +78,9 @@ func registerSidecar(m map[string]setupFunc, app *kingpin.Application, name stri
 			*httpBindAddr,
 			*promURL,
 			*dataDir,
+			*blocksFallback,
 			objStoreConfig,
+			*resumeUploads,
 			rl,
 			*uploadCompacted,
 		)
@@ -93,7 +99,9 @@ func runSidecar(
 	httpBindAddr string,
 	promURL *url.URL,
 	dataDir string,
+	blocksFallback bool,
 	objStoreConfig *pathOrContent,
+	resumeUploads bool,
 	reloader *reloader.Reloader,
 	uploadCompacted bool,
 ) error {
@@ -205,8 +213,28 @@ func runSidecar(
 		}
 		logger := log.With(logger, "component", component.Sidecar.String())
 
+		var localBlocks store.TSDBReader
+		if blocksFallback {
+			blocks := store.NewTSDBBlocks(logger, dataDir)
+			localBlocks = blocks
+
+			ctx, cancel := context.WithCancel(context.Background())
+			g.Add(func() error {
+				defer runutil.CloseWithLogOnErr(logger, blocks, "TSDB blocks")
+
+				return runutil.Repeat(30*time.Second, ctx.Done(), func() error {
+					if err := blocks.Sync(); err != nil {
+						level.Warn(logger).Log("msg", "syncing TSDB blocks failed", "err", err)
+					}
+					return nil
+				})
+			}, func(error) {
+				cancel()
+			})
+		}
+
 		promStore, err := store.NewPrometheusStore(
-			logger, nil, promURL, component.Sidecar, m.Labels, m.Timestamps)
+			logger, nil, promURL, component.Sidecar, m.Labels, m.Timestamps, localBlocks)
 		if err != nil {
 			return errors.Wrap(err, "create Prometheus store")
 		}
@@ -263,9 +291,9 @@ func runSidecar(
 
 			var s *shipper.Shipper
 			if uploadCompacted {
-				s = shipper.NewWithCompacted(logger, reg, dataDir, bkt, m.Labels, metadata.SidecarSource)
+				s = shipper.NewWithCompacted(logger, reg, dataDir, bkt, m.Labels, metadata.SidecarSource, resumeUploads)
 			} else {
-				s = shipper.New(logger, reg, dataDir, bkt, m.Labels, metadata.SidecarSource)
+				s = shipper.New(logger, reg, dataDir, bkt, m.Labels, metadata.SidecarSource, resumeUploads)
 			}
 
 			return runutil.Repeat(30*time.Second, ctx.Done(), func() error {
@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"net"
+	"net/http"
+	"path/filepath"
 	"time"
 
 	"github.com/go-kit/kit/log"
@@ -11,27 +13,48 @@ import (
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/route"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
+	"github.com/thanos-io/thanos/pkg/model"
+	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/client"
 	"github.com/thanos-io/thanos/pkg/runutil"
 	"github.com/thanos-io/thanos/pkg/store"
+	v1 "github.com/thanos-io/thanos/pkg/store/api"
 	storecache "github.com/thanos-io/thanos/pkg/store/cache"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/ui"
 	"google.golang.org/grpc"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	yaml "gopkg.in/yaml.v2"
 )
 
+// bucketCacheSubrangeSize is the size of aligned sub-ranges the bucket disk cache fetches and stores.
+const bucketCacheSubrangeSize = 16 * 1024
+
 // registerStore registers a store command.
 func registerStore(m map[string]setupFunc, app *kingpin.Application, name string) {
-	cmd := app.Command(name, "store node giving access to blocks in a bucket provider. Now supported GCS, S3, Azure, Swift and Tencent COS.")
+	cmd := app.Command(name, "store node giving access to blocks in a bucket provider. Now supported GCS, S3, Azure, Swift, Tencent COS and local filesystem.")
 
 	grpcBindAddr, httpBindAddr, cert, key, clientCA := regCommonServerFlags(cmd)
 
 	dataDir := cmd.Flag("data-dir", "Data directory in which to cache remote blocks.").
 		Default("./data").String()
 
-	indexCacheSize := cmd.Flag("index-cache-size", "Maximum size of items held in the index cache.").
+	indexCacheSize := cmd.Flag("index-cache-size", "Maximum size of items held in the in-memory index cache. Ignored if an index cache configuration is given.").
 		Default("250MB").Bytes()
 
+	indexCacheConfig := regCacheFlags(cmd, "index-cache", "If not set, an in-memory cache of index-cache-size is used.")
+
+	chunksCacheConfig := regCacheFlags(cmd, "chunks-cache", "If not set, chunks are not cached.")
+
+	bucketCacheSize := cmd.Flag("bucket-cache-size", "Maximum size of the local disk cache for index and chunk data read from the bucket. 0 disables the cache.").
+		Default("0").Bytes()
+
+	bucketCacheDir := cmd.Flag("bucket-cache-dir", "Directory for the bucket disk cache. Defaults to the bucket-cache directory inside data-dir.").
+		Default("").String()
+
 	chunkPoolSize := cmd.Flag("chunk-pool-size", "Maximum size of concurrently allocatable bytes for chunks.").
 		Default("2GB").Bytes()
 
@@ -39,6 +62,15 @@ func registerStore(m map[string]setupFunc, app *kingpin.Application, name string
 		"Maximum amount of samples returned via a single Series call. 0 means no limit. NOTE: for efficiency we take 120 as the number of samples in chunk (it cannot be bigger than that), so the actual number of samples might be lower, even though the maximum could be hit.").
 		Default("0").Uint()
 
+	maxSeries := cmd.Flag("store.grpc.series-limit", "Maximum number of series matched by a single Series call in all queried blocks. 0 means no limit.").
+		Default("0").Uint64()
+
+	maxPostingsBytes := cmd.Flag("store.grpc.series-postings-bytes-limit", "Maximum size of postings fetched from the bucket by a single Series call. 0 means no limit.").
+		Default("0").Bytes()
+
+	maxChunkBytes := cmd.Flag("store.grpc.series-chunk-bytes-limit", "Maximum size of chunks fetched from the bucket by a single Series call. 0 means no limit.").
+		Default("0").Bytes()
+
 	maxConcurrent := cmd.Flag("store.grpc.series-max-concurrency", "Maximum number of concurrent Series calls.").Default("20").Int()
 
 	objStoreConfig := regCommonObjStoreFlags(cmd, "", true)
@@ -49,7 +81,46 @@ func registerStore(m map[string]setupFunc, app *kingpin.Application, name string
 	blockSyncConcurrency := cmd.Flag("block-sync-concurrency", "Number of goroutines to use when syncing blocks from object storage.").
 		Default("20").Int()
 
+	lazyBlockLoading := cmd.Flag("block-lazy-loading", "If enabled, synced blocks are registered with their meta only and loaded on their first query.").
+		Default("false").Bool()
+
+	blockIdleTimeout := cmd.Flag("block-idle-timeout", "Loaded blocks that have not been queried for this duration are unloaded on the next sync and loaded again on their next query. 0 disables unloading.").
+		Default("0s").Duration()
+
+	minTime := model.TimeOrDuration(cmd.Flag("min-time", "Start of time range limit. Only blocks overlapping the time range are synced and served. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
+		Default("0000-01-01T00:00:00Z"))
+
+	maxTime := model.TimeOrDuration(cmd.Flag("max-time", "End of time range limit. Only blocks overlapping the time range are synced and served. Option can be a constant time in RFC3339 format or time duration relative to current time, such as -1d or 2h45m. Valid duration units are ms, s, m, h, d, w, y.").
+		Default("9999-12-31T23:59:59Z"))
+
+	selectorRelabelConfig := regSelectorRelabelFlags(cmd)
+
+	shardIndex := cmd.Flag("selector.shard-index", "Index of the shard of blocks served by this store, lower than selector.shard-total. Blocks are assigned to shards by the hash of their ULID.").
+		Default("0").Uint64()
+
+	shardTotal := cmd.Flag("selector.shard-total", "Total number of shards blocks are split into. 1 serves all blocks.").
+		Default("1").Uint64()
+
+	enableAdminAPI := cmd.Flag("web.enable-admin-api", "Enable the API endpoints to drop and resync single blocks. They are not authenticated, so only enable them if the HTTP address is not reachable by untrusted clients.").
+		Default("false").Bool()
+
 	m[name] = func(g *run.Group, logger log.Logger, reg *prometheus.Registry, tracer opentracing.Tracer, debugLogging bool) error {
+		if minTime.PrometheusTimestamp() >= maxTime.PrometheusTimestamp() {
+			return errors.Errorf("invalid argument: --min-time '%s' must be before --max-time '%s'", minTime, maxTime)
+		}
+		if *shardTotal == 0 || *shardIndex >= *shardTotal {
+			return errors.Errorf("invalid argument: --selector.shard-index %d must be lower than --selector.shard-total %d", *shardIndex, *shardTotal)
+		}
+
+		relabelContentYaml, err := selectorRelabelConfig.Content()
+		if err != nil {
+			return errors.Wrap(err, "get content of relabel configuration")
+		}
+		var relabelConfig []*relabel.Config
+		if err := yaml.Unmarshal(relabelContentYaml, &relabelConfig); err != nil {
+			return errors.Wrap(err, "parse relabel configuration")
+		}
+
 		return runStore(g,
 			logger,
 			reg,
@@ -62,13 +133,32 @@ func registerStore(m map[string]setupFunc, app *kingpin.Application, name string
 			*clientCA,
 			*httpBindAddr,
 			uint64(*indexCacheSize),
+			indexCacheConfig,
+			chunksCacheConfig,
+			uint64(*bucketCacheSize),
+			*bucketCacheDir,
 			uint64(*chunkPoolSize),
 			uint64(*maxSampleCount),
+			store.SeriesLimits{
+				MaxSeries:        *maxSeries,
+				MaxPostingsBytes: uint64(*maxPostingsBytes),
+				MaxChunkBytes:    uint64(*maxChunkBytes),
+			},
 			int(*maxConcurrent),
 			name,
 			debugLogging,
 			*syncInterval,
 			*blockSyncConcurrency,
+			*lazyBlockLoading,
+			*blockIdleTimeout,
+			&store.FilterConfig{
+				MinTime:       *minTime,
+				MaxTime:       *maxTime,
+				RelabelConfig: relabelConfig,
+				ShardIndex:    *shardIndex,
+				ShardTotal:    *shardTotal,
+			},
+			*enableAdminAPI,
 		)
 	}
 }
@@ -87,13 +177,22 @@ func runStore(
 	clientCA string,
 	httpBindAddr string,
 	indexCacheSizeBytes uint64,
+	indexCacheConfig *pathOrContent,
+	chunksCacheConfig *pathOrContent,
+	bucketCacheSizeBytes uint64,
+	bucketCacheDir string,
 	chunkPoolSizeBytes uint64,
 	maxSampleCount uint64,
+	seriesLimits store.SeriesLimits,
 	maxConcurrent int,
 	component string,
 	verbose bool,
 	syncInterval time.Duration,
 	blockSyncConcurrency int,
+	lazyBlockLoading bool,
+	blockIdleTimeout time.Duration,
+	filterConfig *store.FilterConfig,
+	enableAdminAPI bool,
 ) error {
 	{
 		confContentYaml, err := objStoreConfig.Content()
@@ -113,21 +212,74 @@ func runStore(
 			}
 		}()
 
-		// TODO(bwplotka): Add as a flag?
-		maxItemSizeBytes := indexCacheSizeBytes / 2
+		indexCacheContentYaml, err := indexCacheConfig.Content()
+		if err != nil {
+			return errors.Wrap(err, "get content of index cache configuration")
+		}
+
+		var (
+			indexCache     storecache.IndexCache
+			stopIndexCache = func() {}
+		)
+		if len(indexCacheContentYaml) > 0 {
+			indexCache, stopIndexCache, err = storecache.NewIndexCache(logger, indexCacheContentYaml, reg)
+		} else {
+			// TODO(bwplotka): Add as a flag?
+			maxItemSizeBytes := indexCacheSizeBytes / 2
 
-		indexCache, err := storecache.NewIndexCache(logger, reg, storecache.Opts{
-			MaxSizeBytes:     indexCacheSizeBytes,
-			MaxItemSizeBytes: maxItemSizeBytes,
-		})
+			indexCache, err = storecache.NewInMemoryIndexCache(logger, reg, storecache.Opts{
+				MaxSizeBytes:     indexCacheSizeBytes,
+				MaxItemSizeBytes: maxItemSizeBytes,
+			})
+		}
 		if err != nil {
 			return errors.Wrap(err, "create index cache")
 		}
+		defer func() {
+			if err != nil {
+				stopIndexCache()
+			}
+		}()
+
+		chunksCacheContentYaml, err := chunksCacheConfig.Content()
+		if err != nil {
+			return errors.Wrap(err, "get content of chunks cache configuration")
+		}
+
+		var (
+			chunksCache     storecache.ChunksCache
+			stopChunksCache = func() {}
+		)
+		if len(chunksCacheContentYaml) > 0 {
+			chunksCache, stopChunksCache, err = storecache.NewChunksCache(logger, chunksCacheContentYaml, reg)
+			if err != nil {
+				return errors.Wrap(err, "create chunks cache")
+			}
+		}
+		defer func() {
+			if err != nil {
+				stopChunksCache()
+			}
+		}()
+
+		var bucketReader objstore.BucketReader = bkt
+		if bucketCacheSizeBytes > 0 {
+			if bucketCacheDir == "" {
+				bucketCacheDir = filepath.Join(dataDir, "bucket-cache")
+			}
+			bucketReader, err = storecache.NewCachingBucket(logger, reg, bkt, bucketCacheDir, storecache.CachingBucketOpts{
+				MaxSizeBytes: bucketCacheSizeBytes,
+				SubrangeSize: bucketCacheSubrangeSize,
+			})
+			if err != nil {
+				return errors.Wrap(err, "create bucket cache")
+			}
+		}
 
 		bs, err := store.NewBucketStore(
 			logger,
 			reg,
-			bkt,
+			bucketReader,
 			dataDir,
 			indexCache,
 			chunkPoolSizeBytes,
@@ -135,6 +287,13 @@ func runStore(
 			maxConcurrent,
 			verbose,
 			blockSyncConcurrency,
+			store.BucketStoreOpts{
+				ChunksCache:      chunksCache,
+				SeriesLimits:     seriesLimits,
+				LazyBlockLoading: lazyBlockLoading,
+				BlockIdleTimeout: blockIdleTimeout,
+				FilterConfig:     filterConfig,
+			},
 		)
 		if err != nil {
 			return errors.Wrap(err, "create object storage store")
@@ -150,6 +309,8 @@ func runStore(
 		ctx, cancel := context.WithCancel(context.Background())
 		g.Add(func() error {
 			defer runutil.CloseWithLogOnErr(logger, bkt, "bucket client")
+			defer stopIndexCache()
+			defer stopChunksCache()
 
 			err := runutil.Repeat(syncInterval, ctx.Done(), func() error {
 				if err := bs.SyncBlocks(ctx); err != nil {
@@ -183,9 +344,30 @@ func runStore(
 		}, func(error) {
 			s.Stop()
 		})
-	}
-	if err := metricHTTPListenGroup(g, logger, reg, httpBindAddr); err != nil {
-		return err
+
+		// Start UI, admin API & metrics HTTP server.
+		router := route.New()
+		ins := extpromhttp.NewInstrumentationMiddleware(reg)
+
+		ui.NewStoreUI(logger, bs, enableAdminAPI).Register(router, ins)
+		v1.NewAPI(logger, bs, enableAdminAPI).Register(router.WithPrefix("/api/v1"), tracer, logger, ins)
+
+		mux := http.NewServeMux()
+		registerMetrics(mux, reg)
+		registerProfile(mux)
+		mux.Handle("/", router)
+
+		hl, err := net.Listen("tcp", httpBindAddr)
+		if err != nil {
+			return errors.Wrapf(err, "listen HTTP on address %s", httpBindAddr)
+		}
+
+		g.Add(func() error {
+			level.Info(logger).Log("msg", "Listening for HTTP requests", "address", httpBindAddr)
+			return errors.Wrap(http.Serve(hl, mux), "serve HTTP")
+		}, func(error) {
+			runutil.CloseWithLogOnErr(logger, hl, "store HTTP listener")
+		})
 	}
 
 	level.Info(logger).Log("msg", "starting store node")
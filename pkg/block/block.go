@@ -9,6 +9,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 
@@ -28,6 +29,8 @@ const (
 	IndexFilename = "index"
 	// IndexCacheFilename is the canonical name for index cache file that stores essential information needed.
 	IndexCacheFilename = "index.cache.json"
+	// IndexHeaderFilename is the canonical name for the binary index header file, a compact alternative to the index cache file.
+	IndexHeaderFilename = "index-header"
 	// ChunksDirname is the known dir name for chunks with compressed samples.
 	ChunksDirname = "chunks"
 
@@ -56,10 +59,11 @@ func Download(ctx context.Context, logger log.Logger, bucket objstore.Bucket, id
 }
 
 // Upload uploads block from given block dir that ends with block id.
-// It makes sure cleanup is done on error to avoid partial block uploads.
+// It makes sure cleanup is done on error to avoid partial block uploads, unless objstore.WithSkipExisting
+// is given, in which case the partial block is left in place so a retry can resume the upload.
 // It also verifies basic features of Thanos block.
 // TODO(bplotka): Ensure bucket operations have reasonable backoff retries.
-func Upload(ctx context.Context, logger log.Logger, bkt objstore.Bucket, bdir string) error {
+func Upload(ctx context.Context, logger log.Logger, bkt objstore.Bucket, bdir string, options ...objstore.UploadOption) error {
 	df, err := os.Stat(bdir)
 	if err != nil {
 		return errors.Wrap(err, "stat bdir")
@@ -84,28 +88,44 @@ func Upload(ctx context.Context, logger log.Logger, bkt objstore.Bucket, bdir st
 		return errors.Errorf("empty external labels are not allowed for Thanos block.")
 	}
 
+	resumable := objstore.ApplyUploadOptions(options...).SkipExisting
+	fail := func(err error) error {
+		if resumable {
+			return err
+		}
+		return cleanUp(bkt, id, err)
+	}
+
 	if err := objstore.UploadFile(ctx, logger, bkt, path.Join(bdir, MetaFilename), path.Join(DebugMetas, fmt.Sprintf("%s.json", id))); err != nil {
 		return errors.Wrap(err, "upload meta file to debug dir")
 	}
 
-	if err := objstore.UploadDir(ctx, logger, bkt, path.Join(bdir, ChunksDirname), path.Join(id.String(), ChunksDirname)); err != nil {
-		return cleanUp(bkt, id, errors.Wrap(err, "upload chunks"))
+	if err := objstore.UploadDir(ctx, logger, bkt, path.Join(bdir, ChunksDirname), path.Join(id.String(), ChunksDirname), options...); err != nil {
+		return fail(errors.Wrap(err, "upload chunks"))
 	}
 
-	if err := objstore.UploadFile(ctx, logger, bkt, path.Join(bdir, IndexFilename), path.Join(id.String(), IndexFilename)); err != nil {
-		return cleanUp(bkt, id, errors.Wrap(err, "upload index"))
+	if err := objstore.UploadFile(ctx, logger, bkt, path.Join(bdir, IndexFilename), path.Join(id.String(), IndexFilename), options...); err != nil {
+		return fail(errors.Wrap(err, "upload index"))
 	}
 
 	if meta.Thanos.Source == metadata.CompactorSource {
-		if err := objstore.UploadFile(ctx, logger, bkt, path.Join(bdir, IndexCacheFilename), path.Join(id.String(), IndexCacheFilename)); err != nil {
-			return cleanUp(bkt, id, errors.Wrap(err, "upload index cache"))
+		if err := objstore.UploadFile(ctx, logger, bkt, path.Join(bdir, IndexCacheFilename), path.Join(id.String(), IndexCacheFilename), options...); err != nil {
+			return fail(errors.Wrap(err, "upload index cache"))
+		}
+	}
+
+	if _, err := os.Stat(path.Join(bdir, IndexHeaderFilename)); err == nil {
+		if err := objstore.UploadFile(ctx, logger, bkt, path.Join(bdir, IndexHeaderFilename), path.Join(id.String(), IndexHeaderFilename), options...); err != nil {
+			return fail(errors.Wrap(err, "upload index header"))
 		}
+	} else if !os.IsNotExist(err) {
+		return fail(errors.Wrap(err, "stat index header"))
 	}
 
 	// Meta.json always need to be uploaded as a last item. This will allow to assume block directories without meta file
 	// to be pending uploads.
 	if err := objstore.UploadFile(ctx, logger, bkt, path.Join(bdir, MetaFilename), path.Join(id.String(), MetaFilename)); err != nil {
-		return cleanUp(bkt, id, errors.Wrap(err, "upload meta file"))
+		return fail(errors.Wrap(err, "upload meta file"))
 	}
 
 	return nil
@@ -128,7 +148,7 @@ func Delete(ctx context.Context, bucket objstore.Bucket, id ulid.ULID) error {
 
 // DownloadMeta downloads only meta file from bucket by block ID.
 // TODO(bwplotka): Differentiate between network error & partial upload.
-func DownloadMeta(ctx context.Context, logger log.Logger, bkt objstore.Bucket, id ulid.ULID) (metadata.Meta, error) {
+func DownloadMeta(ctx context.Context, logger log.Logger, bkt objstore.BucketReader, id ulid.ULID) (metadata.Meta, error) {
 	rc, err := bkt.Get(ctx, path.Join(id.String(), MetaFilename))
 	if err != nil {
 		return metadata.Meta{}, errors.Wrapf(err, "meta.json bkt get for %s", id.String())
@@ -149,6 +169,29 @@ func DownloadMeta(ctx context.Context, logger log.Logger, bkt objstore.Bucket, i
 	return m, nil
 }
 
+// Size returns the total size in bytes of all objects of the block in the bucket.
+func Size(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID) (int64, error) {
+	var size int64
+	var sizeDir func(dir string) error
+	sizeDir = func(dir string) error {
+		return bkt.Iter(ctx, dir, func(name string) error {
+			if strings.HasSuffix(name, objstore.DirDelim) {
+				return sizeDir(name)
+			}
+			attrs, err := bkt.Attributes(ctx, name)
+			if err != nil {
+				return errors.Wrapf(err, "attributes of %s", name)
+			}
+			size += attrs.Size
+			return nil
+		})
+	}
+	if err := sizeDir(id.String()); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
 func IsBlockDir(path string) (id ulid.ULID, ok bool) {
 	id, err := ulid.Parse(filepath.Base(path))
 	return id, err == nil
@@ -1,9 +1,13 @@
 package block
 
 import (
+	"context"
+	"path"
+	"strings"
 	"testing"
 
 	"github.com/oklog/ulid"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
 )
 
 // NOTE(bplotka): For block packages we cannot use testutil, because they import block package. Consider moving simple
@@ -56,3 +60,29 @@ func TestIsBlockDir(t *testing.T) {
 		})
 	}
 }
+
+func TestSize(t *testing.T) {
+	ctx := context.Background()
+	bkt := inmem.NewBucket()
+
+	id := ulid.MustNew(1, nil)
+	for name, content := range map[string]string{
+		path.Join(id.String(), MetaFilename):                    "meta",
+		path.Join(id.String(), IndexFilename):                   "index",
+		path.Join(id.String(), ChunksDirname, "0001"):           "chunks-1",
+		path.Join(id.String(), ChunksDirname, "0002"):           "chunks-2",
+		path.Join(ulid.MustNew(2, nil).String(), IndexFilename): "other block",
+	} {
+		if err := bkt.Upload(ctx, name, strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	size, err := Size(ctx, bkt, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := int64(len("meta") + len("index") + 2*len("chunks-1")); size != exp {
+		t.Errorf("expected size %d, got %d", exp, size)
+	}
+}
@@ -90,32 +90,45 @@ func getSymbolTable(b index.ByteSlice) (map[uint32]string, error) {
 // WriteIndexCache writes a cache file containing the first lookup stages
 // for an index file.
 func WriteIndexCache(logger log.Logger, indexFn string, fn string) error {
+	v, err := newIndexCache(logger, indexFn)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(fn)
+	if err != nil {
+		return errors.Wrap(err, "create index cache file")
+	}
+	defer runutil.CloseWithLogOnErr(logger, f, "index cache writer")
+
+	if err := json.NewEncoder(f).Encode(v); err != nil {
+		return errors.Wrap(err, "encode file")
+	}
+	return nil
+}
+
+// newIndexCache reads the first lookup stages of an index file.
+func newIndexCache(logger log.Logger, indexFn string) (*indexCache, error) {
 	indexFile, err := fileutil.OpenMmapFile(indexFn)
 	if err != nil {
-		return errors.Wrapf(err, "open mmap index file %s", indexFn)
+		return nil, errors.Wrapf(err, "open mmap index file %s", indexFn)
 	}
 	defer runutil.CloseWithLogOnErr(logger, indexFile, "close index cache mmap file from %s", indexFn)
 
 	b := realByteSlice(indexFile.Bytes())
 	indexr, err := index.NewReader(b)
 	if err != nil {
-		return errors.Wrap(err, "open index reader")
+		return nil, errors.Wrap(err, "open index reader")
 	}
 	defer runutil.CloseWithLogOnErr(logger, indexr, "load index cache reader")
 
 	// We assume reader verified index already.
 	symbols, err := getSymbolTable(b)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	f, err := os.Create(fn)
-	if err != nil {
-		return errors.Wrap(err, "create index cache file")
-	}
-	defer runutil.CloseWithLogOnErr(logger, f, "index cache writer")
-
-	v := indexCache{
+	v := &indexCache{
 		Version:      indexr.Version(),
 		CacheVersion: IndexCacheVersion1,
 		Symbols:      symbols,
@@ -125,7 +138,7 @@ func WriteIndexCache(logger log.Logger, indexFn string, fn string) error {
 	// Extract label value indices.
 	lnames, err := indexr.LabelIndices()
 	if err != nil {
-		return errors.Wrap(err, "read label indices")
+		return nil, errors.Wrap(err, "read label indices")
 	}
 	for _, lns := range lnames {
 		if len(lns) != 1 {
@@ -135,17 +148,17 @@ func WriteIndexCache(logger log.Logger, indexFn string, fn string) error {
 
 		tpls, err := indexr.LabelValues(ln)
 		if err != nil {
-			return errors.Wrap(err, "get label values")
+			return nil, errors.Wrap(err, "get label values")
 		}
 		vals := make([]string, 0, tpls.Len())
 
 		for i := 0; i < tpls.Len(); i++ {
 			v, err := tpls.At(i)
 			if err != nil {
-				return errors.Wrap(err, "get label value")
+				return nil, errors.Wrap(err, "get label value")
 			}
 			if len(v) != 1 {
-				return errors.Errorf("unexpected tuple length %d", len(v))
+				return nil, errors.Errorf("unexpected tuple length %d", len(v))
 			}
 			vals = append(vals, v[0])
 		}
@@ -155,7 +168,7 @@ func WriteIndexCache(logger log.Logger, indexFn string, fn string) error {
 	// Extract postings ranges.
 	pranges, err := indexr.PostingsRanges()
 	if err != nil {
-		return errors.Wrap(err, "read postings ranges")
+		return nil, errors.Wrap(err, "read postings ranges")
 	}
 	for l, rng := range pranges {
 		v.Postings = append(v.Postings, postingsRange{
@@ -165,11 +178,7 @@ func WriteIndexCache(logger log.Logger, indexFn string, fn string) error {
 			End:   rng.End,
 		})
 	}
-
-	if err := json.NewEncoder(f).Encode(&v); err != nil {
-		return errors.Wrap(err, "encode file")
-	}
-	return nil
+	return v, nil
 }
 
 // ReadIndexCache reads an index cache file.
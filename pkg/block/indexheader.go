@@ -0,0 +1,564 @@
+package block
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/tsdb/encoding"
+	"github.com/prometheus/tsdb/fileutil"
+	"github.com/prometheus/tsdb/index"
+	"github.com/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+const (
+	// IndexHeaderVersion1 is the only binary index header format version supported by Thanos.
+	IndexHeaderVersion1 = 1
+
+	// indexHeaderMagic is the magic number at the beginning of every binary index header file.
+	indexHeaderMagic = 0xBAAAD792
+
+	// indexHeaderHeaderLen is the length of the magic number, the format version and the index version.
+	indexHeaderHeaderLen = 4 + 1 + 1
+	// indexHeaderTOCLen is the length of the table of contents: two section offsets and a CRC32 checksum.
+	indexHeaderTOCLen = 2*8 + 4
+
+	symbolEntryLen     = 4 + 4
+	labelNameEntryLen  = 4 + 4
+	labelValueEntryLen = 4 + 8 + 8
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// IndexHeader gives access to the first lookup stages of a block index: its symbols,
+// label names and values and the offsets of postings lists within the index file.
+type IndexHeader interface {
+	io.Closer
+
+	// IndexVersion returns the version of the index file the header was built from.
+	IndexVersion() int
+
+	// LookupSymbol returns the symbol referenced by o in the index file.
+	LookupSymbol(o uint32) (string, error)
+
+	// PostingsOffset returns the byte range of the postings list for the given label pair
+	// within the index file. It returns false if the block has no postings for it.
+	PostingsOffset(name, value string) (index.Range, bool)
+
+	// LabelValues returns all values of the given label name, sorted.
+	LabelValues(name string) []string
+
+	// LabelNames returns all label names, sorted.
+	LabelNames() []string
+
+	// Size returns the approximate number of bytes held in memory by the header.
+	Size() int64
+}
+
+// WriteIndexHeader writes a binary index header file for the given index file. The binary
+// header holds the same information as the JSON index cache but is compact and can be
+// read lazily from a memory mapped file, without decoding it fully on startup.
+//
+// The file starts with a magic number, the format version and the index version, followed by the
+// deduplicated strings, a table of postings ranges per label name, the symbols table and the label
+// names table. Tables are sorted and hold fixed size entries referencing strings by their offset, so
+// that lookups can binary search them. The file ends with a TOC holding the offsets of the symbols and
+// label names tables.
+func WriteIndexHeader(logger log.Logger, indexFn string, fn string) error {
+	v, err := newIndexCache(logger, indexFn)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it once complete, so that a failed or interrupted write
+	// never leaves a partial index header behind.
+	tmp := fn + ".tmp"
+	if err := writeIndexHeaderFile(logger, v, tmp); err != nil {
+		if rerr := os.RemoveAll(tmp); rerr != nil {
+			level.Warn(logger).Log("msg", "failed to remove temporary index header file", "path", tmp, "err", rerr)
+		}
+		return err
+	}
+	if err := os.Rename(tmp, fn); err != nil {
+		if rerr := os.RemoveAll(tmp); rerr != nil {
+			level.Warn(logger).Log("msg", "failed to remove temporary index header file", "path", tmp, "err", rerr)
+		}
+		return errors.Wrap(err, "rename index header file")
+	}
+
+	// Sync the parent directory to persist the rename.
+	pdir, err := fileutil.OpenDir(filepath.Dir(fn))
+	if err != nil {
+		return errors.Wrap(err, "open index header dir")
+	}
+	defer runutil.CloseWithLogOnErr(logger, pdir, "index header dir")
+	return errors.Wrap(fileutil.Fdatasync(pdir), "sync index header dir")
+}
+
+func writeIndexHeaderFile(logger log.Logger, v *indexCache, fn string) error {
+	f, err := os.Create(fn)
+	if err != nil {
+		return errors.Wrap(err, "create index header file")
+	}
+	defer runutil.CloseWithLogOnErr(logger, f, "index header writer")
+
+	w := &indexHeaderWriter{w: bufio.NewWriter(f)}
+	if err := w.writeIndexHeader(v); err != nil {
+		return errors.Wrap(err, "write index header")
+	}
+	if err := w.w.Flush(); err != nil {
+		return errors.Wrap(err, "flush index header")
+	}
+	return errors.Wrap(f.Sync(), "sync index header")
+}
+
+type labelValueEntry struct {
+	value string
+	rng   index.Range
+}
+
+type indexHeaderWriter struct {
+	w   *bufio.Writer
+	pos uint64
+	buf encoding.Encbuf
+}
+
+// flush writes the content of the encoding buffer to the file and resets it.
+func (w *indexHeaderWriter) flush() error {
+	n, err := w.w.Write(w.buf.Get())
+	w.pos += uint64(n)
+	w.buf.Reset()
+	return err
+}
+
+// offset returns the current position in the file, which must be addressable with 4 bytes.
+func (w *indexHeaderWriter) offset() (uint32, error) {
+	if w.pos > math.MaxUint32 {
+		return 0, errors.Errorf("index header exceeds max size of %d bytes", uint64(math.MaxUint32))
+	}
+	return uint32(w.pos), nil
+}
+
+func (w *indexHeaderWriter) writeIndexHeader(v *indexCache) error {
+	w.buf.PutBE32(indexHeaderMagic)
+	w.buf.PutByte(IndexHeaderVersion1)
+	w.buf.PutByte(byte(v.Version))
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	// Group postings by label name. The postings list of the all postings key is part of them
+	// with an empty name.
+	lvals := map[string][]labelValueEntry{}
+	for _, p := range v.Postings {
+		lvals[p.Name] = append(lvals[p.Name], labelValueEntry{
+			value: p.Value,
+			rng:   index.Range{Start: p.Start, End: p.End},
+		})
+	}
+	names := make([]string, 0, len(lvals))
+	for n := range lvals {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	refs := make([]uint32, 0, len(v.Symbols))
+	for ref := range v.Symbols {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i] < refs[j] })
+
+	// Write every distinct string once.
+	strs := map[string]uint32{}
+	putStr := func(s string) error {
+		if _, ok := strs[s]; ok {
+			return nil
+		}
+		off, err := w.offset()
+		if err != nil {
+			return err
+		}
+		strs[s] = off
+		w.buf.PutUvarintStr(s)
+		return w.flush()
+	}
+	for _, ref := range refs {
+		if err := putStr(v.Symbols[ref]); err != nil {
+			return err
+		}
+	}
+	for _, n := range names {
+		if err := putStr(n); err != nil {
+			return err
+		}
+		for _, e := range lvals[n] {
+			if err := putStr(e.value); err != nil {
+				return err
+			}
+		}
+	}
+
+	valueTables := make([]uint32, 0, len(names))
+	for _, n := range names {
+		off, err := w.offset()
+		if err != nil {
+			return err
+		}
+		valueTables = append(valueTables, off)
+
+		entries := lvals[n]
+		sort.Slice(entries, func(i, j int) bool { return entries[i].value < entries[j].value })
+
+		w.buf.PutBE32int(len(entries))
+		for _, e := range entries {
+			w.buf.PutBE32(strs[e.value])
+			w.buf.PutBE64int64(e.rng.Start)
+			w.buf.PutBE64int64(e.rng.End)
+		}
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+
+	symbolsOff := w.pos
+	w.buf.PutBE32int(len(refs))
+	for _, ref := range refs {
+		w.buf.PutBE32(ref)
+		w.buf.PutBE32(strs[v.Symbols[ref]])
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	labelNamesOff := w.pos
+	w.buf.PutBE32int(len(names))
+	for i, n := range names {
+		w.buf.PutBE32(strs[n])
+		w.buf.PutBE32(valueTables[i])
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	w.buf.PutBE64(symbolsOff)
+	w.buf.PutBE64(labelNamesOff)
+	w.buf.PutBE32(crc32.Checksum(w.buf.Get(), castagnoliTable))
+	return w.flush()
+}
+
+// BinaryIndexHeader is an IndexHeader reading a binary index header file written by
+// WriteIndexHeader. The file is memory mapped and only the parts needed by a lookup are read.
+type BinaryIndexHeader struct {
+	f *fileutil.MmapFile
+	b []byte
+
+	indexVersion int
+
+	symbols    []byte
+	numSymbols int
+	labelNames []byte
+	numNames   int
+}
+
+// NewBinaryIndexHeader opens the binary index header file fn.
+func NewBinaryIndexHeader(fn string) (*BinaryIndexHeader, error) {
+	f, err := fileutil.OpenMmapFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newBinaryIndexHeader(f.Bytes())
+	if err != nil {
+		runutil.CloseWithLogOnErr(log.NewNopLogger(), f, "close index header")
+		return nil, errors.Wrapf(err, "read index header %s", fn)
+	}
+	r.f = f
+	return r, nil
+}
+
+func newBinaryIndexHeader(b []byte) (*BinaryIndexHeader, error) {
+	if len(b) < indexHeaderHeaderLen+indexHeaderTOCLen {
+		return nil, errors.Wrap(encoding.ErrInvalidSize, "index header")
+	}
+	if m := binary.BigEndian.Uint32(b[:4]); m != indexHeaderMagic {
+		return nil, errors.Errorf("invalid magic number %x", m)
+	}
+	if v := b[4]; v != IndexHeaderVersion1 {
+		return nil, errors.Errorf("unknown index header version %d", v)
+	}
+
+	toc := b[len(b)-indexHeaderTOCLen:]
+	if crc32.Checksum(toc[:len(toc)-4], castagnoliTable) != binary.BigEndian.Uint32(toc[len(toc)-4:]) {
+		return nil, errors.Wrap(encoding.ErrInvalidChecksum, "read TOC")
+	}
+	var (
+		symbolsOff    = binary.BigEndian.Uint64(toc[0:8])
+		labelNamesOff = binary.BigEndian.Uint64(toc[8:16])
+		tocOff        = uint64(len(b) - indexHeaderTOCLen)
+	)
+	if symbolsOff+4 > labelNamesOff || labelNamesOff+4 > tocOff {
+		return nil, errors.New("invalid TOC")
+	}
+
+	r := &BinaryIndexHeader{
+		b:            b,
+		indexVersion: int(b[5]),
+		symbols:      b[symbolsOff+4 : labelNamesOff],
+		numSymbols:   int(binary.BigEndian.Uint32(b[symbolsOff:])),
+		labelNames:   b[labelNamesOff+4 : tocOff],
+		numNames:     int(binary.BigEndian.Uint32(b[labelNamesOff:])),
+	}
+	if len(r.symbols) != r.numSymbols*symbolEntryLen {
+		return nil, errors.Wrap(encoding.ErrInvalidSize, "read symbols")
+	}
+	if len(r.labelNames) != r.numNames*labelNameEntryLen {
+		return nil, errors.Wrap(encoding.ErrInvalidSize, "read label names")
+	}
+	return r, nil
+}
+
+// Close unmaps the index header file.
+func (r *BinaryIndexHeader) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Close()
+}
+
+// IndexVersion returns the version of the index file the header was built from.
+func (r *BinaryIndexHeader) IndexVersion() int {
+	return r.indexVersion
+}
+
+// Size returns the size of the memory mapped index header file.
+func (r *BinaryIndexHeader) Size() int64 {
+	return int64(len(r.b))
+}
+
+// str reads the string stored at the given offset.
+func (r *BinaryIndexHeader) str(off uint32) (string, error) {
+	if int(off) >= len(r.b) {
+		return "", errors.Wrapf(encoding.ErrInvalidSize, "string offset %d", off)
+	}
+	d := encoding.Decbuf{B: r.b[off:]}
+	s := d.UvarintStr()
+	return s, errors.Wrapf(d.Err(), "read string at offset %d", off)
+}
+
+// LookupSymbol returns the symbol referenced by o in the index file.
+func (r *BinaryIndexHeader) LookupSymbol(o uint32) (string, error) {
+	ref := func(i int) uint32 {
+		return binary.BigEndian.Uint32(r.symbols[i*symbolEntryLen:])
+	}
+
+	// Symbol references of index version 2 are sequence numbers. Try the direct lookup first.
+	i := int(o)
+	if i >= r.numSymbols || ref(i) != o {
+		i = sort.Search(r.numSymbols, func(i int) bool { return ref(i) >= o })
+		if i == r.numSymbols || ref(i) != o {
+			return "", errors.Errorf("unknown symbol offset %d", o)
+		}
+	}
+	return r.str(binary.BigEndian.Uint32(r.symbols[i*symbolEntryLen+4:]))
+}
+
+// labelName returns the name and the values table offset of the i-th label name.
+func (r *BinaryIndexHeader) labelName(i int) (string, uint32, error) {
+	e := r.labelNames[i*labelNameEntryLen:]
+	n, err := r.str(binary.BigEndian.Uint32(e))
+	return n, binary.BigEndian.Uint32(e[4:]), err
+}
+
+// values returns the values table of the given label name, if present.
+func (r *BinaryIndexHeader) values(name string) ([]byte, int, error) {
+	var err error
+	i := sort.Search(r.numNames, func(i int) bool {
+		n, _, lerr := r.labelName(i)
+		if lerr != nil {
+			err = lerr
+		}
+		return n >= name
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if i == r.numNames {
+		return nil, 0, nil
+	}
+	n, off, err := r.labelName(i)
+	if err != nil || n != name {
+		return nil, 0, err
+	}
+	if int(off)+4 > len(r.b) {
+		return nil, 0, errors.Wrapf(encoding.ErrInvalidSize, "values of label %s", name)
+	}
+	num := int(binary.BigEndian.Uint32(r.b[off:]))
+	start, end := int(off)+4, int(off)+4+num*labelValueEntryLen
+	if end > len(r.b) {
+		return nil, 0, errors.Wrapf(encoding.ErrInvalidSize, "values of label %s", name)
+	}
+	return r.b[start:end], num, nil
+}
+
+// PostingsOffset returns the byte range of the postings list for the given label pair
+// within the index file. It returns false if the block has no postings for it.
+func (r *BinaryIndexHeader) PostingsOffset(name, value string) (index.Range, bool) {
+	vals, num, err := r.values(name)
+	if err != nil || num == 0 {
+		return index.Range{}, false
+	}
+	i := sort.Search(num, func(i int) bool {
+		v, verr := r.str(binary.BigEndian.Uint32(vals[i*labelValueEntryLen:]))
+		if verr != nil {
+			err = verr
+		}
+		return v >= value
+	})
+	if err != nil || i == num {
+		return index.Range{}, false
+	}
+	e := vals[i*labelValueEntryLen:]
+	if v, err := r.str(binary.BigEndian.Uint32(e)); err != nil || v != value {
+		return index.Range{}, false
+	}
+	return index.Range{
+		Start: int64(binary.BigEndian.Uint64(e[4:])),
+		End:   int64(binary.BigEndian.Uint64(e[12:])),
+	}, true
+}
+
+// LabelValues returns all values of the given label name, sorted.
+func (r *BinaryIndexHeader) LabelValues(name string) []string {
+	if name == "" {
+		return nil
+	}
+	vals, num, err := r.values(name)
+	if err != nil {
+		return nil
+	}
+	res := make([]string, 0, num)
+	for i := 0; i < num; i++ {
+		v, err := r.str(binary.BigEndian.Uint32(vals[i*labelValueEntryLen:]))
+		if err != nil {
+			return nil
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+// LabelNames returns all label names, sorted.
+func (r *BinaryIndexHeader) LabelNames() []string {
+	res := make([]string, 0, r.numNames)
+	for i := 0; i < r.numNames; i++ {
+		n, _, err := r.labelName(i)
+		if err != nil {
+			return nil
+		}
+		// Skip the name of the all postings key.
+		if n == "" {
+			continue
+		}
+		res = append(res, n)
+	}
+	return res
+}
+
+// JSONIndexHeader is an IndexHeader backed by a JSON index cache file, which is fully
+// decoded into memory.
+type JSONIndexHeader struct {
+	indexVersion int
+	symbols      map[uint32]string
+	lvals        map[string][]string
+	postings     map[labels.Label]index.Range
+	size         int64
+}
+
+// NewJSONIndexHeader reads the JSON index cache file fn.
+func NewJSONIndexHeader(logger log.Logger, fn string) (*JSONIndexHeader, error) {
+	version, symbols, lvals, postings, err := ReadIndexCache(logger, fn)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONIndexHeader{
+		indexVersion: version,
+		symbols:      symbols,
+		lvals:        lvals,
+		postings:     postings,
+		size:         jsonIndexHeaderSize(symbols, lvals, postings),
+	}, nil
+}
+
+// jsonIndexHeaderSize estimates the memory used by the decoded index cache, counting the strings and
+// fixed size map entries but not the map overhead.
+func jsonIndexHeaderSize(symbols map[uint32]string, lvals map[string][]string, postings map[labels.Label]index.Range) int64 {
+	const (
+		stringHeaderSize = 16
+		rangeSize        = 16
+	)
+	var size int64
+	for _, s := range symbols {
+		size += 4 + stringHeaderSize + int64(len(s))
+	}
+	for n, vs := range lvals {
+		size += stringHeaderSize + int64(len(n))
+		for _, v := range vs {
+			size += stringHeaderSize + int64(len(v))
+		}
+	}
+	for l := range postings {
+		size += 2*stringHeaderSize + int64(len(l.Name)+len(l.Value)) + rangeSize
+	}
+	return size
+}
+
+// Close implements IndexHeader. It is a no-op.
+func (r *JSONIndexHeader) Close() error { return nil }
+
+// Size returns the estimated size of the decoded index cache.
+func (r *JSONIndexHeader) Size() int64 { return r.size }
+
+// IndexVersion returns the version of the index file the header was built from.
+func (r *JSONIndexHeader) IndexVersion() int {
+	return r.indexVersion
+}
+
+// LookupSymbol returns the symbol referenced by o in the index file.
+func (r *JSONIndexHeader) LookupSymbol(o uint32) (string, error) {
+	s, ok := r.symbols[o]
+	if !ok {
+		return "", errors.Errorf("unknown symbol offset %d", o)
+	}
+	return s, nil
+}
+
+// PostingsOffset returns the byte range of the postings list for the given label pair
+// within the index file. It returns false if the block has no postings for it.
+func (r *JSONIndexHeader) PostingsOffset(name, value string) (index.Range, bool) {
+	rng, ok := r.postings[labels.Label{Name: name, Value: value}]
+	return rng, ok
+}
+
+// LabelValues returns all values of the given label name, sorted.
+func (r *JSONIndexHeader) LabelValues(name string) []string {
+	res := make([]string, 0, len(r.lvals[name]))
+	return append(res, r.lvals[name]...)
+}
+
+// LabelNames returns all label names, sorted.
+func (r *JSONIndexHeader) LabelNames() []string {
+	res := make([]string, 0, len(r.lvals))
+	for ln := range r.lvals {
+		res = append(res, ln)
+	}
+	sort.Strings(res)
+	return res
+}
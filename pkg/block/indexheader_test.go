@@ -0,0 +1,131 @@
+package block
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/tsdb/index"
+	"github.com/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestWriteReadIndexHeader(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir, err := ioutil.TempDir("", "test-index-header")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(tmpDir)) }()
+
+	b, err := testutil.CreateBlock(ctx, tmpDir, []labels.Labels{
+		{{Name: "a", Value: "1"}},
+		{{Name: "a", Value: "2"}},
+		{{Name: "a", Value: "3"}},
+		{{Name: "a", Value: "4"}},
+		{{Name: "b", Value: "1"}},
+		{{Name: "a", Value: "1"}, {Name: "c", Value: "x"}},
+	}, 100, 0, 1000, nil, 124)
+	testutil.Ok(t, err)
+
+	indexFn := filepath.Join(tmpDir, b.String(), IndexFilename)
+	headerFn := filepath.Join(tmpDir, IndexHeaderFilename)
+	cacheFn := filepath.Join(tmpDir, IndexCacheFilename)
+	testutil.Ok(t, WriteIndexHeader(log.NewNopLogger(), indexFn, headerFn))
+	testutil.Ok(t, WriteIndexCache(log.NewNopLogger(), indexFn, cacheFn))
+
+	// The header is written to a temporary file first, which must be gone once it is renamed.
+	_, err = os.Stat(headerFn + ".tmp")
+	testutil.Assert(t, os.IsNotExist(err), "expected no temporary index header file, got %v", err)
+
+	header, err := NewBinaryIndexHeader(headerFn)
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, header.Close()) }()
+
+	testutil.Equals(t, 2, header.IndexVersion())
+	testutil.Equals(t, []string{"a", "b", "c"}, header.LabelNames())
+	testutil.Equals(t, []string{"1", "2", "3", "4"}, header.LabelValues("a"))
+	testutil.Equals(t, []string{"x"}, header.LabelValues("c"))
+	testutil.Equals(t, 0, len(header.LabelValues("missing")))
+
+	// The binary header must be equivalent to the JSON index cache.
+	version, symbols, lvals, postings, err := ReadIndexCache(log.NewNopLogger(), cacheFn)
+	testutil.Ok(t, err)
+	testutil.Equals(t, version, header.IndexVersion())
+
+	for o, s := range symbols {
+		hs, err := header.LookupSymbol(o)
+		testutil.Ok(t, err)
+		testutil.Equals(t, s, hs)
+	}
+	_, err = header.LookupSymbol(1e6)
+	testutil.NotOk(t, err)
+
+	for ln, vals := range lvals {
+		testutil.Equals(t, vals, header.LabelValues(ln))
+	}
+	testutil.Equals(t, 7, len(postings))
+	for l, rng := range postings {
+		hrng, ok := header.PostingsOffset(l.Name, l.Value)
+		testutil.Assert(t, ok, "missing postings for %v", l)
+		testutil.Equals(t, rng, hrng)
+	}
+	_, ok := header.PostingsOffset("a", "5")
+	testutil.Assert(t, !ok, "unexpected postings for a=5")
+	_, ok = header.PostingsOffset("d", "1")
+	testutil.Assert(t, !ok, "unexpected postings for d=1")
+
+	jsonHeader, err := NewJSONIndexHeader(log.NewNopLogger(), cacheFn)
+	testutil.Ok(t, err)
+	testutil.Equals(t, header.LabelNames(), jsonHeader.LabelNames())
+	rng, ok := jsonHeader.PostingsOffset(index.AllPostingsKey())
+	testutil.Assert(t, ok, "missing all postings")
+	hrng, _ := header.PostingsOffset(index.AllPostingsKey())
+	testutil.Equals(t, rng, hrng)
+
+	fi, err := os.Stat(headerFn)
+	testutil.Ok(t, err)
+	testutil.Equals(t, fi.Size(), header.Size())
+	testutil.Assert(t, jsonHeader.Size() > 0, "expected non zero size of JSON index header")
+}
+
+func TestNewBinaryIndexHeader_Corrupted(t *testing.T) {
+	ctx := context.Background()
+
+	tmpDir, err := ioutil.TempDir("", "test-index-header")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(tmpDir)) }()
+
+	b, err := testutil.CreateBlock(ctx, tmpDir, []labels.Labels{
+		{{Name: "a", Value: "1"}},
+	}, 100, 0, 1000, nil, 124)
+	testutil.Ok(t, err)
+
+	headerFn := filepath.Join(tmpDir, IndexHeaderFilename)
+	testutil.Ok(t, WriteIndexHeader(log.NewNopLogger(), filepath.Join(tmpDir, b.String(), IndexFilename), headerFn))
+
+	content, err := ioutil.ReadFile(headerFn)
+	testutil.Ok(t, err)
+
+	// Corrupted TOC.
+	corrupted := append([]byte{}, content...)
+	corrupted[len(corrupted)-5]++
+	_, err = newBinaryIndexHeader(corrupted)
+	testutil.NotOk(t, err)
+
+	// Invalid magic number.
+	corrupted = append([]byte{}, content...)
+	corrupted[0]++
+	_, err = newBinaryIndexHeader(corrupted)
+	testutil.NotOk(t, err)
+
+	// Truncated file.
+	_, err = newBinaryIndexHeader(content[:len(content)-1])
+	testutil.NotOk(t, err)
+
+	_, err = NewBinaryIndexHeader(filepath.Join(tmpDir, "missing"))
+	testutil.Assert(t, os.IsNotExist(errors.Cause(err)), "expected not exist error, got %v", err)
+}
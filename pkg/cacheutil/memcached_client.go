@@ -0,0 +1,337 @@
+// Package cacheutil implements clients of remote caches shared by multiple Thanos components.
+package cacheutil
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	opGetMulti = "getmulti"
+	opSet      = "set"
+
+	reasonMaxItemSize     = "max-item-size"
+	reasonAsyncBufferFull = "async-buffer-full"
+
+	// maxKeyLength is the maximum length of a key accepted by memcached.
+	maxKeyLength = 250
+)
+
+var (
+	errMemcachedAsyncBufferFull = errors.New("the async buffer is full")
+	errMemcachedMaxItemSize     = errors.New("the item exceeds the maximum size")
+
+	defaultMemcachedClientConfig = MemcachedClientConfig{
+		Timeout:              500 * time.Millisecond,
+		MaxIdleConnections:   100,
+		MaxAsyncConcurrency:  20,
+		MaxAsyncBufferSize:   10000,
+		MaxGetMultiBatchSize: 100,
+		MaxItemSize:          1024 * 1024,
+	}
+)
+
+// MemcachedClient is a high level client to interact with memcached.
+type MemcachedClient interface {
+	// GetMulti fetches multiple keys at once from memcached. Keys which are missing or could not be
+	// fetched because of an error are not part of the returned map.
+	GetMulti(ctx context.Context, keys []string) map[string][]byte
+
+	// SetAsync enqueues an asynchronous operation to store a key into memcached.
+	// It returns an error if the operation can not be enqueued.
+	SetAsync(key string, value []byte, ttl time.Duration) error
+
+	// Stop waits for all enqueued operations to be done and closes all connections.
+	Stop()
+}
+
+// MemcachedClientConfig is the config accepted by MemcachedClient.
+type MemcachedClientConfig struct {
+	// Addresses specifies the list of memcached addresses in host:port format. Keys are
+	// sharded across them by their hash.
+	Addresses []string `yaml:"addresses"`
+
+	// Timeout specifies the socket read/write timeout.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// MaxIdleConnections specifies the maximum number of idle connections kept open per memcached server.
+	MaxIdleConnections int `yaml:"max_idle_connections"`
+
+	// MaxAsyncConcurrency specifies the maximum number of concurrent asynchronous operations.
+	MaxAsyncConcurrency int `yaml:"max_async_concurrency"`
+
+	// MaxAsyncBufferSize specifies the maximum number of enqueued asynchronous operations. Operations
+	// enqueued while the buffer is full are skipped.
+	MaxAsyncBufferSize int `yaml:"max_async_buffer_size"`
+
+	// MaxGetMultiBatchSize specifies the maximum number of keys fetched from a server with a single
+	// request. Bigger multi-gets are split into batches fetched concurrently. 0 means no limit.
+	MaxGetMultiBatchSize int `yaml:"max_get_multi_batch_size"`
+
+	// MaxItemSize specifies the maximum size of an item stored in memcached. Bigger items are skipped.
+	// It should be set to the max item size configured in memcached (1MiB by default).
+	MaxItemSize int `yaml:"max_item_size"`
+}
+
+func (c *MemcachedClientConfig) validate() error {
+	if len(c.Addresses) == 0 {
+		return errors.New("no memcached addresses provided")
+	}
+	if c.MaxAsyncConcurrency <= 0 {
+		return errors.New("max async concurrency must be positive")
+	}
+	return nil
+}
+
+// parseMemcachedClientConfig unmarshals a buffer into a MemcachedClientConfig with default values.
+func parseMemcachedClientConfig(conf []byte) (MemcachedClientConfig, error) {
+	config := defaultMemcachedClientConfig
+	if err := yaml.Unmarshal(conf, &config); err != nil {
+		return MemcachedClientConfig{}, err
+	}
+	return config, nil
+}
+
+type memcachedClient struct {
+	logger  log.Logger
+	config  MemcachedClientConfig
+	servers []*memcachedServer
+
+	// Channel used to notify internal goroutines when they should quit.
+	stop chan struct{}
+	// Channel used to enqueue async operations.
+	asyncQueue chan func()
+	// Wait group used to wait for all async goroutines to complete before quitting.
+	workers sync.WaitGroup
+
+	operations *prometheus.CounterVec
+	failures   *prometheus.CounterVec
+	skipped    *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+}
+
+// NewMemcachedClient makes a new MemcachedClient from the given YAML config.
+func NewMemcachedClient(logger log.Logger, name string, conf []byte, reg prometheus.Registerer) (MemcachedClient, error) {
+	config, err := parseMemcachedClientConfig(conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing memcached client configuration")
+	}
+	return NewMemcachedClientWithConfig(logger, name, config, reg)
+}
+
+// NewMemcachedClientWithConfig makes a new MemcachedClient from the given config.
+func NewMemcachedClientWithConfig(logger log.Logger, name string, config MemcachedClientConfig, reg prometheus.Registerer) (MemcachedClient, error) {
+	if err := config.validate(); err != nil {
+		return nil, errors.Wrap(err, "validate memcached client configuration")
+	}
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+
+	c := &memcachedClient{
+		logger:     logger,
+		config:     config,
+		stop:       make(chan struct{}),
+		asyncQueue: make(chan func(), config.MaxAsyncBufferSize),
+	}
+	for _, addr := range config.Addresses {
+		c.servers = append(c.servers, &memcachedServer{
+			addr:    addr,
+			timeout: config.Timeout,
+			idle:    make(chan *memcachedConn, config.MaxIdleConnections),
+		})
+	}
+
+	constLabels := prometheus.Labels{"name": name}
+	c.operations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "thanos_memcached_operations_total",
+		Help:        "Total number of operations against memcached.",
+		ConstLabels: constLabels,
+	}, []string{"operation"})
+	c.failures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "thanos_memcached_operation_failures_total",
+		Help:        "Total number of operations against memcached that failed.",
+		ConstLabels: constLabels,
+	}, []string{"operation"})
+	c.skipped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:        "thanos_memcached_operation_skipped_total",
+		Help:        "Total number of operations against memcached that have been skipped.",
+		ConstLabels: constLabels,
+	}, []string{"operation", "reason"})
+	c.duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:        "thanos_memcached_operation_duration_seconds",
+		Help:        "Duration of operations against memcached.",
+		ConstLabels: constLabels,
+		Buckets:     []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.2, 0.5, 1},
+	}, []string{"operation"})
+	for _, op := range []string{opGetMulti, opSet} {
+		c.operations.WithLabelValues(op)
+		c.failures.WithLabelValues(op)
+		c.duration.WithLabelValues(op)
+	}
+	c.skipped.WithLabelValues(opSet, reasonMaxItemSize)
+	c.skipped.WithLabelValues(opSet, reasonAsyncBufferFull)
+
+	if reg != nil {
+		reg.MustRegister(c.operations, c.failures, c.skipped, c.duration)
+	}
+
+	level.Info(logger).Log("msg", "created memcached client", "addresses", fmt.Sprintf("%v", config.Addresses))
+
+	c.workers.Add(config.MaxAsyncConcurrency)
+	for i := 0; i < config.MaxAsyncConcurrency; i++ {
+		go c.asyncQueueProcessLoop()
+	}
+	return c, nil
+}
+
+func (c *memcachedClient) Stop() {
+	close(c.stop)
+
+	// Wait until all workers have terminated.
+	c.workers.Wait()
+
+	for _, s := range c.servers {
+		s.closeIdle()
+	}
+}
+
+func (c *memcachedClient) SetAsync(key string, value []byte, ttl time.Duration) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	// Skip hitting memcached at all if the item is bigger than the max allowed size.
+	if c.config.MaxItemSize > 0 && len(value) > c.config.MaxItemSize {
+		c.skipped.WithLabelValues(opSet, reasonMaxItemSize).Inc()
+		return errMemcachedMaxItemSize
+	}
+
+	err := c.enqueueAsync(func() {
+		start := time.Now()
+		c.operations.WithLabelValues(opSet).Inc()
+
+		if err := c.serverFor(key).set(key, value, ttl); err != nil {
+			level.Debug(c.logger).Log("msg", "failed to store item to memcached", "key", key, "err", err)
+			c.failures.WithLabelValues(opSet).Inc()
+			return
+		}
+		c.duration.WithLabelValues(opSet).Observe(time.Since(start).Seconds())
+	})
+	if err == errMemcachedAsyncBufferFull {
+		c.skipped.WithLabelValues(opSet, reasonAsyncBufferFull).Inc()
+	}
+	return err
+}
+
+func (c *memcachedClient) GetMulti(ctx context.Context, keys []string) map[string][]byte {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var (
+		mtx  sync.Mutex
+		hits = make(map[string][]byte, len(keys))
+	)
+
+	// Keys are fetched with one request per server and batch, all of them concurrently.
+	g, gctx := errgroup.WithContext(ctx)
+	for _, b := range c.batches(keys) {
+		server, batch := b.server, b.keys
+		g.Go(func() error {
+			start := time.Now()
+			c.operations.WithLabelValues(opGetMulti).Inc()
+
+			items, err := server.getMulti(gctx, batch)
+			if err != nil {
+				level.Debug(c.logger).Log("msg", "failed to fetch items from memcached", "server", server.addr, "keys", len(batch), "err", err)
+				c.failures.WithLabelValues(opGetMulti).Inc()
+				// Failed fetches are misses, the caller can fall back to the source of the data.
+				return nil
+			}
+			c.duration.WithLabelValues(opGetMulti).Observe(time.Since(start).Seconds())
+
+			mtx.Lock()
+			defer mtx.Unlock()
+			for k, v := range items {
+				hits[k] = v
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	return hits
+}
+
+type serverBatch struct {
+	server *memcachedServer
+	keys   []string
+}
+
+// batches groups keys by the server they belong to and splits them into batches of the max configured size.
+func (c *memcachedClient) batches(keys []string) []serverBatch {
+	byServer := map[*memcachedServer][]string{}
+	for _, k := range keys {
+		s := c.serverFor(k)
+		byServer[s] = append(byServer[s], k)
+	}
+
+	var batches []serverBatch
+	for _, s := range c.servers {
+		keys := byServer[s]
+		for len(keys) > 0 {
+			n := len(keys)
+			if c.config.MaxGetMultiBatchSize > 0 && n > c.config.MaxGetMultiBatchSize {
+				n = c.config.MaxGetMultiBatchSize
+			}
+			batches = append(batches, serverBatch{server: s, keys: keys[:n]})
+			keys = keys[n:]
+		}
+	}
+	return batches
+}
+
+func (c *memcachedClient) serverFor(key string) *memcachedServer {
+	return c.servers[xxhash.Sum64String(key)%uint64(len(c.servers))]
+}
+
+func (c *memcachedClient) enqueueAsync(op func()) error {
+	select {
+	case c.asyncQueue <- op:
+		return nil
+	default:
+		return errMemcachedAsyncBufferFull
+	}
+}
+
+func (c *memcachedClient) asyncQueueProcessLoop() {
+	defer c.workers.Done()
+
+	for {
+		select {
+		case op := <-c.asyncQueue:
+			op()
+		case <-c.stop:
+			// Process the operations enqueued before stopping.
+			for {
+				select {
+				case op := <-c.asyncQueue:
+					op()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
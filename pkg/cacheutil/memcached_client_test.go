@@ -0,0 +1,82 @@
+package cacheutil
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fortytw2/leaktest"
+	"github.com/go-kit/kit/log"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestMemcachedClient(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	ctx := context.Background()
+
+	s1, err := testutil.NewFakeMemcachedServer()
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, s1.Close()) }()
+	s2, err := testutil.NewFakeMemcachedServer()
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, s2.Close()) }()
+
+	conf := defaultMemcachedClientConfig
+	conf.Addresses = []string{s1.Addr(), s2.Addr()}
+	conf.MaxGetMultiBatchSize = 3
+	conf.MaxItemSize = 10
+
+	c, err := NewMemcachedClientWithConfig(log.NewNopLogger(), "test", conf, nil)
+	testutil.Ok(t, err)
+
+	expected := map[string][]byte{}
+	for i := 0; i < 20; i++ {
+		k := "key-" + strconv.Itoa(i)
+		expected[k] = []byte("value " + strconv.Itoa(i))
+		testutil.Ok(t, c.SetAsync(k, expected[k], time.Hour))
+	}
+	testutil.Equals(t, errMemcachedMaxItemSize, c.SetAsync("too-big", []byte("12345678901"), time.Hour))
+	testutil.NotOk(t, c.SetAsync("invalid key", []byte("v"), time.Hour))
+
+	// Stop waits for all enqueued operations.
+	c.Stop()
+
+	// Keys are sharded across both servers.
+	testutil.Equals(t, 20, len(s1.Items())+len(s2.Items()))
+	testutil.Assert(t, len(s1.Items()) > 0 && len(s2.Items()) > 0, "expected keys on both servers")
+
+	var keys []string
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	testutil.Equals(t, expected, c.GetMulti(ctx, keys))
+
+	// Keys are fetched in batches of the configured size from every server.
+	testutil.Equals(t, (len(s1.Items())+2)/3+(len(s2.Items())+2)/3, s1.Gets()+s2.Gets())
+
+	// Missing keys are not returned.
+	testutil.Equals(t, map[string][]byte{"key-1": expected["key-1"]}, c.GetMulti(ctx, []string{"key-1", "missing"}))
+
+	// Unavailable servers result in misses.
+	testutil.Ok(t, s2.Close())
+	hits := c.GetMulti(ctx, keys)
+	testutil.Equals(t, len(s1.Items()), len(hits))
+}
+
+func TestMemcachedClientConfig(t *testing.T) {
+	conf, err := parseMemcachedClientConfig([]byte(`
+addresses: [localhost:11211]
+timeout: 1s
+max_get_multi_batch_size: 0
+`))
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"localhost:11211"}, conf.Addresses)
+	testutil.Equals(t, time.Second, conf.Timeout)
+	testutil.Equals(t, 0, conf.MaxGetMultiBatchSize)
+	testutil.Equals(t, defaultMemcachedClientConfig.MaxAsyncConcurrency, conf.MaxAsyncConcurrency)
+
+	_, err = NewMemcachedClient(nil, "test", []byte(`timeout: 1s`), nil)
+	testutil.NotOk(t, err)
+}
@@ -0,0 +1,220 @@
+package cacheutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	crlf = []byte("\r\n")
+
+	respEnd      = []byte("END\r\n")
+	respStored   = []byte("STORED\r\n")
+	respNotStore = []byte("NOT_STORED\r\n")
+	prefixValue  = []byte("VALUE ")
+)
+
+// memcachedServer is a single memcached server speaking the text protocol, with a pool of idle connections.
+type memcachedServer struct {
+	addr    string
+	timeout time.Duration
+	idle    chan *memcachedConn
+}
+
+type memcachedConn struct {
+	net.Conn
+	rw *bufio.ReadWriter
+}
+
+func (s *memcachedServer) conn(ctx context.Context) (*memcachedConn, error) {
+	select {
+	case c := <-s.idle:
+		return c, nil
+	default:
+	}
+
+	d := net.Dialer{Timeout: s.timeout}
+	c, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "dial %s", s.addr)
+	}
+	return &memcachedConn{Conn: c, rw: bufio.NewReadWriter(bufio.NewReader(c), bufio.NewWriter(c))}, nil
+}
+
+// release returns the connection to the idle pool if it is still usable, or closes it otherwise.
+func (s *memcachedServer) release(c *memcachedConn, err error) {
+	if err == nil {
+		select {
+		case s.idle <- c:
+			return
+		default:
+		}
+	}
+	_ = c.Close()
+}
+
+func (s *memcachedServer) closeIdle() {
+	for {
+		select {
+		case c := <-s.idle:
+			_ = c.Close()
+		default:
+			return
+		}
+	}
+}
+
+// deadline returns the deadline of an operation, which is the configured timeout or the context deadline if earlier.
+func (s *memcachedServer) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d
+	}
+	return deadline
+}
+
+// getMulti fetches the given keys with a single get command.
+func (s *memcachedServer) getMulti(ctx context.Context, keys []string) (_ map[string][]byte, err error) {
+	for _, k := range keys {
+		if err := validKey(k); err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := s.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { s.release(c, err) }()
+
+	if err := c.SetDeadline(s.deadline(ctx)); err != nil {
+		return nil, err
+	}
+
+	if _, err := c.rw.WriteString("get"); err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if _, err := fmt.Fprintf(c.rw, " %s", k); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := c.rw.Write(crlf); err != nil {
+		return nil, err
+	}
+	if err := c.rw.Flush(); err != nil {
+		return nil, err
+	}
+
+	items := make(map[string][]byte, len(keys))
+	for {
+		line, err := c.rw.ReadSlice('\n')
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(line, respEnd) {
+			return items, nil
+		}
+		if !bytes.HasPrefix(line, prefixValue) {
+			return nil, errors.Errorf("unexpected response line %q", line)
+		}
+
+		// VALUE <key> <flags> <bytes> [<cas unique>]
+		fields := bytes.Fields(line)
+		if len(fields) < 4 {
+			return nil, errors.Errorf("malformed response line %q", line)
+		}
+		key := string(fields[1])
+		size, err := strconv.Atoi(string(fields[3]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "malformed response line %q", line)
+		}
+
+		value := make([]byte, size+len(crlf))
+		if _, err := io.ReadFull(c.rw, value); err != nil {
+			return nil, err
+		}
+		if !bytes.HasSuffix(value, crlf) {
+			return nil, errors.New("corrupt value in response")
+		}
+		items[key] = value[:size]
+	}
+}
+
+// set stores the value under the given key with a set command.
+func (s *memcachedServer) set(key string, value []byte, ttl time.Duration) (err error) {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	c, err := s.conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { s.release(c, err) }()
+
+	if err := c.SetDeadline(s.deadline(ctx)); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(c.rw, "set %s 0 %d %d\r\n", key, expiration(ttl), len(value)); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(value); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(crlf); err != nil {
+		return err
+	}
+	if err := c.rw.Flush(); err != nil {
+		return err
+	}
+
+	line, err := c.rw.ReadSlice('\n')
+	if err != nil {
+		return err
+	}
+	switch {
+	case bytes.Equal(line, respStored):
+		return nil
+	case bytes.Equal(line, respNotStore):
+		return errors.New("item not stored")
+	default:
+		return errors.Errorf("unexpected response line %q", line)
+	}
+}
+
+// expiration converts the TTL to the expiration time used by memcached. TTLs longer than 30 days
+// are interpreted as an absolute unix timestamp by memcached.
+func expiration(ttl time.Duration) int64 {
+	const maxRelativeExpiration = 30 * 24 * time.Hour
+
+	if ttl <= 0 {
+		return 0
+	}
+	if ttl > maxRelativeExpiration {
+		return time.Now().Add(ttl).Unix()
+	}
+	return int64(ttl / time.Second)
+}
+
+func validKey(key string) error {
+	if len(key) == 0 || len(key) > maxKeyLength {
+		return errors.Errorf("invalid memcached key length %d", len(key))
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return errors.Errorf("invalid character in memcached key %q", key)
+		}
+	}
+	return nil
+}
@@ -40,16 +40,17 @@ var blockTooFreshSentinelError = errors.New("Block too fresh")
 // Syncer syncronizes block metas from a bucket into a local directory.
 // It sorts them into compaction groups based on equal label sets.
 type Syncer struct {
-	logger               log.Logger
-	reg                  prometheus.Registerer
-	bkt                  objstore.Bucket
-	consistencyDelay     time.Duration
-	mtx                  sync.Mutex
-	blocks               map[ulid.ULID]*metadata.Meta
-	blocksMtx            sync.Mutex
-	blockSyncConcurrency int
-	metrics              *syncerMetrics
-	acceptMalformedIndex bool
+	logger                 log.Logger
+	reg                    prometheus.Registerer
+	bkt                    objstore.Bucket
+	consistencyDelay       time.Duration
+	mtx                    sync.Mutex
+	blocks                 map[ulid.ULID]*metadata.Meta
+	blocksMtx              sync.Mutex
+	blockSyncConcurrency   int
+	metrics                *syncerMetrics
+	acceptMalformedIndex   bool
+	blockUploadConcurrency int
 }
 
 type syncerMetrics struct {
@@ -131,19 +132,21 @@ func newSyncerMetrics(reg prometheus.Registerer) *syncerMetrics {
 
 // NewSyncer returns a new Syncer for the given Bucket and directory.
 // Blocks must be at least as old as the sync delay for being considered.
-func NewSyncer(logger log.Logger, reg prometheus.Registerer, bkt objstore.Bucket, consistencyDelay time.Duration, blockSyncConcurrency int, acceptMalformedIndex bool) (*Syncer, error) {
+// Compacted blocks are uploaded with blockUploadConcurrency files in parallel.
+func NewSyncer(logger log.Logger, reg prometheus.Registerer, bkt objstore.Bucket, consistencyDelay time.Duration, blockSyncConcurrency int, acceptMalformedIndex bool, blockUploadConcurrency int) (*Syncer, error) {
 	if logger == nil {
 		logger = log.NewNopLogger()
 	}
 	return &Syncer{
-		logger:               logger,
-		reg:                  reg,
-		consistencyDelay:     consistencyDelay,
-		blocks:               map[ulid.ULID]*metadata.Meta{},
-		bkt:                  bkt,
-		metrics:              newSyncerMetrics(reg),
-		blockSyncConcurrency: blockSyncConcurrency,
-		acceptMalformedIndex: acceptMalformedIndex,
+		logger:                 logger,
+		reg:                    reg,
+		consistencyDelay:       consistencyDelay,
+		blocks:                 map[ulid.ULID]*metadata.Meta{},
+		bkt:                    bkt,
+		metrics:                newSyncerMetrics(reg),
+		blockSyncConcurrency:   blockSyncConcurrency,
+		acceptMalformedIndex:   acceptMalformedIndex,
+		blockUploadConcurrency: blockUploadConcurrency,
 	}, nil
 }
 
@@ -198,6 +201,8 @@ func (c *Syncer) syncMetas(ctx context.Context) error {
 						continue
 					}
 					errChan <- err
+					// Stop feeding the workers, the sync fails anyway.
+					cancel()
 					return
 				}
 
@@ -220,7 +225,7 @@ func (c *Syncer) syncMetas(ctx context.Context) error {
 		remote[id] = struct{}{}
 
 		select {
-		case <-ctx.Done():
+		case <-workCtx.Done():
 		case metaIDsChan <- id:
 		}
 
@@ -331,6 +336,7 @@ func (c *Syncer) Groups() (res []*Group, err error) {
 				labels.FromMap(m.Thanos.Labels),
 				m.Thanos.Downsample.Resolution,
 				c.acceptMalformedIndex,
+				c.blockUploadConcurrency,
 				c.metrics.compactions.WithLabelValues(GroupKey(*m)),
 				c.metrics.compactionFailures.WithLabelValues(GroupKey(*m)),
 				c.metrics.garbageCollectedBlocks,
@@ -477,6 +483,7 @@ type Group struct {
 	mtx                         sync.Mutex
 	blocks                      map[ulid.ULID]*metadata.Meta
 	acceptMalformedIndex        bool
+	blockUploadConcurrency      int
 	compactions                 prometheus.Counter
 	compactionFailures          prometheus.Counter
 	groupGarbageCollectedBlocks prometheus.Counter
@@ -489,6 +496,7 @@ func newGroup(
 	lset labels.Labels,
 	resolution int64,
 	acceptMalformedIndex bool,
+	blockUploadConcurrency int,
 	compactions prometheus.Counter,
 	compactionFailures prometheus.Counter,
 	groupGarbageCollectedBlocks prometheus.Counter,
@@ -503,6 +511,7 @@ func newGroup(
 		resolution:                  resolution,
 		blocks:                      map[ulid.ULID]*metadata.Meta{},
 		acceptMalformedIndex:        acceptMalformedIndex,
+		blockUploadConcurrency:      blockUploadConcurrency,
 		compactions:                 compactions,
 		compactionFailures:          compactionFailures,
 		groupGarbageCollectedBlocks: groupGarbageCollectedBlocks,
@@ -870,6 +879,7 @@ func (cg *Group) compact(ctx context.Context, dir string, comp tsdb.Compactor) (
 	bdir := filepath.Join(dir, compID.String())
 	index := filepath.Join(bdir, block.IndexFilename)
 	indexCache := filepath.Join(bdir, block.IndexCacheFilename)
+	indexHeader := filepath.Join(bdir, block.IndexHeaderFilename)
 
 	newMeta, err := metadata.InjectThanos(cg.logger, bdir, metadata.Thanos{
 		Labels:     cg.labels.Map(),
@@ -898,9 +908,13 @@ func (cg *Group) compact(ctx context.Context, dir string, comp tsdb.Compactor) (
 		return false, ulid.ULID{}, errors.Wrap(err, "write index cache")
 	}
 
+	if err := block.WriteIndexHeader(cg.logger, index, indexHeader); err != nil {
+		return false, ulid.ULID{}, errors.Wrap(err, "write index header")
+	}
+
 	begin = time.Now()
 
-	if err := block.Upload(ctx, cg.logger, cg.bkt, bdir); err != nil {
+	if err := block.Upload(ctx, cg.logger, cg.bkt, bdir, objstore.WithUploadConcurrency(cg.blockUploadConcurrency)); err != nil {
 		return false, ulid.ULID{}, retry(errors.Wrapf(err, "upload of %s failed", compID))
 	}
 	level.Debug(cg.logger).Log("msg", "uploaded block", "result_block", compID, "duration", time.Since(begin))
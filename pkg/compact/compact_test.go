@@ -3,13 +3,18 @@ package compact
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"path"
 	"testing"
 	"time"
 
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
+	"github.com/prometheus/tsdb"
 	terrors "github.com/prometheus/tsdb/errors"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/inmem"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
@@ -75,7 +80,7 @@ func TestSyncer_SyncMetas_HandlesMalformedBlocks(t *testing.T) {
 	defer cancel()
 
 	bkt := inmem.NewBucket()
-	sy, err := NewSyncer(nil, nil, bkt, 10*time.Second, 1, false)
+	sy, err := NewSyncer(nil, nil, bkt, 10*time.Second, 1, false, 1)
 	testutil.Ok(t, err)
 
 	// Generate 1 block which is older than MinimumAgeForRemoval which has chunk data but no meta.  Compactor should delete it.
@@ -103,3 +108,93 @@ func TestSyncer_SyncMetas_HandlesMalformedBlocks(t *testing.T) {
 	testutil.Ok(t, err)
 	testutil.Equals(t, true, exists)
 }
+
+func TestSyncer_SyncMetas_FaultyBucket(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	inner := inmem.NewBucket()
+	bkt := objstore.BucketWithFaults(inner, objstore.Faults{}, 0)
+	sy, err := NewSyncer(nil, nil, bkt, 0, 2, false, 1)
+	testutil.Ok(t, err)
+
+	// Blocks are older than MinimumAgeForRemoval, so the syncer would delete them if it considered them malformed.
+	uploadBlock := func(b objstore.Bucket, i int) {
+		id := ulid.MustNew(uint64(time.Now().Add(-time.Hour).Unix()*1000)+uint64(i), nil)
+		meta := metadata.Meta{
+			BlockMeta: tsdb.BlockMeta{ULID: id, MinTime: int64(i) * 1000, MaxTime: int64(i+1) * 1000, Version: 1},
+			Thanos: metadata.Thanos{
+				Labels: map[string]string{"a": "1"},
+				Source: metadata.TestSource,
+			},
+		}
+		var buf bytes.Buffer
+		testutil.Ok(t, json.NewEncoder(&buf).Encode(&meta))
+		testutil.Ok(t, b.Upload(ctx, path.Join(id.String(), "chunks", "000001"), bytes.NewReader([]byte{0, 1, 2, 3})))
+		testutil.Ok(t, b.Upload(ctx, path.Join(id.String(), block.MetaFilename), &buf))
+	}
+	for i := 0; i < 3; i++ {
+		uploadBlock(inner, i)
+	}
+	syncedBlocks := func() int {
+		sy.blocksMtx.Lock()
+		defer sy.blocksMtx.Unlock()
+		return len(sy.blocks)
+	}
+
+	for _, tcase := range []struct {
+		name   string
+		faults objstore.Faults
+	}{
+		{
+			name:   "iter fails",
+			faults: objstore.Faults{Match: func(op, _ string) bool { return op == objstore.OpIter }, ErrorProbability: 1},
+		},
+		{
+			name:   "meta.json download fails",
+			faults: objstore.Faults{Match: func(op, _ string) bool { return op == objstore.OpGet }, ErrorProbability: 1},
+		},
+		{
+			name:   "meta.json read is truncated",
+			faults: objstore.Faults{TruncatedReadProbability: 1},
+		},
+		{
+			name:   "meta.json download and exists check fail",
+			faults: objstore.Faults{Match: func(op, _ string) bool { return op != objstore.OpIter }, ErrorProbability: 1},
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			bkt.SetFaults(tcase.faults)
+
+			err := sy.SyncMetas(ctx)
+			testutil.NotOk(t, err)
+			testutil.Assert(t, IsRetryError(err), "expected retry error, got %v", err)
+
+			// Nothing is deleted from the bucket because of a flaky provider.
+			testutil.Equals(t, 6, len(inner.Objects()))
+		})
+	}
+
+	bkt.SetFaults(objstore.Faults{IterVisibilityDelay: 100 * time.Millisecond})
+	testutil.Ok(t, sy.SyncMetas(ctx))
+	testutil.Equals(t, 3, syncedBlocks())
+
+	// Newly uploaded block is synced once it becomes visible.
+	uploadBlock(bkt, 3)
+	testutil.Ok(t, sy.SyncMetas(ctx))
+	testutil.Equals(t, 3, syncedBlocks())
+
+	time.Sleep(100 * time.Millisecond)
+	testutil.Ok(t, sy.SyncMetas(ctx))
+	testutil.Equals(t, 4, syncedBlocks())
+
+	// Already synced blocks do not need to be downloaded again.
+	bkt.SetFaults(objstore.Faults{Match: func(op, _ string) bool { return op == objstore.OpGet }, ErrorProbability: 1})
+	testutil.Ok(t, sy.SyncMetas(ctx))
+	testutil.Equals(t, 4, syncedBlocks())
+
+	groups, err := sy.Groups()
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, len(groups))
+	testutil.Equals(t, 4, len(groups[0].IDs()))
+}
@@ -202,6 +202,14 @@ func (w *streamedBlockWriter) Close() error {
 		return errors.Wrap(err, "write index cache")
 	}
 
+	if err := block.WriteIndexHeader(
+		w.logger,
+		filepath.Join(w.blockDir, block.IndexFilename),
+		filepath.Join(w.blockDir, block.IndexHeaderFilename),
+	); err != nil {
+		return errors.Wrap(err, "write index header")
+	}
+
 	if err := w.writeMetaFile(); err != nil {
 		return errors.Wrap(err, "write meta meta")
 	}
@@ -0,0 +1,76 @@
+// Package model contains types shared by Thanos components, e.g. for flag parsing.
+package model
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+// TimeOrDurationValue is a flag type that can be either an absolute time in RFC3339 format or a duration
+// relative to the current time, e.g. -2d.
+type TimeOrDurationValue struct {
+	Time *time.Time
+	Dur  *model.Duration
+}
+
+// Set converts string to TimeOrDurationValue.
+func (tdv *TimeOrDurationValue) Set(s string) error {
+	t, err := time.Parse(time.RFC3339, s)
+	if err == nil {
+		tdv.Time = &t
+		tdv.Dur = nil
+		return nil
+	}
+
+	// Parse as a duration, allowing a leading minus sign for times in the past.
+	sign := time.Duration(1)
+	ds := s
+	if len(ds) > 0 && ds[0] == '-' {
+		sign = -1
+		ds = ds[1:]
+	}
+	dur, err := model.ParseDuration(ds)
+	if err != nil {
+		return errors.Errorf("%q is neither a RFC3339 time nor a duration", s)
+	}
+	dur = model.Duration(sign * time.Duration(dur))
+	tdv.Dur = &dur
+	tdv.Time = nil
+	return nil
+}
+
+// String returns either the time or the duration as a string.
+func (tdv *TimeOrDurationValue) String() string {
+	switch {
+	case tdv.Time != nil:
+		return tdv.Time.String()
+	case tdv.Dur != nil:
+		if time.Duration(*tdv.Dur) < 0 {
+			return "-" + model.Duration(-time.Duration(*tdv.Dur)).String()
+		}
+		return tdv.Dur.String()
+	}
+	return "nil"
+}
+
+// PrometheusTimestamp returns the value as a timestamp in milliseconds. Durations are relative to the current time.
+func (tdv *TimeOrDurationValue) PrometheusTimestamp() int64 {
+	if tdv.Dur != nil {
+		return timestamp.FromTime(time.Now().Add(time.Duration(*tdv.Dur)))
+	}
+	if tdv.Time != nil {
+		return timestamp.FromTime(*tdv.Time)
+	}
+	return 0
+}
+
+// TimeOrDuration helper for parsing TimeOrDuration with kingpin.
+func TimeOrDuration(flags *kingpin.FlagClause) *TimeOrDurationValue {
+	value := new(TimeOrDurationValue)
+	flags.SetValue(value)
+	return value
+}
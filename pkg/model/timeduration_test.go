@@ -0,0 +1,30 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/thanos-io/thanos/pkg/model"
+	"github.com/thanos-io/thanos/pkg/testutil"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestTimeOrDurationValue(t *testing.T) {
+	cmd := kingpin.New("test", "test")
+
+	minTime := model.TimeOrDuration(cmd.Flag("min-time", "Start of the time range."))
+	maxTime := model.TimeOrDuration(cmd.Flag("max-time", "End of the time range."))
+
+	_, err := cmd.Parse([]string{"--min-time=-10h", "--max-time=2019-07-01T00:00:00Z"})
+	testutil.Ok(t, err)
+
+	testutil.Equals(t, "-10h", minTime.String())
+	expected := timestamp.FromTime(time.Now().Add(-10 * time.Hour))
+	testutil.Assert(t, minTime.PrometheusTimestamp()-expected < 1000, "unexpected min time %d, expected about %d", minTime.PrometheusTimestamp(), expected)
+
+	testutil.Equals(t, int64(1561939200000), maxTime.PrometheusTimestamp())
+
+	_, err = cmd.Parse([]string{"--min-time=yesterday"})
+	testutil.NotOk(t, err)
+}
@@ -165,6 +165,14 @@ func (b *Bucket) IsObjNotFoundErr(err error) bool {
 	return false
 }
 
+// IsRetryableErr returns true if the operation that returned the error can be retried.
+func (b *Bucket) IsRetryableErr(err error) bool {
+	if storageErr, ok := errors.Cause(err).(blob.StorageError); ok && storageErr.Response() != nil {
+		return objstore.IsRetryableHTTPStatus(storageErr.Response().StatusCode)
+	}
+	return objstore.IsTransientErr(err)
+}
+
 func (b *Bucket) getBlobReader(ctx context.Context, name string, offset, length int64) (io.ReadCloser, error) {
 	level.Debug(b.logger).Log("msg", "getting blob", "blob", name, "offset", offset, "length", length)
 	if len(name) == 0 {
@@ -242,6 +250,26 @@ func (b *Bucket) Exists(ctx context.Context, name string) (bool, error) {
 	return true, nil
 }
 
+// Attributes returns information about the specified object.
+func (b *Bucket) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	level.Debug(b.logger).Log("msg", "getting blob attributes", "blob", name)
+	blobURL, err := getBlobURL(ctx, *b.config, name)
+	if err != nil {
+		return objstore.ObjectAttributes{}, errors.Wrapf(err, "cannot get Azure blob URL, address: %s", name)
+	}
+
+	props, err := blobURL.GetProperties(ctx, blob.BlobAccessConditions{})
+	if err != nil {
+		return objstore.ObjectAttributes{}, errors.Wrapf(err, "cannot get properties for Azure blob, address: %s", name)
+	}
+
+	return objstore.ObjectAttributes{
+		Size:         props.ContentLength(),
+		LastModified: props.LastModified(),
+		MD5:          props.ContentMD5(),
+	}, nil
+}
+
 // Upload the contents of the reader as an object into the bucket.
 func (b *Bucket) Upload(ctx context.Context, name string, r io.Reader) error {
 	level.Debug(b.logger).Log("msg", "Uploading blob", "blob", name)
@@ -12,6 +12,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/azure"
 	"github.com/thanos-io/thanos/pkg/objstore/cos"
+	"github.com/thanos-io/thanos/pkg/objstore/filesystem"
 	"github.com/thanos-io/thanos/pkg/objstore/gcs"
 	"github.com/thanos-io/thanos/pkg/objstore/s3"
 	"github.com/thanos-io/thanos/pkg/objstore/swift"
@@ -21,16 +22,21 @@ import (
 type ObjProvider string
 
 const (
-	GCS   ObjProvider = "GCS"
-	S3    ObjProvider = "S3"
-	AZURE ObjProvider = "AZURE"
-	SWIFT ObjProvider = "SWIFT"
-	COS   ObjProvider = "COS"
+	GCS        ObjProvider = "GCS"
+	S3         ObjProvider = "S3"
+	AZURE      ObjProvider = "AZURE"
+	SWIFT      ObjProvider = "SWIFT"
+	COS        ObjProvider = "COS"
+	FILESYSTEM ObjProvider = "FILESYSTEM"
 )
 
 type BucketConfig struct {
-	Type   ObjProvider `yaml:"type"`
-	Config interface{} `yaml:"config"`
+	Type       ObjProvider               `yaml:"type"`
+	Config     interface{}               `yaml:"config"`
+	Prefix     string                    `yaml:"prefix"`
+	Retry      objstore.RetryConfig      `yaml:"retry"`
+	RateLimit  objstore.RateLimitConfig  `yaml:"rate_limit"`
+	Encryption objstore.EncryptionConfig `yaml:"encryption"`
 }
 
 // NewBucket initializes and returns new object storage clients.
@@ -59,11 +65,33 @@ func NewBucket(logger log.Logger, confContentYaml []byte, reg prometheus.Registe
 		bucket, err = swift.NewContainer(logger, config)
 	case string(COS):
 		bucket, err = cos.NewBucket(logger, config, component)
+	case string(FILESYSTEM):
+		bucket, err = filesystem.NewBucketFromConfig(config)
 	default:
 		return nil, errors.Errorf("bucket with type %s is not supported", bucketConf.Type)
 	}
 	if err != nil {
 		return nil, errors.Wrap(err, fmt.Sprintf("create %s client", bucketConf.Type))
 	}
+
+	if bucketConf.RateLimit.Enabled() {
+		bucket = objstore.BucketWithRateLimits(bucket, bucketConf.RateLimit, reg)
+	}
+	if bucketConf.Retry.Enabled() {
+		bucket = objstore.BucketWithRetries(logger, bucket, bucketConf.Retry, reg)
+	}
+	bucket = objstore.BucketWithPrefix(bucket, bucketConf.Prefix)
+
+	if bucketConf.Encryption.Enabled() {
+		key, err := bucketConf.Encryption.ParseKey()
+		if err != nil {
+			return nil, errors.Wrap(err, "parse encryption configuration")
+		}
+		bucket, err = objstore.BucketWithEncryption(bucket, key)
+		if err != nil {
+			return nil, errors.Wrap(err, "create encrypted bucket")
+		}
+		level.Info(logger).Log("msg", "client-side encryption of objects enabled")
+	}
 	return objstore.BucketWithMetrics(bucket.Name(), bucket, reg), nil
 }
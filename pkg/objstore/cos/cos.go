@@ -1,6 +1,7 @@
 package cos
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -12,6 +13,7 @@ import (
 	"time"
 
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	cos "github.com/mozillazg/go-cos"
 	"github.com/pkg/errors"
 	"github.com/thanos-io/thanos/pkg/objstore"
@@ -24,9 +26,10 @@ const dirDelim = "/"
 
 // Bucket implements the store.Bucket interface against cos-compatible(Tencent Object Storage) APIs.
 type Bucket struct {
-	logger log.Logger
-	client *cos.Client
-	name   string
+	logger   log.Logger
+	client   *cos.Client
+	name     string
+	partSize int64
 }
 
 // Config encapsulates the necessary config values to instantiate an cos client.
@@ -36,8 +39,13 @@ type Config struct {
 	AppId     string `yaml:"app_id"`
 	SecretKey string `yaml:"secret_key"`
 	SecretId  string `yaml:"secret_id"`
+	PartSize  uint64 `yaml:"part_size"`
 }
 
+// Minimum object size after which a multipart upload should be used to upload objects to storage.
+// Set to 128 MiB as in the S3 client. COS requires all parts but the last one to be at least 1 MiB.
+const defaultPartSize = 1024 * 1024 * 128
+
 // Validate checks to see if mandatory cos config options are set.
 func (conf *Config) validate() error {
 	if conf.Bucket == "" ||
@@ -77,10 +85,15 @@ func NewBucket(logger log.Logger, conf []byte, component string) (*Bucket, error
 		},
 	})
 
+	if config.PartSize == 0 {
+		config.PartSize = defaultPartSize
+	}
+
 	bkt := &Bucket{
-		logger: logger,
-		client: client,
-		name:   config.Bucket,
+		logger:   logger,
+		client:   client,
+		name:     config.Bucket,
+		partSize: int64(config.PartSize),
 	}
 	return bkt, nil
 }
@@ -91,13 +104,71 @@ func (b *Bucket) Name() string {
 }
 
 // Upload the contents of the reader as an object into the bucket.
+// Objects larger than the configured part size are uploaded in parts.
 func (b *Bucket) Upload(ctx context.Context, name string, r io.Reader) error {
-	if _, err := b.client.Object.Put(ctx, name, r, nil); err != nil {
-		return errors.Wrap(err, "upload cos object")
+	if size, err := objstore.TryToGetSize(r); err == nil && size <= b.partSize {
+		if _, err := b.client.Object.Put(ctx, name, r, nil); err != nil {
+			return errors.Wrap(err, "upload cos object")
+		}
+		return nil
+	}
+
+	// Size is either too big or unknown, read the first part to find out.
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, r, b.partSize)
+	if err != nil && err != io.EOF {
+		return errors.Wrap(err, "read object")
+	}
+	if n < b.partSize {
+		if _, err := b.client.Object.Put(ctx, name, &buf, nil); err != nil {
+			return errors.Wrap(err, "upload cos object")
+		}
+		return nil
+	}
+	return b.multipartUpload(ctx, name, &buf, r)
+}
+
+// multipartUpload uploads the object in parts of the configured size, starting with the already read first one.
+func (b *Bucket) multipartUpload(ctx context.Context, name string, buf *bytes.Buffer, r io.Reader) error {
+	res, _, err := b.client.Object.InitiateMultipartUpload(ctx, name, nil)
+	if err != nil {
+		return errors.Wrap(err, "initiate multipart upload")
+	}
+
+	var parts []cos.Object
+	for {
+		resp, err := b.client.Object.UploadPart(ctx, name, res.UploadID, len(parts)+1, bytes.NewReader(buf.Bytes()), &cos.ObjectUploadPartOptions{
+			ContentLength: buf.Len(),
+		})
+		if err != nil {
+			return b.abortMultipartUpload(name, res.UploadID, errors.Wrapf(err, "upload part %d", len(parts)+1))
+		}
+		parts = append(parts, cos.Object{PartNumber: len(parts) + 1, ETag: resp.Header.Get("ETag")})
+
+		buf.Reset()
+		n, err := io.CopyN(buf, r, b.partSize)
+		if err != nil && err != io.EOF {
+			return b.abortMultipartUpload(name, res.UploadID, errors.Wrap(err, "read object"))
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	if _, _, err := b.client.Object.CompleteMultipartUpload(ctx, name, res.UploadID, &cos.CompleteMultipartUploadOptions{Parts: parts}); err != nil {
+		return b.abortMultipartUpload(name, res.UploadID, errors.Wrap(err, "complete multipart upload"))
 	}
 	return nil
 }
 
+func (b *Bucket) abortMultipartUpload(name, uploadID string, err error) error {
+	// Abort with an uncancelable context, so uploaded parts are not left behind if the upload was canceled.
+	if _, abortErr := b.client.Object.AbortMultipartUpload(context.Background(), name, uploadID); abortErr != nil {
+		level.Warn(b.logger).Log("msg", "failed to abort multipart upload", "name", name, "err", abortErr)
+	}
+	return err
+}
+
 // Delete removes the object with the given name.
 func (b *Bucket) Delete(ctx context.Context, name string) error {
 	if _, err := b.client.Object.Delete(ctx, name); err != nil {
@@ -174,6 +245,25 @@ func (b *Bucket) Exists(ctx context.Context, name string) (bool, error) {
 	return true, nil
 }
 
+// Attributes returns information about the specified object.
+func (b *Bucket) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	resp, err := b.client.Object.Head(ctx, name, nil)
+	if err != nil {
+		return objstore.ObjectAttributes{}, err
+	}
+
+	lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+	if err != nil {
+		return objstore.ObjectAttributes{}, errors.Wrap(err, "parse last modified header")
+	}
+
+	return objstore.ObjectAttributes{
+		Size:         resp.ContentLength,
+		LastModified: lastModified,
+		MD5:          objstore.MD5FromETag(resp.Header.Get("ETag")),
+	}, nil
+}
+
 // IsObjNotFoundErr returns true if error means that object is not found. Relevant to Get operations.
 func (b *Bucket) IsObjNotFoundErr(err error) bool {
 	switch tmpErr := err.(type) {
@@ -188,6 +278,14 @@ func (b *Bucket) IsObjNotFoundErr(err error) bool {
 	}
 }
 
+// IsRetryableErr returns true if the operation that returned the error can be retried.
+func (b *Bucket) IsRetryableErr(err error) bool {
+	if tmpErr, ok := errors.Cause(err).(*cos.ErrorResponse); ok && tmpErr.Response != nil {
+		return objstore.IsRetryableHTTPStatus(tmpErr.Response.StatusCode)
+	}
+	return objstore.IsTransientErr(err)
+}
+
 func (b *Bucket) Close() error { return nil }
 
 type objectInfo struct {
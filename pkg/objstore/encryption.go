@@ -0,0 +1,439 @@
+package objstore
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Encrypted objects are stored in a chunked format, so ranges can be decrypted without reading whole objects:
+//
+//	magic (4b) | version (1b) | chunk 0 | chunk 1 | ... | chunk n (final)
+//
+// Each chunk holds up to encChunkSize bytes of plaintext and is laid out as nonce (12b) | ciphertext | tag (16b).
+// The chunk index and whether the chunk is the final one are authenticated, so reordered, dropped or truncated
+// chunks are detected on read. Every object ends with a final chunk, which might be empty.
+const (
+	encMagic         = "TENC"
+	encVersion       = byte(1)
+	encHeaderSize    = len(encMagic) + 1
+	encChunkSize     = 64 * 1024
+	encNonceSize     = 12
+	encTagSize       = 16
+	encChunkOverhead = encNonceSize + encTagSize
+	encChunkSizeSeal = encChunkSize + encChunkOverhead
+	encKeySize       = 32
+)
+
+// EncryptionConfig configures client-side encryption of objects.
+type EncryptionConfig struct {
+	// Key is a base64 encoded 256 bit AES key. Empty key disables encryption.
+	Key string `yaml:"key"`
+}
+
+// Enabled returns true if encryption is configured.
+func (c EncryptionConfig) Enabled() bool {
+	return c.Key != ""
+}
+
+// ParseKey decodes and validates the configured key.
+func (c EncryptionConfig) ParseKey() ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(c.Key))
+	if err != nil {
+		return nil, errors.Wrap(err, "decode base64 encryption key")
+	}
+	if len(key) != encKeySize {
+		return nil, errors.Errorf("encryption key has to be %d bytes long, got %d", encKeySize, len(key))
+	}
+	return key, nil
+}
+
+// BucketWithEncryption takes a bucket and returns a bucket that encrypts all objects with AES-256-GCM
+// before uploading them and decrypts them when read.
+// Uploads of seekable readers can be retried by a wrapped retrying bucket, as the encrypted stream can be rewound.
+// NOTE: Objects that were uploaded without encryption cannot be read through the returned bucket.
+func BucketWithEncryption(b Bucket, key []byte) (Bucket, error) {
+	if len(key) != encKeySize {
+		return nil, errors.Errorf("encryption key has to be %d bytes long, got %d", encKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "create cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "create GCM")
+	}
+	return &encryptedBucket{bkt: b, aead: aead}, nil
+}
+
+type encryptedBucket struct {
+	bkt  Bucket
+	aead cipher.AEAD
+}
+
+func (b *encryptedBucket) Iter(ctx context.Context, dir string, f func(name string) error) error {
+	return b.bkt.Iter(ctx, dir, f)
+}
+
+func (b *encryptedBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	rc, err := b.bkt.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := readEncHeader(rc); err != nil {
+		_ = rc.Close()
+		return nil, errors.Wrapf(err, "object %s", name)
+	}
+	return newDecryptReader(b.aead, rc, 0, 0, -1), nil
+}
+
+func (b *encryptedBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	if off < 0 {
+		return nil, errors.Errorf("negative offset %d", off)
+	}
+	if length == 0 {
+		return ioutil.NopCloser(strings.NewReader("")), nil
+	}
+
+	first := off / encChunkSize
+	sealedOff := int64(encHeaderSize) + first*encChunkSizeSeal
+	sealedLen := int64(-1)
+	if length > 0 {
+		last := (off + length - 1) / encChunkSize
+		sealedLen = (last - first + 1) * encChunkSizeSeal
+	}
+
+	rc, err := b.bkt.GetRange(ctx, name, sealedOff, sealedLen)
+	if err != nil {
+		return nil, err
+	}
+	return newDecryptReader(b.aead, rc, uint64(first), off-first*encChunkSize, length), nil
+}
+
+func (b *encryptedBucket) Exists(ctx context.Context, name string) (bool, error) {
+	return b.bkt.Exists(ctx, name)
+}
+
+// Attributes returns attributes of the object, with the size of the plaintext.
+func (b *encryptedBucket) Attributes(ctx context.Context, name string) (ObjectAttributes, error) {
+	attrs, err := b.bkt.Attributes(ctx, name)
+	if err != nil {
+		return ObjectAttributes{}, err
+	}
+	attrs.Size, err = plaintextSize(attrs.Size)
+	if err != nil {
+		return ObjectAttributes{}, errors.Wrapf(err, "object %s", name)
+	}
+	// The hash of the ciphertext says nothing about the plaintext.
+	attrs.MD5 = nil
+	return attrs, nil
+}
+
+// plaintextSize returns the size of the plaintext of an encrypted object with the given size.
+func plaintextSize(sealed int64) (int64, error) {
+	s := sealed - int64(encHeaderSize)
+	if s < encChunkOverhead {
+		return 0, errors.Errorf("encrypted object too short: %d bytes", sealed)
+	}
+	chunks := (s + encChunkSizeSeal - 1) / encChunkSizeSeal
+	size := s - chunks*encChunkOverhead
+	if size < (chunks-1)*encChunkSize {
+		return 0, errors.Errorf("invalid encrypted object size %d", sealed)
+	}
+	return size, nil
+}
+
+func (b *encryptedBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	return b.bkt.Upload(ctx, name, newUploadReader(b.aead, r))
+}
+
+func (b *encryptedBucket) Delete(ctx context.Context, name string) error {
+	return b.bkt.Delete(ctx, name)
+}
+
+func (b *encryptedBucket) IsObjNotFoundErr(err error) bool {
+	return b.bkt.IsObjNotFoundErr(err)
+}
+
+func (b *encryptedBucket) Close() error {
+	return b.bkt.Close()
+}
+
+func (b *encryptedBucket) Name() string {
+	return b.bkt.Name()
+}
+
+func readEncHeader(r io.Reader) error {
+	hdr := make([]byte, encHeaderSize)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return errors.Wrap(err, "read encryption header")
+	}
+	if string(hdr[:len(encMagic)]) != encMagic {
+		return errors.New("object is not encrypted or is corrupted")
+	}
+	if hdr[len(encMagic)] != encVersion {
+		return errors.Errorf("unsupported encryption format version %d", hdr[len(encMagic)])
+	}
+	return nil
+}
+
+// chunkAdditionalData returns data authenticated together with the chunk with the given index.
+func chunkAdditionalData(idx uint64, final bool) []byte {
+	ad := make([]byte, 9)
+	binary.BigEndian.PutUint64(ad, idx)
+	if final {
+		ad[8] = 1
+	}
+	return ad
+}
+
+// encryptReader encrypts the plaintext read from the underlying reader on the fly.
+type encryptReader struct {
+	aead cipher.AEAD
+	r    *bufio.Reader
+
+	idx    uint64
+	plain  []byte
+	sealed []byte
+	buf    []byte
+	done   bool
+	err    error
+}
+
+func newEncryptReader(aead cipher.AEAD, r io.Reader) *encryptReader {
+	buf := make([]byte, encHeaderSize, encChunkSizeSeal)
+	copy(buf, encMagic)
+	buf[len(encMagic)] = encVersion
+
+	return &encryptReader{
+		aead:   aead,
+		r:      bufio.NewReaderSize(r, encChunkSize),
+		plain:  make([]byte, encChunkSize),
+		sealed: make([]byte, 0, encChunkSizeSeal),
+		buf:    buf,
+	}
+}
+
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.err = r.sealNext()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *encryptReader) sealNext() error {
+	n, err := io.ReadFull(r.r, r.plain)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	final := n < encChunkSize
+	if !final {
+		// Peek to know whether this full chunk is the final one.
+		if _, err := r.r.Peek(1); err == io.EOF {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	nonce := r.sealed[:encNonceSize]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return errors.Wrap(err, "generate nonce")
+	}
+	r.buf = r.aead.Seal(nonce, nonce, r.plain[:n], chunkAdditionalData(r.idx, final))
+	r.idx++
+	r.done = final
+	return nil
+}
+
+// newUploadReader returns a reader encrypting r. If r is seekable, the returned reader is seekable as well, so
+// the upload can be retried.
+func newUploadReader(aead cipher.AEAD, r io.Reader) io.Reader {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return newEncryptReader(aead, r)
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		// Not really seekable, e.g. a pipe.
+		return newEncryptReader(aead, r)
+	}
+	return &seekableEncryptReader{
+		encryptReader: newEncryptReader(aead, r),
+		aead:          aead,
+		src:           r,
+		seeker:        seeker,
+		start:         start,
+	}
+}
+
+// seekableEncryptReader encrypts a seekable reader. The encrypted stream cannot be seeked arbitrarily,
+// since every chunk is sealed with a fresh nonce, but it can be rewound to its start to be encrypted again.
+type seekableEncryptReader struct {
+	*encryptReader
+	aead   cipher.AEAD
+	src    io.Reader
+	seeker io.Seeker
+	// Offset of the plaintext in the source reader.
+	start int64
+	// Number of encrypted bytes read so far.
+	off int64
+}
+
+func (r *seekableEncryptReader) Read(p []byte) (int, error) {
+	n, err := r.encryptReader.Read(p)
+	r.off += int64(n)
+	return n, err
+}
+
+func (r *seekableEncryptReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.off
+	default:
+		return 0, errors.Errorf("unsupported whence %d for encrypted stream", whence)
+	}
+	if offset == r.off {
+		return r.off, nil
+	}
+	if offset != 0 {
+		return 0, errors.Errorf("encrypted stream can only be rewound to its start, got offset %d", offset)
+	}
+	if _, err := r.seeker.Seek(r.start, io.SeekStart); err != nil {
+		return 0, errors.Wrap(err, "rewind plaintext reader")
+	}
+	r.encryptReader = newEncryptReader(r.aead, r.src)
+	r.off = 0
+	return 0, nil
+}
+
+// decryptReader decrypts chunks read from the underlying reader, starting with the chunk of the given index.
+// It skips the first skip bytes of plaintext and returns at most length bytes, or everything if length is negative.
+type decryptReader struct {
+	aead cipher.AEAD
+	rc   io.ReadCloser
+	r    *bufio.Reader
+
+	idx    uint64
+	skip   int64
+	left   int64
+	sealed []byte
+	plain  []byte
+	buf    []byte
+	done   bool
+}
+
+func newDecryptReader(aead cipher.AEAD, rc io.ReadCloser, idx uint64, skip, length int64) *decryptReader {
+	return &decryptReader{
+		aead:   aead,
+		rc:     rc,
+		r:      bufio.NewReaderSize(rc, encChunkSizeSeal),
+		idx:    idx,
+		skip:   skip,
+		left:   length,
+		sealed: make([]byte, encChunkSizeSeal),
+		plain:  make([]byte, 0, encChunkSize),
+	}
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	if r.left == 0 {
+		return 0, io.EOF
+	}
+	for len(r.buf) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.openNext(); err != nil {
+			return 0, err
+		}
+	}
+	if r.left >= 0 && int64(len(p)) > r.left {
+		p = p[:r.left]
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	if r.left > 0 {
+		r.left -= int64(n)
+	}
+	return n, nil
+}
+
+func (r *decryptReader) openNext() error {
+	n, err := io.ReadFull(r.r, r.sealed)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	if n == 0 {
+		if r.left < 0 {
+			// We read until the end, but never saw the final chunk.
+			return errors.New("encrypted object is truncated")
+		}
+		// Requested range starts beyond the object.
+		r.done = true
+		return nil
+	}
+	if n < encChunkOverhead {
+		return errors.New("encrypted chunk is too short")
+	}
+
+	eof := n < encChunkSizeSeal
+	if !eof {
+		if _, err := r.r.Peek(1); err == io.EOF {
+			eof = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	sealed := r.sealed[:n]
+	nonce, ciphertext := sealed[:encNonceSize], sealed[encNonceSize:]
+
+	// A chunk followed by more data is never final. The last chunk we read is the final one, unless only a part
+	// of the object was requested, which we cannot tell apart from the underlying reader alone.
+	plain, err := r.aead.Open(r.plain[:0], nonce, ciphertext, chunkAdditionalData(r.idx, eof))
+	if err != nil && eof && r.left >= 0 {
+		plain, err = r.aead.Open(r.plain[:0], nonce, ciphertext, chunkAdditionalData(r.idx, false))
+		eof = false
+	}
+	if err != nil {
+		return errors.Wrapf(err, "decrypt chunk %d", r.idx)
+	}
+	r.idx++
+	r.done = eof
+
+	if r.skip > 0 {
+		if r.skip >= int64(len(plain)) {
+			r.skip -= int64(len(plain))
+			plain = plain[:0]
+		} else {
+			plain = plain[r.skip:]
+			r.skip = 0
+		}
+	}
+	r.buf = plain
+	return nil
+}
+
+func (r *decryptReader) Close() error {
+	return r.rc.Close()
+}
@@ -0,0 +1,161 @@
+package objstore_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func testEncryptionKey() []byte {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	return key
+}
+
+func TestBucketWithEncryption_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	inner := inmem.NewBucket()
+
+	bkt, err := objstore.BucketWithEncryption(inner, testEncryptionKey())
+	testutil.Ok(t, err)
+
+	const chunkSize = 64 * 1024
+	for _, size := range []int{0, 1, 100, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 17} {
+		content := make([]byte, size)
+		_, _ = rand.New(rand.NewSource(int64(size))).Read(content)
+
+		testutil.Ok(t, bkt.Upload(ctx, "obj", bytes.NewReader(content)))
+		testutil.Assert(t, !bytes.Contains(inner.Objects()["obj"], content) || size < 16, "plaintext found in uploaded object of size %d", size)
+
+		rc, err := bkt.Get(ctx, "obj")
+		testutil.Ok(t, err)
+		got, err := ioutil.ReadAll(rc)
+		testutil.Ok(t, err)
+		testutil.Ok(t, rc.Close())
+		testutil.Equals(t, content, got, "size %d", size)
+
+		attrs, err := bkt.Attributes(ctx, "obj")
+		testutil.Ok(t, err)
+		testutil.Equals(t, int64(size), attrs.Size)
+
+		for _, rng := range [][2]int64{
+			{0, 1},
+			{0, int64(size)},
+			{1, 10},
+			{chunkSize - 5, 10},
+			{chunkSize, chunkSize},
+			{int64(size) - 3, 3},
+			{int64(size) - 3, 100},
+			{2*chunkSize + 1, 2 * chunkSize},
+		} {
+			off, length := rng[0], rng[1]
+			if off < 0 || off >= int64(size) || length <= 0 {
+				continue
+			}
+			rc, err := bkt.GetRange(ctx, "obj", off, length)
+			testutil.Ok(t, err)
+			got, err := ioutil.ReadAll(rc)
+			testutil.Ok(t, err)
+			testutil.Ok(t, rc.Close())
+
+			end := off + length
+			if end > int64(size) {
+				end = int64(size)
+			}
+			testutil.Equals(t, content[off:end], got, "size %d, off %d, length %d", size, off, length)
+		}
+	}
+}
+
+func TestBucketWithEncryption_Tampering(t *testing.T) {
+	ctx := context.Background()
+	inner := inmem.NewBucket()
+
+	bkt, err := objstore.BucketWithEncryption(inner, testEncryptionKey())
+	testutil.Ok(t, err)
+
+	content := bytes.Repeat([]byte("thanos"), 30000)
+	testutil.Ok(t, bkt.Upload(ctx, "obj", bytes.NewReader(content)))
+	sealed := inner.Objects()["obj"]
+
+	// Wrong key.
+	other, err := objstore.BucketWithEncryption(inner, make([]byte, 32))
+	testutil.Ok(t, err)
+	rc, err := other.Get(ctx, "obj")
+	testutil.Ok(t, err)
+	_, err = ioutil.ReadAll(rc)
+	testutil.NotOk(t, err)
+
+	// Flipped byte.
+	corrupted := append([]byte{}, sealed...)
+	corrupted[len(corrupted)/2] ^= 0xff
+	testutil.Ok(t, inner.Upload(ctx, "obj", bytes.NewReader(corrupted)))
+	rc, err = bkt.Get(ctx, "obj")
+	testutil.Ok(t, err)
+	_, err = ioutil.ReadAll(rc)
+	testutil.NotOk(t, err)
+
+	// Truncated on the chunk boundary.
+	const sealedChunkSize = 64*1024 + 28
+	testutil.Ok(t, inner.Upload(ctx, "obj", bytes.NewReader(sealed[:5+2*sealedChunkSize])))
+	rc, err = bkt.Get(ctx, "obj")
+	testutil.Ok(t, err)
+	_, err = ioutil.ReadAll(rc)
+	testutil.NotOk(t, err)
+
+	// Not encrypted at all.
+	testutil.Ok(t, inner.Upload(ctx, "obj", bytes.NewReader(content)))
+	_, err = bkt.Get(ctx, "obj")
+	testutil.NotOk(t, err)
+}
+
+func TestBucketWithEncryption_RetriedUpload(t *testing.T) {
+	ctx := context.Background()
+	flaky := newFlakyBucket(2)
+
+	retrying := objstore.BucketWithRetries(log.NewNopLogger(), flaky, objstore.RetryConfig{MaxAttempts: 3, MinBackoff: model.Duration(time.Millisecond)}, nil)
+	bkt, err := objstore.BucketWithEncryption(retrying, testEncryptionKey())
+	testutil.Ok(t, err)
+
+	content := bytes.Repeat([]byte("thanos"), 30000)
+	testutil.Ok(t, bkt.Upload(ctx, "obj", bytes.NewReader(content)))
+	testutil.Equals(t, 3, flaky.calls["upload"])
+
+	rc, err := bkt.Get(ctx, "obj")
+	testutil.Ok(t, err)
+	got, err := ioutil.ReadAll(rc)
+	testutil.Ok(t, err)
+	testutil.Ok(t, rc.Close())
+	testutil.Equals(t, content, got)
+
+	// Readers that cannot be rewound are uploaded once.
+	flaky = newFlakyBucket(1)
+	retrying = objstore.BucketWithRetries(log.NewNopLogger(), flaky, objstore.RetryConfig{MaxAttempts: 3, MinBackoff: model.Duration(time.Millisecond)}, nil)
+	bkt, err = objstore.BucketWithEncryption(retrying, testEncryptionKey())
+	testutil.Ok(t, err)
+	testutil.NotOk(t, bkt.Upload(ctx, "obj", ioutil.NopCloser(bytes.NewReader(content))))
+	testutil.Equals(t, 1, flaky.calls["upload"])
+}
+
+func TestEncryptionConfig_ParseKey(t *testing.T) {
+	_, err := objstore.EncryptionConfig{Key: "not base64!"}.ParseKey()
+	testutil.NotOk(t, err)
+
+	_, err = objstore.EncryptionConfig{Key: "c2hvcnQ="}.ParseKey()
+	testutil.NotOk(t, err)
+
+	key, err := objstore.EncryptionConfig{Key: "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="}.ParseKey()
+	testutil.Ok(t, err)
+	testutil.Equals(t, testEncryptionKey(), key)
+}
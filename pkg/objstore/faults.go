@@ -0,0 +1,324 @@
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Names of bucket operations faults can be injected into.
+const (
+	OpIter       = "iter"
+	OpGet        = "get"
+	OpGetRange   = "get_range"
+	OpExists     = "exists"
+	OpAttributes = "attributes"
+	OpUpload     = "upload"
+	OpDelete     = "delete"
+)
+
+// ErrInjectedFault is the default error returned by operations failed by BucketWithFaults.
+var ErrInjectedFault = errors.New("injected fault")
+
+// Faults configures faults injected by BucketWithFaults. The zero value injects nothing.
+// Probabilities are in the [0, 1] range, where 1 means every matching operation is affected.
+type Faults struct {
+	// Match selects operations and object names faults are injected into, e.g. only uploads of meta.json files.
+	// For Iter, the name is the iterated directory. All operations match if nil.
+	Match func(op, name string) bool
+
+	// ErrorProbability is the probability of a matching operation failing with Err without reaching the bucket.
+	ErrorProbability float64
+	// Err is returned by failed operations. ErrInjectedFault is used if nil.
+	Err error
+	// Latency is added to every matching operation.
+	Latency time.Duration
+	// TruncatedReadProbability is the probability of a Get or GetRange reader failing with io.ErrUnexpectedEOF
+	// after returning only part of the object.
+	TruncatedReadProbability float64
+	// PartialUploadProbability is the probability of an upload storing only part of the object
+	// and then failing with Err.
+	PartialUploadProbability float64
+	// IterVisibilityDelay hides objects uploaded through the bucket from Iter results until the delay passes.
+	// Directories are hidden until at least one object in them is visible. Other operations are not affected.
+	IterVisibilityDelay time.Duration
+}
+
+// FaultyBucket is a bucket injecting faults into operations against the wrapped bucket.
+// It is meant for tests and chaos experiments, never wrap production buckets with it.
+type FaultyBucket struct {
+	bkt Bucket
+
+	mtx      sync.Mutex
+	faults   Faults
+	rand     *rand.Rand
+	uploaded map[string]time.Time
+	injected map[string]int
+}
+
+// BucketWithFaults returns a bucket injecting the given faults into operations against b. Random decisions
+// are made using the given seed, so sequential runs of the same operations inject the same faults.
+func BucketWithFaults(b Bucket, faults Faults, seed int64) *FaultyBucket {
+	return &FaultyBucket{
+		bkt:      b,
+		faults:   faults,
+		rand:     rand.New(rand.NewSource(seed)),
+		uploaded: map[string]time.Time{},
+		injected: map[string]int{},
+	}
+}
+
+// SetFaults replaces the injected faults, e.g. to simulate the provider recovering.
+func (b *FaultyBucket) SetFaults(faults Faults) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.faults = faults
+}
+
+// Injected returns the number of faults injected so far into the given operation.
+func (b *FaultyBucket) Injected(op string) int {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	return b.injected[op]
+}
+
+// decide returns the faults for the operation and whether an event with the given probability happens.
+func (b *FaultyBucket) decide(op, name string, probability func(Faults) float64) (Faults, bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	f := b.faults
+	if f.Match != nil && !f.Match(op, name) {
+		return Faults{}, false
+	}
+	p := probability(f)
+	if p <= 0 || b.rand.Float64() >= p {
+		return f, false
+	}
+	b.injected[op]++
+	return f, true
+}
+
+// before adds latency and decides whether the operation fails.
+func (b *FaultyBucket) before(ctx context.Context, op, name string) error {
+	f, fail := b.decide(op, name, func(f Faults) float64 { return f.ErrorProbability })
+	if f.Latency > 0 {
+		if err := sleep(ctx, f.Latency); err != nil {
+			return err
+		}
+	}
+	if fail {
+		return f.err()
+	}
+	return nil
+}
+
+func (f Faults) err() error {
+	if f.Err != nil {
+		return f.Err
+	}
+	return ErrInjectedFault
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Iter calls f for each entry in the given directory that is visible already.
+func (b *FaultyBucket) Iter(ctx context.Context, dir string, f func(string) error) error {
+	if err := b.before(ctx, OpIter, dir); err != nil {
+		return err
+	}
+
+	b.mtx.Lock()
+	delay := b.faults.IterVisibilityDelay
+	b.mtx.Unlock()
+
+	return b.bkt.Iter(ctx, dir, func(name string) error {
+		if delay > 0 {
+			visible, err := b.visible(ctx, name, delay)
+			if err != nil {
+				return err
+			}
+			if !visible {
+				return nil
+			}
+		}
+		return f(name)
+	})
+}
+
+// visible returns true if the object was not uploaded through the bucket recently or, for directories,
+// if any object in it is visible.
+func (b *FaultyBucket) visible(ctx context.Context, name string, delay time.Duration) (bool, error) {
+	if !strings.HasSuffix(name, DirDelim) {
+		b.mtx.Lock()
+		defer b.mtx.Unlock()
+
+		uploaded, ok := b.uploaded[name]
+		return !ok || time.Since(uploaded) >= delay, nil
+	}
+
+	var visible bool
+	errVisible := errors.New("visible")
+	if err := b.bkt.Iter(ctx, name, func(n string) error {
+		v, err := b.visible(ctx, n, delay)
+		if err != nil {
+			return err
+		}
+		if v {
+			visible = true
+			return errVisible
+		}
+		return nil
+	}); err != nil && err != errVisible {
+		return false, err
+	}
+	return visible, nil
+}
+
+// Get returns a reader for the given object name.
+func (b *FaultyBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	if err := b.before(ctx, OpGet, name); err != nil {
+		return nil, err
+	}
+	rc, err := b.bkt.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return b.maybeTruncate(OpGet, name, rc), nil
+}
+
+// GetRange returns a new range reader for the given object name and range.
+func (b *FaultyBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	if err := b.before(ctx, OpGetRange, name); err != nil {
+		return nil, err
+	}
+	rc, err := b.bkt.GetRange(ctx, name, off, length)
+	if err != nil {
+		return nil, err
+	}
+	return b.maybeTruncate(OpGetRange, name, rc), nil
+}
+
+func (b *FaultyBucket) maybeTruncate(op, name string, rc io.ReadCloser) io.ReadCloser {
+	if _, truncate := b.decide(op, name, func(f Faults) float64 { return f.TruncatedReadProbability }); !truncate {
+		return rc
+	}
+	content, err := ioutil.ReadAll(rc)
+	_ = rc.Close()
+	if err != nil {
+		return ioutil.NopCloser(errReader{err: err})
+	}
+
+	b.mtx.Lock()
+	n := b.rand.Intn(len(content) + 1)
+	b.mtx.Unlock()
+	if n == len(content) && n > 0 {
+		n--
+	}
+	return ioutil.NopCloser(io.MultiReader(bytes.NewReader(content[:n]), errReader{err: io.ErrUnexpectedEOF}))
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// Exists checks if the given object exists in the bucket.
+func (b *FaultyBucket) Exists(ctx context.Context, name string) (bool, error) {
+	if err := b.before(ctx, OpExists, name); err != nil {
+		return false, err
+	}
+	return b.bkt.Exists(ctx, name)
+}
+
+// Attributes returns information about the specified object.
+func (b *FaultyBucket) Attributes(ctx context.Context, name string) (ObjectAttributes, error) {
+	if err := b.before(ctx, OpAttributes, name); err != nil {
+		return ObjectAttributes{}, err
+	}
+	return b.bkt.Attributes(ctx, name)
+}
+
+// Upload the contents of the reader as an object into the bucket.
+func (b *FaultyBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	if err := b.before(ctx, OpUpload, name); err != nil {
+		return err
+	}
+
+	if f, partial := b.decide(OpUpload, name, func(f Faults) float64 { return f.PartialUploadProbability }); partial {
+		content, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		if err := b.upload(ctx, name, bytes.NewReader(content[:len(content)/2])); err != nil {
+			return err
+		}
+		return f.err()
+	}
+	return b.upload(ctx, name, r)
+}
+
+func (b *FaultyBucket) upload(ctx context.Context, name string, r io.Reader) error {
+	if err := b.bkt.Upload(ctx, name, r); err != nil {
+		return err
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.uploaded[name] = time.Now()
+	return nil
+}
+
+// Delete removes the object with the given name.
+func (b *FaultyBucket) Delete(ctx context.Context, name string) error {
+	if err := b.before(ctx, OpDelete, name); err != nil {
+		return err
+	}
+	if err := b.bkt.Delete(ctx, name); err != nil {
+		return err
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	delete(b.uploaded, name)
+	return nil
+}
+
+// IsObjNotFoundErr returns true if error means that object is not found. Relevant to Get operations.
+func (b *FaultyBucket) IsObjNotFoundErr(err error) bool {
+	return b.bkt.IsObjNotFoundErr(err)
+}
+
+// Close closes the wrapped bucket.
+func (b *FaultyBucket) Close() error {
+	return b.bkt.Close()
+}
+
+// Name returns the bucket name of the wrapped bucket.
+func (b *FaultyBucket) Name() string {
+	return b.bkt.Name()
+}
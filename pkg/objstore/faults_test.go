@@ -0,0 +1,122 @@
+package objstore_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestBucketWithFaults(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("errors are injected into matching operations", func(t *testing.T) {
+		inner := inmem.NewBucket()
+		bkt := objstore.BucketWithFaults(inner, objstore.Faults{
+			Match:            func(op, name string) bool { return op == objstore.OpUpload && strings.HasSuffix(name, "meta.json") },
+			ErrorProbability: 1,
+		}, 0)
+
+		testutil.Ok(t, bkt.Upload(ctx, "a/index", strings.NewReader("index")))
+		testutil.Equals(t, objstore.ErrInjectedFault, bkt.Upload(ctx, "a/meta.json", strings.NewReader("meta")))
+		testutil.Equals(t, 1, bkt.Injected(objstore.OpUpload))
+		testutil.Equals(t, map[string][]byte{"a/index": []byte("index")}, inner.Objects())
+
+		// The provider recovers.
+		bkt.SetFaults(objstore.Faults{})
+		testutil.Ok(t, bkt.Upload(ctx, "a/meta.json", strings.NewReader("meta")))
+		testutil.Equals(t, 2, len(inner.Objects()))
+	})
+
+	t.Run("faults are reproducible for the same seed", func(t *testing.T) {
+		run := func() (failed []int) {
+			bkt := objstore.BucketWithFaults(inmem.NewBucket(), objstore.Faults{ErrorProbability: 0.5}, 42)
+			for i := 0; i < 20; i++ {
+				if _, err := bkt.Exists(ctx, "a"); err != nil {
+					failed = append(failed, i)
+				}
+			}
+			return failed
+		}
+		failed := run()
+		testutil.Assert(t, len(failed) > 0 && len(failed) < 20, "expected some operations to fail, got %v", failed)
+		testutil.Equals(t, failed, run())
+	})
+
+	t.Run("truncated reads", func(t *testing.T) {
+		inner := inmem.NewBucket()
+		testutil.Ok(t, inner.Upload(ctx, "obj", bytes.NewReader([]byte("@test-data@"))))
+		bkt := objstore.BucketWithFaults(inner, objstore.Faults{TruncatedReadProbability: 1}, 0)
+
+		rc, err := bkt.Get(ctx, "obj")
+		testutil.Ok(t, err)
+		b, err := ioutil.ReadAll(rc)
+		testutil.Equals(t, io.ErrUnexpectedEOF, err)
+		testutil.Ok(t, rc.Close())
+		testutil.Assert(t, len(b) < len("@test-data@"), "expected truncated content, got %q", b)
+		testutil.Equals(t, "@test-data@"[:len(b)], string(b))
+
+		rc, err = bkt.GetRange(ctx, "obj", 1, 5)
+		testutil.Ok(t, err)
+		_, err = ioutil.ReadAll(rc)
+		testutil.Equals(t, io.ErrUnexpectedEOF, err)
+		testutil.Ok(t, rc.Close())
+	})
+
+	t.Run("partial uploads", func(t *testing.T) {
+		inner := inmem.NewBucket()
+		bkt := objstore.BucketWithFaults(inner, objstore.Faults{PartialUploadProbability: 1}, 0)
+
+		testutil.Equals(t, objstore.ErrInjectedFault, bkt.Upload(ctx, "obj", strings.NewReader("@test-data@")))
+		testutil.Equals(t, map[string][]byte{"obj": []byte("@test")}, inner.Objects())
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		bkt := objstore.BucketWithFaults(inmem.NewBucket(), objstore.Faults{Latency: 50 * time.Millisecond}, 0)
+
+		start := time.Now()
+		_, err := bkt.Exists(ctx, "obj")
+		testutil.Ok(t, err)
+		testutil.Assert(t, time.Since(start) >= 50*time.Millisecond, "expected added latency, took %v", time.Since(start))
+
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = bkt.Exists(cctx, "obj")
+		testutil.Equals(t, context.Canceled, err)
+	})
+
+	t.Run("delayed iter visibility", func(t *testing.T) {
+		inner := inmem.NewBucket()
+		testutil.Ok(t, inner.Upload(ctx, "old/obj", strings.NewReader("x")))
+		bkt := objstore.BucketWithFaults(inner, objstore.Faults{IterVisibilityDelay: 100 * time.Millisecond}, 0)
+
+		testutil.Ok(t, bkt.Upload(ctx, "old/new", strings.NewReader("x")))
+		testutil.Ok(t, bkt.Upload(ctx, "new/obj", strings.NewReader("x")))
+
+		iter := func(dir string) (names []string) {
+			testutil.Ok(t, bkt.Iter(ctx, dir, func(name string) error {
+				names = append(names, name)
+				return nil
+			}))
+			return names
+		}
+		testutil.Equals(t, []string{"old/"}, iter(""))
+		testutil.Equals(t, []string{"old/obj"}, iter("old/"))
+
+		// Objects are available for reads right away.
+		ok, err := bkt.Exists(ctx, "new/obj")
+		testutil.Ok(t, err)
+		testutil.Assert(t, ok, "expected object to exist")
+
+		time.Sleep(100 * time.Millisecond)
+		testutil.Equals(t, []string{"new/", "old/"}, iter(""))
+		testutil.Equals(t, []string{"old/new", "old/obj"}, iter("old/"))
+	})
+}
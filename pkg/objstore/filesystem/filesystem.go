@@ -0,0 +1,331 @@
+// Package filesystem implements common object storage abstractions against a local directory.
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// tmpSuffix marks files that are being written by Upload and are not yet visible as objects.
+const tmpSuffix = ".tmp"
+
+// Config stores the configuration for storing and accessing objects in a local directory.
+type Config struct {
+	Directory string `yaml:"directory"`
+}
+
+// Bucket implements the objstore.Bucket interface against the filesystem the binary runs on.
+// Uploads are atomic: objects are written to a temporary file first and renamed into place.
+// NOTE: It does not follow symbolic links.
+type Bucket struct {
+	rootDir string
+}
+
+// NewBucketFromConfig returns a new filesystem Bucket from the given YAML configuration.
+func NewBucketFromConfig(conf []byte) (*Bucket, error) {
+	var c Config
+	if err := yaml.Unmarshal(conf, &c); err != nil {
+		return nil, errors.Wrap(err, "parsing filesystem configuration")
+	}
+	if c.Directory == "" {
+		return nil, errors.New("missing directory for filesystem bucket")
+	}
+	return NewBucket(c.Directory)
+}
+
+// NewBucket returns a new filesystem Bucket rooted in the given directory.
+func NewBucket(rootDir string) (*Bucket, error) {
+	absDir, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "abs %s", rootDir)
+	}
+	if err := os.MkdirAll(absDir, os.ModePerm); err != nil {
+		return nil, errors.Wrapf(err, "create dir %s", absDir)
+	}
+	return &Bucket{rootDir: absDir}, nil
+}
+
+// Iter calls f for each entry in the given directory. The argument to f is the full
+// object name including the prefix of the inspected directory.
+func (b *Bucket) Iter(ctx context.Context, dir string, f func(string) error) error {
+	absDir, err := b.path(dir)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(absDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "stat %s", absDir)
+	}
+	if !info.IsDir() {
+		return nil
+	}
+
+	files, err := ioutil.ReadDir(absDir)
+	if err != nil {
+		return errors.Wrapf(err, "read dir %s", absDir)
+	}
+	if dir != "" {
+		dir = strings.TrimSuffix(dir, objstore.DirDelim) + objstore.DirDelim
+	}
+	for _, file := range files {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		name := dir + file.Name()
+		if file.IsDir() {
+			empty, err := isDirEmpty(filepath.Join(absDir, file.Name()))
+			if err != nil {
+				return err
+			}
+			if empty {
+				// Object stores have no notion of empty directories.
+				continue
+			}
+			name += objstore.DirDelim
+		} else if isTmpFile(file.Name()) {
+			continue
+		}
+		if err := f(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns a reader for the given object name.
+func (b *Bucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return b.GetRange(ctx, name, 0, -1)
+}
+
+type rangeReadCloser struct {
+	io.Reader
+	f *os.File
+}
+
+func (r *rangeReadCloser) Close() error {
+	return r.f.Close()
+}
+
+// GetRange returns a new range reader for the given object name and range.
+// A negative length means reading until the end of the object.
+func (b *Bucket) GetRange(_ context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	if name == "" {
+		return nil, errors.New("filesystem: object name is empty")
+	}
+
+	file, err := b.path(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "stat %s", file)
+	}
+	if info.IsDir() {
+		return nil, errors.Errorf("filesystem: %s is a directory", name)
+	}
+	if off > info.Size() {
+		return nil, errors.Errorf("filesystem: offset larger than content length. Len %d. Offset: %v", info.Size(), off)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open %s", file)
+	}
+	if off > 0 {
+		if _, err := f.Seek(off, io.SeekStart); err != nil {
+			_ = f.Close()
+			return nil, errors.Wrapf(err, "seek %v", off)
+		}
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return &rangeReadCloser{Reader: io.LimitReader(f, length), f: f}, nil
+}
+
+// Exists checks if the given object exists.
+func (b *Bucket) Exists(_ context.Context, name string) (bool, error) {
+	file, err := b.path(name)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "stat %s", file)
+	}
+	return !info.IsDir(), nil
+}
+
+// Attributes returns information about the specified object.
+func (b *Bucket) Attributes(_ context.Context, name string) (objstore.ObjectAttributes, error) {
+	file, err := b.path(name)
+	if err != nil {
+		return objstore.ObjectAttributes{}, err
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return objstore.ObjectAttributes{}, errors.Wrapf(err, "stat %s", file)
+	}
+	if info.IsDir() {
+		return objstore.ObjectAttributes{}, errors.Errorf("%s is a directory", file)
+	}
+	return objstore.ObjectAttributes{
+		Size:         info.Size(),
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+// Upload writes the contents of the reader as an object into the directory. The object becomes visible
+// only once it was fully written.
+func (b *Bucket) Upload(_ context.Context, name string, r io.Reader) (err error) {
+	file, err := b.path(name)
+	if err != nil {
+		return err
+	}
+	if file == b.rootDir {
+		return errors.New("filesystem: object name is empty")
+	}
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return errors.Wrapf(err, "create dir for %s", file)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".*"+tmpSuffix)
+	if err != nil {
+		return errors.Wrapf(err, "create temporary file for %s", file)
+	}
+	defer func() {
+		if err != nil {
+			// Best effort cleanup, the file is not visible as an object anyway.
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return errors.Wrapf(err, "copy to %s", tmp.Name())
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return errors.Wrapf(err, "sync %s", tmp.Name())
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "close %s", tmp.Name())
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return errors.Wrapf(err, "rename %s to %s", tmp.Name(), file)
+	}
+	return nil
+}
+
+// Delete removes the object with the given name. Parent directories left empty are removed as well.
+func (b *Bucket) Delete(_ context.Context, name string) error {
+	file, err := b.path(name)
+	if err != nil {
+		return err
+	}
+	if file == b.rootDir {
+		return errors.New("filesystem: object name is empty")
+	}
+	if err := os.Remove(file); err != nil {
+		return errors.Wrapf(err, "rm %s", file)
+	}
+	for dir := filepath.Dir(file); dir != b.rootDir && strings.HasPrefix(dir, b.rootDir); dir = filepath.Dir(dir) {
+		empty, err := isDirEmpty(dir)
+		if err != nil {
+			return err
+		}
+		if !empty {
+			break
+		}
+		if err := os.Remove(dir); err != nil {
+			return errors.Wrapf(err, "rm %s", dir)
+		}
+	}
+	return nil
+}
+
+// IsObjNotFoundErr returns true if error means that object is not found. Relevant to Get operations.
+func (b *Bucket) IsObjNotFoundErr(err error) bool {
+	return os.IsNotExist(errors.Cause(err))
+}
+
+func (b *Bucket) Close() error { return nil }
+
+// Name returns the bucket name.
+func (b *Bucket) Name() string {
+	return fmt.Sprintf("fs: %s", b.rootDir)
+}
+
+// path returns the absolute path of the given object or directory name. Names resolving to a path outside
+// of the root directory, e.g. through "..", are rejected.
+func (b *Bucket) path(name string) (string, error) {
+	p := filepath.Join(b.rootDir, filepath.FromSlash(name))
+	rel, err := filepath.Rel(b.rootDir, p)
+	if err != nil {
+		return "", errors.Wrapf(err, "filesystem: invalid name %q", name)
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("filesystem: name %q is outside of the bucket directory", name)
+	}
+	return p, nil
+}
+
+func isTmpFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, tmpSuffix)
+}
+
+func isDirEmpty(name string) (ok bool, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return false, errors.Wrapf(err, "open %s", name)
+	}
+	defer runutil.CloseWithErrCapture(&err, f, "close dir %s", name)
+
+	if _, err = f.Readdirnames(1); err == io.EOF {
+		return true, nil
+	}
+	return false, err
+}
+
+// NewTestBucket creates test bkt client that before returning creates temporary directory.
+// In a close function it removes the directory.
+func NewTestBucket(t testing.TB) (objstore.Bucket, func(), error) {
+	dir, err := ioutil.TempDir("", "test_filesystem")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := NewBucket(dir)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, nil, err
+	}
+
+	t.Log("created temporary directory for filesystem tests", dir)
+	return b, func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Logf("deleting directory failed: %s", err)
+		}
+	}, nil
+}
@@ -0,0 +1,109 @@
+package filesystem
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func iterAll(t *testing.T, b *Bucket, dir string) []string {
+	var names []string
+	testutil.Ok(t, b.Iter(context.Background(), dir, func(name string) error {
+		names = append(names, name)
+		return nil
+	}))
+	sort.Strings(names)
+	return names
+}
+
+func TestBucket_PathEscape(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "filesystem-test")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	root := filepath.Join(dir, "root")
+	b, err := NewBucket(root)
+	testutil.Ok(t, err)
+
+	outside := filepath.Join(dir, "outside")
+	testutil.Ok(t, ioutil.WriteFile(outside, []byte("secret"), os.ModePerm))
+
+	for _, name := range []string{"../outside", "a/../../outside", "/../outside", "a/../.."} {
+		t.Run(name, func(t *testing.T) {
+			testutil.NotOk(t, b.Upload(ctx, name, strings.NewReader("overwritten")))
+			_, err := b.Get(ctx, name)
+			testutil.NotOk(t, err)
+			_, err = b.GetRange(ctx, name, 0, 1)
+			testutil.NotOk(t, err)
+			_, err = b.Exists(ctx, name)
+			testutil.NotOk(t, err)
+			_, err = b.Attributes(ctx, name)
+			testutil.NotOk(t, err)
+			testutil.NotOk(t, b.Delete(ctx, name))
+			testutil.NotOk(t, b.Iter(ctx, name, func(string) error { return nil }))
+		})
+	}
+
+	content, err := ioutil.ReadFile(outside)
+	testutil.Ok(t, err)
+	testutil.Equals(t, "secret", string(content))
+
+	// Names are resolved within the root directory.
+	testutil.Ok(t, b.Upload(ctx, "a/../b/obj", strings.NewReader("obj")))
+	testutil.Ok(t, b.Upload(ctx, "/c/obj", strings.NewReader("obj")))
+	testutil.Equals(t, []string{"b/", "c/"}, iterAll(t, b, ""))
+	testutil.Equals(t, []string{"b/obj"}, iterAll(t, b, "b"))
+	testutil.NotOk(t, b.Upload(ctx, "a/..", strings.NewReader("obj")))
+}
+
+func TestBucket_Iter_SkipsTmpFilesAndEmptyDirs(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "filesystem-test")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	b, err := NewBucket(dir)
+	testutil.Ok(t, err)
+
+	testutil.Ok(t, b.Upload(ctx, "a/obj", strings.NewReader("obj")))
+	testutil.Ok(t, b.Upload(ctx, "obj", strings.NewReader("obj")))
+
+	// Leftovers of an interrupted upload.
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "a", ".obj2.123"+tmpSuffix), []byte("partial"), os.ModePerm))
+	testutil.Ok(t, os.MkdirAll(filepath.Join(dir, "tmponly"), os.ModePerm))
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "tmponly", ".obj.123"+tmpSuffix), []byte("partial"), os.ModePerm))
+	// Empty directories, also nested ones.
+	testutil.Ok(t, os.MkdirAll(filepath.Join(dir, "empty"), os.ModePerm))
+	testutil.Ok(t, os.MkdirAll(filepath.Join(dir, "a", "empty"), os.ModePerm))
+
+	testutil.Equals(t, []string{"a/", "obj", "tmponly/"}, iterAll(t, b, ""))
+	testutil.Equals(t, []string{"a/obj"}, iterAll(t, b, "a"))
+	testutil.Equals(t, []string{"a/obj"}, iterAll(t, b, "a/"))
+	testutil.Equals(t, []string(nil), iterAll(t, b, "tmponly"))
+	testutil.Equals(t, []string(nil), iterAll(t, b, "empty"))
+	testutil.Equals(t, []string(nil), iterAll(t, b, "missing"))
+
+	// Temporary files are not objects.
+	ok, err := b.Exists(ctx, "a/obj")
+	testutil.Ok(t, err)
+	testutil.Assert(t, ok, "expected a/obj to exist")
+
+	// Deleting the last object removes the directories left empty, but not the root.
+	testutil.Ok(t, b.Delete(ctx, "a/obj"))
+	testutil.Ok(t, os.Remove(filepath.Join(dir, "a", ".obj2.123"+tmpSuffix)))
+	testutil.Ok(t, b.Upload(ctx, "x/y/obj", strings.NewReader("obj")))
+	testutil.Ok(t, b.Delete(ctx, "x/y/obj"))
+	_, err = os.Stat(filepath.Join(dir, "x"))
+	testutil.Assert(t, os.IsNotExist(err), "expected x/ to be removed, got %v", err)
+	_, err = os.Stat(dir)
+	testutil.Ok(t, err)
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/prometheus/common/version"
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 	yaml "gopkg.in/yaml.v2"
@@ -140,6 +141,20 @@ func (b *Bucket) Exists(ctx context.Context, name string) (bool, error) {
 	return false, nil
 }
 
+// Attributes returns information about the specified object.
+func (b *Bucket) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	attrs, err := b.bkt.Object(name).Attrs(ctx)
+	if err != nil {
+		return objstore.ObjectAttributes{}, err
+	}
+
+	return objstore.ObjectAttributes{
+		Size:         attrs.Size,
+		LastModified: attrs.Updated,
+		MD5:          attrs.MD5,
+	}, nil
+}
+
 // Upload writes the file specified in src to remote GCS location specified as target.
 func (b *Bucket) Upload(ctx context.Context, name string, r io.Reader) error {
 	w := b.bkt.Object(name).NewWriter(ctx)
@@ -160,6 +175,14 @@ func (b *Bucket) IsObjNotFoundErr(err error) bool {
 	return err == storage.ErrObjectNotExist
 }
 
+// IsRetryableErr returns true if the operation that returned the error can be retried.
+func (b *Bucket) IsRetryableErr(err error) bool {
+	if apiErr, ok := errors.Cause(err).(*googleapi.Error); ok {
+		return objstore.IsRetryableHTTPStatus(apiErr.Code)
+	}
+	return objstore.IsTransientErr(err)
+}
+
 func (b *Bucket) Close() error {
 	return b.closer.Close()
 }
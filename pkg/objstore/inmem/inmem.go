@@ -6,8 +6,11 @@ import (
 	"sort"
 
 	"bytes"
+	"crypto/md5"
 	"io/ioutil"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/thanos-io/thanos/pkg/objstore"
@@ -17,18 +20,23 @@ var errNotFound = errors.New("inmem: object not found")
 
 // Bucket implements the store.Bucket and shipper.Bucket interfaces against local memory.
 type Bucket struct {
-	objects map[string][]byte
+	mtx          sync.RWMutex
+	objects      map[string][]byte
+	lastModified map[string]time.Time
+	md5          map[string][]byte
 }
 
 // NewBucket returns a new in memory Bucket.
 // NOTE: Returned bucket is just a naive in memory bucket implementation. For test use cases only.
 func NewBucket() *Bucket {
-	return &Bucket{objects: map[string][]byte{}}
+	return &Bucket{objects: map[string][]byte{}, lastModified: map[string]time.Time{}, md5: map[string][]byte{}}
 }
 
 // Objects returns internally stored objects.
-// NOTE: For assert purposes.
+// NOTE: For assert purposes. It must not be used concurrently with other operations.
 func (b *Bucket) Objects() map[string][]byte {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
 	return b.objects
 }
 
@@ -45,6 +53,8 @@ func (b *Bucket) Iter(_ context.Context, dir string, f func(string) error) error
 		}
 		dirPartsCount++
 	}
+
+	b.mtx.RLock()
 	for filename := range b.objects {
 		if !strings.HasPrefix(filename, dir) || dir == filename {
 			continue
@@ -53,6 +63,7 @@ func (b *Bucket) Iter(_ context.Context, dir string, f func(string) error) error
 		parts := strings.SplitAfter(filename, objstore.DirDelim)
 		unique[strings.Join(parts[:dirPartsCount+1], "")] = struct{}{}
 	}
+	b.mtx.RUnlock()
 
 	var keys []string
 	for n := range unique {
@@ -86,6 +97,9 @@ func (b *Bucket) Get(_ context.Context, name string) (io.ReadCloser, error) {
 		return nil, errors.New("inmem: object name is empty")
 	}
 
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
 	file, ok := b.objects[name]
 	if !ok {
 		return nil, errNotFound
@@ -100,6 +114,9 @@ func (b *Bucket) GetRange(_ context.Context, name string, off, length int64) (io
 		return nil, errors.New("inmem: object name is empty")
 	}
 
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
 	file, ok := b.objects[name]
 	if !ok {
 		return nil, errNotFound
@@ -119,23 +136,55 @@ func (b *Bucket) GetRange(_ context.Context, name string, off, length int64) (io
 
 // Exists checks if the given directory exists in memory.
 func (b *Bucket) Exists(_ context.Context, name string) (bool, error) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
 	_, ok := b.objects[name]
 	return ok, nil
 }
 
+// Attributes returns information about the specified object.
+func (b *Bucket) Attributes(_ context.Context, name string) (objstore.ObjectAttributes, error) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	file, ok := b.objects[name]
+	if !ok {
+		return objstore.ObjectAttributes{}, errNotFound
+	}
+
+	return objstore.ObjectAttributes{
+		Size:         int64(len(file)),
+		LastModified: b.lastModified[name],
+		MD5:          b.md5[name],
+	}, nil
+}
+
 // Upload writes the file specified in src to into the memory.
 func (b *Bucket) Upload(_ context.Context, name string, r io.Reader) error {
 	body, err := ioutil.ReadAll(r)
 	if err != nil {
 		return err
 	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
 	b.objects[name] = body
+	b.lastModified[name] = time.Now()
+	sum := md5.Sum(body)
+	b.md5[name] = sum[:]
 	return nil
 }
 
 // Delete removes all data prefixed with the dir.
 func (b *Bucket) Delete(_ context.Context, name string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
 	delete(b.objects, name)
+	delete(b.lastModified, name)
+	delete(b.md5, name)
 	return nil
 }
 
@@ -1,7 +1,10 @@
 package objstore
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"io"
 	"os"
 	"path/filepath"
@@ -13,6 +16,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thanos-io/thanos/pkg/runutil"
+	"golang.org/x/sync/errgroup"
 )
 
 // Bucket provides read and write access to an object storage bucket.
@@ -49,11 +53,104 @@ type BucketReader interface {
 
 	// IsObjNotFoundErr returns true if error means that object is not found. Relevant to Get operations.
 	IsObjNotFoundErr(err error) bool
+
+	// Attributes returns information about the specified object.
+	Attributes(ctx context.Context, name string) (ObjectAttributes, error)
+}
+
+// ObjectAttributes holds information about an object in the bucket.
+type ObjectAttributes struct {
+	// Size is the object size in bytes.
+	Size int64 `json:"size"`
+
+	// LastModified is the timestamp the object was last modified.
+	LastModified time.Time `json:"last_modified"`
+
+	// MD5 is the MD5 hash of the object content, if the provider exposes it. It is nil otherwise, e.g. for
+	// objects uploaded in multiple parts.
+	MD5 []byte `json:"-"`
+}
+
+// MD5FromETag returns the MD5 hash held by the given ETag, or nil if the ETag is not a plain MD5 hash,
+// e.g. the ETag of an object uploaded in multiple parts.
+func MD5FromETag(etag string) []byte {
+	b, err := hex.DecodeString(strings.Trim(etag, `"`))
+	if err != nil || len(b) != md5.Size {
+		return nil
+	}
+	return b
+}
+
+// ObjectSizer can return the size of the object it reads, e.g. a wrapper of a file reader.
+type ObjectSizer interface {
+	// ObjectSize returns the size of the object in bytes.
+	ObjectSize() (int64, error)
+}
+
+// TryToGetSize tries to get the upfront size of the data the reader returns. It returns an error if the size is unknown.
+func TryToGetSize(r io.Reader) (int64, error) {
+	switch f := r.(type) {
+	case *os.File:
+		fileInfo, err := f.Stat()
+		if err != nil {
+			return 0, errors.Wrap(err, "stat file")
+		}
+		return fileInfo.Size(), nil
+	case *bytes.Buffer:
+		return int64(f.Len()), nil
+	case *bytes.Reader:
+		return int64(f.Len()), nil
+	case *strings.Reader:
+		return int64(f.Len()), nil
+	case ObjectSizer:
+		return f.ObjectSize()
+	}
+	return 0, errors.Errorf("unsupported type of io.Reader: %T", r)
+}
+
+// UploadOption configures UploadDir and UploadFile.
+type UploadOption func(*UploadOptions)
+
+// UploadOptions are the options UploadDir and UploadFile are configured with.
+type UploadOptions struct {
+	// Concurrency is the number of files uploaded in parallel.
+	Concurrency int
+	// SkipExisting is true if files already present in the bucket are not uploaded again.
+	SkipExisting bool
+}
+
+// ApplyUploadOptions returns the UploadOptions resulting from applying the given options to the defaults.
+func ApplyUploadOptions(options ...UploadOption) UploadOptions {
+	opts := UploadOptions{Concurrency: 1}
+	for _, o := range options {
+		o(&opts)
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	return opts
+}
+
+// WithUploadConcurrency sets the number of files UploadDir uploads in parallel. Defaults to 1.
+func WithUploadConcurrency(n int) UploadOption {
+	return func(o *UploadOptions) {
+		o.Concurrency = n
+	}
+}
+
+// WithSkipExisting makes uploads skip files that are already present in the bucket with the same content.
+// This allows resuming an interrupted upload of immutable files, e.g. block files. Contents are compared by their
+// MD5 hash where the provider exposes it (see ObjectAttributes), otherwise only by their size, as objects are
+// stored atomically.
+func WithSkipExisting() UploadOption {
+	return func(o *UploadOptions) {
+		o.SkipExisting = true
+	}
 }
 
 // UploadDir uploads all files in srcdir to the bucket with into a top-level directory
 // named dstdir. It is a caller responsibility to clean partial upload in case of failure.
-func UploadDir(ctx context.Context, logger log.Logger, bkt Bucket, srcdir, dstdir string) error {
+func UploadDir(ctx context.Context, logger log.Logger, bkt Bucket, srcdir, dstdir string, options ...UploadOption) error {
 	df, err := os.Stat(srcdir)
 	if err != nil {
 		return errors.Wrap(err, "stat dir")
@@ -61,34 +158,105 @@ func UploadDir(ctx context.Context, logger log.Logger, bkt Bucket, srcdir, dstdi
 	if !df.IsDir() {
 		return errors.Errorf("%s is not a directory", srcdir)
 	}
-	return filepath.Walk(srcdir, func(src string, fi os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if fi.IsDir() {
+	opts := ApplyUploadOptions(options...)
+
+	g, gctx := errgroup.WithContext(ctx)
+	files := make(chan [2]string)
+	for i := 0; i < opts.Concurrency; i++ {
+		g.Go(func() error {
+			for f := range files {
+				if err := uploadFile(gctx, logger, bkt, f[0], f[1], opts); err != nil {
+					return err
+				}
+			}
 			return nil
-		}
-		dst := filepath.Join(dstdir, strings.TrimPrefix(src, srcdir))
+		})
+	}
+
+	g.Go(func() error {
+		defer close(files)
 
-		return UploadFile(ctx, logger, bkt, src, dst)
+		return filepath.Walk(srcdir, func(src string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if fi.IsDir() {
+				return nil
+			}
+			dst := filepath.Join(dstdir, strings.TrimPrefix(src, srcdir))
+
+			select {
+			case files <- [2]string{src, dst}:
+				return nil
+			case <-gctx.Done():
+				return gctx.Err()
+			}
+		})
 	})
+	return g.Wait()
 }
 
 // UploadFile uploads the file with the given name to the bucket.
 // It is a caller responsibility to clean partial upload in case of failure
-func UploadFile(ctx context.Context, logger log.Logger, bkt Bucket, src, dst string) error {
+func UploadFile(ctx context.Context, logger log.Logger, bkt Bucket, src, dst string, options ...UploadOption) error {
+	return uploadFile(ctx, logger, bkt, src, dst, ApplyUploadOptions(options...))
+}
+
+func uploadFile(ctx context.Context, logger log.Logger, bkt Bucket, src, dst string, opts UploadOptions) error {
 	r, err := os.Open(src)
 	if err != nil {
 		return errors.Wrapf(err, "open file %s", src)
 	}
 	defer runutil.CloseWithLogOnErr(logger, r, "close file %s", src)
 
+	if opts.SkipExisting {
+		uploaded, err := isUploaded(ctx, bkt, r, dst)
+		if err != nil {
+			return err
+		}
+		if uploaded {
+			level.Debug(logger).Log("msg", "skipping upload of file already present in bucket", "src", src, "dst", dst)
+			return nil
+		}
+	}
+
 	if err := bkt.Upload(ctx, dst, r); err != nil {
 		return errors.Wrapf(err, "upload file %s as %s", src, dst)
 	}
 	return nil
 }
 
+// isUploaded returns true if the object dst has the same content as the given file. Contents are compared by their
+// MD5 hash if the bucket exposes it, otherwise by their size.
+func isUploaded(ctx context.Context, bkt Bucket, f *os.File, dst string) (bool, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return false, errors.Wrapf(err, "stat file %s", f.Name())
+	}
+	attrs, err := bkt.Attributes(ctx, dst)
+	if err != nil {
+		if bkt.IsObjNotFoundErr(errors.Cause(err)) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "get attributes of %s", dst)
+	}
+	if attrs.Size != fi.Size() {
+		return false, nil
+	}
+	if attrs.MD5 == nil {
+		return true, nil
+	}
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return false, errors.Wrapf(err, "hash file %s", f.Name())
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return false, errors.Wrapf(err, "rewind file %s", f.Name())
+	}
+	return bytes.Equal(h.Sum(nil), attrs.MD5), nil
+}
+
 // DirDelim is the delimiter used to model a directory structure in an object store bucket.
 const DirDelim = "/"
 
@@ -295,6 +463,20 @@ func (b *metricBucket) Exists(ctx context.Context, name string) (bool, error) {
 	return ok, err
 }
 
+func (b *metricBucket) Attributes(ctx context.Context, name string) (ObjectAttributes, error) {
+	const op = "attributes"
+	start := time.Now()
+
+	attrs, err := b.bkt.Attributes(ctx, name)
+	if err != nil {
+		b.opsFailures.WithLabelValues(op).Inc()
+	}
+	b.ops.WithLabelValues(op).Inc()
+	b.opsDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+
+	return attrs, err
+}
+
 func (b *metricBucket) Upload(ctx context.Context, name string, r io.Reader) error {
 	const op = "upload"
 	start := time.Now()
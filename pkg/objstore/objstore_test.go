@@ -0,0 +1,164 @@
+package objstore_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+// uploadTrackingBucket records uploaded objects and the maximum number of concurrent uploads.
+type uploadTrackingBucket struct {
+	objstore.Bucket
+
+	mtx         sync.Mutex
+	uploads     []string
+	inFlight    int
+	maxInFlight int
+}
+
+func (b *uploadTrackingBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	b.mtx.Lock()
+	b.uploads = append(b.uploads, name)
+	b.inFlight++
+	if b.inFlight > b.maxInFlight {
+		b.maxInFlight = b.inFlight
+	}
+	b.mtx.Unlock()
+
+	defer func() {
+		b.mtx.Lock()
+		b.inFlight--
+		b.mtx.Unlock()
+	}()
+
+	// Give other uploads a chance to start.
+	time.Sleep(10 * time.Millisecond)
+	return b.Bucket.Upload(ctx, name, r)
+}
+
+// noMD5Bucket hides the MD5 hashes of objects, like providers not exposing them.
+type noMD5Bucket struct {
+	objstore.Bucket
+}
+
+func (b *noMD5Bucket) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	attrs, err := b.Bucket.Attributes(ctx, name)
+	attrs.MD5 = nil
+	return attrs, err
+}
+
+func createTestDir(t *testing.T, files int) string {
+	dir, err := ioutil.TempDir("", "upload-dir-test")
+	testutil.Ok(t, err)
+	testutil.Ok(t, os.MkdirAll(filepath.Join(dir, "sub"), 0777))
+
+	for i := 0; i < files; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("%06d", i))
+		if i%2 == 1 {
+			name = filepath.Join(dir, "sub", fmt.Sprintf("%06d", i))
+		}
+		testutil.Ok(t, ioutil.WriteFile(name, []byte(strings.Repeat("x", 10+i)), 0666))
+	}
+	return dir
+}
+
+func TestUploadDir(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	dir := createTestDir(t, 10)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	t.Run("serial by default", func(t *testing.T) {
+		bkt := &uploadTrackingBucket{Bucket: inmem.NewBucket()}
+		testutil.Ok(t, objstore.UploadDir(ctx, logger, bkt, dir, "dst"))
+		testutil.Equals(t, 10, len(bkt.uploads))
+		testutil.Equals(t, 1, bkt.maxInFlight)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		inner := inmem.NewBucket()
+		bkt := &uploadTrackingBucket{Bucket: inner}
+		testutil.Ok(t, objstore.UploadDir(ctx, logger, bkt, dir, "dst", objstore.WithUploadConcurrency(4)))
+		testutil.Equals(t, 10, len(bkt.uploads))
+		testutil.Assert(t, bkt.maxInFlight > 1 && bkt.maxInFlight <= 4, "expected 2-4 concurrent uploads, got %d", bkt.maxInFlight)
+
+		objs := inner.Objects()
+		testutil.Equals(t, 10, len(objs))
+		testutil.Equals(t, []byte(strings.Repeat("x", 13)), objs["dst/sub/000003"])
+	})
+
+	t.Run("failure stops upload", func(t *testing.T) {
+		bkt := objstore.BucketWithFaults(inmem.NewBucket(), objstore.Faults{
+			Match:            func(op, name string) bool { return op == objstore.OpUpload && name == "dst/000004" },
+			ErrorProbability: 1,
+		}, 0)
+		err := objstore.UploadDir(ctx, logger, bkt, dir, "dst", objstore.WithUploadConcurrency(4))
+		testutil.NotOk(t, err)
+		testutil.Equals(t, objstore.ErrInjectedFault, errors.Cause(err))
+	})
+
+	t.Run("resume skips complete files", func(t *testing.T) {
+		inner := inmem.NewBucket()
+		faulty := objstore.BucketWithFaults(inner, objstore.Faults{
+			Match:                    func(op, name string) bool { return op == objstore.OpUpload && name == "dst/sub/000005" },
+			PartialUploadProbability: 1,
+		}, 0)
+		testutil.NotOk(t, objstore.UploadDir(ctx, logger, faulty, dir, "dst", objstore.WithSkipExisting()))
+		testutil.Equals(t, []byte(strings.Repeat("x", 7)), inner.Objects()["dst/sub/000005"])
+
+		bkt := &uploadTrackingBucket{Bucket: inner}
+		testutil.Ok(t, objstore.UploadDir(ctx, logger, bkt, dir, "dst", objstore.WithSkipExisting(), objstore.WithUploadConcurrency(2)))
+
+		objs := inner.Objects()
+		testutil.Equals(t, 10, len(objs))
+		testutil.Equals(t, []byte(strings.Repeat("x", 15)), objs["dst/sub/000005"])
+		// Files uploaded before the failure are not uploaded again.
+		sort.Strings(bkt.uploads)
+		testutil.Equals(t, []string{"dst/sub/000005", "dst/sub/000007", "dst/sub/000009"}, bkt.uploads)
+
+		// Nothing to do once everything is uploaded.
+		bkt.uploads = nil
+		testutil.Ok(t, objstore.UploadDir(ctx, logger, bkt, dir, "dst", objstore.WithSkipExisting()))
+		testutil.Equals(t, 0, len(bkt.uploads))
+	})
+
+	t.Run("resume compares checksums", func(t *testing.T) {
+		inner := inmem.NewBucket()
+		testutil.Ok(t, objstore.UploadDir(ctx, logger, inner, dir, "dst"))
+		// Same size, different content.
+		testutil.Ok(t, inner.Upload(ctx, "dst/sub/000005", strings.NewReader(strings.Repeat("y", 15))))
+
+		bkt := &uploadTrackingBucket{Bucket: inner}
+		testutil.Ok(t, objstore.UploadDir(ctx, logger, bkt, dir, "dst", objstore.WithSkipExisting()))
+		testutil.Equals(t, []string{"dst/sub/000005"}, bkt.uploads)
+		testutil.Equals(t, []byte(strings.Repeat("x", 15)), inner.Objects()["dst/sub/000005"])
+
+		// Without checksums, only sizes are compared.
+		testutil.Ok(t, inner.Upload(ctx, "dst/sub/000005", strings.NewReader(strings.Repeat("y", 15))))
+		bkt = &uploadTrackingBucket{Bucket: &noMD5Bucket{Bucket: inner}}
+		testutil.Ok(t, objstore.UploadDir(ctx, logger, bkt, dir, "dst", objstore.WithSkipExisting()))
+		testutil.Equals(t, 0, len(bkt.uploads))
+	})
+}
+
+func TestMD5FromETag(t *testing.T) {
+	testutil.Equals(t, []byte{0xd4, 0x1d, 0x8c, 0xd9, 0x8f, 0x00, 0xb2, 0x04, 0xe9, 0x80, 0x09, 0x98, 0xec, 0xf8, 0x42, 0x7e}, objstore.MD5FromETag(`"d41d8cd98f00b204e9800998ecf8427e"`))
+	testutil.Equals(t, []byte(nil), objstore.MD5FromETag(`"d41d8cd98f00b204e9800998ecf8427e-2"`))
+	testutil.Equals(t, []byte(nil), objstore.MD5FromETag("0x8D1A"))
+	testutil.Equals(t, []byte(nil), objstore.MD5FromETag(""))
+}
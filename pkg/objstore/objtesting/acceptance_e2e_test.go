@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/testutil"
@@ -29,6 +30,10 @@ func TestObjStore_AcceptanceTest_e2e(t *testing.T) {
 		testutil.Ok(t, err)
 		testutil.Assert(t, !ok, "expected not exits")
 
+		_, err = bkt.Attributes(context.Background(), "id1/obj_1.some")
+		testutil.NotOk(t, err)
+		testutil.Assert(t, bkt.IsObjNotFoundErr(err), "expected not found error but got %s", err)
+
 		// Upload first object.
 		testutil.Ok(t, bkt.Upload(context.Background(), "id1/obj_1.some", strings.NewReader("@test-data@")))
 
@@ -51,6 +56,12 @@ func TestObjStore_AcceptanceTest_e2e(t *testing.T) {
 		testutil.Ok(t, err)
 		testutil.Assert(t, ok, "expected exits")
 
+		attrs, err := bkt.Attributes(context.Background(), "id1/obj_1.some")
+		testutil.Ok(t, err)
+		testutil.Equals(t, int64(len("@test-data@")), attrs.Size)
+		testutil.Assert(t, time.Since(attrs.LastModified) < time.Hour && time.Until(attrs.LastModified) < time.Hour,
+			"expected recent last modified time, got %v", attrs.LastModified)
+
 		// Upload other objects.
 		testutil.Ok(t, bkt.Upload(context.Background(), "id1/obj_2.some", strings.NewReader("@test-data2@")))
 		testutil.Ok(t, bkt.Upload(context.Background(), "id1/obj_3.some", strings.NewReader("@test-data3@")))
@@ -9,6 +9,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/azure"
 	"github.com/thanos-io/thanos/pkg/objstore/cos"
+	"github.com/thanos-io/thanos/pkg/objstore/filesystem"
 	"github.com/thanos-io/thanos/pkg/objstore/gcs"
 	"github.com/thanos-io/thanos/pkg/objstore/inmem"
 	"github.com/thanos-io/thanos/pkg/objstore/s3"
@@ -31,6 +32,20 @@ func ForeachStore(t *testing.T, testFn func(t testing.TB, bkt objstore.Bucket))
 		return
 	}
 
+	// Mandatory Filesystem.
+	bkt, closeFn, err := filesystem.NewTestBucket(t)
+	testutil.Ok(t, err)
+
+	ok := t.Run("filesystem", func(t *testing.T) {
+		defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+		testFn(t, bkt)
+	})
+	closeFn()
+	if !ok {
+		return
+	}
+
 	// Optional GCS.
 	if _, ok := os.LookupEnv("THANOS_SKIP_GCS_TESTS"); !ok {
 		bkt, closeFn, err := gcs.NewTestBucket(t, os.Getenv("GCP_PROJECT"))
@@ -0,0 +1,71 @@
+package objstore
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// BucketWithPrefix takes a bucket and returns a bucket that scopes all operations under the given prefix,
+// so the prefix behaves like the root of the returned bucket. Several independent bucket users can share one
+// underlying bucket this way. An empty prefix returns the bucket as-is.
+func BucketWithPrefix(b Bucket, prefix string) Bucket {
+	prefix = strings.Trim(prefix, DirDelim)
+	if prefix == "" {
+		return b
+	}
+	return &prefixedBucket{bkt: b, prefix: prefix + DirDelim}
+}
+
+type prefixedBucket struct {
+	bkt    Bucket
+	prefix string
+}
+
+func (b *prefixedBucket) withPrefix(name string) string {
+	return b.prefix + name
+}
+
+// Iter calls f for each entry in the given directory, relative to the prefix.
+func (b *prefixedBucket) Iter(ctx context.Context, dir string, f func(string) error) error {
+	return b.bkt.Iter(ctx, b.withPrefix(dir), func(name string) error {
+		return f(strings.TrimPrefix(name, b.prefix))
+	})
+}
+
+func (b *prefixedBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	return b.bkt.Get(ctx, b.withPrefix(name))
+}
+
+func (b *prefixedBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	return b.bkt.GetRange(ctx, b.withPrefix(name), off, length)
+}
+
+func (b *prefixedBucket) Exists(ctx context.Context, name string) (bool, error) {
+	return b.bkt.Exists(ctx, b.withPrefix(name))
+}
+
+func (b *prefixedBucket) Attributes(ctx context.Context, name string) (ObjectAttributes, error) {
+	return b.bkt.Attributes(ctx, b.withPrefix(name))
+}
+
+func (b *prefixedBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	return b.bkt.Upload(ctx, b.withPrefix(name), r)
+}
+
+func (b *prefixedBucket) Delete(ctx context.Context, name string) error {
+	return b.bkt.Delete(ctx, b.withPrefix(name))
+}
+
+func (b *prefixedBucket) IsObjNotFoundErr(err error) bool {
+	return b.bkt.IsObjNotFoundErr(err)
+}
+
+func (b *prefixedBucket) Close() error {
+	return b.bkt.Close()
+}
+
+// Name returns the name of the underlying bucket.
+func (b *prefixedBucket) Name() string {
+	return b.bkt.Name()
+}
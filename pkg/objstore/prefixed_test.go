@@ -0,0 +1,75 @@
+package objstore_test
+
+import (
+	"context"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestBucketWithPrefix(t *testing.T) {
+	ctx := context.Background()
+	inner := inmem.NewBucket()
+	testutil.Ok(t, inner.Upload(ctx, "outside", strings.NewReader("x")))
+	testutil.Ok(t, inner.Upload(ctx, "tenant-b/id1/obj", strings.NewReader("x")))
+
+	for _, prefix := range []string{"tenant-a", "/tenant-a/", "tenant-a/nested"} {
+		t.Run(prefix, func(t *testing.T) {
+			bkt := objstore.BucketWithPrefix(inner, prefix)
+			expectedPrefix := strings.Trim(prefix, "/") + "/"
+
+			testutil.Ok(t, bkt.Upload(ctx, "id1/obj1", strings.NewReader("@test-data@")))
+			testutil.Ok(t, bkt.Upload(ctx, "id1/obj2", strings.NewReader("@test-data2@")))
+			testutil.Ok(t, bkt.Upload(ctx, "obj3", strings.NewReader("@test-data3@")))
+
+			_, ok := inner.Objects()[expectedPrefix+"id1/obj1"]
+			testutil.Assert(t, ok, "object not stored under prefix")
+
+			rc, err := bkt.GetRange(ctx, "id1/obj1", 1, 4)
+			testutil.Ok(t, err)
+			b, err := ioutil.ReadAll(rc)
+			testutil.Ok(t, err)
+			testutil.Ok(t, rc.Close())
+			testutil.Equals(t, "test", string(b))
+
+			ok, err = bkt.Exists(ctx, "id1/obj2")
+			testutil.Ok(t, err)
+			testutil.Assert(t, ok, "expected object to exist")
+
+			_, err = bkt.Get(ctx, "outside")
+			testutil.NotOk(t, err)
+			testutil.Assert(t, bkt.IsObjNotFoundErr(err), "expected not found error, got %v", err)
+
+			var seen []string
+			testutil.Ok(t, bkt.Iter(ctx, "", func(name string) error {
+				seen = append(seen, name)
+				return nil
+			}))
+			sort.Strings(seen)
+			testutil.Equals(t, []string{"id1/", "obj3"}, seen)
+
+			seen = seen[:0]
+			testutil.Ok(t, bkt.Iter(ctx, "id1/", func(name string) error {
+				seen = append(seen, name)
+				return nil
+			}))
+			sort.Strings(seen)
+			testutil.Equals(t, []string{"id1/obj1", "id1/obj2"}, seen)
+
+			testutil.Ok(t, objstore.DeleteDir(ctx, bkt, "id1"))
+			testutil.Ok(t, bkt.Delete(ctx, "obj3"))
+			for name := range inner.Objects() {
+				testutil.Assert(t, !strings.HasPrefix(name, expectedPrefix), "object %s left after delete", name)
+			}
+		})
+	}
+	testutil.Equals(t, 2, len(inner.Objects()))
+
+	// Empty prefix is a no-op.
+	testutil.Equals(t, objstore.Bucket(inner), objstore.BucketWithPrefix(inner, "/"))
+}
@@ -0,0 +1,277 @@
+package objstore
+
+import (
+	"context"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RateLimitConfig configures limits of the bandwidth and the number of operations against a bucket.
+// Zero values mean no limit.
+type RateLimitConfig struct {
+	// ReadBytesPerSecond limits the number of bytes per second read from objects.
+	ReadBytesPerSecond int64 `yaml:"read_bytes_per_second"`
+	// ReadOpsPerSecond limits the number of Iter, Get, GetRange, Exists and Attributes operations per second.
+	ReadOpsPerSecond float64 `yaml:"read_ops_per_second"`
+	// WriteBytesPerSecond limits the number of bytes per second uploaded.
+	WriteBytesPerSecond int64 `yaml:"write_bytes_per_second"`
+	// WriteOpsPerSecond limits the number of Upload and Delete operations per second.
+	WriteOpsPerSecond float64 `yaml:"write_ops_per_second"`
+}
+
+// Enabled returns true if any limit is configured.
+func (c RateLimitConfig) Enabled() bool {
+	return c.ReadBytesPerSecond > 0 || c.ReadOpsPerSecond > 0 || c.WriteBytesPerSecond > 0 || c.WriteOpsPerSecond > 0
+}
+
+const (
+	rateLimitRead  = "read"
+	rateLimitWrite = "write"
+	rateLimitBytes = "bytes"
+	rateLimitOps   = "ops"
+)
+
+// BucketWithRateLimits takes a bucket and returns a bucket that limits operations and transferred bytes against it
+// according to the given config. Operations over the limits wait for their turn, in the order they arrived.
+// The limits are shared by all users of the returned bucket.
+func BucketWithRateLimits(b Bucket, cfg RateLimitConfig, reg prometheus.Registerer) Bucket {
+	bkt := &rateLimitedBucket{
+		bkt: b,
+		waits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "thanos_objstore_bucket_rate_limit_waits_total",
+			Help:        "Total number of times an operation against a bucket had to wait because of a rate limit.",
+			ConstLabels: prometheus.Labels{"bucket": b.Name()},
+		}, []string{"direction", "limit"}),
+		waitSeconds: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "thanos_objstore_bucket_rate_limit_wait_seconds_total",
+			Help:        "Total time operations against a bucket spent waiting because of a rate limit.",
+			ConstLabels: prometheus.Labels{"bucket": b.Name()},
+		}, []string{"direction", "limit"}),
+		waiting: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "thanos_objstore_bucket_rate_limit_waiting",
+			Help:        "Current number of operations against a bucket waiting because of a rate limit.",
+			ConstLabels: prometheus.Labels{"bucket": b.Name()},
+		}, []string{"direction", "limit"}),
+	}
+	if reg != nil {
+		reg.MustRegister(bkt.waits, bkt.waitSeconds, bkt.waiting)
+	}
+
+	bkt.readBytes = bkt.newLimiter(rateLimitRead, rateLimitBytes, float64(cfg.ReadBytesPerSecond))
+	bkt.readOps = bkt.newLimiter(rateLimitRead, rateLimitOps, cfg.ReadOpsPerSecond)
+	bkt.writeBytes = bkt.newLimiter(rateLimitWrite, rateLimitBytes, float64(cfg.WriteBytesPerSecond))
+	bkt.writeOps = bkt.newLimiter(rateLimitWrite, rateLimitOps, cfg.WriteOpsPerSecond)
+	return bkt
+}
+
+type rateLimitedBucket struct {
+	bkt Bucket
+
+	readBytes, readOps, writeBytes, writeOps *rateLimiter
+
+	waits       *prometheus.CounterVec
+	waitSeconds *prometheus.CounterVec
+	waiting     *prometheus.GaugeVec
+}
+
+func (b *rateLimitedBucket) newLimiter(direction, limit string, rate float64) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	// Initialize the metrics with 0.
+	b.waits.WithLabelValues(direction, limit)
+	b.waitSeconds.WithLabelValues(direction, limit)
+	b.waiting.WithLabelValues(direction, limit)
+
+	// Allow bursts of one second worth of tokens, but at least a single operation.
+	burst := math.Max(rate, 1)
+	return &rateLimiter{
+		rate:        rate,
+		burst:       burst,
+		tokens:      burst,
+		last:        time.Now(),
+		waits:       b.waits.WithLabelValues(direction, limit),
+		waitSeconds: b.waitSeconds.WithLabelValues(direction, limit),
+		waiting:     b.waiting.WithLabelValues(direction, limit),
+	}
+}
+
+func (b *rateLimitedBucket) Iter(ctx context.Context, dir string, f func(string) error) error {
+	if err := b.readOps.wait(ctx, 1); err != nil {
+		return err
+	}
+	return b.bkt.Iter(ctx, dir, f)
+}
+
+func (b *rateLimitedBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	if err := b.readOps.wait(ctx, 1); err != nil {
+		return nil, err
+	}
+	rc, err := b.bkt.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return b.limitReadCloser(ctx, rc), nil
+}
+
+func (b *rateLimitedBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	if err := b.readOps.wait(ctx, 1); err != nil {
+		return nil, err
+	}
+	rc, err := b.bkt.GetRange(ctx, name, off, length)
+	if err != nil {
+		return nil, err
+	}
+	return b.limitReadCloser(ctx, rc), nil
+}
+
+func (b *rateLimitedBucket) limitReadCloser(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	if b.readBytes == nil {
+		return rc
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{Reader: &rateLimitedReader{ctx: ctx, r: rc, l: b.readBytes}, Closer: rc}
+}
+
+func (b *rateLimitedBucket) Exists(ctx context.Context, name string) (bool, error) {
+	if err := b.readOps.wait(ctx, 1); err != nil {
+		return false, err
+	}
+	return b.bkt.Exists(ctx, name)
+}
+
+func (b *rateLimitedBucket) Attributes(ctx context.Context, name string) (ObjectAttributes, error) {
+	if err := b.readOps.wait(ctx, 1); err != nil {
+		return ObjectAttributes{}, err
+	}
+	return b.bkt.Attributes(ctx, name)
+}
+
+func (b *rateLimitedBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	if err := b.writeOps.wait(ctx, 1); err != nil {
+		return err
+	}
+	if b.writeBytes != nil {
+		r = &rateLimitedReader{ctx: ctx, r: r, l: b.writeBytes}
+	}
+	return b.bkt.Upload(ctx, name, r)
+}
+
+func (b *rateLimitedBucket) Delete(ctx context.Context, name string) error {
+	if err := b.writeOps.wait(ctx, 1); err != nil {
+		return err
+	}
+	return b.bkt.Delete(ctx, name)
+}
+
+func (b *rateLimitedBucket) IsObjNotFoundErr(err error) bool {
+	return b.bkt.IsObjNotFoundErr(err)
+}
+
+// IsRetryableErr classifies errors with the wrapped bucket, so a retrying bucket wrapping this one still
+// sees which provider errors are retryable.
+func (b *rateLimitedBucket) IsRetryableErr(err error) bool {
+	if c, ok := b.bkt.(RetryableErrClassifier); ok {
+		return c.IsRetryableErr(err)
+	}
+	return IsTransientErr(err)
+}
+
+func (b *rateLimitedBucket) Close() error {
+	return b.bkt.Close()
+}
+
+func (b *rateLimitedBucket) Name() string {
+	return b.bkt.Name()
+}
+
+// rateLimitedReader waits for the bytes limiter after every read, so data is consumed at most at the limited rate.
+type rateLimitedReader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *rateLimiter
+}
+
+// ObjectSize returns the size of the underlying reader, so providers can still use it for uploads.
+func (r *rateLimitedReader) ObjectSize() (int64, error) {
+	return TryToGetSize(r.r)
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	// Read at most a burst at once, so we don't get ahead of the limit by more than one second.
+	if max := int(r.l.burst); max > 0 && len(p) > max {
+		p = p[:max]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := r.l.wait(r.ctx, float64(n)); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// rateLimiter is a token bucket. Tokens are refilled at the given rate per second up to the burst.
+// Callers reserve tokens in the order they arrive and wait until their reservation is covered, so tokens
+// might go negative while requests are queued.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mtx    sync.Mutex
+	tokens float64
+	last   time.Time
+
+	waits       prometheus.Counter
+	waitSeconds prometheus.Counter
+	waiting     prometheus.Gauge
+}
+
+// wait blocks until n tokens are available or the context is done. A nil limiter never blocks.
+func (l *rateLimiter) wait(ctx context.Context, n float64) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mtx.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens -= n
+	deficit := -l.tokens
+	l.mtx.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+
+	d := time.Duration(deficit / l.rate * float64(time.Second))
+	l.waits.Inc()
+	l.waiting.Inc()
+	defer l.waiting.Dec()
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		l.waitSeconds.Add(d.Seconds())
+		return nil
+	case <-ctx.Done():
+		// Give the reservation back, we did not use it.
+		l.mtx.Lock()
+		l.tokens += n
+		l.mtx.Unlock()
+		l.waitSeconds.Add(time.Since(now).Seconds())
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,103 @@
+package objstore_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestBucketWithRateLimits_Bytes(t *testing.T) {
+	ctx := context.Background()
+	inner := inmem.NewBucket()
+	content := make([]byte, 1500)
+	testutil.Ok(t, inner.Upload(ctx, "obj", bytes.NewReader(content)))
+
+	bkt := objstore.BucketWithRateLimits(inner, objstore.RateLimitConfig{ReadBytesPerSecond: 1000, WriteBytesPerSecond: 1000}, prometheus.NewRegistry())
+
+	// The first second worth of bytes is allowed as a burst, the rest has to wait.
+	start := time.Now()
+	rc, err := bkt.Get(ctx, "obj")
+	testutil.Ok(t, err)
+	b, err := ioutil.ReadAll(rc)
+	testutil.Ok(t, err)
+	testutil.Ok(t, rc.Close())
+	testutil.Equals(t, content, b)
+	testutil.Assert(t, time.Since(start) >= 400*time.Millisecond, "read was not limited, took %v", time.Since(start))
+
+	// Writes are limited separately.
+	start = time.Now()
+	testutil.Ok(t, bkt.Upload(ctx, "obj2", bytes.NewReader(content[:1000])))
+	testutil.Assert(t, time.Since(start) < 400*time.Millisecond, "write waited for read limit, took %v", time.Since(start))
+	testutil.Equals(t, content[:1000], inner.Objects()["obj2"])
+}
+
+func TestBucketWithRateLimits_Ops(t *testing.T) {
+	ctx := context.Background()
+	inner := inmem.NewBucket()
+	bkt := objstore.BucketWithRateLimits(inner, objstore.RateLimitConfig{ReadOpsPerSecond: 20}, nil)
+
+	start := time.Now()
+	for i := 0; i < 25; i++ {
+		_, err := bkt.Exists(ctx, "obj")
+		testutil.Ok(t, err)
+	}
+	testutil.Assert(t, time.Since(start) >= 200*time.Millisecond, "ops were not limited, took %v", time.Since(start))
+
+	// Waiting operations give up once the context is done.
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	for i := 0; i < 25; i++ {
+		if _, err := bkt.Exists(cctx, "obj"); err != nil {
+			testutil.Equals(t, context.DeadlineExceeded, err)
+			return
+		}
+	}
+	t.Fatal("expected operation to fail once context is done")
+}
+
+var errThrottled = errors.New("throttled")
+
+// throttlingBucket fails the first failures uploads with an error only the bucket itself knows to be retryable.
+type throttlingBucket struct {
+	objstore.Bucket
+
+	failures int
+	uploads  int
+}
+
+func (b *throttlingBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	b.uploads++
+	if b.uploads <= b.failures {
+		return errThrottled
+	}
+	return b.Bucket.Upload(ctx, name, r)
+}
+
+func (b *throttlingBucket) IsRetryableErr(err error) bool {
+	return errors.Cause(err) == errThrottled
+}
+
+func TestBucketWithRateLimits_Retries(t *testing.T) {
+	ctx := context.Background()
+	mem := inmem.NewBucket()
+	inner := &throttlingBucket{Bucket: mem, failures: 2}
+
+	// Same order as the client factory: rate limits are applied to every attempt.
+	bkt := objstore.BucketWithRateLimits(inner, objstore.RateLimitConfig{WriteOpsPerSecond: 100}, nil)
+	bkt = objstore.BucketWithRetries(log.NewNopLogger(), bkt, objstore.RetryConfig{MaxAttempts: 3, MinBackoff: model.Duration(time.Millisecond)}, nil)
+
+	testutil.Ok(t, bkt.Upload(ctx, "obj", bytes.NewReader([]byte("content"))))
+	testutil.Equals(t, 3, inner.uploads)
+	testutil.Equals(t, []byte("content"), mem.Objects()["obj"])
+}
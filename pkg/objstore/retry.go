@@ -0,0 +1,302 @@
+package objstore
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	defaultRetryMinBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff = 10 * time.Second
+)
+
+// RetryConfig configures retries of failed bucket operations.
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of attempts of a single operation. 0 or 1 disables retries.
+	MaxAttempts int `yaml:"max_attempts"`
+	// MinBackoff is the backoff before the first retry. It is doubled on every retry, up to MaxBackoff.
+	MinBackoff model.Duration `yaml:"min_backoff"`
+	// MaxBackoff is the maximum backoff between retries.
+	MaxBackoff model.Duration `yaml:"max_backoff"`
+	// OperationTimeout is the timeout of a single attempt. For Get and GetRange it includes reading the object.
+	// 0 means no timeout.
+	OperationTimeout model.Duration `yaml:"operation_timeout"`
+}
+
+// Enabled returns true if retries are configured.
+func (c RetryConfig) Enabled() bool {
+	return c.MaxAttempts > 1
+}
+
+// RetryableErrClassifier is implemented by buckets that can tell which of their errors are transient, so
+// the operation that failed can be retried.
+type RetryableErrClassifier interface {
+	// IsRetryableErr returns true if the operation that returned the error can be retried.
+	IsRetryableErr(err error) bool
+}
+
+// IsRetryableHTTPStatus returns true for HTTP status codes that usually indicate a transient failure.
+func IsRetryableHTTPStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// IsTransientErr returns true if the error is a generic transient network error, e.g. a timeout, a reset connection
+// or a response that ended unexpectedly.
+func IsTransientErr(err error) bool {
+	err = errors.Cause(err)
+	if err == io.ErrUnexpectedEOF {
+		return true
+	}
+	if netErr, ok := err.(net.Error); ok {
+		return netErr.Timeout() || netErr.Temporary()
+	}
+	return false
+}
+
+// BucketWithRetries takes a bucket and returns a bucket that retries failed operations with exponential backoff
+// according to the given config. Errors are retried if the bucket classifies them as retryable (see RetryableErrClassifier),
+// otherwise if they are generic transient errors. Not found errors are never retried.
+// Uploads are retried only if the uploaded reader implements io.Seeker. Errors returned while reading objects
+// are not retried, only the requests opening them are.
+func BucketWithRetries(logger log.Logger, b Bucket, cfg RetryConfig, reg prometheus.Registerer) Bucket {
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = model.Duration(defaultRetryMinBackoff)
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = model.Duration(defaultRetryMaxBackoff)
+	}
+
+	bkt := &retryBucket{
+		bkt:    b,
+		logger: logger,
+		cfg:    cfg,
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "thanos_objstore_bucket_operation_retries_total",
+			Help:        "Total number of retries of failed operations against a bucket.",
+			ConstLabels: prometheus.Labels{"bucket": b.Name()},
+		}, []string{"operation"}),
+		exhausted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "thanos_objstore_bucket_operation_retries_exhausted_total",
+			Help:        "Total number of operations against a bucket that failed with a retryable error after all attempts.",
+			ConstLabels: prometheus.Labels{"bucket": b.Name()},
+		}, []string{"operation"}),
+	}
+	if reg != nil {
+		reg.MustRegister(bkt.retries, bkt.exhausted)
+	}
+	return bkt
+}
+
+type retryBucket struct {
+	bkt    Bucket
+	logger log.Logger
+	cfg    RetryConfig
+
+	retries   *prometheus.CounterVec
+	exhausted *prometheus.CounterVec
+}
+
+func (b *retryBucket) isRetryable(ctx, opCtx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		// Canceled by the caller.
+		return false
+	}
+	if opCtx.Err() == context.DeadlineExceeded {
+		// The attempt timed out.
+		return true
+	}
+	if b.bkt.IsObjNotFoundErr(err) {
+		return false
+	}
+	if c, ok := b.bkt.(RetryableErrClassifier); ok {
+		return c.IsRetryableErr(err)
+	}
+	return IsTransientErr(err)
+}
+
+func (b *retryBucket) backoff(attempt int) time.Duration {
+	d := time.Duration(b.cfg.MinBackoff) << uint(attempt)
+	if d > time.Duration(b.cfg.MaxBackoff) || d <= 0 {
+		d = time.Duration(b.cfg.MaxBackoff)
+	}
+	// Jitter between half and the full backoff avoids synchronized retries of concurrent operations.
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// do calls f until it succeeds, fails with a non-retryable error or runs out of attempts.
+// The context passed to f is canceled by the returned cancel function, which has to be called once the
+// result of the successful attempt is no longer used.
+func (b *retryBucket) do(ctx context.Context, op, name string, f func(ctx context.Context) error) (cancel func(), err error) {
+	for attempt := 0; ; attempt++ {
+		opCtx, opCancel := ctx, context.CancelFunc(func() {})
+		if b.cfg.OperationTimeout > 0 {
+			opCtx, opCancel = context.WithTimeout(ctx, time.Duration(b.cfg.OperationTimeout))
+		}
+
+		err = f(opCtx)
+		if err == nil {
+			return opCancel, nil
+		}
+		retryable := b.isRetryable(ctx, opCtx, err)
+		opCancel()
+		if !retryable {
+			return nil, err
+		}
+		if attempt+1 >= b.cfg.MaxAttempts {
+			b.exhausted.WithLabelValues(op).Inc()
+			return nil, err
+		}
+
+		backoff := b.backoff(attempt)
+		level.Debug(b.logger).Log("msg", "retrying bucket operation", "op", op, "name", name, "attempt", attempt+1, "backoff", backoff, "err", err)
+		b.retries.WithLabelValues(op).Inc()
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// Iter calls f for each entry in the given directory. If listing is retried, entries passed to f by previous
+// attempts are skipped. Errors returned by f are never retried.
+func (b *retryBucket) Iter(ctx context.Context, dir string, f func(string) error) error {
+	seen := map[string]struct{}{}
+	var fErr error
+
+	cancel, err := b.do(ctx, "iter", dir, func(ctx context.Context) error {
+		err := b.bkt.Iter(ctx, dir, func(name string) error {
+			if _, ok := seen[name]; ok {
+				return nil
+			}
+			seen[name] = struct{}{}
+			fErr = f(name)
+			return fErr
+		})
+		if fErr != nil {
+			// Stop retrying, this is the caller's error.
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	cancel()
+	return fErr
+}
+
+func (b *retryBucket) Get(ctx context.Context, name string) (rc io.ReadCloser, err error) {
+	cancel, err := b.do(ctx, "get", name, func(ctx context.Context) (err error) {
+		rc, err = b.bkt.Get(ctx, name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cancelReadCloser{ReadCloser: rc, cancel: cancel}, nil
+}
+
+func (b *retryBucket) GetRange(ctx context.Context, name string, off, length int64) (rc io.ReadCloser, err error) {
+	cancel, err := b.do(ctx, "get_range", name, func(ctx context.Context) (err error) {
+		rc, err = b.bkt.GetRange(ctx, name, off, length)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &cancelReadCloser{ReadCloser: rc, cancel: cancel}, nil
+}
+
+func (b *retryBucket) Exists(ctx context.Context, name string) (ok bool, err error) {
+	cancel, err := b.do(ctx, "exists", name, func(ctx context.Context) (err error) {
+		ok, err = b.bkt.Exists(ctx, name)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	cancel()
+	return ok, nil
+}
+
+func (b *retryBucket) Attributes(ctx context.Context, name string) (attrs ObjectAttributes, err error) {
+	cancel, err := b.do(ctx, "attributes", name, func(ctx context.Context) (err error) {
+		attrs, err = b.bkt.Attributes(ctx, name)
+		return err
+	})
+	if err != nil {
+		return ObjectAttributes{}, err
+	}
+	cancel()
+	return attrs, nil
+}
+
+func (b *retryBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return b.bkt.Upload(ctx, name, r)
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		// Not really seekable, e.g. a pipe.
+		return b.bkt.Upload(ctx, name, r)
+	}
+
+	cancel, err := b.do(ctx, "upload", name, func(ctx context.Context) error {
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return errors.Wrap(err, "rewind reader")
+		}
+		return b.bkt.Upload(ctx, name, r)
+	})
+	if err != nil {
+		return err
+	}
+	cancel()
+	return nil
+}
+
+func (b *retryBucket) Delete(ctx context.Context, name string) error {
+	cancel, err := b.do(ctx, "delete", name, func(ctx context.Context) error {
+		return b.bkt.Delete(ctx, name)
+	})
+	if err != nil {
+		return err
+	}
+	cancel()
+	return nil
+}
+
+func (b *retryBucket) IsObjNotFoundErr(err error) bool {
+	return b.bkt.IsObjNotFoundErr(err)
+}
+
+func (b *retryBucket) Close() error {
+	return b.bkt.Close()
+}
+
+func (b *retryBucket) Name() string {
+	return b.bkt.Name()
+}
+
+// cancelReadCloser cancels the context of the operation that opened the reader once it is closed.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel func()
+}
+
+func (rc *cancelReadCloser) Close() error {
+	defer rc.cancel()
+	return rc.ReadCloser.Close()
+}
@@ -0,0 +1,162 @@
+package objstore_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+// flakyBucket fails the first failures calls of every operation with a transient error.
+type flakyBucket struct {
+	objstore.Bucket
+
+	failures int
+	calls    map[string]int
+}
+
+func (b *flakyBucket) fail(op string) error {
+	b.calls[op]++
+	if b.calls[op] <= b.failures {
+		return errors.Wrap(timeoutErr{}, op)
+	}
+	return nil
+}
+
+func (b *flakyBucket) Iter(ctx context.Context, dir string, f func(string) error) error {
+	b.calls["iter"]++
+	failAfterFirst := b.calls["iter"] <= b.failures
+
+	var i int
+	return b.Bucket.Iter(ctx, dir, func(name string) error {
+		if failAfterFirst && i > 0 {
+			return timeoutErr{}
+		}
+		i++
+		return f(name)
+	})
+}
+
+func (b *flakyBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	if err := b.fail("get"); err != nil {
+		return nil, err
+	}
+	return b.Bucket.Get(ctx, name)
+}
+
+func (b *flakyBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	if err := b.fail("upload"); err != nil {
+		// Consume part of the reader, as a failed upload would.
+		_, _ = io.CopyN(ioutil.Discard, r, 3)
+		return err
+	}
+	return b.Bucket.Upload(ctx, name, r)
+}
+
+func newFlakyBucket(failures int) *flakyBucket {
+	return &flakyBucket{Bucket: inmem.NewBucket(), failures: failures, calls: map[string]int{}}
+}
+
+func TestBucketWithRetries(t *testing.T) {
+	ctx := context.Background()
+	cfg := objstore.RetryConfig{
+		MaxAttempts: 3,
+		MinBackoff:  model.Duration(time.Millisecond),
+		MaxBackoff:  model.Duration(5 * time.Millisecond),
+	}
+
+	t.Run("upload and get succeed after transient errors", func(t *testing.T) {
+		flaky := newFlakyBucket(2)
+		bkt := objstore.BucketWithRetries(log.NewNopLogger(), flaky, cfg, prometheus.NewRegistry())
+
+		testutil.Ok(t, bkt.Upload(ctx, "obj", bytes.NewReader([]byte("@test-data@"))))
+		testutil.Equals(t, 3, flaky.calls["upload"])
+
+		rc, err := bkt.Get(ctx, "obj")
+		testutil.Ok(t, err)
+		b, err := ioutil.ReadAll(rc)
+		testutil.Ok(t, err)
+		testutil.Ok(t, rc.Close())
+		testutil.Equals(t, "@test-data@", string(b))
+		testutil.Equals(t, 3, flaky.calls["get"])
+	})
+
+	t.Run("non-seekable upload is not retried", func(t *testing.T) {
+		flaky := newFlakyBucket(1)
+		bkt := objstore.BucketWithRetries(log.NewNopLogger(), flaky, cfg, nil)
+
+		testutil.NotOk(t, bkt.Upload(ctx, "obj", ioutil.NopCloser(strings.NewReader("@test-data@"))))
+		testutil.Equals(t, 1, flaky.calls["upload"])
+	})
+
+	t.Run("attempts are exhausted", func(t *testing.T) {
+		flaky := newFlakyBucket(5)
+		bkt := objstore.BucketWithRetries(log.NewNopLogger(), flaky, cfg, nil)
+
+		_, err := bkt.Get(ctx, "obj")
+		testutil.NotOk(t, err)
+		testutil.Equals(t, 3, flaky.calls["get"])
+	})
+
+	t.Run("not found errors are not retried", func(t *testing.T) {
+		flaky := newFlakyBucket(0)
+		bkt := objstore.BucketWithRetries(log.NewNopLogger(), flaky, cfg, nil)
+
+		_, err := bkt.Get(ctx, "missing")
+		testutil.NotOk(t, err)
+		testutil.Assert(t, bkt.IsObjNotFoundErr(err), "expected not found error, got %v", err)
+		testutil.Equals(t, 1, flaky.calls["get"])
+	})
+
+	t.Run("canceled operations are not retried", func(t *testing.T) {
+		flaky := newFlakyBucket(5)
+		bkt := objstore.BucketWithRetries(log.NewNopLogger(), flaky, cfg, nil)
+
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := bkt.Get(cctx, "obj")
+		testutil.NotOk(t, err)
+		testutil.Equals(t, 1, flaky.calls["get"])
+	})
+
+	t.Run("iter skips entries seen in failed attempts", func(t *testing.T) {
+		flaky := newFlakyBucket(2)
+		for _, name := range []string{"a", "b", "c"} {
+			testutil.Ok(t, flaky.Bucket.Upload(ctx, name, strings.NewReader("x")))
+		}
+		bkt := objstore.BucketWithRetries(log.NewNopLogger(), flaky, cfg, nil)
+
+		var seen []string
+		testutil.Ok(t, bkt.Iter(ctx, "", func(name string) error {
+			seen = append(seen, name)
+			return nil
+		}))
+		testutil.Equals(t, []string{"a", "b", "c"}, seen)
+		testutil.Equals(t, 3, flaky.calls["iter"])
+
+		// Errors of the callback are returned as-is.
+		flaky.calls["iter"] = 0
+		errCallback := errors.New("callback failed")
+		testutil.Equals(t, errCallback, bkt.Iter(ctx, "", func(name string) error {
+			return errCallback
+		}))
+		testutil.Equals(t, 1, flaky.calls["iter"])
+	})
+}
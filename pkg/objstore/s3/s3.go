@@ -294,18 +294,27 @@ func (b *Bucket) Exists(ctx context.Context, name string) (bool, error) {
 	return true, nil
 }
 
+// Attributes returns information about the specified object.
+func (b *Bucket) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	objInfo, err := b.client.StatObject(b.name, name, minio.StatObjectOptions{})
+	if err != nil {
+		return objstore.ObjectAttributes{}, err
+	}
+
+	return objstore.ObjectAttributes{
+		Size:         objInfo.Size,
+		LastModified: objInfo.LastModified,
+		MD5:          objstore.MD5FromETag(objInfo.ETag),
+	}, nil
+}
+
 func (b *Bucket) guessFileSize(name string, r io.Reader) int64 {
-	if f, ok := r.(*os.File); ok {
-		fileInfo, err := f.Stat()
-		if err == nil {
-			return fileInfo.Size()
-		}
-		level.Warn(b.logger).Log("msg", "could not stat file for multipart upload", "name", name, "err", err)
+	size, err := objstore.TryToGetSize(r)
+	if err != nil {
+		level.Warn(b.logger).Log("msg", "could not guess file size for multipart upload", "name", name, "err", err)
 		return -1
 	}
-
-	level.Warn(b.logger).Log("msg", "could not guess file size for multipart upload", "name", name)
-	return -1
+	return size
 }
 
 // Upload the contents of the reader as an object into the bucket.
@@ -341,6 +350,19 @@ func (b *Bucket) IsObjNotFoundErr(err error) bool {
 	return minio.ToErrorResponse(err).Code == "NoSuchKey"
 }
 
+// IsRetryableErr returns true if the operation that returned the error can be retried.
+func (b *Bucket) IsRetryableErr(err error) bool {
+	resp := minio.ToErrorResponse(errors.Cause(err))
+	switch resp.Code {
+	case "SlowDown", "RequestTimeout", "InternalError", "ServiceUnavailable":
+		return true
+	}
+	if resp.StatusCode != 0 {
+		return objstore.IsRetryableHTTPStatus(resp.StatusCode)
+	}
+	return objstore.IsTransientErr(err)
+}
+
 func (b *Bucket) Close() error { return nil }
 
 func configFromEnv() Config {
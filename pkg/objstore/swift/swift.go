@@ -141,12 +141,41 @@ func (c *Container) Exists(ctx context.Context, name string) (bool, error) {
 	return false, err
 }
 
+// Attributes returns information about the specified object.
+func (c *Container) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	response, err := objects.Get(c.client, c.name, name, nil).Extract()
+	if err != nil {
+		return objstore.ObjectAttributes{}, err
+	}
+
+	attrs := objstore.ObjectAttributes{
+		Size:         response.ContentLength,
+		LastModified: response.LastModified,
+	}
+	// ETags of large objects are not hashes of their content.
+	if response.ObjectManifest == "" && !response.StaticLargeObject {
+		attrs.MD5 = objstore.MD5FromETag(response.ETag)
+	}
+	return attrs, nil
+}
+
 // IsObjNotFoundErr returns true if error means that object is not found. Relevant to Get operations.
 func (c *Container) IsObjNotFoundErr(err error) bool {
 	_, ok := err.(gophercloud.ErrDefault404)
 	return ok
 }
 
+// IsRetryableErr returns true if the operation that returned the error can be retried.
+func (c *Container) IsRetryableErr(err error) bool {
+	switch tmpErr := errors.Cause(err).(type) {
+	case gophercloud.ErrDefault408, gophercloud.ErrDefault429, gophercloud.ErrDefault500, gophercloud.ErrDefault503, gophercloud.ErrTimeOut:
+		return true
+	case gophercloud.ErrUnexpectedResponseCode:
+		return objstore.IsRetryableHTTPStatus(tmpErr.Actual)
+	}
+	return objstore.IsTransientErr(err)
+}
+
 // Upload writes the contents of the reader as an object into the container.
 func (c *Container) Upload(ctx context.Context, name string, r io.Reader) error {
 	options := &objects.CreateOpts{Content: r}
@@ -41,6 +41,8 @@ import (
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
 	"github.com/thanos-io/thanos/pkg/query"
 	"github.com/thanos-io/thanos/pkg/runutil"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/strutil"
 	"github.com/thanos-io/thanos/pkg/tracing"
 )
 
@@ -60,6 +62,7 @@ const (
 	errorExec     ErrorType = "execution"
 	errorBadData  ErrorType = "bad_data"
 	ErrorInternal ErrorType = "internal"
+	ErrorNotFound ErrorType = "not_found"
 )
 
 var corsHeaders = map[string]string{
@@ -186,8 +189,9 @@ type queryData struct {
 	ResultType promql.ValueType `json:"resultType"`
 	Result     promql.Value     `json:"result"`
 
-	// Additional Thanos Response field.
-	Warnings []error `json:"warnings,omitempty"`
+	// Additional Thanos Response fields.
+	Warnings []error              `json:"warnings,omitempty"`
+	Stats    *storepb.SeriesStats `json:"stats,omitempty"`
 }
 
 func (api *API) parseEnableDedupParam(r *http.Request) (enableDeduplication bool, _ *ApiError) {
@@ -241,6 +245,37 @@ func (api *API) parsePartialResponseParam(r *http.Request) (enablePartialRespons
 	return enablePartialResponse, nil
 }
 
+func (api *API) parseStatsParam(r *http.Request) (enableStats bool, _ *ApiError) {
+	const statsParam = "stats"
+
+	if val := r.FormValue(statsParam); val != "" {
+		var err error
+		enableStats, err = strconv.ParseBool(val)
+		if err != nil {
+			return false, &ApiError{errorBadData, errors.Wrapf(err, "'%s' parameter", statsParam)}
+		}
+	}
+	return enableStats, nil
+}
+
+// newStatsReporter returns a thread-safe reporter summing up the statistics of all Series calls of a query into
+// the returned stats. If stats are not enabled, both are nil.
+func newStatsReporter(enableStats bool) (query.StatsReporter, *storepb.SeriesStats) {
+	if !enableStats {
+		return nil, nil
+	}
+
+	var (
+		statsmtx sync.Mutex
+		stats    = &storepb.SeriesStats{}
+	)
+	return func(s *storepb.SeriesStats) {
+		statsmtx.Lock()
+		stats.Merge(s)
+		statsmtx.Unlock()
+	}, stats
+}
+
 func (api *API) options(r *http.Request) (interface{}, []error, *ApiError) {
 	return nil, nil, nil
 }
@@ -279,6 +314,11 @@ func (api *API) query(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, apiErr
 	}
 
+	enableStats, apiErr := api.parseStatsParam(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
 	var (
 		warnmtx  sync.Mutex
 		warnings []error
@@ -288,13 +328,14 @@ func (api *API) query(r *http.Request) (interface{}, []error, *ApiError) {
 		warnings = append(warnings, err)
 		warnmtx.Unlock()
 	}
+	statsReporter, stats := newStatsReporter(enableStats)
 
 	// We are starting promQL tracing span here, because we have no control over promQL code.
 	span, ctx := tracing.StartSpan(ctx, "promql_instant_query")
 	defer span.Finish()
 
 	begin := api.now()
-	qry, err := api.queryEngine.NewInstantQuery(api.queryableCreate(enableDedup, 0, enablePartialResponse, warningReporter), r.FormValue("query"), ts)
+	qry, err := api.queryEngine.NewInstantQuery(api.queryableCreate(enableDedup, 0, enablePartialResponse, warningReporter, statsReporter), r.FormValue("query"), ts)
 	if err != nil {
 		return nil, nil, &ApiError{errorBadData, err}
 	}
@@ -316,6 +357,7 @@ func (api *API) query(r *http.Request) (interface{}, []error, *ApiError) {
 	return &queryData{
 		ResultType: res.Value.Type(),
 		Result:     res.Value,
+		Stats:      stats,
 	}, warnings, nil
 }
 
@@ -377,6 +419,11 @@ func (api *API) queryRange(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, apiErr
 	}
 
+	enableStats, apiErr := api.parseStatsParam(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
 	var (
 		warnmtx  sync.Mutex
 		warnings []error
@@ -386,6 +433,7 @@ func (api *API) queryRange(r *http.Request) (interface{}, []error, *ApiError) {
 		warnings = append(warnings, err)
 		warnmtx.Unlock()
 	}
+	statsReporter, stats := newStatsReporter(enableStats)
 
 	// We are starting promQL tracing span here, because we have no control over promQL code.
 	span, ctx := tracing.StartSpan(ctx, "promql_range_query")
@@ -393,7 +441,7 @@ func (api *API) queryRange(r *http.Request) (interface{}, []error, *ApiError) {
 
 	begin := api.now()
 	qry, err := api.queryEngine.NewRangeQuery(
-		api.queryableCreate(enableDedup, maxSourceResolution, enablePartialResponse, warningReporter),
+		api.queryableCreate(enableDedup, maxSourceResolution, enablePartialResponse, warningReporter, statsReporter),
 		r.FormValue("query"),
 		start,
 		end,
@@ -418,6 +466,7 @@ func (api *API) queryRange(r *http.Request) (interface{}, []error, *ApiError) {
 	return &queryData{
 		ResultType: res.Value.Type(),
 		Result:     res.Value,
+		Stats:      stats,
 	}, warnings, nil
 }
 
@@ -429,6 +478,20 @@ func (api *API) labelValues(r *http.Request) (interface{}, []error, *ApiError) {
 		return nil, nil, &ApiError{errorBadData, fmt.Errorf("invalid label name: %q", name)}
 	}
 
+	if err := r.ParseForm(); err != nil {
+		return nil, nil, &ApiError{ErrorInternal, errors.Wrap(err, "parse form")}
+	}
+
+	start, end, apiErr := parseMetadataTimeRange(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	matcherSets, apiErr := parseMatchersParam(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
 	enablePartialResponse, apiErr := api.parsePartialResponseParam(r)
 	if apiErr != nil {
 		return nil, nil, apiErr
@@ -444,7 +507,7 @@ func (api *API) labelValues(r *http.Request) (interface{}, []error, *ApiError) {
 		warnmtx.Unlock()
 	}
 
-	q, err := api.queryableCreate(true, 0, enablePartialResponse, warningReporter).Querier(ctx, math.MinInt64, math.MaxInt64)
+	q, err := api.queryableCreate(true, 0, enablePartialResponse, warningReporter, nil).Querier(ctx, timestamp.FromTime(start), timestamp.FromTime(end))
 	if err != nil {
 		return nil, nil, &ApiError{errorExec, err}
 	}
@@ -452,12 +515,29 @@ func (api *API) labelValues(r *http.Request) (interface{}, []error, *ApiError) {
 
 	// TODO(fabxc): add back request context.
 
-	vals, err := q.LabelValues(name)
-	if err != nil {
-		return nil, nil, &ApiError{errorExec, err}
+	if len(matcherSets) == 0 {
+		vals, err := q.LabelValues(name)
+		if err != nil {
+			return nil, nil, &ApiError{errorExec, err}
+		}
+		return vals, warnings, nil
+	}
+
+	lq, ok := q.(query.LabelQuerier)
+	if !ok {
+		return nil, nil, &ApiError{errorExec, errors.New("querier does not support label matchers")}
+	}
+	// Values of series matching any of the selectors are returned.
+	var sets [][]string
+	for _, mset := range matcherSets {
+		vals, err := lq.LabelValuesFor(name, mset...)
+		if err != nil {
+			return nil, nil, &ApiError{errorExec, err}
+		}
+		sets = append(sets, vals)
 	}
 
-	return vals, warnings, nil
+	return strutil.MergeSlices(sets...), warnings, nil
 }
 
 var (
@@ -465,45 +545,58 @@ var (
 	maxTime = time.Unix(math.MaxInt64/1000-62135596801, 999999999)
 )
 
-func (api *API) series(r *http.Request) (interface{}, []error, *ApiError) {
-	if err := r.ParseForm(); err != nil {
-		return nil, nil, &ApiError{ErrorInternal, errors.Wrap(err, "parse form")}
-	}
-
-	if len(r.Form["match[]"]) == 0 {
-		return nil, nil, &ApiError{errorBadData, fmt.Errorf("no match[] parameter provided")}
-	}
-
-	var start time.Time
+// parseMetadataTimeRange parses the optional start and end parameters of the metadata endpoints.
+// They default to the widest possible time range.
+func parseMetadataTimeRange(r *http.Request) (time.Time, time.Time, *ApiError) {
+	start, end := minTime, maxTime
 	if t := r.FormValue("start"); t != "" {
 		var err error
 		start, err = parseTime(t)
 		if err != nil {
-			return nil, nil, &ApiError{errorBadData, err}
+			return time.Time{}, time.Time{}, &ApiError{errorBadData, err}
 		}
-	} else {
-		start = minTime
 	}
-
-	var end time.Time
 	if t := r.FormValue("end"); t != "" {
 		var err error
 		end, err = parseTime(t)
 		if err != nil {
-			return nil, nil, &ApiError{errorBadData, err}
+			return time.Time{}, time.Time{}, &ApiError{errorBadData, err}
 		}
-	} else {
-		end = maxTime
 	}
+	return start, end, nil
+}
 
+// parseMatchersParam parses the series selectors of all match[] parameters.
+func parseMatchersParam(r *http.Request) ([][]*labels.Matcher, *ApiError) {
 	var matcherSets [][]*labels.Matcher
 	for _, s := range r.Form["match[]"] {
 		matchers, err := promql.ParseMetricSelector(s)
 		if err != nil {
-			return nil, nil, &ApiError{errorBadData, err}
+			return nil, &ApiError{errorBadData, err}
 		}
 		matcherSets = append(matcherSets, matchers)
 	}
+	return matcherSets, nil
+}
+
+func (api *API) series(r *http.Request) (interface{}, []error, *ApiError) {
+	if err := r.ParseForm(); err != nil {
+		return nil, nil, &ApiError{ErrorInternal, errors.Wrap(err, "parse form")}
+	}
+
+	if len(r.Form["match[]"]) == 0 {
+		return nil, nil, &ApiError{errorBadData, fmt.Errorf("no match[] parameter provided")}
+	}
+
+	start, end, apiErr := parseMetadataTimeRange(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	matcherSets, apiErr := parseMatchersParam(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
 
 	enableDedup, apiErr := api.parseEnableDedupParam(r)
 	if apiErr != nil {
@@ -526,7 +619,7 @@ func (api *API) series(r *http.Request) (interface{}, []error, *ApiError) {
 	}
 
 	// TODO(bwplotka): Support downsampling?
-	q, err := api.queryableCreate(enableDedup, 0, enablePartialResponse, warningReporter).Querier(r.Context(), timestamp.FromTime(start), timestamp.FromTime(end))
+	q, err := api.queryableCreate(enableDedup, 0, enablePartialResponse, warningReporter, nil).Querier(r.Context(), timestamp.FromTime(start), timestamp.FromTime(end))
 	if err != nil {
 		return nil, nil, &ApiError{errorExec, err}
 	}
@@ -534,7 +627,8 @@ func (api *API) series(r *http.Request) (interface{}, []error, *ApiError) {
 
 	var sets []storage.SeriesSet
 	for _, mset := range matcherSets {
-		s, _, err := q.Select(&storage.SelectParams{}, mset...)
+		// Only the labels are needed, see querier.Select.
+		s, _, err := q.Select(&storage.SelectParams{Func: "series"}, mset...)
 		if err != nil {
 			return nil, nil, &ApiError{errorExec, err}
 		}
@@ -581,6 +675,8 @@ func RespondError(w http.ResponseWriter, apiErr *ApiError, data interface{}) {
 		code = http.StatusServiceUnavailable
 	case ErrorInternal:
 		code = http.StatusInternalServerError
+	case ErrorNotFound:
+		code = http.StatusNotFound
 	default:
 		code = http.StatusInternalServerError
 	}
@@ -622,6 +718,20 @@ func parseDuration(s string) (time.Duration, error) {
 func (api *API) labelNames(r *http.Request) (interface{}, []error, *ApiError) {
 	ctx := r.Context()
 
+	if err := r.ParseForm(); err != nil {
+		return nil, nil, &ApiError{ErrorInternal, errors.Wrap(err, "parse form")}
+	}
+
+	start, end, apiErr := parseMetadataTimeRange(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
+	matcherSets, apiErr := parseMatchersParam(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+
 	enablePartialResponse, apiErr := api.parsePartialResponseParam(r)
 	if apiErr != nil {
 		return nil, nil, apiErr
@@ -637,16 +747,33 @@ func (api *API) labelNames(r *http.Request) (interface{}, []error, *ApiError) {
 		warnmtx.Unlock()
 	}
 
-	q, err := api.queryableCreate(true, 0, enablePartialResponse, warningReporter).Querier(ctx, math.MinInt64, math.MaxInt64)
+	q, err := api.queryableCreate(true, 0, enablePartialResponse, warningReporter, nil).Querier(ctx, timestamp.FromTime(start), timestamp.FromTime(end))
 	if err != nil {
 		return nil, nil, &ApiError{errorExec, err}
 	}
 	defer runutil.CloseWithLogOnErr(api.logger, q, "queryable labelNames")
 
-	names, err := q.LabelNames()
-	if err != nil {
-		return nil, nil, &ApiError{errorExec, err}
+	if len(matcherSets) == 0 {
+		names, err := q.LabelNames()
+		if err != nil {
+			return nil, nil, &ApiError{errorExec, err}
+		}
+		return names, warnings, nil
+	}
+
+	lq, ok := q.(query.LabelQuerier)
+	if !ok {
+		return nil, nil, &ApiError{errorExec, errors.New("querier does not support label matchers")}
+	}
+	// Names of series matching any of the selectors are returned.
+	var sets [][]string
+	for _, mset := range matcherSets {
+		names, err := lq.LabelNamesFor(mset...)
+		if err != nil {
+			return nil, nil, &ApiError{errorExec, err}
+		}
+		sets = append(sets, names)
 	}
 
-	return names, warnings, nil
+	return strutil.MergeSlices(sets...), warnings, nil
 }
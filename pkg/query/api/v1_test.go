@@ -40,11 +40,12 @@ import (
 	"github.com/thanos-io/thanos/pkg/compact"
 	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
 	"github.com/thanos-io/thanos/pkg/query"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
 
 func testQueryableCreator(queryable storage.Queryable) query.QueryableCreator {
-	return func(_ bool, _ int64, _ bool, _ query.WarningReporter) storage.Queryable {
+	return func(_ bool, _ int64, _ bool, _ query.WarningReporter, _ query.StatsReporter) storage.Queryable {
 		return queryable
 	}
 }
@@ -293,6 +294,34 @@ func TestEndpoints(t *testing.T) {
 			},
 			errType: errorBadData,
 		},
+		// Bad time range and match[] parameters.
+		{
+			endpoint: api.labelValues,
+			params: map[string]string{
+				"name": "foo",
+			},
+			query: url.Values{
+				"start": []string{"foo"},
+			},
+			errType: errorBadData,
+		},
+		{
+			endpoint: api.labelValues,
+			params: map[string]string{
+				"name": "foo",
+			},
+			query: url.Values{
+				"match[]": []string{`{foo`},
+			},
+			errType: errorBadData,
+		},
+		{
+			endpoint: api.labelNames,
+			query: url.Values{
+				"end": []string{"foo"},
+			},
+			errType: errorBadData,
+		},
 		{
 			endpoint: api.series,
 			query: url.Values{
@@ -599,6 +628,83 @@ func TestEndpoints(t *testing.T) {
 	}
 }
 
+type statsStoreServer struct {
+	// This field just exist to pseudo-implement the unused methods of the interface.
+	storepb.StoreServer
+
+	lastSeriesReq *storepb.SeriesRequest
+}
+
+func (s *statsStoreServer) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesServer) error {
+	s.lastSeriesReq = r
+	if !r.QueryStats {
+		return nil
+	}
+	return srv.Send(storepb.NewStatsSeriesResponse(&storepb.SeriesStats{BlocksQueried: 2, SeriesTouched: 3}))
+}
+
+func TestQueryStats(t *testing.T) {
+	store := &statsStoreServer{}
+	api := &API{
+		queryableCreate: query.NewQueryableCreator(nil, store, ""),
+		queryEngine: promql.NewEngine(promql.EngineOpts{
+			MaxConcurrent: 10,
+			MaxSamples:    10000,
+			Timeout:       10 * time.Second,
+		}),
+
+		instantQueryDuration: prometheus.NewHistogram(prometheus.HistogramOpts{}),
+		rangeQueryDuration:   prometheus.NewHistogram(prometheus.HistogramOpts{}),
+
+		now: time.Now,
+	}
+
+	for _, tcase := range []struct {
+		endpoint ApiFunc
+		query    url.Values
+	}{
+		{
+			endpoint: api.query,
+			query:    url.Values{"query": []string{"up + up"}, "time": []string{"100"}},
+		},
+		{
+			endpoint: api.queryRange,
+			query:    url.Values{"query": []string{"up + up"}, "start": []string{"0"}, "end": []string{"100"}, "step": []string{"10"}},
+		},
+	} {
+		do := func(v url.Values) (*queryData, *ApiError) {
+			r, err := http.NewRequest(http.MethodGet, "http://example.com?"+v.Encode(), nil)
+			testutil.Ok(t, err)
+
+			res, _, apiErr := tcase.endpoint(r)
+			if apiErr != nil {
+				return nil, apiErr
+			}
+			return res.(*queryData), nil
+		}
+
+		// Statistics are not requested by default.
+		res, apiErr := do(tcase.query)
+		testutil.Assert(t, apiErr == nil, "unexpected error %v", apiErr)
+		testutil.Assert(t, !store.lastSeriesReq.QueryStats, "expected stats not to be requested")
+		testutil.Assert(t, res.Stats == nil, "expected no stats, got %v", res.Stats)
+
+		// Statistics of all Series calls of the query are summed up.
+		v := url.Values{"stats": []string{"true"}}
+		for k, val := range tcase.query {
+			v[k] = val
+		}
+		res, apiErr = do(v)
+		testutil.Assert(t, apiErr == nil, "unexpected error %v", apiErr)
+		testutil.Assert(t, store.lastSeriesReq.QueryStats, "expected stats to be requested")
+		testutil.Equals(t, &storepb.SeriesStats{BlocksQueried: 4, SeriesTouched: 6}, res.Stats)
+
+		v.Set("stats", "maybe")
+		_, apiErr = do(v)
+		testutil.Assert(t, apiErr != nil && apiErr.Typ == errorBadData, "expected bad data error, got %v", apiErr)
+	}
+}
+
 func TestRespondSuccess(t *testing.T) {
 	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		Respond(w, "test", nil)
@@ -62,6 +62,8 @@ func translateMatchers(ms ...*labels.Matcher) ([]storepb.LabelMatcher, error) {
 type storeSeriesSet struct {
 	series []storepb.Series
 	i      int
+	// Chunks of the current series, which may be split across consecutive elements with the same labels.
+	chunks []storepb.AggrChunk
 }
 
 func newStoreSeriesSet(s []storepb.Series) *storeSeriesSet {
@@ -73,6 +75,18 @@ func (s *storeSeriesSet) Next() bool {
 		return false
 	}
 	s.i++
+	s.chunks = s.series[s.i].Chunks
+
+	// Reassemble the series if its chunks were split across multiple frames.
+	first := s.i
+	for s.i < len(s.series)-1 && storepb.CompareLabels(s.series[s.i].Labels, s.series[s.i+1].Labels) == 0 {
+		if s.i == first {
+			// Do not modify the chunks of the first frame.
+			s.chunks = append([]storepb.AggrChunk(nil), s.chunks...)
+		}
+		s.i++
+		s.chunks = append(s.chunks, s.series[s.i].Chunks...)
+	}
 	return true
 }
 
@@ -81,8 +95,7 @@ func (storeSeriesSet) Err() error {
 }
 
 func (s storeSeriesSet) At() ([]storepb.Label, []storepb.AggrChunk) {
-	ser := s.series[s.i]
-	return ser.Labels, ser.Chunks
+	return s.series[s.i].Labels, s.chunks
 }
 
 // chunkSeries implements storage.Series for a series on storepb types.
@@ -20,15 +20,32 @@ import (
 // It is required to be thread-safe.
 type WarningReporter func(error)
 
+// StatsReporter allows to report statistics of the Series calls to frontend layer.
+// Statistics are requested from the stores only if a reporter is given.
+// It is required to be thread-safe.
+type StatsReporter func(*storepb.SeriesStats)
+
+// LabelQuerier is a storage.Querier which can restrict label names and values to the series matching
+// the given matchers.
+type LabelQuerier interface {
+	storage.Querier
+
+	// LabelValuesFor returns all potential values for a label name of the series matching the matchers.
+	LabelValuesFor(name string, ms ...*labels.Matcher) ([]string, error)
+	// LabelNamesFor returns all the unique label names of the series matching the matchers in sorted order.
+	LabelNamesFor(ms ...*labels.Matcher) ([]string, error)
+}
+
 // QueryableCreator returns implementation of promql.Queryable that fetches data from the proxy store API endpoints.
 // If deduplication is enabled, all data retrieved from it will be deduplicated along the replicaLabel by default.
 // maxResolutionMillis controls downsampling resolution that is allowed (specified in milliseconds).
 // partialResponse controls `partialResponseDisabled` option of StoreAPI and partial response behaviour of proxy.
-type QueryableCreator func(deduplicate bool, maxResolutionMillis int64, partialResponse bool, r WarningReporter) storage.Queryable
+// If s is not nil, statistics of the Series calls are requested and reported to it.
+type QueryableCreator func(deduplicate bool, maxResolutionMillis int64, partialResponse bool, r WarningReporter, s StatsReporter) storage.Queryable
 
 // NewQueryableCreator creates QueryableCreator.
 func NewQueryableCreator(logger log.Logger, proxy storepb.StoreServer, replicaLabel string) QueryableCreator {
-	return func(deduplicate bool, maxResolutionMillis int64, partialResponse bool, r WarningReporter) storage.Queryable {
+	return func(deduplicate bool, maxResolutionMillis int64, partialResponse bool, r WarningReporter, s StatsReporter) storage.Queryable {
 		return &queryable{
 			logger:              logger,
 			replicaLabel:        replicaLabel,
@@ -37,6 +54,7 @@ func NewQueryableCreator(logger log.Logger, proxy storepb.StoreServer, replicaLa
 			maxResolutionMillis: maxResolutionMillis,
 			partialResponse:     partialResponse,
 			warningReporter:     r,
+			statsReporter:       s,
 		}
 	}
 }
@@ -49,11 +67,12 @@ type queryable struct {
 	maxResolutionMillis int64
 	partialResponse     bool
 	warningReporter     WarningReporter
+	statsReporter       StatsReporter
 }
 
 // Querier returns a new storage querier against the underlying proxy store API.
 func (q *queryable) Querier(ctx context.Context, mint, maxt int64) (storage.Querier, error) {
-	return newQuerier(ctx, q.logger, mint, maxt, q.replicaLabel, q.proxy, q.deduplicate, int64(q.maxResolutionMillis), q.partialResponse, q.warningReporter), nil
+	return newQuerier(ctx, q.logger, mint, maxt, q.replicaLabel, q.proxy, q.deduplicate, int64(q.maxResolutionMillis), q.partialResponse, q.warningReporter, q.statsReporter), nil
 }
 
 type querier struct {
@@ -67,6 +86,7 @@ type querier struct {
 	maxResolutionMillis int64
 	partialResponse     bool
 	warningReporter     WarningReporter
+	statsReporter       StatsReporter
 }
 
 // newQuerier creates implementation of storage.Querier that fetches data from the proxy
@@ -81,6 +101,7 @@ func newQuerier(
 	maxResolutionMillis int64,
 	partialResponse bool,
 	warningReporter WarningReporter,
+	statsReporter StatsReporter,
 ) *querier {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -101,6 +122,7 @@ func newQuerier(
 		maxResolutionMillis: maxResolutionMillis,
 		partialResponse:     partialResponse,
 		warningReporter:     warningReporter,
+		statsReporter:       statsReporter,
 	}
 }
 
@@ -115,6 +137,7 @@ type seriesServer struct {
 
 	seriesSet []storepb.Series
 	warnings  []string
+	stats     *storepb.SeriesStats
 }
 
 func (s *seriesServer) Send(r *storepb.SeriesResponse) error {
@@ -123,6 +146,14 @@ func (s *seriesServer) Send(r *storepb.SeriesResponse) error {
 		return nil
 	}
 
+	if st := r.GetStats(); st != nil {
+		if s.stats == nil {
+			s.stats = &storepb.SeriesStats{}
+		}
+		s.stats.Merge(st)
+		return nil
+	}
+
 	if r.GetSeries() == nil {
 		return errors.New("no seriesSet")
 	}
@@ -187,10 +218,19 @@ func (q *querier) Select(params *storage.SelectParams, ms ...*labels.Matcher) (s
 		MaxResolutionWindow:     q.maxResolutionMillis,
 		Aggregates:              queryAggrs,
 		PartialResponseDisabled: !q.partialResponse,
+		// The series API only needs the labels, stores can skip reading the chunks.
+		SkipChunks: params.Func == "series",
+		QueryStats: q.statsReporter != nil,
+		// Series split across multiple frames are reassembled by the storeSeriesSet.
+		AllowSplitSeries: true,
 	}, resp); err != nil {
 		return nil, nil, errors.Wrap(err, "proxy Series()")
 	}
 
+	if q.statsReporter != nil && resp.stats != nil {
+		q.statsReporter(resp.stats)
+	}
+
 	for _, w := range resp.warnings {
 		// NOTE(bwplotka): We could use warnings return arguments here, however need reporter anyway for LabelValues and LabelNames method,
 		// so we choose to be consistent and keep reporter.
@@ -248,10 +288,26 @@ func sortDedupLabels(set []storepb.Series, replicaLabel string) {
 
 // LabelValues returns all potential values for a label name.
 func (q *querier) LabelValues(name string) ([]string, error) {
+	return q.LabelValuesFor(name)
+}
+
+// LabelValuesFor returns all potential values for a label name of the series matching the matchers.
+func (q *querier) LabelValuesFor(name string, ms ...*labels.Matcher) ([]string, error) {
 	span, ctx := tracing.StartSpan(q.ctx, "querier_label_values")
 	defer span.Finish()
 
-	resp, err := q.proxy.LabelValues(ctx, &storepb.LabelValuesRequest{Label: name, PartialResponseDisabled: !q.partialResponse})
+	sms, err := translateMatchers(ms...)
+	if err != nil {
+		return nil, errors.Wrap(err, "convert matchers")
+	}
+
+	resp, err := q.proxy.LabelValues(ctx, &storepb.LabelValuesRequest{
+		Label:                   name,
+		PartialResponseDisabled: !q.partialResponse,
+		Start:                   q.mint,
+		End:                     q.maxt,
+		Matchers:                sms,
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "proxy LabelValues()")
 	}
@@ -265,10 +321,25 @@ func (q *querier) LabelValues(name string) ([]string, error) {
 
 // LabelNames returns all the unique label names present in the block in sorted order.
 func (q *querier) LabelNames() ([]string, error) {
+	return q.LabelNamesFor()
+}
+
+// LabelNamesFor returns all the unique label names of the series matching the matchers in sorted order.
+func (q *querier) LabelNamesFor(ms ...*labels.Matcher) ([]string, error) {
 	span, ctx := tracing.StartSpan(q.ctx, "querier_label_names")
 	defer span.Finish()
 
-	resp, err := q.proxy.LabelNames(ctx, &storepb.LabelNamesRequest{PartialResponseDisabled: !q.partialResponse})
+	sms, err := translateMatchers(ms...)
+	if err != nil {
+		return nil, errors.Wrap(err, "convert matchers")
+	}
+
+	resp, err := q.proxy.LabelNames(ctx, &storepb.LabelNamesRequest{
+		PartialResponseDisabled: !q.partialResponse,
+		Start:                   q.mint,
+		End:                     q.maxt,
+		Matchers:                sms,
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "proxy LabelNames()")
 	}
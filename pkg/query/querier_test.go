@@ -28,7 +28,7 @@ func TestQueryableCreator_MaxResolution(t *testing.T) {
 	queryableCreator := NewQueryableCreator(nil, testProxy, "test")
 
 	oneHourMillis := int64(1*time.Hour) / int64(time.Millisecond)
-	queryable := queryableCreator(false, oneHourMillis, false, func(err error) {})
+	queryable := queryableCreator(false, oneHourMillis, false, func(err error) {}, nil)
 
 	q, err := queryable.Querier(context.Background(), 0, 42)
 	testutil.Ok(t, err)
@@ -55,7 +55,7 @@ func TestQuerier_DownsampledData(t *testing.T) {
 		},
 	}
 
-	q := NewQueryableCreator(nil, testProxy, "")(false, 9999999, false, nil)
+	q := NewQueryableCreator(nil, testProxy, "")(false, 9999999, false, nil, nil)
 
 	engine := promql.NewEngine(
 		promql.EngineOpts{
@@ -172,7 +172,7 @@ func TestQuerier_Series(t *testing.T) {
 
 	// Querier clamps the range to [1,300], which should drop some samples of the result above.
 	// The store API allows endpoints to send more data then initially requested.
-	q := newQuerier(context.Background(), nil, 1, 300, "", testProxy, false, 0, true, nil)
+	q := newQuerier(context.Background(), nil, 1, 300, "", testProxy, false, 0, true, nil, nil)
 	defer func() { testutil.Ok(t, q.Close()) }()
 
 	res, _, err := q.Select(&storage.SelectParams{})
@@ -213,6 +213,172 @@ func TestQuerier_Series(t *testing.T) {
 	testutil.Equals(t, len(expected), i)
 }
 
+func TestQuerier_Series_SplitFrames(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	// Chunks of a single series can be split across consecutive frames.
+	testProxy := &storeServer{
+		resps: []*storepb.SeriesResponse{
+			storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{1, 1}, {2, 2}}),
+			storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{3, 3}, {4, 4}}),
+			storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{5, 5}}),
+			storeSeriesResponse(t, labels.FromStrings("a", "b"), []sample{{1, 1}, {2, 2}}),
+			storeSeriesResponse(t, labels.FromStrings("a", "c"), []sample{{3, 3}}),
+			storeSeriesResponse(t, labels.FromStrings("a", "c"), []sample{{1, 1}, {2, 2}}),
+		},
+	}
+	q := newQuerier(context.Background(), nil, 1, 300, "", testProxy, false, 0, true, nil, nil)
+	defer func() { testutil.Ok(t, q.Close()) }()
+
+	res, _, err := q.Select(&storage.SelectParams{})
+	testutil.Ok(t, err)
+
+	expected := []struct {
+		lset    labels.Labels
+		samples []sample
+	}{
+		{
+			lset:    labels.FromStrings("a", "a"),
+			samples: []sample{{1, 1}, {2, 2}, {3, 3}, {4, 4}, {5, 5}},
+		},
+		{
+			lset:    labels.FromStrings("a", "b"),
+			samples: []sample{{1, 1}, {2, 2}},
+		},
+		{
+			lset:    labels.FromStrings("a", "c"),
+			samples: []sample{{1, 1}, {2, 2}, {3, 3}},
+		},
+	}
+
+	i := 0
+	for res.Next() {
+		testutil.Assert(t, i < len(expected), "more series than expected")
+		testutil.Equals(t, expected[i].lset, res.At().Labels())
+		testutil.Equals(t, expected[i].samples, expandSeries(t, res.At().Iterator()))
+		i++
+	}
+	testutil.Ok(t, res.Err())
+	testutil.Equals(t, len(expected), i)
+
+	// Frames are not modified by the reassembly.
+	testutil.Equals(t, 1, len(testProxy.resps[0].GetSeries().Chunks))
+	testutil.Assert(t, testProxy.lastSeriesReq.AllowSplitSeries, "expected splitting to be allowed")
+}
+
+func TestQuerier_Series_SkipChunks(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	testProxy := &storeServer{
+		resps: []*storepb.SeriesResponse{
+			storepb.NewSeriesResponse(&storepb.Series{Labels: []storepb.Label{{Name: "a", Value: "a"}}}),
+			storepb.NewSeriesResponse(&storepb.Series{Labels: []storepb.Label{{Name: "a", Value: "b"}}}),
+		},
+	}
+	q := newQuerier(context.Background(), nil, 1, 300, "", testProxy, false, 0, true, nil, nil)
+	defer func() { testutil.Ok(t, q.Close()) }()
+
+	// Chunks are requested for regular selects.
+	_, _, err := q.Select(&storage.SelectParams{Func: "rate"})
+	testutil.Ok(t, err)
+	testutil.Assert(t, !testProxy.lastSeriesReq.SkipChunks, "expected chunks to be requested")
+
+	// Only the labels are needed for the series API.
+	res, _, err := q.Select(&storage.SelectParams{Func: "series"})
+	testutil.Ok(t, err)
+	testutil.Assert(t, testProxy.lastSeriesReq.SkipChunks, "expected chunks to be skipped")
+
+	var lsets []labels.Labels
+	for res.Next() {
+		lsets = append(lsets, res.At().Labels())
+		testutil.Equals(t, 0, len(expandSeries(t, res.At().Iterator())))
+	}
+	testutil.Ok(t, res.Err())
+	testutil.Equals(t, []labels.Labels{labels.FromStrings("a", "a"), labels.FromStrings("a", "b")}, lsets)
+}
+
+func TestQuerier_Series_Stats(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	testProxy := &storeServer{
+		resps: []*storepb.SeriesResponse{
+			storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{1, 1}, {2, 2}}),
+			storepb.NewStatsSeriesResponse(&storepb.SeriesStats{BlocksQueried: 1, SeriesTouched: 2}),
+			storeSeriesResponse(t, labels.FromStrings("a", "b"), []sample{{1, 1}}),
+			storepb.NewStatsSeriesResponse(&storepb.SeriesStats{BlocksQueried: 2, SeriesTouched: 3, ChunksFetched: 4}),
+		},
+	}
+
+	// Without a reporter, statistics are not requested.
+	q := newQuerier(context.Background(), nil, 1, 300, "", testProxy, false, 0, true, nil, nil)
+	defer func() { testutil.Ok(t, q.Close()) }()
+
+	_, _, err := q.Select(&storage.SelectParams{})
+	testutil.Ok(t, err)
+	testutil.Assert(t, !testProxy.lastSeriesReq.QueryStats, "expected stats not to be requested")
+
+	var reported []*storepb.SeriesStats
+	q = newQuerier(context.Background(), nil, 1, 300, "", testProxy, false, 0, true, nil, func(s *storepb.SeriesStats) {
+		reported = append(reported, s)
+	})
+	defer func() { testutil.Ok(t, q.Close()) }()
+
+	res, _, err := q.Select(&storage.SelectParams{})
+	testutil.Ok(t, err)
+	testutil.Assert(t, testProxy.lastSeriesReq.QueryStats, "expected stats to be requested")
+
+	// Stats frames are summed up and reported once per Select, they are not part of the result.
+	testutil.Equals(t, []*storepb.SeriesStats{{BlocksQueried: 3, SeriesTouched: 5, ChunksFetched: 4}}, reported)
+
+	var lsets []labels.Labels
+	for res.Next() {
+		lsets = append(lsets, res.At().Labels())
+	}
+	testutil.Ok(t, res.Err())
+	testutil.Equals(t, []labels.Labels{labels.FromStrings("a", "a"), labels.FromStrings("a", "b")}, lsets)
+}
+
+func TestQuerier_LabelNamesAndValues(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	testProxy := &storeServer{}
+	q := newQuerier(context.Background(), nil, 1, 300, "", testProxy, false, 0, true, nil, nil)
+	defer func() { testutil.Ok(t, q.Close()) }()
+
+	// The time range of the querier is passed to the store API.
+	vals, err := q.LabelValues("a")
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"1", "2"}, vals)
+	testutil.Equals(t, &storepb.LabelValuesRequest{Label: "a", Start: 1, End: 300, Matchers: []storepb.LabelMatcher{}}, testProxy.lastLabelValuesReq)
+
+	names, err := q.LabelNames()
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"a", "b"}, names)
+	testutil.Equals(t, &storepb.LabelNamesRequest{Start: 1, End: 300, Matchers: []storepb.LabelMatcher{}}, testProxy.lastLabelNamesReq)
+
+	var lq LabelQuerier = q
+	m, err := labels.NewMatcher(labels.MatchRegexp, "b", "1|2")
+	testutil.Ok(t, err)
+	_, err = lq.LabelValuesFor("a", m)
+	testutil.Ok(t, err)
+	testutil.Equals(t, &storepb.LabelValuesRequest{
+		Label:    "a",
+		Start:    1,
+		End:      300,
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "b", Value: "1|2"}},
+	}, testProxy.lastLabelValuesReq)
+
+	m, err = labels.NewMatcher(labels.MatchEqual, "b", "1")
+	testutil.Ok(t, err)
+	_, err = lq.LabelNamesFor(m)
+	testutil.Ok(t, err)
+	testutil.Equals(t, &storepb.LabelNamesRequest{
+		Start:    1,
+		End:      300,
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "b", Value: "1"}},
+	}, testProxy.lastLabelNamesReq)
+}
+
 func TestSortReplicaLabel(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
@@ -509,9 +675,24 @@ type storeServer struct {
 	storepb.StoreServer
 
 	resps []*storepb.SeriesResponse
+
+	lastSeriesReq      *storepb.SeriesRequest
+	lastLabelValuesReq *storepb.LabelValuesRequest
+	lastLabelNamesReq  *storepb.LabelNamesRequest
+}
+
+func (s *storeServer) LabelValues(_ context.Context, r *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
+	s.lastLabelValuesReq = r
+	return &storepb.LabelValuesResponse{Values: []string{"1", "2"}}, nil
+}
+
+func (s *storeServer) LabelNames(_ context.Context, r *storepb.LabelNamesRequest) (*storepb.LabelNamesResponse, error) {
+	s.lastLabelNamesReq = r
+	return &storepb.LabelNamesResponse{Names: []string{"a", "b"}}, nil
 }
 
 func (s *storeServer) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesServer) error {
+	s.lastSeriesReq = r
 	for _, resp := range s.resps {
 		err := srv.Send(resp)
 		if err != nil {
@@ -250,9 +250,10 @@ func (s *StoreSet) Update(ctx context.Context) {
 		// it indicates misconfiguration.
 		//
 		// Note: No external labels means strictly store gateway or ruler and it is fine to have access to multiple instances of them.
-		// Any other component will error out if it will be configured with empty external labels.
+		// Any other component will error out if it will be configured with empty external labels. Store gateways advertise the
+		// external labels of the blocks they serve, which are shared by store gateways serving different blocks of the same sources.
 		externalLabels := externalLabelsFromStore(store)
-		if len(store.LabelSets()) > 0 && externalLabelOccurrencesInStores[externalLabels] != 1 {
+		if store.storeType != component.Store && len(store.LabelSets()) > 0 && externalLabelOccurrencesInStores[externalLabels] != 1 {
 			store.close()
 			s.updateStoreStatus(store, errors.New(droppingStoreMessage))
 			level.Warn(s.logger).Log("msg", droppingStoreMessage, "address", addr, "extLset", externalLabels, "duplicates", externalLabelOccurrencesInStores[externalLabels])
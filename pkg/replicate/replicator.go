@@ -0,0 +1,275 @@
+// Package replicate implements copying of blocks between object storage buckets.
+package replicate
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/model"
+	"github.com/thanos-io/thanos/pkg/objstore"
+)
+
+// BlockFilter selects blocks to be replicated. Empty fields do not filter anything.
+type BlockFilter struct {
+	// Matchers are matched against external labels of the block. All of them have to match.
+	Matchers []*labels.Matcher
+	// Resolutions are allowed downsampling resolutions in milliseconds.
+	Resolutions []int64
+	// CompactionLevels are allowed compaction levels.
+	CompactionLevels []int
+	// MinTime and MaxTime select blocks overlapping the time range. Durations are relative to the
+	// time of each replication run. Unset values do not limit the time range.
+	MinTime, MaxTime model.TimeOrDurationValue
+}
+
+// timeRange returns the time range selected by the filter in milliseconds.
+func (f BlockFilter) timeRange() (mint, maxt int64) {
+	mint, maxt = math.MinInt64, math.MaxInt64
+	if v := f.MinTime; v.Time != nil || v.Dur != nil {
+		mint = v.PrometheusTimestamp()
+	}
+	if v := f.MaxTime; v.Time != nil || v.Dur != nil {
+		maxt = v.PrometheusTimestamp()
+	}
+	return mint, maxt
+}
+
+// Matches returns true if the block with the given meta has to be replicated. Relative times of the
+// filter are resolved against the current time.
+func (f BlockFilter) Matches(meta *metadata.Meta) bool {
+	mint, maxt := f.timeRange()
+	return f.matches(meta, mint, maxt)
+}
+
+func (f BlockFilter) matches(meta *metadata.Meta, mint, maxt int64) bool {
+	for _, m := range f.Matchers {
+		if !m.Matches(meta.Thanos.Labels[m.Name]) {
+			return false
+		}
+	}
+
+	if len(f.Resolutions) > 0 {
+		found := false
+		for _, r := range f.Resolutions {
+			if r == meta.Thanos.Downsample.Resolution {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.CompactionLevels) > 0 {
+		found := false
+		for _, l := range f.CompactionLevels {
+			if l == meta.Compaction.Level {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return meta.MaxTime > mint && meta.MinTime < maxt
+}
+
+type metrics struct {
+	runs                 *prometheus.CounterVec
+	blocksReplicated     prometheus.Counter
+	blocksAlreadyPresent prometheus.Counter
+	objectsReplicated    prometheus.Counter
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	var m metrics
+
+	m.runs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_replicate_replication_runs_total",
+		Help: "The number of replication runs split by success and error.",
+	}, []string{"result"})
+	m.blocksReplicated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_replicate_blocks_replicated_total",
+		Help: "Total number of blocks replicated.",
+	})
+	m.blocksAlreadyPresent = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_replicate_blocks_already_replicated_total",
+		Help: "Total number of blocks skipped because they were already present in the destination bucket.",
+	})
+	m.objectsReplicated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_replicate_objects_replicated_total",
+		Help: "Total number of objects replicated.",
+	})
+
+	if reg != nil {
+		reg.MustRegister(m.runs, m.blocksReplicated, m.blocksAlreadyPresent, m.objectsReplicated)
+	}
+	return &m
+}
+
+// Replicator copies blocks matching a filter from one bucket to another.
+type Replicator struct {
+	logger  log.Logger
+	from    objstore.BucketReader
+	to      objstore.Bucket
+	filter  BlockFilter
+	tmpDir  string
+	metrics *metrics
+}
+
+// NewReplicator returns a new Replicator. Objects are staged in temporary files in tmpDir while they are copied.
+func NewReplicator(logger log.Logger, reg prometheus.Registerer, from objstore.BucketReader, to objstore.Bucket, filter BlockFilter, tmpDir string) *Replicator {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	return &Replicator{
+		logger:  logger,
+		from:    from,
+		to:      to,
+		filter:  filter,
+		tmpDir:  tmpDir,
+		metrics: newMetrics(reg),
+	}
+}
+
+// Replicate copies all matching blocks that are not present in the destination bucket yet.
+// Blocks are copied one by one, with meta.json uploaded last, so partially copied blocks are never
+// considered complete.
+func (r *Replicator) Replicate(ctx context.Context) error {
+	err := r.replicate(ctx)
+	if err != nil {
+		r.metrics.runs.WithLabelValues("error").Inc()
+		return err
+	}
+	r.metrics.runs.WithLabelValues("success").Inc()
+	return nil
+}
+
+func (r *Replicator) replicate(ctx context.Context) error {
+	// Resolve relative times once per run, so that the time range moves along with repeated runs
+	// but is the same for all blocks of a run.
+	mint, maxt := r.filter.timeRange()
+
+	var ids []ulid.ULID
+	if err := r.from.Iter(ctx, "", func(name string) error {
+		if id, ok := block.IsBlockDir(name); ok {
+			ids = append(ids, id)
+		}
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "iterate source bucket")
+	}
+
+	for _, id := range ids {
+		if err := r.replicateBlock(ctx, id, mint, maxt); err != nil {
+			return errors.Wrapf(err, "replicate block %s", id)
+		}
+	}
+	return nil
+}
+
+func (r *Replicator) replicateBlock(ctx context.Context, id ulid.ULID, mint, maxt int64) error {
+	metaFile := path.Join(id.String(), block.MetaFilename)
+
+	meta, err := block.DownloadMeta(ctx, r.logger, r.from, id)
+	if err != nil {
+		if r.from.IsObjNotFoundErr(errors.Cause(err)) {
+			// Block is still being uploaded or was partially deleted.
+			level.Debug(r.logger).Log("msg", "skipping block without meta.json", "block", id)
+			return nil
+		}
+		return errors.Wrap(err, "download meta")
+	}
+	if !r.filter.matches(&meta, mint, maxt) {
+		return nil
+	}
+
+	exists, err := r.to.Exists(ctx, metaFile)
+	if err != nil {
+		return errors.Wrap(err, "check meta.json in destination")
+	}
+	if exists {
+		r.metrics.blocksAlreadyPresent.Inc()
+		return nil
+	}
+
+	level.Info(r.logger).Log("msg", "replicating block", "block", id)
+
+	tmp, err := ioutil.TempDir(r.tmpDir, "replicate-"+id.String())
+	if err != nil {
+		return errors.Wrap(err, "create temporary dir")
+	}
+	defer func() {
+		if err := os.RemoveAll(tmp); err != nil {
+			level.Warn(r.logger).Log("msg", "failed to remove temporary dir", "dir", tmp, "err", err)
+		}
+	}()
+
+	if err := r.copyDir(ctx, id.String(), tmp, metaFile); err != nil {
+		return r.cleanUp(id, err)
+	}
+	// Meta.json always needs to be uploaded as a last item, like block.Upload does.
+	if err := r.copyObject(ctx, metaFile, tmp); err != nil {
+		return r.cleanUp(id, err)
+	}
+
+	r.metrics.blocksReplicated.Inc()
+	level.Info(r.logger).Log("msg", "replicated block", "block", id)
+	return nil
+}
+
+// copyDir copies all objects in the given directory, recursively, except the skipped one.
+func (r *Replicator) copyDir(ctx context.Context, dir, tmp, skip string) error {
+	return r.from.Iter(ctx, dir, func(name string) error {
+		if strings.HasSuffix(name, objstore.DirDelim) {
+			return r.copyDir(ctx, name, tmp, skip)
+		}
+		if name == skip {
+			return nil
+		}
+		return r.copyObject(ctx, name, tmp)
+	})
+}
+
+// copyObject copies a single object through a temporary file, so the upload knows its size and can be retried.
+func (r *Replicator) copyObject(ctx context.Context, name, tmp string) error {
+	file := filepath.Join(tmp, "object")
+	defer func() {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			level.Warn(r.logger).Log("msg", "failed to remove temporary file", "file", file, "err", err)
+		}
+	}()
+
+	if err := objstore.DownloadFile(ctx, r.logger, r.from, name, file); err != nil {
+		return errors.Wrapf(err, "download %s", name)
+	}
+	if err := objstore.UploadFile(ctx, r.logger, r.to, file, name); err != nil {
+		return errors.Wrapf(err, "upload %s", name)
+	}
+	r.metrics.objectsReplicated.Inc()
+	return nil
+}
+
+func (r *Replicator) cleanUp(id ulid.ULID, err error) error {
+	// Cleanup the partial block with an uncancelable context.
+	if cleanErr := block.Delete(context.Background(), r.to, id); cleanErr != nil {
+		return errors.Wrapf(err, "failed to clean block after replication issue. Partial block in destination bucket. Err: %s", cleanErr.Error())
+	}
+	return err
+}
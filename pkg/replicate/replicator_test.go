@@ -0,0 +1,183 @@
+package replicate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	prommodel "github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/tsdb"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/model"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func uploadTestBlock(t *testing.T, bkt objstore.Bucket, id ulid.ULID, lset map[string]string, resolution, minTime, maxTime int64, withMeta bool) {
+	ctx := context.Background()
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(id.String(), block.IndexFilename), strings.NewReader("index of "+id.String())))
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(id.String(), block.ChunksDirname, "000001"), strings.NewReader("chunks of "+id.String())))
+
+	if !withMeta {
+		return
+	}
+	meta := metadata.Meta{
+		BlockMeta: tsdb.BlockMeta{
+			ULID:       id,
+			MinTime:    minTime,
+			MaxTime:    maxTime,
+			Version:    1,
+			Compaction: tsdb.BlockMetaCompaction{Level: 1},
+		},
+		Thanos: metadata.Thanos{
+			Labels:     lset,
+			Downsample: metadata.ThanosDownsample{Resolution: resolution},
+			Source:     metadata.TestSource,
+		},
+	}
+	b, err := json.Marshal(&meta)
+	testutil.Ok(t, err)
+	testutil.Ok(t, bkt.Upload(ctx, path.Join(id.String(), block.MetaFilename), bytes.NewReader(b)))
+}
+
+func TestReplicator_Replicate(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "replicate-test")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(tmpDir)) }()
+
+	var (
+		ctx  = context.Background()
+		from = inmem.NewBucket()
+		to   = inmem.NewBucket()
+
+		matching     = ulid.MustNew(1, nil)
+		otherCluster = ulid.MustNew(2, nil)
+		downsampled  = ulid.MustNew(3, nil)
+		tooOld       = ulid.MustNew(4, nil)
+		partial      = ulid.MustNew(5, nil)
+	)
+	uploadTestBlock(t, from, matching, map[string]string{"cluster": "eu1"}, 0, 1000, 2000, true)
+	uploadTestBlock(t, from, otherCluster, map[string]string{"cluster": "us1"}, 0, 1000, 2000, true)
+	uploadTestBlock(t, from, downsampled, map[string]string{"cluster": "eu1"}, 300000, 1000, 2000, true)
+	uploadTestBlock(t, from, tooOld, map[string]string{"cluster": "eu1"}, 0, 0, 500, true)
+	uploadTestBlock(t, from, partial, map[string]string{"cluster": "eu1"}, 0, 1000, 2000, false)
+
+	m, err := labels.NewMatcher(labels.MatchRegexp, "cluster", "eu.*")
+	testutil.Ok(t, err)
+
+	r := NewReplicator(log.NewNopLogger(), prometheus.NewRegistry(), from, to, BlockFilter{
+		Matchers:    []*labels.Matcher{m},
+		Resolutions: []int64{0},
+		MinTime:     timeValue(800),
+	}, tmpDir)
+
+	testutil.Ok(t, r.Replicate(ctx))
+
+	expected := map[string][]byte{}
+	for name, b := range from.Objects() {
+		if strings.HasPrefix(name, matching.String()) {
+			expected[name] = b
+		}
+	}
+	testutil.Equals(t, 3, len(expected))
+	testutil.Equals(t, expected, to.Objects())
+	testutil.Equals(t, float64(1), promtest.ToFloat64(r.metrics.blocksReplicated))
+	testutil.Equals(t, float64(3), promtest.ToFloat64(r.metrics.objectsReplicated))
+
+	// Blocks present in the destination are skipped.
+	testutil.Ok(t, r.Replicate(ctx))
+	testutil.Equals(t, float64(1), promtest.ToFloat64(r.metrics.blocksReplicated))
+	testutil.Equals(t, float64(1), promtest.ToFloat64(r.metrics.blocksAlreadyPresent))
+	testutil.Equals(t, float64(2), promtest.ToFloat64(r.metrics.runs.WithLabelValues("success")))
+}
+
+func timeValue(ms int64) model.TimeOrDurationValue {
+	t := timestamp.Time(ms)
+	return model.TimeOrDurationValue{Time: &t}
+}
+
+func durationValue(d time.Duration) model.TimeOrDurationValue {
+	dur := prommodel.Duration(d)
+	return model.TimeOrDurationValue{Dur: &dur}
+}
+
+func TestReplicator_Replicate_RelativeTimeRange(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "replicate-test")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(tmpDir)) }()
+
+	var (
+		ctx  = context.Background()
+		from = inmem.NewBucket()
+		to   = inmem.NewBucket()
+		id   = ulid.MustNew(1, nil)
+	)
+	// The block starts shortly after the end of the time range at the first run. The time range
+	// is relative to the time of each run, so it moves to overlap the block by the second run.
+	mint := timestamp.FromTime(time.Now().Add(-time.Hour + 500*time.Millisecond))
+	uploadTestBlock(t, from, id, map[string]string{"cluster": "eu1"}, 0, mint, mint+1000, true)
+
+	r := NewReplicator(log.NewNopLogger(), prometheus.NewRegistry(), from, to, BlockFilter{
+		MaxTime: durationValue(-time.Hour),
+	}, tmpDir)
+
+	testutil.Ok(t, r.Replicate(ctx))
+	testutil.Equals(t, 0, len(to.Objects()))
+
+	time.Sleep(time.Second)
+
+	testutil.Ok(t, r.Replicate(ctx))
+	testutil.Equals(t, from.Objects(), to.Objects())
+	testutil.Equals(t, float64(1), promtest.ToFloat64(r.metrics.blocksReplicated))
+}
+
+func mustNewMatcher(t *testing.T, mt labels.MatchType, name, value string) *labels.Matcher {
+	m, err := labels.NewMatcher(mt, name, value)
+	testutil.Ok(t, err)
+	return m
+}
+
+func TestBlockFilter_Matches(t *testing.T) {
+	meta := &metadata.Meta{
+		BlockMeta: tsdb.BlockMeta{MinTime: 100, MaxTime: 200, Compaction: tsdb.BlockMetaCompaction{Level: 2}},
+		Thanos: metadata.Thanos{
+			Labels:     map[string]string{"cluster": "eu1"},
+			Downsample: metadata.ThanosDownsample{Resolution: 300000},
+		},
+	}
+
+	for _, tcase := range []struct {
+		filter   BlockFilter
+		expected bool
+	}{
+		{filter: BlockFilter{}, expected: true},
+		{filter: BlockFilter{Matchers: []*labels.Matcher{mustNewMatcher(t, labels.MatchEqual, "cluster", "eu1")}}, expected: true},
+		{filter: BlockFilter{Matchers: []*labels.Matcher{mustNewMatcher(t, labels.MatchNotEqual, "cluster", "eu1")}}, expected: false},
+		{filter: BlockFilter{Matchers: []*labels.Matcher{mustNewMatcher(t, labels.MatchEqual, "replica", "")}}, expected: true},
+		{filter: BlockFilter{Resolutions: []int64{0, 300000}}, expected: true},
+		{filter: BlockFilter{Resolutions: []int64{0}}, expected: false},
+		{filter: BlockFilter{CompactionLevels: []int{1}}, expected: false},
+		{filter: BlockFilter{CompactionLevels: []int{2, 3}}, expected: true},
+		{filter: BlockFilter{MinTime: timeValue(150), MaxTime: timeValue(250)}, expected: true},
+		{filter: BlockFilter{MinTime: timeValue(200)}, expected: false},
+		{filter: BlockFilter{MaxTime: timeValue(100)}, expected: false},
+		{filter: BlockFilter{MinTime: durationValue(-time.Hour)}, expected: false},
+		{filter: BlockFilter{MaxTime: durationValue(-time.Hour)}, expected: true},
+	} {
+		testutil.Equals(t, tcase.expected, tcase.filter.Matches(meta), "filter %+v", tcase.filter)
+	}
+}
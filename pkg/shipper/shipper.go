@@ -82,10 +82,13 @@ type Shipper struct {
 	labels          func() labels.Labels
 	source          metadata.SourceType
 	uploadCompacted bool
+	resumeUploads   bool
 }
 
 // New creates a new shipper that detects new TSDB blocks in dir and uploads them
 // to remote if necessary. It attaches the Thanos metadata section in each meta JSON file.
+// If resumeUploads is true, files of a block left in the bucket by a failed upload are kept and not uploaded
+// again by the next attempt. Otherwise partial uploads are removed from the bucket.
 func New(
 	logger log.Logger,
 	r prometheus.Registerer,
@@ -93,6 +96,7 @@ func New(
 	bucket objstore.Bucket,
 	lbls func() labels.Labels,
 	source metadata.SourceType,
+	resumeUploads bool,
 ) *Shipper {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -102,12 +106,13 @@ func New(
 	}
 
 	return &Shipper{
-		logger:  logger,
-		dir:     dir,
-		bucket:  bucket,
-		labels:  lbls,
-		metrics: newMetrics(r, false),
-		source:  source,
+		logger:        logger,
+		dir:           dir,
+		bucket:        bucket,
+		labels:        lbls,
+		metrics:       newMetrics(r, false),
+		source:        source,
+		resumeUploads: resumeUploads,
 	}
 }
 
@@ -121,6 +126,7 @@ func NewWithCompacted(
 	bucket objstore.Bucket,
 	lbls func() labels.Labels,
 	source metadata.SourceType,
+	resumeUploads bool,
 ) *Shipper {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -137,6 +143,7 @@ func NewWithCompacted(
 		metrics:         newMetrics(r, true),
 		source:          source,
 		uploadCompacted: true,
+		resumeUploads:   resumeUploads,
 	}
 }
 
@@ -377,6 +384,10 @@ func (s *Shipper) upload(ctx context.Context, meta *metadata.Meta) error {
 	if err := metadata.Write(s.logger, updir, meta); err != nil {
 		return errors.Wrap(err, "write meta file")
 	}
+	if s.resumeUploads {
+		// Files left behind by a previously failed upload of the same block are not uploaded again.
+		return block.Upload(ctx, s.logger, s.bucket, updir, objstore.WithSkipExisting())
+	}
 	return block.Upload(ctx, s.logger, s.bucket, updir)
 }
 
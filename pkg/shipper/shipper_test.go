@@ -1,16 +1,23 @@
 package shipper
 
 import (
+	"context"
 	"io/ioutil"
 	"math"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/go-kit/kit/log"
 	"github.com/oklog/ulid"
 	"github.com/prometheus/tsdb"
+	"github.com/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
 
@@ -21,7 +28,7 @@ func TestShipperTimestamps(t *testing.T) {
 		testutil.Ok(t, os.RemoveAll(dir))
 	}()
 
-	s := New(nil, nil, dir, nil, nil, metadata.TestSource)
+	s := New(nil, nil, dir, nil, nil, metadata.TestSource, false)
 
 	// Missing thanos meta file.
 	_, _, err = s.Timestamps()
@@ -77,3 +84,151 @@ func TestShipperTimestamps(t *testing.T) {
 	testutil.Equals(t, int64(1000), mint)
 	testutil.Equals(t, int64(2000), maxt)
 }
+
+func TestShipper_Sync_FaultyBucket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shipper-test")
+	testutil.Ok(t, err)
+	defer func() {
+		testutil.Ok(t, os.RemoveAll(dir))
+	}()
+
+	ctx := context.Background()
+	inner := inmem.NewBucket()
+	bkt := objstore.BucketWithFaults(inner, objstore.Faults{}, 0)
+
+	extLset := labels.FromStrings("prometheus", "prom-1")
+	s := New(log.NewNopLogger(), nil, dir, bkt, func() labels.Labels { return extLset }, metadata.TestSource, false)
+
+	var ids []ulid.ULID
+	for i := 0; i < 3; i++ {
+		id := ulid.MustNew(uint64(i), nil)
+		bdir := filepath.Join(dir, id.String())
+		testutil.Ok(t, os.MkdirAll(filepath.Join(bdir, block.ChunksDirname), os.ModePerm))
+		testutil.Ok(t, ioutil.WriteFile(filepath.Join(bdir, block.IndexFilename), []byte("indexcontents"), os.ModePerm))
+		testutil.Ok(t, ioutil.WriteFile(filepath.Join(bdir, block.ChunksDirname, "0001"), []byte("chunkcontents"), os.ModePerm))
+		testutil.Ok(t, metadata.Write(log.NewNopLogger(), bdir, &metadata.Meta{
+			BlockMeta: tsdb.BlockMeta{
+				ULID:       id,
+				MinTime:    int64(i) * 1000,
+				MaxTime:    int64(i+1) * 1000,
+				Version:    1,
+				Stats:      tsdb.BlockStats{NumSamples: 1},
+				Compaction: tsdb.BlockMetaCompaction{Level: 1},
+			},
+		}))
+		ids = append(ids, id)
+	}
+
+	// blockObjects returns objects of the blocks in the bucket, ignoring debug metas.
+	blockObjects := func() (names []string) {
+		for n := range inner.Objects() {
+			if !strings.HasPrefix(n, block.DebugMetas) {
+				names = append(names, n)
+			}
+		}
+		return names
+	}
+
+	for _, tcase := range []struct {
+		name   string
+		faults objstore.Faults
+	}{
+		{
+			name: "meta.json upload fails",
+			faults: objstore.Faults{
+				Match:            func(op, name string) bool { return op == objstore.OpUpload && path.Base(name) == block.MetaFilename },
+				ErrorProbability: 1,
+			},
+		},
+		{
+			name: "chunks are uploaded partially",
+			faults: objstore.Faults{
+				Match:                    func(op, name string) bool { return strings.Contains(name, block.ChunksDirname) },
+				PartialUploadProbability: 1,
+			},
+		},
+		{
+			name:   "exists check fails",
+			faults: objstore.Faults{Match: func(op, _ string) bool { return op == objstore.OpExists }, ErrorProbability: 1},
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			bkt.SetFaults(tcase.faults)
+
+			uploaded, err := s.Sync(ctx)
+			testutil.NotOk(t, err)
+			testutil.Equals(t, 0, uploaded)
+
+			// Partially uploaded blocks are cleaned up and not marked as uploaded.
+			testutil.Equals(t, 0, len(blockObjects()))
+			shipMeta, err := ReadMetaFile(dir)
+			testutil.Ok(t, err)
+			testutil.Equals(t, 0, len(shipMeta.Uploaded))
+		})
+	}
+
+	// Once the bucket recovers, all blocks are uploaded.
+	bkt.SetFaults(objstore.Faults{})
+	uploaded, err := s.Sync(ctx)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 3, uploaded)
+	testutil.Equals(t, 9, len(blockObjects()))
+
+	shipMeta, err := ReadMetaFile(dir)
+	testutil.Ok(t, err)
+	testutil.Equals(t, ids, shipMeta.Uploaded)
+}
+
+func TestShipper_Sync_ResumeUploads(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shipper-test")
+	testutil.Ok(t, err)
+	defer func() {
+		testutil.Ok(t, os.RemoveAll(dir))
+	}()
+
+	ctx := context.Background()
+	inner := inmem.NewBucket()
+	// The index is uploaded after the chunks, so all chunks are in the bucket once its upload fails.
+	bkt := objstore.BucketWithFaults(inner, objstore.Faults{
+		Match:            func(op, name string) bool { return op == objstore.OpUpload && path.Base(name) == block.IndexFilename },
+		ErrorProbability: 1,
+	}, 0)
+
+	extLset := labels.FromStrings("prometheus", "prom-1")
+	s := New(log.NewNopLogger(), nil, dir, bkt, func() labels.Labels { return extLset }, metadata.TestSource, true)
+
+	id := ulid.MustNew(1, nil)
+	bdir := filepath.Join(dir, id.String())
+	testutil.Ok(t, os.MkdirAll(filepath.Join(bdir, block.ChunksDirname), os.ModePerm))
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(bdir, block.IndexFilename), []byte("indexcontents"), os.ModePerm))
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(bdir, block.ChunksDirname, "0001"), []byte("chunkcontents"), os.ModePerm))
+	testutil.Ok(t, metadata.Write(log.NewNopLogger(), bdir, &metadata.Meta{
+		BlockMeta: tsdb.BlockMeta{
+			ULID:       id,
+			MinTime:    1000,
+			MaxTime:    2000,
+			Version:    1,
+			Stats:      tsdb.BlockStats{NumSamples: 1},
+			Compaction: tsdb.BlockMetaCompaction{Level: 1},
+		},
+	}))
+
+	uploaded, err := s.Sync(ctx)
+	testutil.NotOk(t, err)
+	testutil.Equals(t, 0, uploaded)
+
+	// The uploaded chunks are kept in the bucket, but the block is not complete.
+	chunksName := path.Join(id.String(), block.ChunksDirname, "0001")
+	testutil.Equals(t, []byte("chunkcontents"), inner.Objects()[chunksName])
+	_, ok := inner.Objects()[path.Join(id.String(), block.MetaFilename)]
+	testutil.Assert(t, !ok, "unexpected complete block")
+
+	// The next sync uploads only the missing files.
+	bkt.SetFaults(objstore.Faults{Match: func(op, name string) bool { return op == objstore.OpUpload && name == chunksName }, ErrorProbability: 1})
+	uploaded, err = s.Sync(ctx)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 1, uploaded)
+	testutil.Equals(t, []byte("indexcontents"), inner.Objects()[path.Join(id.String(), block.IndexFilename)])
+	_, ok = inner.Objects()[path.Join(id.String(), block.MetaFilename)]
+	testutil.Assert(t, ok, "expected complete block")
+}
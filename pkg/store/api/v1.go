@@ -0,0 +1,150 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/NYTimes/gziphandler"
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/route"
+	"github.com/prometheus/tsdb/labels"
+	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
+	qapi "github.com/thanos-io/thanos/pkg/query/api"
+	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/tracing"
+)
+
+// BlocksManager lists and manages the blocks served by a store.
+type BlocksManager interface {
+	Blocks() []store.BlockInfo
+	DropBlock(id ulid.ULID) error
+	ResyncBlock(ctx context.Context, id ulid.ULID) error
+}
+
+// API is the admin API of the store gateway.
+type API struct {
+	logger      log.Logger
+	blocks      BlocksManager
+	enableAdmin bool
+}
+
+// NewAPI returns the store gateway API. The endpoints modifying the served blocks are only registered
+// if enableAdmin is true, as they are not authenticated.
+func NewAPI(logger log.Logger, blocks BlocksManager, enableAdmin bool) *API {
+	return &API{
+		logger:      logger,
+		blocks:      blocks,
+		enableAdmin: enableAdmin,
+	}
+}
+
+func (api *API) Register(r *route.Router, tracer opentracing.Tracer, logger log.Logger, ins extpromhttp.InstrumentationMiddleware) {
+	instr := func(name string, cors bool, f qapi.ApiFunc) http.HandlerFunc {
+		hf := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cors {
+				qapi.SetCORS(w)
+			}
+			if data, warnings, err := f(r); err != nil {
+				qapi.RespondError(w, err, data)
+			} else if data != nil {
+				qapi.Respond(w, data, warnings)
+			} else {
+				w.WriteHeader(http.StatusNoContent)
+			}
+		})
+		return ins.NewHandler(name, tracing.HTTPMiddleware(tracer, name, logger, gziphandler.GzipHandler(hf)))
+	}
+
+	r.Get("/blocks", instr("blocks", true, api.listBlocks))
+
+	if !api.enableAdmin {
+		return
+	}
+	// Admin endpoints must not be callable from other origins.
+	r.Post("/blocks/:id/drop", instr("drop_block", false, api.dropBlock))
+	r.Post("/blocks/:id/resync", instr("resync_block", false, api.resyncBlock))
+}
+
+type BlockDiscovery struct {
+	Blocks []*Block `json:"blocks"`
+}
+
+type Block struct {
+	ULID                 string        `json:"ulid"`
+	Labels               labels.Labels `json:"labels"`
+	Resolution           int64         `json:"resolution"`
+	MinTime              int64         `json:"minTime"`
+	MaxTime              int64         `json:"maxTime"`
+	Loaded               bool          `json:"loaded"`
+	IndexHeaderSizeBytes int64         `json:"indexHeaderSizeBytes"`
+	LoadedAt             *time.Time    `json:"loadedAt,omitempty"`
+	LastUsed             *time.Time    `json:"lastUsed,omitempty"`
+	Queries              uint64        `json:"queries"`
+}
+
+func (api *API) listBlocks(r *http.Request) (interface{}, []error, *qapi.ApiError) {
+	res := &BlockDiscovery{Blocks: []*Block{}}
+	for _, b := range api.blocks.Blocks() {
+		ab := &Block{
+			ULID:                 b.ULID.String(),
+			Labels:               b.Labels,
+			Resolution:           b.Resolution,
+			MinTime:              b.MinTime,
+			MaxTime:              b.MaxTime,
+			Loaded:               b.Loaded,
+			IndexHeaderSizeBytes: b.IndexHeaderSize,
+			Queries:              b.Queries,
+		}
+		if b.Loaded {
+			loadedAt := b.LoadedAt
+			ab.LoadedAt = &loadedAt
+		}
+		if !b.LastUsed.IsZero() {
+			lastUsed := b.LastUsed
+			ab.LastUsed = &lastUsed
+		}
+		res.Blocks = append(res.Blocks, ab)
+	}
+	return res, nil, nil
+}
+
+func (api *API) dropBlock(r *http.Request) (interface{}, []error, *qapi.ApiError) {
+	id, apiErr := blockID(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	return nil, nil, blockAPIError(api.blocks.DropBlock(id))
+}
+
+func (api *API) resyncBlock(r *http.Request) (interface{}, []error, *qapi.ApiError) {
+	id, apiErr := blockID(r)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	return nil, nil, blockAPIError(api.blocks.ResyncBlock(r.Context(), id))
+}
+
+// blockID parses the ULID of the block given in the request path. Invalid IDs are reported as not found,
+// as no block can exist for them.
+func blockID(r *http.Request) (ulid.ULID, *qapi.ApiError) {
+	v := route.Param(r.Context(), "id")
+	id, err := ulid.Parse(v)
+	if err != nil {
+		return ulid.ULID{}, &qapi.ApiError{Typ: qapi.ErrorNotFound, Err: errors.Wrapf(store.ErrBlockNotFound, "invalid block ID %q", v)}
+	}
+	return id, nil
+}
+
+func blockAPIError(err error) *qapi.ApiError {
+	if err == nil {
+		return nil
+	}
+	if errors.Cause(err) == store.ErrBlockNotFound {
+		return &qapi.ApiError{Typ: qapi.ErrorNotFound, Err: err}
+	}
+	return &qapi.ApiError{Typ: qapi.ErrorInternal, Err: err}
+}
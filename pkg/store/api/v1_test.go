@@ -0,0 +1,177 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/route"
+	"github.com/prometheus/tsdb/labels"
+	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
+	qapi "github.com/thanos-io/thanos/pkg/query/api"
+	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+type blocksManagerMock struct {
+	blocks []store.BlockInfo
+
+	dropped, resynced []ulid.ULID
+}
+
+func (m *blocksManagerMock) Blocks() []store.BlockInfo {
+	return m.blocks
+}
+
+func (m *blocksManagerMock) find(id ulid.ULID) error {
+	for _, b := range m.blocks {
+		if b.ULID == id {
+			return nil
+		}
+	}
+	return store.ErrBlockNotFound
+}
+
+func (m *blocksManagerMock) DropBlock(id ulid.ULID) error {
+	if err := m.find(id); err != nil {
+		return err
+	}
+	m.dropped = append(m.dropped, id)
+	return nil
+}
+
+func (m *blocksManagerMock) ResyncBlock(_ context.Context, id ulid.ULID) error {
+	if err := m.find(id); err != nil {
+		return errors.Wrap(err, "resync")
+	}
+	m.resynced = append(m.resynced, id)
+	return nil
+}
+
+func TestAPI_Blocks(t *testing.T) {
+	var (
+		id1      = ulid.MustNew(1, nil)
+		id2      = ulid.MustNew(2, nil)
+		loadedAt = time.Unix(100, 0)
+		lastUsed = time.Unix(200, 0)
+	)
+	m := &blocksManagerMock{blocks: []store.BlockInfo{
+		{
+			ULID:            id1,
+			Labels:          labels.FromStrings("ext", "1"),
+			MinTime:         0,
+			MaxTime:         1000,
+			Loaded:          true,
+			IndexHeaderSize: 1024,
+			LoadedAt:        loadedAt,
+			LastUsed:        lastUsed,
+			Queries:         3,
+		},
+		{
+			ULID:       id2,
+			Labels:     labels.FromStrings("ext", "1"),
+			Resolution: 300000,
+			MinTime:    1000,
+			MaxTime:    2000,
+		},
+	}}
+	api := NewAPI(log.NewNopLogger(), m, true)
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com/blocks", nil)
+	testutil.Ok(t, err)
+	res, _, apiErr := api.listBlocks(req)
+	testutil.Assert(t, apiErr == nil, "unexpected error: %v", apiErr)
+	testutil.Equals(t, &BlockDiscovery{Blocks: []*Block{
+		{
+			ULID:                 id1.String(),
+			Labels:               labels.FromStrings("ext", "1"),
+			MinTime:              0,
+			MaxTime:              1000,
+			Loaded:               true,
+			IndexHeaderSizeBytes: 1024,
+			LoadedAt:             &loadedAt,
+			LastUsed:             &lastUsed,
+			Queries:              3,
+		},
+		{
+			ULID:       id2.String(),
+			Labels:     labels.FromStrings("ext", "1"),
+			Resolution: 300000,
+			MinTime:    1000,
+			MaxTime:    2000,
+		},
+	}}, res)
+
+	for _, tcase := range []struct {
+		endpoint qapi.ApiFunc
+		id       string
+		errType  qapi.ErrorType
+	}{
+		{endpoint: api.dropBlock, id: id1.String()},
+		{endpoint: api.resyncBlock, id: id2.String()},
+		{endpoint: api.dropBlock, id: ulid.MustNew(3, nil).String(), errType: qapi.ErrorNotFound},
+		{endpoint: api.resyncBlock, id: ulid.MustNew(3, nil).String(), errType: qapi.ErrorNotFound},
+		{endpoint: api.dropBlock, id: "invalid", errType: qapi.ErrorNotFound},
+	} {
+		req, err := http.NewRequest(http.MethodPost, "http://example.com/blocks/"+tcase.id, nil)
+		testutil.Ok(t, err)
+		req = req.WithContext(route.WithParam(context.Background(), "id", tcase.id))
+
+		res, _, apiErr := tcase.endpoint(req)
+		testutil.Assert(t, res == nil, "unexpected response %v", res)
+		if tcase.errType == "" {
+			testutil.Assert(t, apiErr == nil, "unexpected error: %v", apiErr)
+			continue
+		}
+		testutil.Assert(t, apiErr != nil, "expected error for block %s", tcase.id)
+		testutil.Equals(t, tcase.errType, apiErr.Typ)
+	}
+	testutil.Equals(t, []ulid.ULID{id1}, m.dropped)
+	testutil.Equals(t, []ulid.ULID{id2}, m.resynced)
+}
+
+func TestAPI_Register_AdminEndpoints(t *testing.T) {
+	id := ulid.MustNew(1, nil)
+
+	for _, enableAdmin := range []bool{false, true} {
+		m := &blocksManagerMock{blocks: []store.BlockInfo{{ULID: id}}}
+		r := route.New()
+		NewAPI(log.NewNopLogger(), m, enableAdmin).Register(r, &opentracing.NoopTracer{}, log.NewNopLogger(), extpromhttp.NewNopInstrumentationMiddleware())
+
+		do := func(method, path string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest(method, "http://example.com"+path, nil)
+			testutil.Ok(t, err)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			return rec
+		}
+
+		// Listing blocks is always possible, also from other origins.
+		rec := do(http.MethodGet, "/blocks")
+		testutil.Equals(t, http.StatusOK, rec.Code)
+		testutil.Equals(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+
+		for _, action := range []string{"drop", "resync"} {
+			rec := do(http.MethodPost, "/blocks/"+id.String()+"/"+action)
+			testutil.Equals(t, "", rec.Header().Get("Access-Control-Allow-Origin"))
+			if !enableAdmin {
+				testutil.Assert(t, rec.Code >= 400, "expected %s to be disabled, got status %d", action, rec.Code)
+				continue
+			}
+			testutil.Equals(t, http.StatusNoContent, rec.Code)
+		}
+
+		if !enableAdmin {
+			testutil.Equals(t, 0, len(m.dropped)+len(m.resynced))
+			continue
+		}
+		testutil.Equals(t, []ulid.ULID{id}, m.dropped)
+		testutil.Equals(t, []ulid.ULID{id}, m.resynced)
+	}
+}
@@ -14,14 +14,18 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/cespare/xxhash"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/oklog/run"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	promlabels "github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/pkg/relabel"
 	"github.com/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/tsdb/chunks"
 	"github.com/prometheus/tsdb/fileutil"
@@ -32,9 +36,11 @@ import (
 	"github.com/thanos-io/thanos/pkg/compact/downsample"
 	"github.com/thanos-io/thanos/pkg/component"
 	"github.com/thanos-io/thanos/pkg/extprom"
+	"github.com/thanos-io/thanos/pkg/model"
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/pool"
 	"github.com/thanos-io/thanos/pkg/runutil"
+	storecache "github.com/thanos-io/thanos/pkg/store/cache"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/strutil"
 	"github.com/thanos-io/thanos/pkg/tracing"
@@ -52,9 +58,13 @@ import (
 const maxSamplesPerChunk = 120
 
 type bucketStoreMetrics struct {
+	blocksKnown           prometheus.Gauge
 	blocksLoaded          prometheus.Gauge
 	blockLoads            prometheus.Counter
 	blockLoadFailures     prometheus.Counter
+	blockLazyLoads        prometheus.Counter
+	blockLazyLoadFailures prometheus.Counter
+	blockUnloads          prometheus.Counter
 	blockDrops            prometheus.Counter
 	blockDropFailures     prometheus.Counter
 	seriesDataTouched     *prometheus.SummaryVec
@@ -66,7 +76,7 @@ type bucketStoreMetrics struct {
 	seriesMergeDuration   prometheus.Histogram
 	resultSeriesCount     prometheus.Summary
 	chunkSizeBytes        prometheus.Histogram
-	queriesDropped        prometheus.Counter
+	queriesDropped        *prometheus.CounterVec
 	queriesLimit          prometheus.Gauge
 }
 
@@ -89,6 +99,22 @@ func newBucketStoreMetrics(reg prometheus.Registerer) *bucketStoreMetrics {
 		Name: "thanos_bucket_store_block_drop_failures_total",
 		Help: "Total number of local blocks that failed to be dropped.",
 	})
+	m.blockLazyLoads = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_bucket_store_block_lazy_loads_total",
+		Help: "Total number of blocks loaded on first query, after being registered or unloaded.",
+	})
+	m.blockLazyLoadFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_bucket_store_block_lazy_load_failures_total",
+		Help: "Total number of failed attempts to load blocks on first query.",
+	})
+	m.blockUnloads = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_bucket_store_block_unloads_total",
+		Help: "Total number of blocks unloaded after being idle.",
+	})
+	m.blocksKnown = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "thanos_bucket_store_blocks_known",
+		Help: "Number of blocks known to the store, including those that are not loaded.",
+	})
 	m.blocksLoaded = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "thanos_bucket_store_blocks_loaded",
 		Help: "Number of currently loaded blocks.",
@@ -143,10 +169,13 @@ func newBucketStoreMetrics(reg prometheus.Registerer) *bucketStoreMetrics {
 		},
 	})
 
-	m.queriesDropped = prometheus.NewCounter(prometheus.CounterOpts{
+	m.queriesDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name: "thanos_bucket_store_queries_dropped_total",
-		Help: "Number of queries that were dropped due to the sample limit.",
-	})
+		Help: "Number of queries that were dropped due to a limit.",
+	}, []string{"reason"})
+	for _, reason := range []string{limitSamples, limitSeries, limitPostingsBytes, limitChunkBytes} {
+		m.queriesDropped.WithLabelValues(reason)
+	}
 	m.queriesLimit = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "thanos_bucket_store_queries_concurrent_max",
 		Help: "Number of maximum concurrent queries.",
@@ -156,8 +185,12 @@ func newBucketStoreMetrics(reg prometheus.Registerer) *bucketStoreMetrics {
 		reg.MustRegister(
 			m.blockLoads,
 			m.blockLoadFailures,
+			m.blockLazyLoads,
+			m.blockLazyLoadFailures,
+			m.blockUnloads,
 			m.blockDrops,
 			m.blockDropFailures,
+			m.blocksKnown,
 			m.blocksLoaded,
 			m.seriesDataTouched,
 			m.seriesDataFetched,
@@ -175,54 +208,92 @@ func newBucketStoreMetrics(reg prometheus.Registerer) *bucketStoreMetrics {
 	return &m
 }
 
-type indexCache interface {
-	SetPostings(b ulid.ULID, l labels.Label, v []byte)
-	Postings(b ulid.ULID, l labels.Label) ([]byte, bool)
-	SetSeries(b ulid.ULID, id uint64, v []byte)
-	Series(b ulid.ULID, id uint64) ([]byte, bool)
-}
-
 // BucketStore implements the store API backed by a bucket. It loads all index
 // files to local disk.
 type BucketStore struct {
-	logger     log.Logger
-	metrics    *bucketStoreMetrics
-	bucket     objstore.BucketReader
-	dir        string
-	indexCache indexCache
-	chunkPool  *pool.BytesPool
+	logger      log.Logger
+	metrics     *bucketStoreMetrics
+	bucket      objstore.BucketReader
+	dir         string
+	indexCache  storecache.IndexCache
+	chunksCache storecache.ChunksCache
+	chunkPool   *pool.BytesPool
 
 	// Sets of blocks that have the same labels. They are indexed by a hash over their label set.
 	mtx       sync.RWMutex
 	blocks    map[ulid.ULID]*bucketBlock
 	blockSets map[uint64]*bucketBlockSet
+	// Blocks dropped with DropBlock, which are not synced again until they are resynced with ResyncBlock.
+	droppedBlocks map[ulid.ULID]struct{}
 
 	// Verbose enabled additional logging.
 	debugLogging bool
 	// Number of goroutines to use when syncing blocks from object storage.
 	blockSyncConcurrency int
+	// If enabled, synced blocks are registered with their meta only and loaded on first query.
+	lazyBlockLoading bool
+	// Loaded blocks that have not been queried for this duration are unloaded on sync. 0 disables unloading.
+	blockIdleTimeout time.Duration
+	// Selects the blocks synced and served by the store. All blocks are served if nil.
+	filterConfig *FilterConfig
 
 	// Query gate which limits the maximum amount of concurrent queries.
 	queryGate *Gate
 
 	// samplesLimiter limits the number of samples per each Series() call.
 	samplesLimiter *Limiter
-	partitioner    partitioner
+	// seriesLimits are the limits of the resources used by each Series() call.
+	seriesLimits SeriesLimits
+	partitioner  partitioner
+	// Maximum size of the chunks sent in a single series frame. Bigger series are split across multiple frames.
+	maxFrameBytes int
+}
+
+// FilterConfig selects the blocks synced and served by a BucketStore.
+type FilterConfig struct {
+	// MinTime and MaxTime select blocks overlapping the time range. Durations are relative to the
+	// time of each sync. Unset values do not limit the time range.
+	MinTime, MaxTime model.TimeOrDurationValue
+
+	// RelabelConfig selects blocks by their external labels. Blocks whose labels are dropped by the
+	// relabeling are not served.
+	RelabelConfig []*relabel.Config
+
+	// ShardIndex and ShardTotal select blocks by the hash of their ULID, so that blocks can be split
+	// across ShardTotal store gateways. All blocks are selected if ShardTotal is lower than 2.
+	ShardIndex, ShardTotal uint64
+}
+
+// BucketStoreOpts configures the optional features of a BucketStore. The zero value disables all of them.
+type BucketStoreOpts struct {
+	// ChunksCache caches chunks fetched from the bucket. Chunks are not cached if it is nil.
+	ChunksCache storecache.ChunksCache
+	// SeriesLimits limit the resources used by each Series call.
+	SeriesLimits SeriesLimits
+	// LazyBlockLoading makes blocks load on their first query instead of on sync.
+	LazyBlockLoading bool
+	// BlockIdleTimeout is the duration after which loaded blocks that have not been queried are
+	// unloaded on sync. 0 disables unloading.
+	BlockIdleTimeout time.Duration
+	// FilterConfig selects the blocks synced and served. All blocks are served if it is nil.
+	FilterConfig *FilterConfig
 }
 
 // NewBucketStore creates a new bucket backed store that implements the store API against
 // an object store bucket. It is optimized to work against high latency backends.
+// The number of samples fetched by each Series call is limited by maxSampleCount.
 func NewBucketStore(
 	logger log.Logger,
 	reg prometheus.Registerer,
 	bucket objstore.BucketReader,
 	dir string,
-	indexCache indexCache,
+	indexCache storecache.IndexCache,
 	maxChunkPoolBytes uint64,
 	maxSampleCount uint64,
 	maxConcurrent int,
 	debugLogging bool,
 	blockSyncConcurrency int,
+	opts BucketStoreOpts,
 ) (*BucketStore, error) {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -245,17 +316,24 @@ func NewBucketStore(
 		bucket:               bucket,
 		dir:                  dir,
 		indexCache:           indexCache,
+		chunksCache:          opts.ChunksCache,
 		chunkPool:            chunkPool,
 		blocks:               map[ulid.ULID]*bucketBlock{},
 		blockSets:            map[uint64]*bucketBlockSet{},
+		droppedBlocks:        map[ulid.ULID]struct{}{},
 		debugLogging:         debugLogging,
 		blockSyncConcurrency: blockSyncConcurrency,
+		lazyBlockLoading:     opts.LazyBlockLoading,
+		blockIdleTimeout:     opts.BlockIdleTimeout,
+		filterConfig:         opts.FilterConfig,
 		queryGate: NewGate(
 			maxConcurrent,
 			extprom.WrapRegistererWithPrefix("thanos_bucket_store_series_", reg),
 		),
-		samplesLimiter: NewLimiter(maxSampleCount, metrics.queriesDropped),
+		samplesLimiter: NewLimiter(maxSampleCount, metrics.queriesDropped.WithLabelValues(limitSamples)),
+		seriesLimits:   opts.SeriesLimits,
 		partitioner:    gapBasedPartitioner{maxGapSize: maxGapSize},
+		maxFrameBytes:  storepb.MaxSeriesFrameBytes,
 	}
 	s.metrics = metrics
 
@@ -285,6 +363,9 @@ func (s *BucketStore) Close() (err error) {
 // SyncBlocks synchronizes the stores state with the Bucket bucket.
 // It will reuse disk space as persistent cache based on s.dir param.
 func (s *BucketStore) SyncBlocks(ctx context.Context) error {
+	// Relative time ranges are evaluated once per sync.
+	mint, maxt := s.timeWindow()
+
 	var wg sync.WaitGroup
 	blockc := make(chan ulid.ULID)
 
@@ -292,7 +373,7 @@ func (s *BucketStore) SyncBlocks(ctx context.Context) error {
 		wg.Add(1)
 		go func() {
 			for id := range blockc {
-				if err := s.addBlock(ctx, id); err != nil {
+				if err := s.addBlock(ctx, id, mint, maxt); err != nil {
 					level.Warn(s.logger).Log("msg", "loading block failed", "id", id, "err", err)
 					continue
 				}
@@ -309,11 +390,18 @@ func (s *BucketStore) SyncBlocks(ctx context.Context) error {
 		if err != nil {
 			return nil
 		}
-		allIDs[id] = struct{}{}
-
 		if b := s.getBlock(id); b != nil {
+			// Known blocks that moved out of a relative time range are dropped below.
+			if overlaps(b.meta, mint, maxt) {
+				allIDs[id] = struct{}{}
+			}
+			return nil
+		}
+		if !s.inShard(id) || s.isDropped(id) {
 			return nil
 		}
+		allIDs[id] = struct{}{}
+
 		select {
 		case <-ctx.Done():
 		case blockc <- id:
@@ -339,6 +427,78 @@ func (s *BucketStore) SyncBlocks(ctx context.Context) error {
 		s.metrics.blockDrops.Inc()
 	}
 
+	if s.blockIdleTimeout > 0 {
+		s.unloadIdleBlocks()
+	}
+	return nil
+}
+
+// timeWindow returns the time range of the blocks synced and served by the store.
+func (s *BucketStore) timeWindow() (mint, maxt int64) {
+	mint, maxt = math.MinInt64, math.MaxInt64
+	if s.filterConfig == nil {
+		return mint, maxt
+	}
+	if v := s.filterConfig.MinTime; v.Time != nil || v.Dur != nil {
+		mint = v.PrometheusTimestamp()
+	}
+	if v := s.filterConfig.MaxTime; v.Time != nil || v.Dur != nil {
+		maxt = v.PrometheusTimestamp()
+	}
+	return mint, maxt
+}
+
+// inShard returns true if the block belongs to the shard of the store.
+func (s *BucketStore) inShard(id ulid.ULID) bool {
+	if s.filterConfig == nil || s.filterConfig.ShardTotal < 2 {
+		return true
+	}
+	return xxhash.Sum64(id[:])%s.filterConfig.ShardTotal == s.filterConfig.ShardIndex
+}
+
+// matchesLabels returns true if the external labels of the block are kept by the relabel config.
+func (s *BucketStore) matchesLabels(meta *metadata.Meta) bool {
+	if s.filterConfig == nil || len(s.filterConfig.RelabelConfig) == 0 {
+		return true
+	}
+	return relabel.Process(promlabels.FromMap(meta.Thanos.Labels), s.filterConfig.RelabelConfig...) != nil
+}
+
+// overlaps returns true if the block overlaps the given time range.
+func overlaps(meta *metadata.Meta, mint, maxt int64) bool {
+	return meta.MaxTime > mint && meta.MinTime < maxt
+}
+
+// unloadIdleBlocks unloads all blocks that have not been queried for the configured idle timeout.
+// Unloaded blocks keep their local files and are loaded again on their next query.
+func (s *BucketStore) unloadIdleBlocks() {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	for id, b := range s.blocks {
+		unloaded, err := b.unloadIfIdle(s.blockIdleTimeout)
+		if !unloaded {
+			continue
+		}
+		s.metrics.blockUnloads.Inc()
+		s.metrics.blocksLoaded.Dec()
+		if err != nil {
+			level.Warn(s.logger).Log("msg", "unloading idle block failed", "block", id, "err", err)
+		}
+	}
+}
+
+// loadBlock loads the index header and chunk objects of the block if they are not loaded yet.
+func (s *BucketStore) loadBlock(ctx context.Context, b *bucketBlock) error {
+	loaded, err := b.load(ctx)
+	if err != nil {
+		s.metrics.blockLazyLoadFailures.Inc()
+		return errors.Wrap(err, "load block")
+	}
+	if loaded {
+		s.metrics.blockLazyLoads.Inc()
+		s.metrics.blocksLoaded.Inc()
+	}
 	return nil
 }
 
@@ -383,7 +543,8 @@ func (s *BucketStore) getBlock(id ulid.ULID) *bucketBlock {
 	return s.blocks[id]
 }
 
-func (s *BucketStore) addBlock(ctx context.Context, id ulid.ULID) (err error) {
+// addBlock adds the block unless it does not overlap the given time range or its labels are not selected.
+func (s *BucketStore) addBlock(ctx context.Context, id ulid.ULID, mint, maxt int64) (err error) {
 	dir := filepath.Join(s.dir, id.String())
 
 	defer func() {
@@ -394,7 +555,6 @@ func (s *BucketStore) addBlock(ctx context.Context, id ulid.ULID) (err error) {
 			}
 		}
 	}()
-	s.metrics.blockLoads.Inc()
 
 	b, err := newBucketBlock(
 		ctx,
@@ -403,15 +563,41 @@ func (s *BucketStore) addBlock(ctx context.Context, id ulid.ULID) (err error) {
 		id,
 		dir,
 		s.indexCache,
+		s.chunksCache,
 		s.chunkPool,
 		s.partitioner,
 	)
 	if err != nil {
 		return errors.Wrap(err, "new bucket block")
 	}
+	// The meta.json of skipped blocks is kept on disk, so it is not downloaded again on every sync.
+	if !overlaps(b.meta, mint, maxt) || !s.matchesLabels(b.meta) {
+		return nil
+	}
+	s.metrics.blockLoads.Inc()
+
+	if !s.lazyBlockLoading {
+		if _, err := b.load(ctx); err != nil {
+			return errors.Wrap(err, "load block")
+		}
+		s.metrics.blocksLoaded.Inc()
+	}
+
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
+	// The block may have been dropped while it was being loaded.
+	if _, ok := s.droppedBlocks[id]; ok {
+		unloaded, err := b.unload()
+		if unloaded {
+			s.metrics.blocksLoaded.Dec()
+		}
+		if err != nil {
+			level.Warn(s.logger).Log("msg", "unloading dropped block failed", "block", id, "err", err)
+		}
+		return os.RemoveAll(dir)
+	}
+
 	lset := labels.FromMap(b.meta.Thanos.Labels)
 	h := lset.Hash()
 
@@ -426,7 +612,7 @@ func (s *BucketStore) addBlock(ctx context.Context, id ulid.ULID) (err error) {
 	}
 	s.blocks[b.meta.ULID] = b
 
-	s.metrics.blocksLoaded.Inc()
+	s.metrics.blocksKnown.Inc()
 
 	return nil
 }
@@ -445,14 +631,121 @@ func (s *BucketStore) removeBlock(id ulid.ULID) error {
 		return nil
 	}
 
-	s.metrics.blocksLoaded.Dec()
-	if err := b.Close(); err != nil {
+	s.metrics.blocksKnown.Dec()
+
+	// Wait for all pending readers before releasing the block.
+	b.pendingReaders.Wait()
+	unloaded, err := b.unload()
+	if unloaded {
+		s.metrics.blocksLoaded.Dec()
+	}
+	if err != nil {
 		return errors.Wrap(err, "close block")
 	}
 	return os.RemoveAll(b.dir)
 }
 
-// TimeRange returns the minimum and maximum timestamp of data available in the store.
+func (s *BucketStore) isDropped(id ulid.ULID) bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	_, ok := s.droppedBlocks[id]
+	return ok
+}
+
+// ErrBlockNotFound is returned by the block admin methods of BucketStore for blocks it does not serve.
+var ErrBlockNotFound = errors.New("block not found")
+
+// BlockInfo describes a block served by a BucketStore and its resource usage.
+type BlockInfo struct {
+	ULID       ulid.ULID
+	Labels     labels.Labels
+	Resolution int64
+	MinTime    int64
+	MaxTime    int64
+
+	// Loaded is true if the index header of the block is loaded. IndexHeaderSize is its approximate memory
+	// usage and LoadedAt the time it was loaded.
+	Loaded          bool
+	IndexHeaderSize int64
+	LoadedAt        time.Time
+	LastUsed        time.Time
+
+	// Queries is the number of queries that touched the block since it was synced.
+	Queries uint64
+}
+
+// Blocks returns information about all blocks served by the store, sorted by their time range.
+func (s *BucketStore) Blocks() []BlockInfo {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	res := make([]BlockInfo, 0, len(s.blocks))
+	for _, b := range s.blocks {
+		res = append(res, b.info())
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].MinTime != res[j].MinTime {
+			return res[i].MinTime < res[j].MinTime
+		}
+		return res[i].ULID.Compare(res[j].ULID) < 0
+	})
+	return res
+}
+
+// DropBlock removes the block from the store and deletes its local files. The block is not synced again
+// until it is resynced with ResyncBlock.
+func (s *BucketStore) DropBlock(id ulid.ULID) error {
+	s.mtx.Lock()
+	_, ok := s.blocks[id]
+	if ok {
+		s.droppedBlocks[id] = struct{}{}
+	}
+	s.mtx.Unlock()
+
+	if !ok {
+		return ErrBlockNotFound
+	}
+	level.Info(s.logger).Log("msg", "dropping block", "block", id)
+
+	if err := s.removeBlock(id); err != nil {
+		s.metrics.blockDropFailures.Inc()
+		return errors.Wrap(err, "remove block")
+	}
+	s.metrics.blockDrops.Inc()
+	return nil
+}
+
+// ResyncBlock removes the block from the store together with its local files and syncs it again from
+// the bucket. It also syncs blocks dropped with DropBlock.
+func (s *BucketStore) ResyncBlock(ctx context.Context, id ulid.ULID) error {
+	s.mtx.Lock()
+	_, known := s.blocks[id]
+	_, dropped := s.droppedBlocks[id]
+	delete(s.droppedBlocks, id)
+	s.mtx.Unlock()
+
+	if !known && !dropped {
+		return ErrBlockNotFound
+	}
+	level.Info(s.logger).Log("msg", "resyncing block", "block", id)
+
+	if known {
+		if err := s.removeBlock(id); err != nil {
+			s.metrics.blockDropFailures.Inc()
+			return errors.Wrap(err, "remove block")
+		}
+		s.metrics.blockDrops.Inc()
+	}
+
+	mint, maxt := s.timeWindow()
+	if err := s.addBlock(ctx, id, mint, maxt); err != nil {
+		return errors.Wrap(err, "add block")
+	}
+	return nil
+}
+
+// TimeRange returns the minimum and maximum timestamp of data available in the store,
+// limited to the time range of the filter config.
 func (s *BucketStore) TimeRange() (mint, maxt int64) {
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
@@ -468,17 +761,54 @@ func (s *BucketStore) TimeRange() (mint, maxt int64) {
 			maxt = b.meta.MaxTime
 		}
 	}
+
+	wmint, wmaxt := s.timeWindow()
+	if mint < wmint {
+		mint = wmint
+	}
+	if maxt > wmaxt {
+		maxt = wmaxt
+	}
 	return mint, maxt
 }
 
+// LabelSets returns the sorted external label sets of all served blocks.
+func (s *BucketStore) LabelSets() []storepb.LabelSet {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	var lsets []labels.Labels
+	for _, bs := range s.blockSets {
+		if bs.empty() {
+			continue
+		}
+		lsets = append(lsets, bs.labels)
+	}
+	sort.Slice(lsets, func(i, j int) bool {
+		return labels.Compare(lsets[i], lsets[j]) < 0
+	})
+
+	res := make([]storepb.LabelSet, 0, len(lsets))
+	for _, lset := range lsets {
+		ls := storepb.LabelSet{Labels: make([]storepb.Label, 0, len(lset))}
+		for _, l := range lset {
+			ls.Labels = append(ls.Labels, storepb.Label{Name: l.Name, Value: l.Value})
+		}
+		res = append(res, ls)
+	}
+	return res
+}
+
 // Info implements the storepb.StoreServer interface.
 func (s *BucketStore) Info(context.Context, *storepb.InfoRequest) (*storepb.InfoResponse, error) {
 	mint, maxt := s.TimeRange()
-	// Store nodes hold global data and thus have no labels.
+	// Store nodes hold global data and thus have no labels. The external label sets of the served
+	// blocks allow queriers to skip store nodes that cannot match a query.
 	return &storepb.InfoResponse{
 		StoreType: component.Store.ToProto(),
 		MinTime:   mint,
 		MaxTime:   maxt,
+		LabelSets: s.LabelSets(),
 	}, nil
 }
 
@@ -525,9 +855,9 @@ func blockSeries(
 	chunkr *bucketChunkReader,
 	matchers []labels.Matcher,
 	req *storepb.SeriesRequest,
-	samplesLimiter *Limiter,
+	limiters *seriesLimiters,
 ) (storepb.SeriesSet, *queryStats, error) {
-	ps, err := indexr.ExpandedPostings(matchers)
+	ps, err := indexr.ExpandedPostings(matchers, limiters.postingsBytes)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "expanded matching posting")
 	}
@@ -535,6 +865,9 @@ func blockSeries(
 	if len(ps) == 0 {
 		return storepb.EmptySeriesSet(), indexr.stats, nil
 	}
+	if err := limiters.series.Reserve(uint64(len(ps))); err != nil {
+		return nil, nil, errors.Wrap(err, "exceeded series limit")
+	}
 
 	// Preload all series index data.
 	// TODO(bwplotka): Consider not keeping all series in memory all the time.
@@ -588,6 +921,11 @@ func blockSeries(
 				break
 			}
 
+			if req.SkipChunks {
+				// Only the labels of series with data in the requested time range are needed.
+				res = append(res, s)
+				break
+			}
 			if err := chunkr.addPreload(meta.Ref); err != nil {
 				return nil, nil, errors.Wrap(err, "add chunk preload")
 			}
@@ -602,8 +940,12 @@ func blockSeries(
 		}
 	}
 
+	if req.SkipChunks {
+		return newBucketSeriesSet(res), indexr.stats, nil
+	}
+
 	// Preload all chunks that were marked in the previous stage.
-	if err := chunkr.preload(samplesLimiter); err != nil {
+	if err := chunkr.preload(limiters.samples, limiters.chunkBytes); err != nil {
 		return nil, nil, errors.Wrap(err, "preload chunks")
 	}
 
@@ -723,11 +1065,15 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		return status.Error(codes.InvalidArgument, err.Error())
 	}
 	var (
-		stats = &queryStats{}
-		g     run.Group
-		res   []storepb.SeriesSet
-		mtx   sync.Mutex
+		stats         = &queryStats{}
+		blocksQueried int
+		limiters      = s.newSeriesLimiters()
+		res           []storepb.SeriesSet
+		mtx           sync.Mutex
 	)
+	// Errors of all blocks must be reported, e.g. a block exceeding a limit after other blocks already succeeded.
+	g, gctx := errgroup.WithContext(srv.Context())
+
 	s.mtx.RLock()
 
 	for _, bs := range s.blockSets {
@@ -742,28 +1088,34 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		}
 
 		for _, b := range blocks {
-			stats.blocksQueried++
+			blocksQueried++
 
 			b := b
-			ctx, cancel := context.WithCancel(srv.Context())
 
 			// We must keep the readers open until all their data has been sent.
-			indexr := b.indexReader(ctx)
-			chunkr := b.chunkReader(ctx)
-
+			indexr := b.indexReader(gctx)
 			// Defer all closes to the end of Series method.
 			defer runutil.CloseWithLogOnErr(s.logger, indexr, "series block")
-			defer runutil.CloseWithLogOnErr(s.logger, chunkr, "series block")
 
-			g.Add(func() error {
-				part, pstats, err := blockSeries(ctx,
+			// Chunks are not read at all if only the series labels were requested.
+			var chunkr *bucketChunkReader
+			if !req.SkipChunks {
+				chunkr = b.chunkReader(gctx)
+				defer runutil.CloseWithLogOnErr(s.logger, chunkr, "series block")
+			}
+
+			g.Go(func() error {
+				if err := s.loadBlock(gctx, b); err != nil {
+					return errors.Wrapf(err, "fetch series for block %s", b.meta.ULID)
+				}
+				part, pstats, err := blockSeries(gctx,
 					b.meta.ULID,
 					b.meta.Thanos.Labels,
 					indexr,
 					chunkr,
 					blockMatchers,
 					req,
-					s.samplesLimiter,
+					limiters,
 				)
 				if err != nil {
 					return errors.Wrapf(err, "fetch series for block %s", b.meta.ULID)
@@ -775,10 +1127,6 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 				mtx.Unlock()
 
 				return nil
-			}, func(err error) {
-				if err != nil {
-					cancel()
-				}
 			})
 		}
 	}
@@ -808,11 +1156,12 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 	{
 		span, _ := tracing.StartSpan(srv.Context(), "bucket_store_preload_all")
 		begin := time.Now()
-		err := g.Run()
+		err := g.Wait()
 		span.Finish()
+		stats.blocksQueried += blocksQueried
 
 		if err != nil {
-			return status.Error(codes.Aborted, err.Error())
+			return status.Error(limitsErrorCode(err, codes.Aborted), err.Error())
 		}
 		stats.getAllDuration = time.Since(begin)
 		s.metrics.seriesGetAllDuration.Observe(stats.getAllDuration.Seconds())
@@ -829,6 +1178,12 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		// Chunks of returned series might be out of order w.r.t to their time range.
 		// This must be accounted for later by clients.
 		set := storepb.MergeSeriesSets(res...)
+
+		// Series are only split if the client reassembles them.
+		maxFrameBytes := 0
+		if req.AllowSplitSeries {
+			maxFrameBytes = s.maxFrameBytes
+		}
 		for set.Next() {
 			var series storepb.Series
 
@@ -838,8 +1193,11 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 			stats.mergedChunksCount += len(series.Chunks)
 			s.metrics.chunkSizeBytes.Observe(float64(chunksSize(series.Chunks)))
 
-			if err := srv.Send(storepb.NewSeriesResponse(&series)); err != nil {
-				return status.Error(codes.Unknown, errors.Wrap(err, "send series response").Error())
+			frames := storepb.SplitSeries(series, maxFrameBytes)
+			for i := range frames {
+				if err := srv.Send(storepb.NewSeriesResponse(&frames[i])); err != nil {
+					return status.Error(codes.Unknown, errors.Wrap(err, "send series response").Error())
+				}
 			}
 		}
 		if set.Err() != nil {
@@ -848,9 +1206,39 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		stats.mergeDuration = time.Since(begin)
 		s.metrics.seriesMergeDuration.Observe(stats.mergeDuration.Seconds())
 	}
+
+	if req.QueryStats {
+		if err := srv.Send(storepb.NewStatsSeriesResponse(stats.toProto())); err != nil {
+			return status.Error(codes.Unknown, errors.Wrap(err, "send stats response").Error())
+		}
+	}
 	return nil
 }
 
+const (
+	limitSamples       = "samples"
+	limitSeries        = "series"
+	limitPostingsBytes = "postings-bytes"
+	limitChunkBytes    = "chunk-bytes"
+)
+
+// seriesLimiters are the limiters of a single Series() call, shared by all queried blocks.
+type seriesLimiters struct {
+	samples       *Limiter
+	series        *AccumulatingLimiter
+	postingsBytes *AccumulatingLimiter
+	chunkBytes    *AccumulatingLimiter
+}
+
+func (s *BucketStore) newSeriesLimiters() *seriesLimiters {
+	return &seriesLimiters{
+		samples:       s.samplesLimiter,
+		series:        NewAccumulatingLimiter(s.seriesLimits.MaxSeries, s.metrics.queriesDropped.WithLabelValues(limitSeries)),
+		postingsBytes: NewAccumulatingLimiter(s.seriesLimits.MaxPostingsBytes, s.metrics.queriesDropped.WithLabelValues(limitPostingsBytes)),
+		chunkBytes:    NewAccumulatingLimiter(s.seriesLimits.MaxChunkBytes, s.metrics.queriesDropped.WithLabelValues(limitChunkBytes)),
+	}
+}
+
 func chunksSize(chks []storepb.AggrChunk) (size int) {
 	for _, chk := range chks {
 		size += chk.Size() // This gets the encoded proto size.
@@ -859,34 +1247,68 @@ func chunksSize(chks []storepb.AggrChunk) (size int) {
 }
 
 // LabelNames implements the storepb.StoreServer interface.
-func (s *BucketStore) LabelNames(ctx context.Context, _ *storepb.LabelNamesRequest) (*storepb.LabelNamesResponse, error) {
+func (s *BucketStore) LabelNames(ctx context.Context, req *storepb.LabelNamesRequest) (*storepb.LabelNamesResponse, error) {
+	matchers, err := translateMatchers(req.Matchers)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	mint, maxt := req.TimeRange()
+
+	var (
+		mtx      sync.Mutex
+		sets     [][]string
+		limiters = s.newSeriesLimiters()
+	)
 	g, gctx := errgroup.WithContext(ctx)
 
 	s.mtx.RLock()
 
-	var mtx sync.Mutex
-	var sets [][]string
+	for _, bs := range s.blockSets {
+		blockMatchers, ok := bs.labelMatchers(matchers...)
+		if !ok {
+			continue
+		}
+		for _, b := range bs.getFor(mint, maxt, math.MaxInt64) {
+			b := b
+			indexr := b.indexReader(gctx)
+			g.Go(func() error {
+				defer runutil.CloseWithLogOnErr(s.logger, indexr, "label names")
 
-	for _, b := range s.blocks {
-		indexr := b.indexReader(gctx)
-		g.Go(func() error {
-			defer runutil.CloseWithLogOnErr(s.logger, indexr, "label names")
+				if err := s.loadBlock(gctx, b); err != nil {
+					return errors.Wrapf(err, "label names for block %s", b.meta.ULID)
+				}
 
-			res := indexr.LabelNames()
-			sort.Strings(res)
+				var res []string
+				if len(blockMatchers) == 0 {
+					res = indexr.LabelNames()
+				} else {
+					names := map[string]struct{}{}
+					if err := matchingSeriesLabels(indexr, blockMatchers, mint, maxt, limiters, func(lset labels.Labels) {
+						for _, l := range lset {
+							names[l.Name] = struct{}{}
+						}
+					}); err != nil {
+						return errors.Wrapf(err, "label names for block %s", b.meta.ULID)
+					}
+					for n := range names {
+						res = append(res, n)
+					}
+				}
+				sort.Strings(res)
 
-			mtx.Lock()
-			sets = append(sets, res)
-			mtx.Unlock()
+				mtx.Lock()
+				sets = append(sets, res)
+				mtx.Unlock()
 
-			return nil
-		})
+				return nil
+			})
+		}
 	}
 
 	s.mtx.RUnlock()
 
 	if err := g.Wait(); err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, status.Error(limitsErrorCode(err, codes.Internal), err.Error())
 	}
 	return &storepb.LabelNamesResponse{
 		Names: strutil.MergeSlices(sets...),
@@ -895,40 +1317,116 @@ func (s *BucketStore) LabelNames(ctx context.Context, _ *storepb.LabelNamesReque
 
 // LabelValues implements the storepb.StoreServer interface.
 func (s *BucketStore) LabelValues(ctx context.Context, req *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
+	matchers, err := translateMatchers(req.Matchers)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	mint, maxt := req.TimeRange()
+
+	var (
+		mtx      sync.Mutex
+		sets     [][]string
+		limiters = s.newSeriesLimiters()
+	)
 	g, gctx := errgroup.WithContext(ctx)
 
 	s.mtx.RLock()
 
-	var mtx sync.Mutex
-	var sets [][]string
-
-	for _, b := range s.blocks {
-		indexr := b.indexReader(gctx)
-		// TODO(fabxc): only aggregate chunk metas first and add a subsequent fetch stage
-		// where we consolidate requests.
-		g.Go(func() error {
-			defer runutil.CloseWithLogOnErr(s.logger, indexr, "label values")
+	for _, bs := range s.blockSets {
+		blockMatchers, ok := bs.labelMatchers(matchers...)
+		if !ok {
+			continue
+		}
+		for _, b := range bs.getFor(mint, maxt, math.MaxInt64) {
+			b := b
+			indexr := b.indexReader(gctx)
+			// TODO(fabxc): only aggregate chunk metas first and add a subsequent fetch stage
+			// where we consolidate requests.
+			g.Go(func() error {
+				defer runutil.CloseWithLogOnErr(s.logger, indexr, "label values")
+
+				if err := s.loadBlock(gctx, b); err != nil {
+					return errors.Wrapf(err, "label values for block %s", b.meta.ULID)
+				}
 
-			res := indexr.LabelValues(req.Label)
+				var res []string
+				if len(blockMatchers) == 0 {
+					res = indexr.LabelValues(req.Label)
+				} else {
+					vals := map[string]struct{}{}
+					if err := matchingSeriesLabels(indexr, blockMatchers, mint, maxt, limiters, func(lset labels.Labels) {
+						if v := lset.Get(req.Label); v != "" {
+							vals[v] = struct{}{}
+						}
+					}); err != nil {
+						return errors.Wrapf(err, "label values for block %s", b.meta.ULID)
+					}
+					for v := range vals {
+						res = append(res, v)
+					}
+					sort.Strings(res)
+				}
 
-			mtx.Lock()
-			sets = append(sets, res)
-			mtx.Unlock()
+				mtx.Lock()
+				sets = append(sets, res)
+				mtx.Unlock()
 
-			return nil
-		})
+				return nil
+			})
+		}
 	}
 
 	s.mtx.RUnlock()
 
 	if err := g.Wait(); err != nil {
-		return nil, status.Error(codes.Aborted, err.Error())
+		return nil, status.Error(limitsErrorCode(err, codes.Aborted), err.Error())
 	}
 	return &storepb.LabelValuesResponse{
 		Values: strutil.MergeSlices(sets...),
 	}, nil
 }
 
+// matchingSeriesLabels calls f with the labels of all series of the block which match the matchers
+// and have chunks within the given time range.
+func matchingSeriesLabels(
+	indexr *bucketIndexReader,
+	matchers []labels.Matcher,
+	mint, maxt int64,
+	limiters *seriesLimiters,
+	f func(lset labels.Labels),
+) error {
+	ps, err := indexr.ExpandedPostings(matchers, limiters.postingsBytes)
+	if err != nil {
+		return errors.Wrap(err, "expanded matching posting")
+	}
+	if len(ps) == 0 {
+		return nil
+	}
+	if err := limiters.series.Reserve(uint64(len(ps))); err != nil {
+		return errors.Wrap(err, "exceeded series limit")
+	}
+	if err := indexr.PreloadSeries(ps); err != nil {
+		return errors.Wrap(err, "preload series")
+	}
+
+	var (
+		lset labels.Labels
+		chks []chunks.Meta
+	)
+	for _, id := range ps {
+		if err := indexr.LoadedSeries(id, &lset, &chks); err != nil {
+			return errors.Wrap(err, "read series")
+		}
+		for _, meta := range chks {
+			if meta.MaxTime >= mint && meta.MinTime <= maxt {
+				f(lset)
+				break
+			}
+		}
+	}
+	return nil
+}
+
 // bucketBlockSet holds all blocks of an equal label set. It internally splits
 // them up by downsampling resolution and allows querying
 type bucketBlockSet struct {
@@ -968,6 +1466,19 @@ func (s *bucketBlockSet) add(b *bucketBlock) error {
 	return nil
 }
 
+// empty returns true if the set holds no blocks.
+func (s *bucketBlockSet) empty() bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	for _, bs := range s.blocks {
+		if len(bs) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *bucketBlockSet) remove(id ulid.ULID) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
@@ -1052,20 +1563,27 @@ func (s *bucketBlockSet) labelMatchers(matchers ...labels.Matcher) ([]labels.Mat
 // bucketBlock represents a block that is located in a bucket. It holds intermediate
 // state for the block on local disk.
 type bucketBlock struct {
-	logger     log.Logger
-	bucket     objstore.BucketReader
-	meta       *metadata.Meta
-	dir        string
-	indexCache indexCache
-	chunkPool  *pool.BytesPool
-
-	indexVersion int
-	symbols      map[uint32]string
-	lvals        map[string][]string
-	postings     map[labels.Label]index.Range
-
-	id        ulid.ULID
-	chunkObjs []string
+	// Number of queries of the block. Accessed atomically, it is kept first for 64-bit alignment.
+	queries uint64
+
+	logger      log.Logger
+	bucket      objstore.BucketReader
+	meta        *metadata.Meta
+	dir         string
+	indexCache  storecache.IndexCache
+	chunksCache storecache.ChunksCache
+	chunkPool   *pool.BytesPool
+
+	id ulid.ULID
+
+	// loadMtx guards the parts of the block that are loaded on demand and unloaded when the block is idle.
+	loadMtx     sync.Mutex
+	indexHeader block.IndexHeader
+	chunkObjs   []string
+	// Number of open readers of the block, which is not unloaded while they are in use.
+	readers  int
+	lastUsed time.Time
+	loadedAt time.Time
 
 	pendingReaders sync.WaitGroup
 
@@ -1078,7 +1596,8 @@ func newBucketBlock(
 	bkt objstore.BucketReader,
 	id ulid.ULID,
 	dir string,
-	indexCache indexCache,
+	indexCache storecache.IndexCache,
+	chunksCache storecache.ChunksCache,
 	chunkPool *pool.BytesPool,
 	p partitioner,
 ) (b *bucketBlock, err error) {
@@ -1087,6 +1606,7 @@ func newBucketBlock(
 		bucket:      bkt,
 		id:          id,
 		indexCache:  indexCache,
+		chunksCache: chunksCache,
 		chunkPool:   chunkPool,
 		dir:         dir,
 		partitioner: p,
@@ -1094,18 +1614,115 @@ func newBucketBlock(
 	if err = b.loadMeta(ctx, id); err != nil {
 		return nil, errors.Wrap(err, "load meta")
 	}
-	if err = b.loadIndexCacheFile(ctx); err != nil {
-		return nil, errors.Wrap(err, "load index cache")
+	return b, nil
+}
+
+// load loads the index header and the object handles of the chunk files, unless they are loaded already.
+// It returns true if the block has been loaded by this call.
+func (b *bucketBlock) load(ctx context.Context) (bool, error) {
+	b.loadMtx.Lock()
+	defer b.loadMtx.Unlock()
+
+	if b.indexHeader != nil {
+		return false, nil
+	}
+	if err := b.loadIndexHeader(ctx); err != nil {
+		// On error the typed nil header returned by the constructors must not be mistaken for a loaded one.
+		b.indexHeader = nil
+		// The local index header or index cache file may be corrupted or partially written. Remove them,
+		// so that the next load downloads or builds them again instead of failing on them forever.
+		b.removeIndexHeaderFiles()
+		return false, errors.Wrap(err, "load index header")
 	}
+
 	// Get object handles for all chunk files.
-	err = bkt.Iter(ctx, path.Join(id.String(), block.ChunksDirname), func(n string) error {
-		b.chunkObjs = append(b.chunkObjs, n)
+	var chunkObjs []string
+	err := b.bucket.Iter(ctx, path.Join(b.id.String(), block.ChunksDirname), func(n string) error {
+		chunkObjs = append(chunkObjs, n)
 		return nil
 	})
 	if err != nil {
-		return nil, errors.Wrap(err, "list chunk files")
+		runutil.CloseWithLogOnErr(b.logger, b.indexHeader, "close index header")
+		b.indexHeader = nil
+		return false, errors.Wrap(err, "list chunk files")
 	}
-	return b, nil
+	b.chunkObjs = chunkObjs
+	b.lastUsed = time.Now()
+	b.loadedAt = b.lastUsed
+	return true, nil
+}
+
+// info returns information about the block and its resource usage.
+func (b *bucketBlock) info() BlockInfo {
+	b.loadMtx.Lock()
+	defer b.loadMtx.Unlock()
+
+	bi := BlockInfo{
+		ULID:       b.id,
+		Labels:     labels.FromMap(b.meta.Thanos.Labels),
+		Resolution: b.meta.Thanos.Downsample.Resolution,
+		MinTime:    b.meta.MinTime,
+		MaxTime:    b.meta.MaxTime,
+		LastUsed:   b.lastUsed,
+		Queries:    atomic.LoadUint64(&b.queries),
+	}
+	if b.indexHeader != nil {
+		bi.Loaded = true
+		bi.IndexHeaderSize = b.indexHeader.Size()
+		bi.LoadedAt = b.loadedAt
+	}
+	return bi
+}
+
+// unloadIfIdle releases the index header of the block if it is loaded, has no open readers and has not
+// been used for the given duration. It returns true if the block has been unloaded.
+func (b *bucketBlock) unloadIfIdle(idleTimeout time.Duration) (bool, error) {
+	b.loadMtx.Lock()
+	defer b.loadMtx.Unlock()
+
+	if b.readers > 0 || time.Since(b.lastUsed) < idleTimeout {
+		return false, nil
+	}
+	return b.unloadLocked()
+}
+
+// unload releases the index header of the block if it is loaded. It returns true if the block has been unloaded.
+func (b *bucketBlock) unload() (bool, error) {
+	b.loadMtx.Lock()
+	defer b.loadMtx.Unlock()
+
+	return b.unloadLocked()
+}
+
+func (b *bucketBlock) unloadLocked() (bool, error) {
+	if b.indexHeader == nil {
+		return false, nil
+	}
+	err := b.indexHeader.Close()
+	b.indexHeader = nil
+	b.chunkObjs = nil
+	return true, err
+}
+
+// acquire marks the block as used by a new reader, which prevents it from being unloaded until released.
+func (b *bucketBlock) acquire() {
+	b.pendingReaders.Add(1)
+
+	b.loadMtx.Lock()
+	defer b.loadMtx.Unlock()
+
+	b.readers++
+	b.lastUsed = time.Now()
+}
+
+// release marks a reader of the block as done.
+func (b *bucketBlock) release() {
+	b.loadMtx.Lock()
+	b.readers--
+	b.lastUsed = time.Now()
+	b.loadMtx.Unlock()
+
+	b.pendingReaders.Done()
 }
 
 func (b *bucketBlock) indexFilename() string {
@@ -1116,6 +1733,10 @@ func (b *bucketBlock) indexCacheFilename() string {
 	return path.Join(b.id.String(), block.IndexCacheFilename)
 }
 
+func (b *bucketBlock) indexHeaderFilename() string {
+	return path.Join(b.id.String(), block.IndexHeaderFilename)
+}
+
 func (b *bucketBlock) loadMeta(ctx context.Context, id ulid.ULID) error {
 	// If we haven't seen the block before download the meta.json file.
 	if _, err := os.Stat(b.dir); os.IsNotExist(err) {
@@ -1138,9 +1759,30 @@ func (b *bucketBlock) loadMeta(ctx context.Context, id ulid.ULID) error {
 	return nil
 }
 
-func (b *bucketBlock) loadIndexCacheFile(ctx context.Context) (err error) {
+// loadIndexHeader loads the first lookup stages of the block index. The binary index header is
+// preferred over the JSON index cache file. If none of them exists, the binary index header is
+// built from the index file.
+func (b *bucketBlock) loadIndexHeader(ctx context.Context) (err error) {
+	headerfn := filepath.Join(b.dir, block.IndexHeaderFilename)
+	if b.indexHeader, err = block.NewBinaryIndexHeader(headerfn); err == nil {
+		return nil
+	}
+	if !os.IsNotExist(errors.Cause(err)) {
+		return errors.Wrap(err, "read index header")
+	}
+
+	// Try to download the index header file from object store.
+	if err = objstore.DownloadFile(ctx, b.logger, b.bucket, b.indexHeaderFilename(), headerfn); err == nil {
+		b.indexHeader, err = block.NewBinaryIndexHeader(headerfn)
+		return errors.Wrap(err, "read index header")
+	}
+	if !b.bucket.IsObjNotFoundErr(errors.Cause(err)) {
+		return errors.Wrap(err, "download index header file")
+	}
+
+	// Fall back to the index cache file of blocks written before the index header was introduced.
 	cachefn := filepath.Join(b.dir, block.IndexCacheFilename)
-	if err = b.loadIndexCacheFileFromFile(ctx, cachefn); err == nil {
+	if b.indexHeader, err = block.NewJSONIndexHeader(b.logger, cachefn); err == nil {
 		return nil
 	}
 	if !os.IsNotExist(errors.Cause(err)) {
@@ -1149,14 +1791,14 @@ func (b *bucketBlock) loadIndexCacheFile(ctx context.Context) (err error) {
 
 	// Try to download index cache file from object store.
 	if err = objstore.DownloadFile(ctx, b.logger, b.bucket, b.indexCacheFilename(), cachefn); err == nil {
-		return b.loadIndexCacheFileFromFile(ctx, cachefn)
+		b.indexHeader, err = block.NewJSONIndexHeader(b.logger, cachefn)
+		return errors.Wrap(err, "read index cache")
 	}
-
 	if !b.bucket.IsObjNotFoundErr(errors.Cause(err)) {
 		return errors.Wrap(err, "download index cache file")
 	}
 
-	// No cache exists on disk yet, build it from the downloaded index and retry.
+	// No index header exists on disk yet, build it from the downloaded index and retry.
 	fn := filepath.Join(b.dir, block.IndexFilename)
 
 	if err := objstore.DownloadFile(ctx, b.logger, b.bucket, b.indexFilename(), fn); err != nil {
@@ -1169,16 +1811,21 @@ func (b *bucketBlock) loadIndexCacheFile(ctx context.Context) (err error) {
 		}
 	}()
 
-	if err := block.WriteIndexCache(b.logger, fn, cachefn); err != nil {
-		return errors.Wrap(err, "write index cache")
+	if err := block.WriteIndexHeader(b.logger, fn, headerfn); err != nil {
+		return errors.Wrap(err, "write index header")
 	}
 
-	return errors.Wrap(b.loadIndexCacheFileFromFile(ctx, cachefn), "read index cache")
+	b.indexHeader, err = block.NewBinaryIndexHeader(headerfn)
+	return errors.Wrap(err, "read index header")
 }
 
-func (b *bucketBlock) loadIndexCacheFileFromFile(ctx context.Context, cache string) (err error) {
-	b.indexVersion, b.symbols, b.lvals, b.postings, err = block.ReadIndexCache(b.logger, cache)
-	return err
+// removeIndexHeaderFiles removes the local index header and index cache files of the block.
+func (b *bucketBlock) removeIndexHeaderFiles() {
+	for _, fn := range []string{block.IndexHeaderFilename, block.IndexCacheFilename} {
+		if err := os.Remove(filepath.Join(b.dir, fn)); err != nil && !os.IsNotExist(err) {
+			level.Warn(b.logger).Log("msg", "failed to remove local index file", "path", filepath.Join(b.dir, fn), "err", err)
+		}
+	}
 }
 
 func (b *bucketBlock) readIndexRange(ctx context.Context, off, length int64) ([]byte, error) {
@@ -1215,20 +1862,26 @@ func (b *bucketBlock) readChunkRange(ctx context.Context, seq int, off, length i
 	return &internalBuf, nil
 }
 
+// indexReader returns a new index reader of the block. The block must be loaded before it is used.
+// Every query of the block opens exactly one index reader, which is counted as a query of the block.
 func (b *bucketBlock) indexReader(ctx context.Context) *bucketIndexReader {
-	b.pendingReaders.Add(1)
+	atomic.AddUint64(&b.queries, 1)
+	b.acquire()
 	return newBucketIndexReader(ctx, b.logger, b, b.indexCache)
 }
 
+// chunkReader returns a new chunk reader of the block. The block must be loaded before it is used.
 func (b *bucketBlock) chunkReader(ctx context.Context) *bucketChunkReader {
-	b.pendingReaders.Add(1)
+	b.acquire()
 	return newBucketChunkReader(ctx, b)
 }
 
 // Close waits for all pending readers to finish and then closes all underlying resources.
 func (b *bucketBlock) Close() error {
 	b.pendingReaders.Wait()
-	return nil
+
+	_, err := b.unload()
+	return err
 }
 
 // bucketIndexReader is a custom index reader (not conforming index.Reader interface) that gets postings
@@ -1239,13 +1892,13 @@ type bucketIndexReader struct {
 	block  *bucketBlock
 	dec    *index.Decoder
 	stats  *queryStats
-	cache  indexCache
+	cache  storecache.IndexCache
 
 	mtx          sync.Mutex
 	loadedSeries map[uint64][]byte
 }
 
-func newBucketIndexReader(ctx context.Context, logger log.Logger, block *bucketBlock, cache indexCache) *bucketIndexReader {
+func newBucketIndexReader(ctx context.Context, logger log.Logger, block *bucketBlock, cache storecache.IndexCache) *bucketIndexReader {
 	r := &bucketIndexReader{
 		logger:       logger,
 		ctx:          ctx,
@@ -1260,9 +1913,9 @@ func newBucketIndexReader(ctx context.Context, logger log.Logger, block *bucketB
 }
 
 func (r *bucketIndexReader) lookupSymbol(o uint32) (string, error) {
-	s, ok := r.block.symbols[o]
-	if !ok {
-		return "", errors.Errorf("bucketIndexReader: unknown symbol offset %d", o)
+	s, err := r.block.indexHeader.LookupSymbol(o)
+	if err != nil {
+		return "", errors.Wrap(err, "bucketIndexReader")
 	}
 	return s, nil
 }
@@ -1276,7 +1929,7 @@ func (r *bucketIndexReader) lookupSymbol(o uint32) (string, error) {
 // Reminder: A posting is a reference (represented as a uint64) to a series reference, which in turn points to the first
 // chunk where the series contains the matching label-value pair for a given block of data. Postings can be fetched by
 // single label name=value.
-func (r *bucketIndexReader) ExpandedPostings(ms []labels.Matcher) ([]uint64, error) {
+func (r *bucketIndexReader) ExpandedPostings(ms []labels.Matcher, bytesLimiter *AccumulatingLimiter) ([]uint64, error) {
 	var postingGroups []*postingGroup
 
 	// NOTE: Derived from tsdb.PostingsForMatchers.
@@ -1289,7 +1942,7 @@ func (r *bucketIndexReader) ExpandedPostings(ms []labels.Matcher) ([]uint64, err
 		return nil, nil
 	}
 
-	if err := r.fetchPostings(postingGroups); err != nil {
+	if err := r.fetchPostings(postingGroups, bytesLimiter); err != nil {
 		return nil, errors.Wrap(err, "get postings")
 	}
 
@@ -1305,7 +1958,7 @@ func (r *bucketIndexReader) ExpandedPostings(ms []labels.Matcher) ([]uint64, err
 
 	// As of version two all series entries are 16 byte padded. All references
 	// we get have to account for that to get the correct offset.
-	if r.block.indexVersion >= 2 {
+	if r.block.indexHeader.IndexVersion() >= 2 {
 		for i, id := range ps {
 			ps[i] = id * 16
 		}
@@ -1367,6 +2020,15 @@ func toPostingGroup(lvalsFn func(name string) []string, m labels.Matcher) *posti
 		allName, allValue := index.AllPostingsKey()
 
 		matchingLabels = append(matchingLabels, labels.Label{Name: allName, Value: allValue})
+		if nm, ok := m.(*labels.NotMatcher); ok {
+			if sm, ok := nm.Matcher.(*setMatcher); ok {
+				// Values not in this block have no postings and are skipped when fetching.
+				for _, val := range sm.Values() {
+					matchingLabels = append(matchingLabels, labels.Label{Name: m.Name(), Value: val})
+				}
+				return newPostingGroup(matchingLabels, allWithout)
+			}
+		}
 		for _, val := range lvalsFn(m.Name()) {
 			if !m.Matches(val) {
 				matchingLabels = append(matchingLabels, labels.Label{Name: m.Name(), Value: val})
@@ -1383,9 +2045,27 @@ func toPostingGroup(lvalsFn func(name string) []string, m labels.Matcher) *posti
 		return newPostingGroup(matchingLabels, allWithout)
 	}
 
-	// Fast-path for equal matching.
-	if em, ok := m.(*labels.EqualMatcher); ok {
-		return newPostingGroup(labels.Labels{{Name: em.Name(), Value: em.Value()}}, merge)
+	switch tm := m.(type) {
+	case *labels.EqualMatcher:
+		// Fast-path for equal matching.
+		return newPostingGroup(labels.Labels{{Name: tm.Name(), Value: tm.Value()}}, merge)
+
+	case *setMatcher:
+		// Fast-path for regexes matching a set of values, they are looked up directly.
+		for _, val := range tm.Values() {
+			matchingLabels = append(matchingLabels, labels.Label{Name: tm.Name(), Value: val})
+		}
+		return newPostingGroup(matchingLabels, merge)
+
+	case *prefixMatcher:
+		// Label values are sorted, only the ones starting with the prefix need to be checked.
+		vals := lvalsFn(tm.Name())
+		for i := sort.SearchStrings(vals, tm.Prefix()); i < len(vals) && strings.HasPrefix(vals[i], tm.Prefix()); i++ {
+			if tm.Matches(vals[i]) {
+				matchingLabels = append(matchingLabels, labels.Label{Name: tm.Name(), Value: vals[i]})
+			}
+		}
+		return newPostingGroup(matchingLabels, merge)
 	}
 
 	for _, val := range lvalsFn(m.Name()) {
@@ -1404,16 +2084,23 @@ type postingPtr struct {
 }
 
 // fetchPostings fill postings requested by posting groups.
-func (r *bucketIndexReader) fetchPostings(groups []*postingGroup) error {
+func (r *bucketIndexReader) fetchPostings(groups []*postingGroup, bytesLimiter *AccumulatingLimiter) error {
 	var ptrs []postingPtr
 
-	// Iterate over all groups and fetch posting from cache.
-	// If we have a miss, mark key to be fetched in `ptrs` slice.
+	// Fetch postings of all groups from the cache with a single request.
 	// Overlaps are well handled by partitioner, so we don't need to deduplicate keys.
+	var keys []labels.Label
+	for _, g := range groups {
+		keys = append(keys, g.keys...)
+	}
+	fromCache, _ := r.cache.FetchMultiPostings(r.ctx, r.block.meta.ULID, keys)
+
+	// Iterate over all groups and use postings from cache.
+	// If we have a miss, mark key to be fetched in `ptrs` slice.
 	for i, g := range groups {
 		for j, key := range g.keys {
 			// Get postings for the given key from cache first.
-			if b, ok := r.cache.Postings(r.block.meta.ULID, key); ok {
+			if b, ok := fromCache[key]; ok {
 				r.stats.postingsTouched++
 				r.stats.postingsTouchedSizeSum += len(b)
 
@@ -1426,7 +2113,7 @@ func (r *bucketIndexReader) fetchPostings(groups []*postingGroup) error {
 			}
 
 			// Cache miss; save pointer for actual posting in index stored in object store.
-			ptr, ok := r.block.postings[key]
+			ptr, ok := r.block.indexHeader.PostingsOffset(key.Name, key.Value)
 			if !ok {
 				// This block does not have any posting for given key.
 				g.Fill(j, index.EmptyPostings())
@@ -1448,6 +2135,14 @@ func (r *bucketIndexReader) fetchPostings(groups []*postingGroup) error {
 		return uint64(ptrs[i].ptr.Start), uint64(ptrs[i].ptr.End)
 	})
 
+	var fetchSize uint64
+	for _, part := range parts {
+		fetchSize += part.end - part.start
+	}
+	if err := bytesLimiter.Reserve(fetchSize); err != nil {
+		return errors.Wrap(err, "exceeded postings bytes limit")
+	}
+
 	var g run.Group
 	for _, part := range parts {
 		ctx, cancel := context.WithCancel(r.ctx)
@@ -1505,16 +2200,12 @@ func (r *bucketIndexReader) fetchPostings(groups []*postingGroup) error {
 func (r *bucketIndexReader) PreloadSeries(ids []uint64) error {
 	const maxSeriesSize = 64 * 1024
 
-	var newIDs []uint64
-
-	for _, id := range ids {
-		if b, ok := r.cache.Series(r.block.meta.ULID, id); ok {
-			r.loadedSeries[id] = b
-			continue
-		}
-		newIDs = append(newIDs, id)
+	// Load series from cache, overwriting the list of ids to preload
+	// with the missing ones.
+	fromCache, ids := r.cache.FetchMultiSeries(r.ctx, r.block.meta.ULID, ids)
+	for id, b := range fromCache {
+		r.loadedSeries[id] = b
 	}
-	ids = newIDs
 
 	parts := r.block.partitioner.Partition(len(ids), func(i int) (start, end uint64) {
 		return ids[i], ids[i] + maxSeriesSize
@@ -1637,22 +2328,17 @@ func (r *bucketIndexReader) LoadedSeries(ref uint64, lset *labels.Labels, chks *
 
 // LabelValues returns label values for single name.
 func (r *bucketIndexReader) LabelValues(name string) []string {
-	res := make([]string, 0, len(r.block.lvals[name]))
-	return append(res, r.block.lvals[name]...)
+	return r.block.indexHeader.LabelValues(name)
 }
 
 // LabelNames returns a list of label names.
 func (r *bucketIndexReader) LabelNames() []string {
-	res := make([]string, 0, len(r.block.lvals))
-	for ln := range r.block.lvals {
-		res = append(res, ln)
-	}
-	return res
+	return r.block.indexHeader.LabelNames()
 }
 
 // Close released the underlying resources of the reader.
 func (r *bucketIndexReader) Close() error {
-	r.block.pendingReaders.Done()
+	r.block.release()
 	return nil
 }
 
@@ -1671,11 +2357,10 @@ type bucketChunkReader struct {
 
 func newBucketChunkReader(ctx context.Context, block *bucketBlock) *bucketChunkReader {
 	return &bucketChunkReader{
-		ctx:      ctx,
-		block:    block,
-		stats:    &queryStats{},
-		preloads: make([][]uint32, len(block.chunkObjs)),
-		chunks:   map[uint64]chunkenc.Chunk{},
+		ctx:    ctx,
+		block:  block,
+		stats:  &queryStats{},
+		chunks: map[uint64]chunkenc.Chunk{},
 	}
 }
 
@@ -1685,6 +2370,10 @@ func (r *bucketChunkReader) addPreload(id uint64) error {
 		seq = int(id >> 32)
 		off = uint32(id)
 	)
+	// Chunk files are known only once the block is loaded, which may happen after the reader is created.
+	if r.preloads == nil {
+		r.preloads = make([][]uint32, len(r.block.chunkObjs))
+	}
 	if seq >= len(r.preloads) {
 		return errors.Errorf("reference sequence %d out of range", seq)
 	}
@@ -1693,7 +2382,7 @@ func (r *bucketChunkReader) addPreload(id uint64) error {
 }
 
 // preload all added chunk IDs. Must be called before the first call to Chunk is made.
-func (r *bucketChunkReader) preload(samplesLimiter *Limiter) error {
+func (r *bucketChunkReader) preload(samplesLimiter *Limiter, bytesLimiter *AccumulatingLimiter) error {
 	const maxChunkSize = 16000
 
 	var g run.Group
@@ -1708,18 +2397,33 @@ func (r *bucketChunkReader) preload(samplesLimiter *Limiter) error {
 		return errors.Wrap(err, "exceeded samples limit")
 	}
 
+	if r.block.chunksCache != nil {
+		r.loadCachedChunks()
+	}
+
+	// Partition all chunk files first, so that the limit is checked before fetching anything.
+	partsBySeq := make([][]part, len(r.preloads))
+	var fetchSize uint64
 	for seq, offsets := range r.preloads {
 		sort.Slice(offsets, func(i, j int) bool {
 			return offsets[i] < offsets[j]
 		})
-		parts := r.block.partitioner.Partition(len(offsets), func(i int) (start, end uint64) {
+		partsBySeq[seq] = r.block.partitioner.Partition(len(offsets), func(i int) (start, end uint64) {
 			return uint64(offsets[i]), uint64(offsets[i]) + maxChunkSize
 		})
+		for _, p := range partsBySeq[seq] {
+			fetchSize += p.end - p.start
+		}
+	}
+	if err := bytesLimiter.Reserve(fetchSize); err != nil {
+		return errors.Wrap(err, "exceeded chunk bytes limit")
+	}
 
+	for seq, offsets := range r.preloads {
 		seq := seq
 		offsets := offsets
 
-		for _, p := range parts {
+		for _, p := range partsBySeq[seq] {
 			ctx, cancel := context.WithCancel(r.ctx)
 			s, e := uint32(p.start), uint32(p.end)
 			m, n := p.elemRng[0], p.elemRng[1]
@@ -1736,6 +2440,38 @@ func (r *bucketChunkReader) preload(samplesLimiter *Limiter) error {
 	return g.Run()
 }
 
+// loadCachedChunks loads all chunks available in the chunks cache and removes them from the preloads.
+func (r *bucketChunkReader) loadCachedChunks() {
+	var refs []uint64
+	for seq, offsets := range r.preloads {
+		for _, o := range offsets {
+			refs = append(refs, uint64(seq<<32)|uint64(o))
+		}
+	}
+	if len(refs) == 0 {
+		return
+	}
+
+	hits, _ := r.block.chunksCache.FetchMultiChunks(r.ctx, r.block.meta.ULID, refs)
+	if len(hits) == 0 {
+		return
+	}
+	for ref, c := range hits {
+		r.chunks[ref] = rawChunk(c)
+	}
+	r.stats.chunksCacheHits += len(hits)
+
+	for seq, offsets := range r.preloads {
+		missing := offsets[:0]
+		for _, o := range offsets {
+			if _, ok := hits[uint64(seq<<32)|uint64(o)]; !ok {
+				missing = append(missing, o)
+			}
+		}
+		r.preloads[seq] = missing
+	}
+}
+
 func (r *bucketChunkReader) loadChunks(ctx context.Context, offs []uint32, seq int, start, end uint32) error {
 	begin := time.Now()
 
@@ -1765,6 +2501,10 @@ func (r *bucketChunkReader) loadChunks(ctx context.Context, offs []uint32, seq i
 		}
 		cid := uint64(seq<<32) | uint64(o)
 		r.chunks[cid] = rawChunk(cb[n : n+int(l)+1])
+
+		if r.block.chunksCache != nil {
+			r.block.chunksCache.SetChunk(r.block.meta.ULID, cid, cb[n:n+int(l)+1])
+		}
 	}
 	return nil
 }
@@ -1807,7 +2547,7 @@ func (b rawChunk) NumSamples() int {
 }
 
 func (r *bucketChunkReader) Close() error {
-	r.block.pendingReaders.Done()
+	r.block.release()
 
 	for _, b := range r.chunkBytes {
 		r.block.chunkPool.Put(b)
@@ -1839,6 +2579,7 @@ type queryStats struct {
 	chunksFetchedSizeSum   int
 	chunksFetchCount       int
 	chunksFetchDurationSum time.Duration
+	chunksCacheHits        int
 
 	getAllDuration    time.Duration
 	mergedSeriesCount int
@@ -1869,6 +2610,7 @@ func (s queryStats) merge(o *queryStats) *queryStats {
 	s.chunksFetchedSizeSum += o.chunksFetchedSizeSum
 	s.chunksFetchCount += o.chunksFetchCount
 	s.chunksFetchDurationSum += o.chunksFetchDurationSum
+	s.chunksCacheHits += o.chunksCacheHits
 
 	s.getAllDuration += o.getAllDuration
 	s.mergedSeriesCount += o.mergedSeriesCount
@@ -1877,3 +2619,37 @@ func (s queryStats) merge(o *queryStats) *queryStats {
 
 	return &s
 }
+
+// toProto converts the statistics into their StoreAPI representation.
+func (s queryStats) toProto() *storepb.SeriesStats {
+	return &storepb.SeriesStats{
+		BlocksQueried: int64(s.blocksQueried),
+
+		PostingsTouched:          int64(s.postingsTouched),
+		PostingsTouchedSizeBytes: int64(s.postingsTouchedSizeSum),
+		PostingsFetched:          int64(s.postingsFetched),
+		PostingsFetchedSizeBytes: int64(s.postingsFetchedSizeSum),
+		PostingsFetchCount:       int64(s.postingsFetchCount),
+		PostingsFetchDurationNs:  int64(s.postingsFetchDurationSum),
+
+		SeriesTouched:          int64(s.seriesTouched),
+		SeriesTouchedSizeBytes: int64(s.seriesTouchedSizeSum),
+		SeriesFetched:          int64(s.seriesFetched),
+		SeriesFetchedSizeBytes: int64(s.seriesFetchedSizeSum),
+		SeriesFetchCount:       int64(s.seriesFetchCount),
+		SeriesFetchDurationNs:  int64(s.seriesFetchDurationSum),
+
+		ChunksTouched:          int64(s.chunksTouched),
+		ChunksTouchedSizeBytes: int64(s.chunksTouchedSizeSum),
+		ChunksFetched:          int64(s.chunksFetched),
+		ChunksFetchedSizeBytes: int64(s.chunksFetchedSizeSum),
+		ChunksFetchCount:       int64(s.chunksFetchCount),
+		ChunksFetchDurationNs:  int64(s.chunksFetchDurationSum),
+		ChunksCacheHits:        int64(s.chunksCacheHits),
+
+		GetAllDurationNs:  int64(s.getAllDuration),
+		MergedSeriesCount: int64(s.mergedSeriesCount),
+		MergedChunksCount: int64(s.mergedChunksCount),
+		MergeDurationNs:   int64(s.mergeDuration),
+	}
+}
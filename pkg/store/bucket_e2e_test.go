@@ -17,6 +17,7 @@ import (
 	"github.com/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/cacheutil"
 	"github.com/thanos-io/thanos/pkg/objstore"
 	"github.com/thanos-io/thanos/pkg/objstore/objtesting"
 	"github.com/thanos-io/thanos/pkg/runutil"
@@ -27,16 +28,21 @@ import (
 
 type noopCache struct{}
 
-func (noopCache) SetPostings(b ulid.ULID, l labels.Label, v []byte)   {}
-func (noopCache) Postings(b ulid.ULID, l labels.Label) ([]byte, bool) { return nil, false }
-func (noopCache) SetSeries(b ulid.ULID, id uint64, v []byte)          {}
-func (noopCache) Series(b ulid.ULID, id uint64) ([]byte, bool)        { return nil, false }
+func (noopCache) SetPostings(b ulid.ULID, l labels.Label, v []byte) {}
+func (noopCache) FetchMultiPostings(_ context.Context, _ ulid.ULID, keys []labels.Label) (map[labels.Label][]byte, []labels.Label) {
+	return map[labels.Label][]byte{}, keys
+}
+
+func (noopCache) SetSeries(b ulid.ULID, id uint64, v []byte) {}
+func (noopCache) FetchMultiSeries(_ context.Context, _ ulid.ULID, ids []uint64) (map[uint64][]byte, []uint64) {
+	return map[uint64][]byte{}, ids
+}
 
 type swappableCache struct {
-	ptr indexCache
+	ptr storecache.IndexCache
 }
 
-func (c *swappableCache) SwapWith(ptr2 indexCache) {
+func (c *swappableCache) SwapWith(ptr2 storecache.IndexCache) {
 	c.ptr = ptr2
 }
 
@@ -44,16 +50,39 @@ func (c *swappableCache) SetPostings(b ulid.ULID, l labels.Label, v []byte) {
 	c.ptr.SetPostings(b, l, v)
 }
 
-func (c *swappableCache) Postings(b ulid.ULID, l labels.Label) ([]byte, bool) {
-	return c.ptr.Postings(b, l)
+func (c *swappableCache) FetchMultiPostings(ctx context.Context, b ulid.ULID, keys []labels.Label) (map[labels.Label][]byte, []labels.Label) {
+	return c.ptr.FetchMultiPostings(ctx, b, keys)
 }
 
 func (c *swappableCache) SetSeries(b ulid.ULID, id uint64, v []byte) {
 	c.ptr.SetSeries(b, id, v)
 }
 
-func (c *swappableCache) Series(b ulid.ULID, id uint64) ([]byte, bool) {
-	return c.ptr.Series(b, id)
+func (c *swappableCache) FetchMultiSeries(ctx context.Context, b ulid.ULID, ids []uint64) (map[uint64][]byte, []uint64) {
+	return c.ptr.FetchMultiSeries(ctx, b, ids)
+}
+
+type noopChunksCache struct{}
+
+func (noopChunksCache) SetChunk(b ulid.ULID, ref uint64, v []byte) {}
+func (noopChunksCache) FetchMultiChunks(_ context.Context, _ ulid.ULID, refs []uint64) (map[uint64][]byte, []uint64) {
+	return map[uint64][]byte{}, refs
+}
+
+type swappableChunksCache struct {
+	ptr storecache.ChunksCache
+}
+
+func (c *swappableChunksCache) SwapWith(ptr2 storecache.ChunksCache) {
+	c.ptr = ptr2
+}
+
+func (c *swappableChunksCache) SetChunk(b ulid.ULID, ref uint64, v []byte) {
+	c.ptr.SetChunk(b, ref, v)
+}
+
+func (c *swappableChunksCache) FetchMultiChunks(ctx context.Context, b ulid.ULID, refs []uint64) (map[uint64][]byte, []uint64) {
+	return c.ptr.FetchMultiChunks(ctx, b, refs)
 }
 
 type storeSuite struct {
@@ -63,6 +92,7 @@ type storeSuite struct {
 	store            *BucketStore
 	minTime, maxTime int64
 	cache            *swappableCache
+	chunksCache      *swappableChunksCache
 
 	logger log.Logger
 }
@@ -90,9 +120,10 @@ func prepareStoreWithTestBlocks(t testing.TB, dir string, bkt objstore.Bucket, m
 
 	ctx, cancel := context.WithCancel(context.Background())
 	s := &storeSuite{
-		cancel: cancel,
-		logger: log.NewLogfmtLogger(os.Stderr),
-		cache:  &swappableCache{},
+		cancel:      cancel,
+		logger:      log.NewLogfmtLogger(os.Stderr),
+		cache:       &swappableCache{},
+		chunksCache: &swappableChunksCache{ptr: noopChunksCache{}},
 	}
 	blocks := 0
 	for i := 0; i < 3; i++ {
@@ -128,7 +159,7 @@ func prepareStoreWithTestBlocks(t testing.TB, dir string, bkt objstore.Bucket, m
 		testutil.Ok(t, os.RemoveAll(dir2))
 	}
 
-	store, err := NewBucketStore(s.logger, nil, bkt, dir, s.cache, 0, maxSampleCount, 20, false, 20)
+	store, err := NewBucketStore(s.logger, nil, bkt, dir, s.cache, 0, maxSampleCount, 20, false, 20, BucketStoreOpts{ChunksCache: s.chunksCache})
 	testutil.Ok(t, err)
 
 	s.store = store
@@ -162,6 +193,55 @@ func prepareStoreWithTestBlocks(t testing.TB, dir string, bkt objstore.Bucket, m
 	return s
 }
 
+// testBlock describes a block uploaded by prepareBucketStore. Each series gets 10 samples spread over the time range.
+type testBlock struct {
+	series     []labels.Labels
+	mint, maxt int64
+	extLset    labels.Labels
+}
+
+// bucketStoreFixture is a BucketStore serving blocks from a bucket, with all local files in a temporary directory.
+// Blocks are created in the "blocks" subdirectory and the store uses the "store" one.
+type bucketStoreFixture struct {
+	store *BucketStore
+	bkt   objstore.Bucket
+	dir   string
+	// ids are the IDs of the blocks uploaded by prepareBucketStore, in order.
+	ids []ulid.ULID
+}
+
+func (f *bucketStoreFixture) Close(t testing.TB) {
+	testutil.Ok(t, f.store.Close())
+	testutil.Ok(t, os.RemoveAll(f.dir))
+}
+
+// prepareBucketStore uploads the given blocks to bkt and returns a fixture with a store created with opts
+// over them, synced once.
+func prepareBucketStore(t testing.TB, bkt objstore.Bucket, blocks []testBlock, opts BucketStoreOpts) *bucketStoreFixture {
+	dir, err := ioutil.TempDir("", "bucketstore-test")
+	testutil.Ok(t, err)
+
+	f := &bucketStoreFixture{bkt: bkt, dir: dir}
+	for _, b := range blocks {
+		f.ids = append(f.ids, uploadTestBlock(t, bkt, filepath.Join(dir, "blocks"), b))
+	}
+
+	f.store, err = NewBucketStore(nil, nil, bkt, filepath.Join(dir, "store"), noopCache{}, 0, 0, 20, false, 2, opts)
+	testutil.Ok(t, err)
+	testutil.Ok(t, f.store.SyncBlocks(context.Background()))
+	return f
+}
+
+// uploadTestBlock creates the given block in dir and uploads it to bkt.
+func uploadTestBlock(t testing.TB, bkt objstore.Bucket, dir string, b testBlock) ulid.ULID {
+	ctx := context.Background()
+
+	id, err := testutil.CreateBlock(ctx, dir, b.series, 10, b.mint, b.maxt, b.extLset, 0)
+	testutil.Ok(t, err)
+	testutil.Ok(t, block.Upload(ctx, log.NewNopLogger(), bkt, filepath.Join(dir, id.String())))
+	return id
+}
+
 func testBucketStore_e2e(t testing.TB, ctx context.Context, s *storeSuite) {
 	mint, maxt := s.store.TimeRange()
 	testutil.Equals(t, s.minTime, mint)
@@ -379,7 +459,7 @@ func TestBucketStore_e2e(t *testing.T) {
 		testBucketStore_e2e(t, ctx, s)
 
 		t.Log("Test with large, sufficient index cache")
-		indexCache, err := storecache.NewIndexCache(s.logger, nil, storecache.Opts{
+		indexCache, err := storecache.NewInMemoryIndexCache(s.logger, nil, storecache.Opts{
 			MaxItemSizeBytes: 1e5,
 			MaxSizeBytes:     2e5,
 		})
@@ -388,13 +468,55 @@ func TestBucketStore_e2e(t *testing.T) {
 		testBucketStore_e2e(t, ctx, s)
 
 		t.Log("Test with small index cache")
-		indexCache2, err := storecache.NewIndexCache(s.logger, nil, storecache.Opts{
+		indexCache2, err := storecache.NewInMemoryIndexCache(s.logger, nil, storecache.Opts{
 			MaxItemSizeBytes: 50,
 			MaxSizeBytes:     100,
 		})
 		testutil.Ok(t, err)
 		s.cache.SwapWith(indexCache2)
 		testBucketStore_e2e(t, ctx, s)
+
+		t.Log("Test with memcached index cache")
+		memcachedServer, err := testutil.NewFakeMemcachedServer()
+		testutil.Ok(t, err)
+		defer func() { testutil.Ok(t, memcachedServer.Close()) }()
+
+		memcached, err := cacheutil.NewMemcachedClientWithConfig(s.logger, "test", cacheutil.MemcachedClientConfig{
+			Addresses:            []string{memcachedServer.Addr()},
+			Timeout:              time.Second,
+			MaxIdleConnections:   10,
+			MaxAsyncConcurrency:  10,
+			MaxAsyncBufferSize:   10000,
+			MaxGetMultiBatchSize: 10,
+		}, nil)
+		testutil.Ok(t, err)
+		defer memcached.Stop()
+
+		indexCache3, err := storecache.NewMemcachedIndexCache(s.logger, memcached, nil)
+		testutil.Ok(t, err)
+		s.cache.SwapWith(indexCache3)
+		testBucketStore_e2e(t, ctx, s)
+		// Run again, now reading from the warm cache.
+		testBucketStore_e2e(t, ctx, s)
+		testutil.Assert(t, len(memcachedServer.Items()) > 0, "expected items stored in memcached")
+
+		t.Log("Test with chunks cache")
+		chunksCache, err := storecache.NewInMemoryChunksCache(s.logger, nil, storecache.Opts{
+			MaxItemSizeBytes: 1e5,
+			MaxSizeBytes:     1e6,
+		})
+		testutil.Ok(t, err)
+		s.chunksCache.SwapWith(chunksCache)
+		testBucketStore_e2e(t, ctx, s)
+
+		t.Log("Test with small chunks cache")
+		chunksCache2, err := storecache.NewInMemoryChunksCache(s.logger, nil, storecache.Opts{
+			MaxItemSizeBytes: 50,
+			MaxSizeBytes:     100,
+		})
+		testutil.Ok(t, err)
+		s.chunksCache.SwapWith(chunksCache2)
+		testBucketStore_e2e(t, ctx, s)
 	})
 }
 
@@ -423,7 +545,7 @@ func TestBucketStore_ManyParts_e2e(t *testing.T) {
 		s := prepareStoreWithTestBlocks(t, dir, bkt, true, 0)
 		defer s.Close()
 
-		indexCache, err := storecache.NewIndexCache(s.logger, nil, storecache.Opts{
+		indexCache, err := storecache.NewInMemoryIndexCache(s.logger, nil, storecache.Opts{
 			MaxItemSizeBytes: 1e5,
 			MaxSizeBytes:     2e5,
 		})
@@ -2,8 +2,13 @@ package store
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,11 +17,22 @@ import (
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
 	"github.com/oklog/ulid"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/pkg/relabel"
+	"github.com/prometheus/prometheus/pkg/timestamp"
+	"github.com/prometheus/tsdb/fileutil"
 	"github.com/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/compact/downsample"
+	"github.com/thanos-io/thanos/pkg/model"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	yaml "gopkg.in/yaml.v2"
 )
 
 func TestBucketBlock_Property(t *testing.T) {
@@ -407,6 +423,117 @@ func TestGapBasedPartitioner_Partition(t *testing.T) {
 	}
 }
 
+func TestFindSetMatches(t *testing.T) {
+	for _, c := range []struct {
+		pattern  string
+		expected []string
+	}{
+		{pattern: "foo", expected: []string{"foo"}},
+		{pattern: "foo|bar|baz", expected: []string{"bar", "baz", "foo"}},
+		{pattern: "a|b|c", expected: []string{"a", "b", "c"}},
+		{pattern: "api-(eu|us)-[12]", expected: []string{"api-eu-1", "api-eu-2", "api-us-1", "api-us-2"}},
+		{pattern: "foo|foobar|foo", expected: []string{"foo", "foobar"}},
+		{pattern: "foo|", expected: []string{"", "foo"}},
+		{pattern: "foo.*"},
+		{pattern: "foo.+|bar"},
+		{pattern: "(?i)foo"},
+		{pattern: "[a-z]+"},
+		{pattern: "[^a]"},
+		{pattern: "(a|b|c|d)(a|b|c|d)(a|b|c|d)(a|b|c|d)(a|b|c|d)"},
+		{pattern: "(foo"},
+	} {
+		t.Run(c.pattern, func(t *testing.T) {
+			testutil.Equals(t, c.expected, findSetMatches(c.pattern))
+		})
+	}
+}
+
+func TestToPostingGroup_RegexpMatchers(t *testing.T) {
+	vals := []string{"bar", "baz", "foo", "foo1", "foo2", "foobar", "qux"}
+	lvalsFn := func(string) []string { return vals }
+	exists := map[string]bool{}
+	for _, v := range vals {
+		exists[v] = true
+	}
+
+	for _, c := range []struct {
+		m        storepb.LabelMatcher
+		expected interface{}
+	}{
+		{m: storepb.LabelMatcher{Type: storepb.LabelMatcher_RE, Name: "n", Value: "foo|bar|missing"}, expected: &setMatcher{}},
+		{m: storepb.LabelMatcher{Type: storepb.LabelMatcher_RE, Name: "n", Value: "foo.*"}, expected: &prefixMatcher{}},
+		{m: storepb.LabelMatcher{Type: storepb.LabelMatcher_RE, Name: "n", Value: "foo[0-9]+"}, expected: &prefixMatcher{}},
+		{m: storepb.LabelMatcher{Type: storepb.LabelMatcher_RE, Name: "n", Value: "zzz.*"}, expected: &prefixMatcher{}},
+		{m: storepb.LabelMatcher{Type: storepb.LabelMatcher_RE, Name: "n", Value: ".*o.*"}},
+		{m: storepb.LabelMatcher{Type: storepb.LabelMatcher_RE, Name: "n", Value: "foo|"}, expected: &setMatcher{}},
+		{m: storepb.LabelMatcher{Type: storepb.LabelMatcher_NRE, Name: "n", Value: "foo|bar|missing"}},
+		{m: storepb.LabelMatcher{Type: storepb.LabelMatcher_NRE, Name: "n", Value: "foo.*"}},
+	} {
+		t.Run(c.m.String(), func(t *testing.T) {
+			m, err := translateMatcher(c.m)
+			testutil.Ok(t, err)
+			if c.expected != nil {
+				testutil.Equals(t, fmt.Sprintf("%T", c.expected), fmt.Sprintf("%T", m))
+			}
+
+			plain, err := labels.NewRegexpMatcher(c.m.Name, "^(?:"+c.m.Value+")$")
+			testutil.Ok(t, err)
+			if c.m.Type == storepb.LabelMatcher_NRE {
+				plain = labels.Not(plain)
+			}
+			expected := toPostingGroup(lvalsFn, plain)
+
+			// Looked up values which are not present have no postings and do not change the result.
+			var keys labels.Labels
+			for _, k := range toPostingGroup(lvalsFn, m).keys {
+				if k.Name != c.m.Name || exists[k.Value] {
+					keys = append(keys, k)
+				}
+			}
+			testutil.Equals(t, expected.keys, keys)
+		})
+	}
+}
+
+func BenchmarkToPostingGroup(b *testing.B) {
+	var vals []string
+	for i := 0; i < 100000; i++ {
+		vals = append(vals, fmt.Sprintf("value-%06d", i))
+	}
+	sort.Strings(vals)
+	lvalsFn := func(string) []string { return vals }
+
+	for _, c := range []struct {
+		name    string
+		matcher storepb.LabelMatcher
+	}{
+		{name: "set", matcher: storepb.LabelMatcher{Type: storepb.LabelMatcher_RE, Name: "n", Value: "value-000001|value-050000|value-099999"}},
+		{name: "prefix", matcher: storepb.LabelMatcher{Type: storepb.LabelMatcher_RE, Name: "n", Value: "value-0000.*"}},
+		{name: "not-set", matcher: storepb.LabelMatcher{Type: storepb.LabelMatcher_NRE, Name: "n", Value: "value-000001|value-050000|value-099999"}},
+	} {
+		optimized, err := translateMatcher(c.matcher)
+		testutil.Ok(b, err)
+		plain, err := labels.NewRegexpMatcher(c.matcher.Name, "^(?:"+c.matcher.Value+")$")
+		testutil.Ok(b, err)
+		if c.matcher.Type == storepb.LabelMatcher_NRE {
+			plain = labels.Not(plain)
+		}
+
+		b.Run(c.name+"/regexp", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				toPostingGroup(lvalsFn, plain)
+			}
+		})
+		b.Run(c.name+"/optimized", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				toPostingGroup(lvalsFn, optimized)
+			}
+		})
+	}
+}
+
 func TestBucketStore_Info(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
@@ -416,7 +543,7 @@ func TestBucketStore_Info(t *testing.T) {
 	dir, err := ioutil.TempDir("", "prometheus-test")
 	testutil.Ok(t, err)
 
-	bucketStore, err := NewBucketStore(nil, nil, nil, dir, noopCache{}, 2e5, 0, 0, false, 20)
+	bucketStore, err := NewBucketStore(nil, nil, nil, dir, noopCache{}, 2e5, 0, 0, false, 20, BucketStoreOpts{})
 	testutil.Ok(t, err)
 
 	resp, err := bucketStore.Info(ctx, &storepb.InfoRequest{})
@@ -426,3 +553,566 @@ func TestBucketStore_Info(t *testing.T) {
 	testutil.Equals(t, int64(math.MaxInt64), resp.MinTime)
 	testutil.Equals(t, int64(math.MinInt64), resp.MaxTime)
 }
+
+func TestBucketStore_SyncBlocks_FaultyBucket(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inner := inmem.NewBucket()
+	bkt := objstore.BucketWithFaults(inner, objstore.Faults{}, 0)
+	f := prepareBucketStore(t, bkt, nil, BucketStoreOpts{})
+	defer f.Close(t)
+	store := f.store
+
+	series := []labels.Labels{labels.FromStrings("a", "1"), labels.FromStrings("a", "2")}
+	extLset := labels.FromStrings("ext1", "value1")
+	blocksDir := filepath.Join(f.dir, "blocks")
+	uploadTestBlock(t, inner, blocksDir, testBlock{series: series, mint: 0, maxt: 1000, extLset: extLset})
+	uploadTestBlock(t, inner, blocksDir, testBlock{series: series, mint: 1000, maxt: 2000, extLset: extLset})
+
+	// Blocks that cannot be downloaded fully are not loaded and do not leave partial files behind.
+	bkt.SetFaults(objstore.Faults{TruncatedReadProbability: 1})
+	testutil.Ok(t, store.SyncBlocks(ctx))
+	testutil.Equals(t, 0, store.numBlocks())
+	names, err := fileutil.ReadDir(store.dir)
+	testutil.Ok(t, err)
+	testutil.Equals(t, 0, len(names))
+
+	// Blocks are loaded once the bucket recovers.
+	bkt.SetFaults(objstore.Faults{})
+	testutil.Ok(t, store.SyncBlocks(ctx))
+	testutil.Equals(t, 2, store.numBlocks())
+
+	// Loaded blocks are not dropped if the bucket cannot be listed.
+	bkt.SetFaults(objstore.Faults{Match: func(op, _ string) bool { return op == objstore.OpIter }, ErrorProbability: 1})
+	testutil.NotOk(t, store.SyncBlocks(ctx))
+	testutil.Equals(t, 2, store.numBlocks())
+
+	// Newly uploaded blocks are loaded once they become visible.
+	bkt.SetFaults(objstore.Faults{IterVisibilityDelay: 100 * time.Millisecond})
+	uploadTestBlock(t, bkt, blocksDir, testBlock{series: series, mint: 2000, maxt: 3000, extLset: extLset})
+	testutil.Ok(t, store.SyncBlocks(ctx))
+	testutil.Equals(t, 2, store.numBlocks())
+
+	time.Sleep(100 * time.Millisecond)
+	testutil.Ok(t, store.SyncBlocks(ctx))
+	testutil.Equals(t, 3, store.numBlocks())
+}
+
+func TestBucketStore_LazyBlockLoading(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	series := []labels.Labels{labels.FromStrings("a", "1"), labels.FromStrings("a", "2")}
+	extLset := labels.FromStrings("ext1", "value1")
+	f := prepareBucketStore(t, inmem.NewBucket(), []testBlock{
+		{series: series, mint: 0, maxt: 1000, extLset: extLset},
+		{series: series, mint: 1000, maxt: 2000, extLset: extLset},
+	}, BucketStoreOpts{LazyBlockLoading: true, BlockIdleTimeout: time.Millisecond})
+	defer f.Close(t)
+	store := f.store
+
+	// Synced blocks are known but not loaded.
+	testutil.Equals(t, 2, store.numBlocks())
+	testutil.Equals(t, float64(2), promtest.ToFloat64(store.metrics.blocksKnown))
+	testutil.Equals(t, float64(0), promtest.ToFloat64(store.metrics.blocksLoaded))
+
+	// Only the block matching the query time range is loaded.
+	srv := newStoreSeriesServer(ctx)
+	testutil.Ok(t, store.Series(&storepb.SeriesRequest{
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "1"}},
+		MinTime:  0,
+		MaxTime:  500,
+	}, srv))
+	testutil.Equals(t, 1, len(srv.SeriesSet))
+	testutil.Equals(t, float64(1), promtest.ToFloat64(store.metrics.blocksLoaded))
+	testutil.Equals(t, float64(1), promtest.ToFloat64(store.metrics.blockLazyLoads))
+
+	// Label values load all blocks.
+	resp, err := store.LabelValues(ctx, &storepb.LabelValuesRequest{Label: "a"})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"1", "2"}, resp.Values)
+	testutil.Equals(t, float64(2), promtest.ToFloat64(store.metrics.blocksLoaded))
+	testutil.Equals(t, float64(2), promtest.ToFloat64(store.metrics.blockLazyLoads))
+
+	// Idle blocks are unloaded on sync and loaded again on their next query.
+	time.Sleep(10 * time.Millisecond)
+	testutil.Ok(t, store.SyncBlocks(ctx))
+	testutil.Equals(t, float64(0), promtest.ToFloat64(store.metrics.blocksLoaded))
+	testutil.Equals(t, float64(2), promtest.ToFloat64(store.metrics.blockUnloads))
+	testutil.Equals(t, float64(2), promtest.ToFloat64(store.metrics.blocksKnown))
+
+	srv = newStoreSeriesServer(ctx)
+	testutil.Ok(t, store.Series(&storepb.SeriesRequest{
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "2"}},
+		MinTime:  0,
+		MaxTime:  2000,
+	}, srv))
+	testutil.Equals(t, 1, len(srv.SeriesSet))
+	testutil.Equals(t, 2, len(srv.SeriesSet[0].Chunks))
+	testutil.Equals(t, float64(2), promtest.ToFloat64(store.metrics.blocksLoaded))
+	testutil.Equals(t, float64(4), promtest.ToFloat64(store.metrics.blockLazyLoads))
+}
+
+func TestBucketStore_LazyBlockLoading_CorruptedIndexHeader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f := prepareBucketStore(t, inmem.NewBucket(), []testBlock{
+		{series: []labels.Labels{labels.FromStrings("a", "1")}, mint: 0, maxt: 1000, extLset: labels.FromStrings("ext1", "value1")},
+	}, BucketStoreOpts{LazyBlockLoading: true})
+	defer f.Close(t)
+	store := f.store
+
+	headerFn := filepath.Join(store.dir, f.ids[0].String(), block.IndexHeaderFilename)
+	testutil.Ok(t, ioutil.WriteFile(headerFn, []byte("corrupted"), 0666))
+
+	req := &storepb.SeriesRequest{
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "1"}},
+		MinTime:  0,
+		MaxTime:  1000,
+	}
+	testutil.NotOk(t, store.Series(req, newStoreSeriesServer(ctx)))
+	testutil.Equals(t, float64(1), promtest.ToFloat64(store.metrics.blockLazyLoadFailures))
+
+	// The corrupted index header is removed on failure and built again by the next load.
+	_, err := os.Stat(headerFn)
+	testutil.Assert(t, os.IsNotExist(err), "expected corrupted index header to be removed")
+
+	srv := newStoreSeriesServer(ctx)
+	testutil.Ok(t, store.Series(req, srv))
+	testutil.Equals(t, 1, len(srv.SeriesSet))
+	testutil.Equals(t, float64(1), promtest.ToFloat64(store.metrics.blockLazyLoads))
+}
+
+func TestBucketStore_LabelNamesAndValues_Filtering(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f := prepareBucketStore(t, inmem.NewBucket(), []testBlock{
+		{
+			series:  []labels.Labels{labels.FromStrings("a", "1", "b", "1"), labels.FromStrings("a", "2", "c", "1")},
+			mint:    0,
+			maxt:    1000,
+			extLset: labels.FromStrings("ext1", "value1"),
+		},
+		{
+			series:  []labels.Labels{labels.FromStrings("a", "3", "d", "1")},
+			mint:    1000,
+			maxt:    2000,
+			extLset: labels.FromStrings("ext1", "value2"),
+		},
+	}, BucketStoreOpts{})
+	defer f.Close(t)
+	store := f.store
+
+	for _, tcase := range []struct {
+		name           string
+		start, end     int64
+		matchers       []storepb.LabelMatcher
+		expectedNames  []string
+		expectedValues []string
+	}{
+		{
+			name:           "no filters",
+			expectedNames:  []string{"a", "b", "c", "d"},
+			expectedValues: []string{"1", "2", "3"},
+		},
+		{
+			name:           "time range",
+			start:          1000,
+			end:            1999,
+			expectedNames:  []string{"a", "d"},
+			expectedValues: []string{"3"},
+		},
+		{
+			name:           "matchers",
+			matchers:       []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "b", Value: "1"}},
+			expectedNames:  []string{"a", "b"},
+			expectedValues: []string{"1"},
+		},
+		{
+			name:           "external label matchers",
+			matchers:       []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "ext1", Value: "value2"}},
+			expectedNames:  []string{"a", "d"},
+			expectedValues: []string{"3"},
+		},
+		{
+			name:           "time range and matchers",
+			start:          0,
+			end:            999,
+			matchers:       []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: "2|3"}},
+			expectedNames:  []string{"a", "c"},
+			expectedValues: []string{"2"},
+		},
+		{
+			name:     "no match",
+			matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "ext1", Value: "value3"}},
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			names, err := store.LabelNames(ctx, &storepb.LabelNamesRequest{Start: tcase.start, End: tcase.end, Matchers: tcase.matchers})
+			testutil.Ok(t, err)
+			testutil.Equals(t, tcase.expectedNames, names.Names)
+
+			vals, err := store.LabelValues(ctx, &storepb.LabelValuesRequest{Label: "a", Start: tcase.start, End: tcase.end, Matchers: tcase.matchers})
+			testutil.Ok(t, err)
+			testutil.Equals(t, tcase.expectedValues, vals.Values)
+		})
+	}
+}
+
+func TestBucketStore_TimeFilter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	minTime, maxTime := timestamp.Time(1500), timestamp.Time(2500)
+	filterConfig := &FilterConfig{
+		MinTime: model.TimeOrDurationValue{Time: &minTime},
+		MaxTime: model.TimeOrDurationValue{Time: &maxTime},
+	}
+	series := []labels.Labels{labels.FromStrings("a", "1")}
+	extLset := labels.FromStrings("ext1", "value1")
+	f := prepareBucketStore(t, inmem.NewBucket(), []testBlock{
+		{series: series, mint: 0, maxt: 1000, extLset: extLset},
+		{series: series, mint: 1000, maxt: 2000, extLset: extLset},
+		{series: series, mint: 2000, maxt: 3000, extLset: extLset},
+	}, BucketStoreOpts{FilterConfig: filterConfig})
+	defer f.Close(t)
+	store := f.store
+
+	// Only blocks overlapping the time range are synced and the advertised time range is limited to it.
+	testutil.Equals(t, 2, store.numBlocks())
+
+	resp, err := store.Info(ctx, &storepb.InfoRequest{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, int64(1500), resp.MinTime)
+	testutil.Equals(t, int64(2500), resp.MaxTime)
+
+	// Known blocks no longer overlapping the time range are dropped.
+	maxTime = timestamp.Time(2000)
+	testutil.Ok(t, store.SyncBlocks(ctx))
+	testutil.Equals(t, 1, store.numBlocks())
+
+	mint, maxt := store.TimeRange()
+	testutil.Equals(t, int64(1500), mint)
+	testutil.Equals(t, int64(2000), maxt)
+}
+
+func TestBucketStore_LabelAndShardFilter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var relabelConfig []*relabel.Config
+	testutil.Ok(t, yaml.Unmarshal([]byte(`
+- action: keep
+  source_labels: [cluster]
+  regex: a
+`), &relabelConfig))
+
+	var blocks []testBlock
+	series := []labels.Labels{labels.FromStrings("a", "1")}
+	for i := int64(0); i < 8; i++ {
+		for _, cluster := range []string{"a", "b"} {
+			blocks = append(blocks, testBlock{series: series, mint: i * 1000, maxt: (i + 1) * 1000, extLset: labels.FromStrings("cluster", cluster)})
+		}
+	}
+
+	// Blocks not kept by the relabel config are not served and their label sets are not advertised.
+	f := prepareBucketStore(t, inmem.NewBucket(), blocks, BucketStoreOpts{FilterConfig: &FilterConfig{
+		RelabelConfig: relabelConfig,
+	}})
+	defer f.Close(t)
+	store := f.store
+
+	testutil.Equals(t, 8, store.numBlocks())
+
+	resp, err := store.Info(ctx, &storepb.InfoRequest{})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []storepb.LabelSet{{Labels: []storepb.Label{{Name: "cluster", Value: "a"}}}}, resp.LabelSets)
+
+	// Shards serve disjoint sets of blocks covering the whole bucket.
+	served := map[ulid.ULID]struct{}{}
+	for i := uint64(0); i < 2; i++ {
+		shard, err := NewBucketStore(nil, nil, f.bkt, filepath.Join(f.dir, fmt.Sprintf("shard-%d", i)), noopCache{}, 0, 0, 20, false, 2, BucketStoreOpts{FilterConfig: &FilterConfig{
+			ShardIndex: i,
+			ShardTotal: 2,
+		}})
+		testutil.Ok(t, err)
+		testutil.Ok(t, shard.SyncBlocks(ctx))
+
+		testutil.Assert(t, shard.numBlocks() > 0 && shard.numBlocks() < 16, "unexpected number of blocks in shard %d: %d", i, shard.numBlocks())
+		for id := range shard.blocks {
+			_, ok := served[id]
+			testutil.Assert(t, !ok, "block %s served by multiple shards", id)
+			served[id] = struct{}{}
+		}
+		testutil.Ok(t, shard.Close())
+	}
+	testutil.Equals(t, 16, len(served))
+}
+
+func TestBucketStore_SeriesLimits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	series := []labels.Labels{labels.FromStrings("a", "1"), labels.FromStrings("a", "2")}
+	extLset := labels.FromStrings("ext1", "value1")
+	f := prepareBucketStore(t, inmem.NewBucket(), []testBlock{
+		{series: series, mint: 0, maxt: 1000, extLset: extLset},
+		{series: series, mint: 1000, maxt: 2000, extLset: extLset},
+	}, BucketStoreOpts{})
+	defer f.Close(t)
+	store := f.store
+
+	req := &storepb.SeriesRequest{
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: ".+"}},
+		MinTime:  0,
+		MaxTime:  2000,
+	}
+	for _, tcase := range []struct {
+		limits      SeriesLimits
+		maxSamples  uint64
+		exceeded    string
+		expectedErr string
+		dropped     float64
+	}{
+		{
+			limits: SeriesLimits{MaxSeries: 4, MaxPostingsBytes: 1e6, MaxChunkBytes: 1e6},
+		},
+		{
+			limits:      SeriesLimits{MaxSeries: 3},
+			exceeded:    limitSeries,
+			expectedErr: "exceeded series limit: limit 3 violated (got 4)",
+			dropped:     1,
+		},
+		{
+			limits:      SeriesLimits{MaxPostingsBytes: 1},
+			exceeded:    limitPostingsBytes,
+			expectedErr: "exceeded postings bytes limit: limit 1 violated",
+			dropped:     1,
+		},
+		{
+			limits:      SeriesLimits{MaxChunkBytes: 1},
+			exceeded:    limitChunkBytes,
+			expectedErr: "exceeded chunk bytes limit: limit 1 violated",
+			dropped:     1,
+		},
+		{
+			maxSamples:  1,
+			exceeded:    limitSamples,
+			expectedErr: "exceeded samples limit: limit 1 violated",
+			// The samples limit is checked for each block separately.
+			dropped: 2,
+		},
+	} {
+		store.seriesLimits = tcase.limits
+		store.samplesLimiter = NewLimiter(tcase.maxSamples, store.metrics.queriesDropped.WithLabelValues(limitSamples))
+
+		srv := newStoreSeriesServer(ctx)
+		err := store.Series(req, srv)
+		if tcase.exceeded == "" {
+			testutil.Ok(t, err)
+			testutil.Equals(t, 2, len(srv.SeriesSet))
+			continue
+		}
+		testutil.NotOk(t, err)
+		testutil.Equals(t, codes.ResourceExhausted, status.Code(err))
+		testutil.Assert(t, strings.Contains(err.Error(), tcase.expectedErr), "unexpected error: %v", err)
+		testutil.Equals(t, tcase.dropped, promtest.ToFloat64(store.metrics.queriesDropped.WithLabelValues(tcase.exceeded)))
+	}
+}
+
+func TestBucketStore_SeriesQueryStats(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	series := []labels.Labels{labels.FromStrings("a", "1"), labels.FromStrings("a", "2")}
+	extLset := labels.FromStrings("ext1", "value1")
+	f := prepareBucketStore(t, inmem.NewBucket(), []testBlock{
+		{series: series, mint: 0, maxt: 1000, extLset: extLset},
+		{series: series, mint: 1000, maxt: 2000, extLset: extLset},
+	}, BucketStoreOpts{})
+	defer f.Close(t)
+	store := f.store
+
+	req := &storepb.SeriesRequest{
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: ".+"}},
+		MinTime:  0,
+		MaxTime:  2000,
+	}
+
+	// Stats are sent only if requested.
+	srv := newStoreSeriesServer(ctx)
+	testutil.Ok(t, store.Series(req, srv))
+	testutil.Equals(t, 2, len(srv.SeriesSet))
+	testutil.Assert(t, srv.Stats == nil, "unexpected stats frame")
+
+	req.QueryStats = true
+	srv = newStoreSeriesServer(ctx)
+	testutil.Ok(t, store.Series(req, srv))
+	testutil.Equals(t, 2, len(srv.SeriesSet))
+	testutil.Assert(t, srv.Stats != nil, "expected stats frame")
+	testutil.Equals(t, int64(2), srv.Stats.BlocksQueried)
+	testutil.Equals(t, int64(4), srv.Stats.SeriesTouched)
+	testutil.Equals(t, int64(2), srv.Stats.MergedSeriesCount)
+	testutil.Assert(t, srv.Stats.ChunksFetched > 0, "expected fetched chunks")
+}
+
+func TestBucketStore_BlockAdmin(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	series := []labels.Labels{labels.FromStrings("a", "1"), labels.FromStrings("a", "2")}
+	extLset := labels.FromStrings("ext1", "value1")
+	f := prepareBucketStore(t, inmem.NewBucket(), []testBlock{
+		{series: series, mint: 0, maxt: 1000, extLset: extLset},
+		{series: series, mint: 1000, maxt: 2000, extLset: extLset},
+	}, BucketStoreOpts{})
+	defer f.Close(t)
+	store := f.store
+	ids := f.ids
+
+	blocks := store.Blocks()
+	testutil.Equals(t, 2, len(blocks))
+	for i, b := range blocks {
+		testutil.Equals(t, ids[i], b.ULID)
+		testutil.Equals(t, labels.FromStrings("ext1", "value1"), b.Labels)
+		testutil.Assert(t, b.Loaded, "expected block %s to be loaded", b.ULID)
+		testutil.Assert(t, b.IndexHeaderSize > 0, "expected index header size of block %s", b.ULID)
+		testutil.Equals(t, uint64(0), b.Queries)
+	}
+
+	// Only the blocks touched by a query count it.
+	srv := newStoreSeriesServer(ctx)
+	testutil.Ok(t, store.Series(&storepb.SeriesRequest{
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "1"}},
+		MinTime:  1500,
+		MaxTime:  2000,
+	}, srv))
+	blocks = store.Blocks()
+	testutil.Equals(t, uint64(0), blocks[0].Queries)
+	testutil.Equals(t, uint64(1), blocks[1].Queries)
+
+	// Dropped blocks are not synced again until they are resynced.
+	testutil.Ok(t, store.DropBlock(ids[0]))
+	testutil.Ok(t, store.SyncBlocks(ctx))
+	blocks = store.Blocks()
+	testutil.Equals(t, 1, len(blocks))
+	testutil.Equals(t, ids[1], blocks[0].ULID)
+	_, err := os.Stat(filepath.Join(store.dir, ids[0].String()))
+	testutil.Assert(t, os.IsNotExist(err), "expected local files of dropped block to be removed")
+
+	testutil.Ok(t, store.ResyncBlock(ctx, ids[0]))
+	testutil.Ok(t, store.ResyncBlock(ctx, ids[1]))
+	blocks = store.Blocks()
+	testutil.Equals(t, 2, len(blocks))
+	for _, b := range blocks {
+		testutil.Assert(t, b.Loaded, "expected block %s to be loaded", b.ULID)
+		testutil.Equals(t, uint64(0), b.Queries)
+	}
+
+	unknown := ulid.MustNew(1, nil)
+	testutil.Equals(t, ErrBlockNotFound, store.DropBlock(unknown))
+	testutil.Equals(t, ErrBlockNotFound, store.ResyncBlock(ctx, unknown))
+}
+
+func TestBucketStore_Series_SkipChunks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	extLset := labels.FromStrings("ext1", "value1")
+	f := prepareBucketStore(t, inmem.NewBucket(), []testBlock{
+		{series: []labels.Labels{labels.FromStrings("a", "1"), labels.FromStrings("a", "2")}, mint: 0, maxt: 1000, extLset: extLset},
+		{series: []labels.Labels{labels.FromStrings("a", "2"), labels.FromStrings("a", "3")}, mint: 1000, maxt: 2000, extLset: extLset},
+	}, BucketStoreOpts{})
+	defer f.Close(t)
+	store := f.store
+
+	for _, tcase := range []struct {
+		mint, maxt int64
+		expected   []string
+	}{
+		{mint: 0, maxt: 2000, expected: []string{"1", "2", "3"}},
+		{mint: 0, maxt: 500, expected: []string{"1", "2"}},
+		{mint: 1500, maxt: 2000, expected: []string{"2", "3"}},
+	} {
+		t.Run("", func(t *testing.T) {
+			srv := newStoreSeriesServer(ctx)
+			testutil.Ok(t, store.Series(&storepb.SeriesRequest{
+				Matchers:   []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: ".+"}},
+				MinTime:    tcase.mint,
+				MaxTime:    tcase.maxt,
+				SkipChunks: true,
+				QueryStats: true,
+			}, srv))
+
+			var got []string
+			for _, s := range srv.SeriesSet {
+				testutil.Equals(t, 0, len(s.Chunks))
+				testutil.Equals(t, []storepb.Label{{Name: "a", Value: s.Labels[0].Value}, {Name: "ext1", Value: "value1"}}, s.Labels)
+				got = append(got, s.Labels[0].Value)
+			}
+			testutil.Equals(t, tcase.expected, got)
+
+			// No chunks are read from the bucket.
+			testutil.Assert(t, srv.Stats != nil, "expected stats frame")
+			testutil.Equals(t, int64(0), srv.Stats.ChunksTouched)
+			testutil.Equals(t, int64(0), srv.Stats.ChunksFetched)
+		})
+	}
+}
+
+func TestBucketStore_Series_SplitFrames(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	extLset := labels.FromStrings("ext1", "value1")
+	f := prepareBucketStore(t, inmem.NewBucket(), []testBlock{
+		{series: []labels.Labels{labels.FromStrings("a", "1"), labels.FromStrings("a", "2")}, mint: 0, maxt: 1000, extLset: extLset},
+		{series: []labels.Labels{labels.FromStrings("a", "2"), labels.FromStrings("a", "3")}, mint: 1000, maxt: 2000, extLset: extLset},
+	}, BucketStoreOpts{})
+	defer f.Close(t)
+	store := f.store
+
+	req := &storepb.SeriesRequest{
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: ".+"}},
+		MinTime:  0,
+		MaxTime:  2000,
+	}
+
+	srv := newStoreSeriesServer(ctx)
+	testutil.Ok(t, store.Series(req, srv))
+	testutil.Equals(t, 3, len(srv.SeriesSet))
+	testutil.Equals(t, 2, len(srv.SeriesSet[1].Chunks))
+	expected := reassembleSeries(srv.SeriesSet)
+	// Chunk data is backed by pooled buffers reused by the next request.
+	for _, s := range expected {
+		for i, c := range s.Chunks {
+			s.Chunks[i].Raw = &storepb.Chunk{Type: c.Raw.Type, Data: append([]byte(nil), c.Raw.Data...)}
+		}
+	}
+
+	// Series are not split if the client does not reassemble them.
+	store.maxFrameBytes = 1
+	srv = newStoreSeriesServer(ctx)
+	testutil.Ok(t, store.Series(req, srv))
+	var chunks []int
+	for _, s := range srv.SeriesSet {
+		chunks = append(chunks, len(s.Chunks))
+	}
+	testutil.Equals(t, []int{1, 2, 1}, chunks)
+
+	// With a frame fitting a single chunk only, the series spanning both blocks is sent in two frames.
+	req.AllowSplitSeries = true
+	srv = newStoreSeriesServer(ctx)
+	testutil.Ok(t, store.Series(req, srv))
+
+	var got []string
+	for _, s := range srv.SeriesSet {
+		testutil.Equals(t, 1, len(s.Chunks))
+		got = append(got, s.Labels[0].Value)
+	}
+	testutil.Equals(t, []string{"1", "2", "2", "3"}, got)
+	testutil.Equals(t, expected, reassembleSeries(srv.SeriesSet))
+}
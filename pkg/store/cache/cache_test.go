@@ -18,11 +18,11 @@ import (
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
 
-func TestIndexCache_AvoidsDeadlock(t *testing.T) {
+func TestInMemoryIndexCache_AvoidsDeadlock(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
 	metrics := prometheus.NewRegistry()
-	cache, err := NewIndexCache(log.NewNopLogger(), metrics, Opts{
+	cache, err := NewInMemoryIndexCache(log.NewNopLogger(), metrics, Opts{
 		MaxItemSizeBytes: sliceHeaderSize + 5,
 		MaxSizeBytes:     sliceHeaderSize + 5,
 	})
@@ -51,7 +51,7 @@ func TestIndexCache_AvoidsDeadlock(t *testing.T) {
 	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.current.WithLabelValues(cacheTypePostings)))
 }
 
-func TestIndexCache_UpdateItem(t *testing.T) {
+func TestInMemoryIndexCache_UpdateItem(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
 	const maxSize = 2 * (sliceHeaderSize + 1)
@@ -74,7 +74,7 @@ func TestIndexCache_UpdateItem(t *testing.T) {
 	})
 
 	metrics := prometheus.NewRegistry()
-	cache, err := NewIndexCache(log.NewSyncLogger(errorLogger), metrics, Opts{
+	cache, err := NewInMemoryIndexCache(log.NewSyncLogger(errorLogger), metrics, Opts{
 		MaxItemSizeBytes: maxSize,
 		MaxSizeBytes:     maxSize,
 	})
@@ -145,11 +145,11 @@ func TestIndexCache_UpdateItem(t *testing.T) {
 }
 
 // This should not happen as we hardcode math.MaxInt, but we still add test to check this out.
-func TestIndexCache_MaxNumberOfItemsHit(t *testing.T) {
+func TestInMemoryIndexCache_MaxNumberOfItemsHit(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
 	metrics := prometheus.NewRegistry()
-	cache, err := NewIndexCache(log.NewNopLogger(), metrics, Opts{
+	cache, err := NewInMemoryIndexCache(log.NewNopLogger(), metrics, Opts{
 		MaxItemSizeBytes: 2*sliceHeaderSize + 10,
 		MaxSizeBytes:     2*sliceHeaderSize + 10,
 	})
@@ -178,11 +178,11 @@ func TestIndexCache_MaxNumberOfItemsHit(t *testing.T) {
 	testutil.Equals(t, float64(0), promtest.ToFloat64(cache.hits.WithLabelValues(cacheTypeSeries)))
 }
 
-func TestIndexCache_Eviction_WithMetrics(t *testing.T) {
+func TestInMemoryIndexCache_Eviction_WithMetrics(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
 	metrics := prometheus.NewRegistry()
-	cache, err := NewIndexCache(log.NewNopLogger(), metrics, Opts{
+	cache, err := NewInMemoryIndexCache(log.NewNopLogger(), metrics, Opts{
 		MaxItemSizeBytes: 2*sliceHeaderSize + 5,
 		MaxSizeBytes:     2*sliceHeaderSize + 5,
 	})
@@ -0,0 +1,396 @@
+package storecache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	lru "github.com/hashicorp/golang-lru/simplelru"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+)
+
+const cachingBucketTmpSuffix = ".tmp"
+
+// CachingBucketOpts configures the CachingBucket.
+type CachingBucketOpts struct {
+	// MaxSizeBytes represents overall maximum number of bytes the cache can hold on disk.
+	MaxSizeBytes uint64
+	// SubrangeSize is the size of aligned sub-ranges objects are fetched and cached in.
+	SubrangeSize int64
+}
+
+// CachingBucket is a objstore.BucketReader that caches byte ranges read via GetRange in a local directory.
+// Objects are split into aligned sub-ranges of SubrangeSize bytes, which are fetched from the underlying bucket
+// on first use and evicted from disk in LRU order when the cache exceeds MaxSizeBytes. All other operations
+// are passed to the underlying bucket as-is.
+// NOTE: It assumes that objects are immutable, which holds for all block files.
+type CachingBucket struct {
+	objstore.BucketReader
+
+	logger       log.Logger
+	dir          string
+	maxSizeBytes uint64
+	subrangeSize int64
+
+	mtx     sync.Mutex
+	lru     *lru.LRU
+	curSize uint64
+
+	requestedBytes prometheus.Counter
+	fetchedBytes   prometheus.Counter
+	requests       prometheus.Counter
+	hits           prometheus.Counter
+	added          prometheus.Counter
+	evicted        prometheus.Counter
+	current        prometheus.Gauge
+	currentSize    prometheus.Gauge
+	errors         prometheus.Counter
+}
+
+// NewCachingBucket creates a new CachingBucket that stores cached sub-ranges in the given directory.
+// Sub-ranges cached by a previous run in the same directory are reused.
+func NewCachingBucket(logger log.Logger, reg prometheus.Registerer, bkt objstore.BucketReader, dir string, opts CachingBucketOpts) (*CachingBucket, error) {
+	if opts.SubrangeSize <= 0 {
+		return nil, errors.Errorf("subrange size has to be positive, got %v", opts.SubrangeSize)
+	}
+	if uint64(opts.SubrangeSize) > opts.MaxSizeBytes {
+		return nil, errors.Errorf("subrange size (%v) cannot be bigger than overall cache size (%v)", opts.SubrangeSize, opts.MaxSizeBytes)
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, errors.Wrap(err, "create cache dir")
+	}
+
+	c := &CachingBucket{
+		BucketReader: bkt,
+		logger:       logger,
+		dir:          dir,
+		maxSizeBytes: opts.MaxSizeBytes,
+		subrangeSize: opts.SubrangeSize,
+	}
+
+	c.requestedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_bucket_cache_requested_bytes_total",
+		Help: "Total number of bytes requested via GetRange from the caching bucket.",
+	})
+	c.fetchedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_bucket_cache_fetched_bytes_total",
+		Help: "Total number of bytes fetched from the underlying bucket because of cache misses.",
+	})
+	c.requests = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_bucket_cache_requests_total",
+		Help: "Total number of sub-ranges requested from the bucket cache.",
+	})
+	c.hits = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_bucket_cache_hits_total",
+		Help: "Total number of sub-ranges requested from the bucket cache that were a hit.",
+	})
+	c.added = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_bucket_cache_items_added_total",
+		Help: "Total number of sub-ranges that were added to the bucket cache.",
+	})
+	c.evicted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_bucket_cache_items_evicted_total",
+		Help: "Total number of sub-ranges that were evicted from the bucket cache.",
+	})
+	c.current = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "thanos_store_bucket_cache_items",
+		Help: "Current number of sub-ranges in the bucket cache.",
+	})
+	c.currentSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "thanos_store_bucket_cache_items_size_bytes",
+		Help: "Current byte size of sub-ranges in the bucket cache.",
+	})
+	c.errors = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_bucket_cache_errors_total",
+		Help: "Total number of failed reads and writes of the bucket cache directory. Failures fall back to the underlying bucket.",
+	})
+
+	if reg != nil {
+		reg.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "thanos_store_bucket_cache_max_size_bytes",
+			Help: "Maximum number of bytes to be held in the bucket cache.",
+		}, func() float64 {
+			return float64(c.maxSizeBytes)
+		}))
+		reg.MustRegister(c.requestedBytes, c.fetchedBytes, c.requests, c.hits, c.added, c.evicted, c.current, c.currentSize, c.errors)
+	}
+
+	// Initialize LRU cache with a high size limit since we will manage evictions ourselves
+	// based on stored size using `RemoveOldest` method.
+	l, err := lru.NewLRU(math.MaxInt64, c.onEvict)
+	if err != nil {
+		return nil, err
+	}
+	c.lru = l
+
+	if err := c.loadDir(); err != nil {
+		return nil, errors.Wrap(err, "load cache dir")
+	}
+
+	level.Info(logger).Log(
+		"msg", "created bucket cache",
+		"dir", dir,
+		"maxSizeBytes", c.maxSizeBytes,
+		"subrangeSize", c.subrangeSize,
+		"items", c.lru.Len(),
+		"sizeBytes", c.curSize,
+	)
+	return c, nil
+}
+
+type cachedFile struct {
+	path    string
+	size    uint64
+	modTime time.Time
+}
+
+// loadDir adds all sub-ranges found in the cache directory to the LRU, oldest first.
+func (c *CachingBucket) loadDir() error {
+	var files []cachedFile
+	if err := filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(path, cachingBucketTmpSuffix) {
+			// Leftover of an interrupted write.
+			return os.Remove(path)
+		}
+		files = append(files, cachedFile{path: path, size: uint64(info.Size()), modTime: info.ModTime()})
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for _, f := range files {
+		c.add(f.path, f.size)
+	}
+	return nil
+}
+
+func (c *CachingBucket) onEvict(key, val interface{}) {
+	size := val.(uint64)
+
+	if err := os.Remove(key.(string)); err != nil && !os.IsNotExist(err) {
+		level.Warn(c.logger).Log("msg", "failed to remove evicted sub-range", "path", key, "err", err)
+		c.errors.Inc()
+	}
+	c.evicted.Inc()
+	c.current.Dec()
+	c.currentSize.Sub(float64(size))
+	c.curSize -= size
+}
+
+// add registers the sub-range written to the given path in the LRU, evicting older ones if needed.
+// It has to be called with mtx held.
+func (c *CachingBucket) add(path string, size uint64) {
+	if _, ok := c.lru.Get(path); ok {
+		return
+	}
+	for c.curSize+size > c.maxSizeBytes {
+		if _, _, ok := c.lru.RemoveOldest(); !ok {
+			break
+		}
+	}
+	c.lru.Add(path, size)
+
+	c.added.Inc()
+	c.current.Inc()
+	c.currentSize.Add(float64(size))
+	c.curSize += size
+}
+
+// subrangePath returns the path of the cached sub-range of the given object starting at the given offset.
+// Object names are hashed, so any name maps to a valid file without clashing with other objects.
+func (c *CachingBucket) subrangePath(name string, start int64) string {
+	h := sha256.Sum256([]byte(name))
+	key := hex.EncodeToString(h[:])
+	return filepath.Join(c.dir, key[:2], key+"-"+strconv.FormatInt(start, 10))
+}
+
+// GetRange returns a new range reader for the given object name and range. The range is served from
+// cached sub-ranges where possible and missing ones are fetched from the underlying bucket with a single request.
+// Reads with negative length are passed to the underlying bucket.
+func (c *CachingBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	if off < 0 || length <= 0 {
+		return c.BucketReader.GetRange(ctx, name, off, length)
+	}
+	c.requestedBytes.Add(float64(length))
+
+	var (
+		first = off / c.subrangeSize * c.subrangeSize
+		end   = off + length
+	)
+
+	subranges, ok := c.cached(name, first, end)
+	if !ok {
+		var err error
+		subranges, err = c.fetch(ctx, name, first, end)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	buf := make([]byte, 0, length)
+	for i, sub := range subranges {
+		start := first + int64(i)*c.subrangeSize
+		lo, hi := int64(0), int64(len(sub))
+		if off > start {
+			lo = off - start
+		}
+		if end < start+hi {
+			hi = end - start
+		}
+		if lo >= hi {
+			break
+		}
+		buf = append(buf, sub[lo:hi]...)
+	}
+	return ioutil.NopCloser(bytes.NewReader(buf)), nil
+}
+
+// cached returns the sub-ranges covering [first, end) if all of them are in the cache.
+// A sub-range shorter than the subrange size marks the end of the object.
+func (c *CachingBucket) cached(name string, first, end int64) ([][]byte, bool) {
+	var subranges [][]byte
+	for start := first; start < end; start += c.subrangeSize {
+		c.requests.Inc()
+
+		path := c.subrangePath(name, start)
+
+		c.mtx.Lock()
+		_, ok := c.lru.Get(path)
+		c.mtx.Unlock()
+		if !ok {
+			return nil, false
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				level.Warn(c.logger).Log("msg", "failed to read cached sub-range", "path", path, "err", err)
+				c.errors.Inc()
+			}
+			return nil, false
+		}
+		c.hits.Inc()
+
+		subranges = append(subranges, b)
+		if int64(len(b)) < c.subrangeSize {
+			break
+		}
+	}
+	return subranges, true
+}
+
+// fetch reads the sub-ranges covering [first, end) from the underlying bucket and stores them in the cache.
+// The request always starts with the first sub-range, which contains the requested offset, so it is valid as
+// long as the original range is valid.
+func (c *CachingBucket) fetch(ctx context.Context, name string, first, end int64) ([][]byte, error) {
+	last := (end - 1) / c.subrangeSize * c.subrangeSize
+	length := last + c.subrangeSize - first
+
+	rc, err := c.BucketReader.GetRange(ctx, name, first, length)
+	if err != nil {
+		// Not wrapped, so the underlying IsObjNotFoundErr still recognizes it.
+		return nil, err
+	}
+	defer runutil.CloseWithLogOnErr(c.logger, rc, "caching bucket range reader")
+
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read range of %s", name)
+	}
+	c.fetchedBytes.Add(float64(len(b)))
+
+	var subranges [][]byte
+	for start := first; start <= last; start += c.subrangeSize {
+		i := start - first
+		if i > int64(len(b)) {
+			break
+		}
+		sub := b[i:]
+		if int64(len(sub)) > c.subrangeSize {
+			sub = sub[:c.subrangeSize]
+		}
+		// Storing an empty sub-range when the object ends exactly on the boundary records the end of the object.
+		c.store(c.subrangePath(name, start), sub)
+
+		subranges = append(subranges, sub)
+		if int64(len(sub)) < c.subrangeSize {
+			break
+		}
+	}
+	return subranges, nil
+}
+
+// store atomically writes the sub-range to the given path and adds it to the cache. Failures are only logged,
+// as the data was already fetched.
+func (c *CachingBucket) store(path string, b []byte) {
+	c.mtx.Lock()
+	_, ok := c.lru.Get(path)
+	c.mtx.Unlock()
+	if ok {
+		return
+	}
+
+	if err := writeFileAtomic(path, b); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to write sub-range to cache", "path", path, "err", err)
+		c.errors.Inc()
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.add(path, uint64(len(b)))
+}
+
+func writeFileAtomic(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*"+cachingBucketTmpSuffix)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package storecache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/objstore"
+	"github.com/thanos-io/thanos/pkg/objstore/inmem"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+// countingBucket counts GetRange calls reaching the wrapped bucket.
+type countingBucket struct {
+	objstore.BucketReader
+	getRangeCalls int
+}
+
+func (b *countingBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	b.getRangeCalls++
+	return b.BucketReader.GetRange(ctx, name, off, length)
+}
+
+func readRange(t *testing.T, bkt objstore.BucketReader, name string, off, length int64) []byte {
+	rc, err := bkt.GetRange(context.Background(), name, off, length)
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, rc.Close()) }()
+
+	b, err := ioutil.ReadAll(rc)
+	testutil.Ok(t, err)
+	return b
+}
+
+func TestCachingBucket_GetRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "caching-bucket")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	inner := inmem.NewBucket()
+	content := make([]byte, 1000)
+	_, _ = rand.New(rand.NewSource(1)).Read(content)
+	testutil.Ok(t, inner.Upload(context.Background(), "obj", bytes.NewReader(content)))
+	// Object ending exactly on the sub-range boundary.
+	testutil.Ok(t, inner.Upload(context.Background(), "aligned", bytes.NewReader(content[:800])))
+
+	counting := &countingBucket{BucketReader: inner}
+	cb, err := NewCachingBucket(log.NewNopLogger(), prometheus.NewRegistry(), counting, dir, CachingBucketOpts{
+		MaxSizeBytes: 10000,
+		SubrangeSize: 100,
+	})
+	testutil.Ok(t, err)
+
+	for _, tcase := range []struct {
+		off, length   int64
+		expectedCalls int
+	}{
+		{off: 0, length: 10, expectedCalls: 1},
+		{off: 5, length: 50, expectedCalls: 0},
+		{off: 150, length: 200, expectedCalls: 1},
+		{off: 90, length: 300, expectedCalls: 0},
+		// Partially cached ranges are fetched again from the first sub-range.
+		{off: 300, length: 200, expectedCalls: 1},
+		{off: 950, length: 100, expectedCalls: 1},
+		{off: 950, length: 100, expectedCalls: 0},
+		{off: 999, length: 1, expectedCalls: 0},
+	} {
+		counting.getRangeCalls = 0
+
+		end := tcase.off + tcase.length
+		if end > int64(len(content)) {
+			end = int64(len(content))
+		}
+		testutil.Equals(t, content[tcase.off:end], readRange(t, cb, "obj", tcase.off, tcase.length), "off %d, length %d", tcase.off, tcase.length)
+		testutil.Equals(t, tcase.expectedCalls, counting.getRangeCalls, "off %d, length %d", tcase.off, tcase.length)
+	}
+
+	counting.getRangeCalls = 0
+	testutil.Equals(t, content[750:800], readRange(t, cb, "aligned", 750, 100))
+	testutil.Equals(t, content[750:800], readRange(t, cb, "aligned", 750, 100))
+	testutil.Equals(t, 1, counting.getRangeCalls)
+
+	// Missing objects are reported as such.
+	_, err = cb.GetRange(context.Background(), "missing", 0, 10)
+	testutil.NotOk(t, err)
+	testutil.Assert(t, cb.IsObjNotFoundErr(err), "expected not found error, got %v", err)
+}
+
+func TestCachingBucket_EvictionAndReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "caching-bucket")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	inner := inmem.NewBucket()
+	content := make([]byte, 1000)
+	_, _ = rand.New(rand.NewSource(1)).Read(content)
+	testutil.Ok(t, inner.Upload(context.Background(), "obj", bytes.NewReader(content)))
+
+	counting := &countingBucket{BucketReader: inner}
+	opts := CachingBucketOpts{MaxSizeBytes: 300, SubrangeSize: 100}
+
+	cb, err := NewCachingBucket(log.NewNopLogger(), nil, counting, dir, opts)
+	testutil.Ok(t, err)
+
+	for off := int64(0); off < 500; off += 100 {
+		testutil.Equals(t, content[off:off+100], readRange(t, cb, "obj", off, 100))
+	}
+	testutil.Equals(t, 5, counting.getRangeCalls)
+	testutil.Equals(t, uint64(300), cb.curSize)
+	testutil.Equals(t, 3, cb.lru.Len())
+	testutil.Equals(t, float64(2), promtest.ToFloat64(cb.evicted))
+
+	// Oldest sub-ranges were evicted from disk.
+	_, err = os.Stat(cb.subrangePath("obj", 0))
+	testutil.Assert(t, os.IsNotExist(err), "expected evicted sub-range to be removed, got %v", err)
+
+	// A new instance picks up cached sub-ranges from the directory.
+	counting.getRangeCalls = 0
+	cb, err = NewCachingBucket(log.NewNopLogger(), nil, counting, dir, opts)
+	testutil.Ok(t, err)
+	testutil.Equals(t, uint64(300), cb.curSize)
+
+	testutil.Equals(t, content[200:500], readRange(t, cb, "obj", 200, 300))
+	testutil.Equals(t, 0, counting.getRangeCalls)
+
+	testutil.Equals(t, content[0:100], readRange(t, cb, "obj", 0, 100))
+	testutil.Equals(t, 1, counting.getRangeCalls)
+}
@@ -0,0 +1,187 @@
+package storecache
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	lru "github.com/hashicorp/golang-lru/simplelru"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ChunksCache is the interface exported by chunks cache backends. Chunks are identified by the ULID of
+// their block and their reference within it, and stored as raw bytes including the encoding.
+type ChunksCache interface {
+	// SetChunk stores a single chunk. The value can be reused by the caller once the call returns.
+	SetChunk(b ulid.ULID, ref uint64, v []byte)
+
+	// FetchMultiChunks fetches multiple chunks - each identified by its reference -
+	// and returns a map containing cache hits, along with a list of missing references.
+	FetchMultiChunks(ctx context.Context, b ulid.ULID, refs []uint64) (hits map[uint64][]byte, misses []uint64)
+}
+
+type cacheKeyChunk struct {
+	block ulid.ULID
+	ref   uint64
+}
+
+// chunkKeySize is the size of cacheKeyChunk: ULID + uint64.
+const chunkKeySize = 16 + 8
+
+// InMemoryChunksCache is a ChunksCache keeping chunks in an in-process LRU.
+type InMemoryChunksCache struct {
+	mtx sync.Mutex
+
+	logger           log.Logger
+	lru              *lru.LRU
+	maxSizeBytes     uint64
+	maxItemSizeBytes uint64
+
+	curSize uint64
+
+	requests    prometheus.Counter
+	hits        prometheus.Counter
+	added       prometheus.Counter
+	evicted     prometheus.Counter
+	overflow    prometheus.Counter
+	current     prometheus.Gauge
+	currentSize prometheus.Gauge
+}
+
+// NewInMemoryChunksCache creates a new thread-safe LRU cache for chunks and ensures the total cache
+// size approximately does not exceed opts.MaxSizeBytes.
+func NewInMemoryChunksCache(logger log.Logger, reg prometheus.Registerer, opts Opts) (*InMemoryChunksCache, error) {
+	if opts.MaxItemSizeBytes > opts.MaxSizeBytes {
+		return nil, errors.Errorf("max item size (%v) cannot be bigger than overall cache size (%v)", opts.MaxItemSizeBytes, opts.MaxSizeBytes)
+	}
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+
+	c := &InMemoryChunksCache{
+		logger:           logger,
+		maxSizeBytes:     opts.MaxSizeBytes,
+		maxItemSizeBytes: opts.MaxItemSizeBytes,
+	}
+
+	c.requests = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_chunks_cache_requests_total",
+		Help: "Total number of chunks requested from the cache.",
+	})
+	c.hits = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_chunks_cache_hits_total",
+		Help: "Total number of chunks requested from the cache that were a hit.",
+	})
+	c.added = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_chunks_cache_items_added_total",
+		Help: "Total number of chunks that were added to the cache.",
+	})
+	c.evicted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_chunks_cache_items_evicted_total",
+		Help: "Total number of chunks that were evicted from the cache.",
+	})
+	c.overflow = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_chunks_cache_items_overflowed_total",
+		Help: "Total number of chunks that could not be added to the cache due to being too big.",
+	})
+	c.current = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "thanos_store_chunks_cache_items",
+		Help: "Current number of chunks in the cache.",
+	})
+	c.currentSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "thanos_store_chunks_cache_items_size_bytes",
+		Help: "Current byte size of chunks in the cache.",
+	})
+
+	if reg != nil {
+		reg.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "thanos_store_chunks_cache_max_size_bytes",
+			Help: "Maximum number of bytes to be held in the chunks cache.",
+		}, func() float64 {
+			return float64(c.maxSizeBytes)
+		}))
+		reg.MustRegister(c.requests, c.hits, c.added, c.evicted, c.overflow, c.current, c.currentSize)
+	}
+
+	// Initialize LRU cache with a high size limit since we will manage evictions ourselves
+	// based on stored size using `RemoveOldest` method.
+	l, err := lru.NewLRU(math.MaxInt64, c.onEvict)
+	if err != nil {
+		return nil, err
+	}
+	c.lru = l
+
+	level.Info(logger).Log(
+		"msg", "created chunks cache",
+		"maxItemSizeBytes", c.maxItemSizeBytes,
+		"maxSizeBytes", c.maxSizeBytes,
+	)
+	return c, nil
+}
+
+func (c *InMemoryChunksCache) onEvict(_, val interface{}) {
+	size := chunkKeySize + sliceHeaderSize + uint64(len(val.([]byte)))
+
+	c.evicted.Inc()
+	c.current.Dec()
+	c.currentSize.Sub(float64(size))
+	c.curSize -= size
+}
+
+// SetChunk stores the chunk identified by the ulid and ref. If the chunk already exists in the cache it is not mutated.
+func (c *InMemoryChunksCache) SetChunk(b ulid.ULID, ref uint64, v []byte) {
+	size := chunkKeySize + sliceHeaderSize + uint64(len(v))
+	key := cacheKeyChunk{block: b, ref: ref}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if _, ok := c.lru.Get(key); ok {
+		return
+	}
+	if size > c.maxItemSizeBytes {
+		c.overflow.Inc()
+		return
+	}
+	for c.curSize+size > c.maxSizeBytes {
+		if _, _, ok := c.lru.RemoveOldest(); !ok {
+			break
+		}
+	}
+
+	// Chunk bytes come from pooled buffers reused by the caller, copy them.
+	cv := make([]byte, len(v))
+	copy(cv, v)
+	c.lru.Add(key, cv)
+
+	c.added.Inc()
+	c.current.Inc()
+	c.currentSize.Add(float64(size))
+	c.curSize += size
+}
+
+// FetchMultiChunks fetches multiple chunks - each identified by its reference -
+// and returns a map containing cache hits, along with a list of missing references.
+func (c *InMemoryChunksCache) FetchMultiChunks(_ context.Context, b ulid.ULID, refs []uint64) (hits map[uint64][]byte, misses []uint64) {
+	hits = map[uint64][]byte{}
+
+	c.requests.Add(float64(len(refs)))
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for _, ref := range refs {
+		v, ok := c.lru.Get(cacheKeyChunk{block: b, ref: ref})
+		if !ok {
+			misses = append(misses, ref)
+			continue
+		}
+		hits[ref] = v.([]byte)
+	}
+	c.hits.Add(float64(len(hits)))
+	return hits, misses
+}
@@ -0,0 +1,126 @@
+package storecache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fortytw2/leaktest"
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/thanos-io/thanos/pkg/cacheutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestInMemoryChunksCache_Eviction(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	ctx := context.Background()
+	metrics := prometheus.NewRegistry()
+	// Room for two chunks of 10 bytes.
+	itemSize := uint64(chunkKeySize + sliceHeaderSize + 10)
+	cache, err := NewInMemoryChunksCache(log.NewNopLogger(), metrics, Opts{
+		MaxSizeBytes:     2 * itemSize,
+		MaxItemSizeBytes: itemSize,
+	})
+	testutil.Ok(t, err)
+
+	id := ulid.MustNew(0, nil)
+
+	v := []byte("0123456789")
+	cache.SetChunk(id, 1, v)
+	// Values are copied, so the caller can reuse the buffer.
+	copy(v, "aaaaaaaaaa")
+	cache.SetChunk(id, 2, v)
+
+	hits, misses := cache.FetchMultiChunks(ctx, id, []uint64{1, 2, 3})
+	testutil.Equals(t, map[uint64][]byte{1: []byte("0123456789"), 2: []byte("aaaaaaaaaa")}, hits)
+	testutil.Equals(t, []uint64{3}, misses)
+
+	// Too big items are not added.
+	cache.SetChunk(id, 3, []byte("01234567890"))
+	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.overflow))
+
+	// Adding a third chunk evicts the oldest one.
+	cache.SetChunk(id, 3, []byte("bbbbbbbbbb"))
+	hits, misses = cache.FetchMultiChunks(ctx, id, []uint64{1, 2, 3})
+	testutil.Equals(t, map[uint64][]byte{2: []byte("aaaaaaaaaa"), 3: []byte("bbbbbbbbbb")}, hits)
+	testutil.Equals(t, []uint64{1}, misses)
+
+	// Chunks are per block.
+	hits, misses = cache.FetchMultiChunks(ctx, ulid.MustNew(1, nil), []uint64{2})
+	testutil.Equals(t, 0, len(hits))
+	testutil.Equals(t, []uint64{2}, misses)
+
+	testutil.Equals(t, float64(3), promtest.ToFloat64(cache.added))
+	testutil.Equals(t, float64(1), promtest.ToFloat64(cache.evicted))
+	testutil.Equals(t, float64(2), promtest.ToFloat64(cache.current))
+	testutil.Equals(t, float64(2*itemSize), promtest.ToFloat64(cache.currentSize))
+	testutil.Equals(t, float64(7), promtest.ToFloat64(cache.requests))
+	testutil.Equals(t, float64(4), promtest.ToFloat64(cache.hits))
+}
+
+func TestMemcachedChunksCache(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	ctx := context.Background()
+	server, err := testutil.NewFakeMemcachedServer()
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, server.Close()) }()
+
+	memcached, err := cacheutil.NewMemcachedClient(log.NewNopLogger(), "test", []byte("addresses: ["+server.Addr()+"]"), nil)
+	testutil.Ok(t, err)
+
+	c, err := NewMemcachedChunksCache(log.NewNopLogger(), memcached, prometheus.NewRegistry())
+	testutil.Ok(t, err)
+
+	var (
+		b1 = ulid.MustNew(1, nil)
+		b2 = ulid.MustNew(2, nil)
+	)
+	c.SetChunk(b1, 1, []byte("chunk 1"))
+	c.SetChunk(b1, 2, []byte("chunk 2"))
+	c.SetChunk(b2, 1, []byte("chunk 3"))
+
+	// Wait for the asynchronous sets.
+	memcached.Stop()
+	testutil.Equals(t, 3, len(server.Items()))
+
+	hits, misses := c.FetchMultiChunks(ctx, b1, []uint64{1, 2, 3})
+	testutil.Equals(t, map[uint64][]byte{1: []byte("chunk 1"), 2: []byte("chunk 2")}, hits)
+	testutil.Equals(t, []uint64{3}, misses)
+
+	hits, misses = c.FetchMultiChunks(ctx, b2, []uint64{1, 2})
+	testutil.Equals(t, map[uint64][]byte{1: []byte("chunk 3")}, hits)
+	testutil.Equals(t, []uint64{2}, misses)
+
+	testutil.Equals(t, float64(5), promtest.ToFloat64(c.requests))
+	testutil.Equals(t, float64(3), promtest.ToFloat64(c.hits))
+}
+
+func TestNewChunksCache(t *testing.T) {
+	c, stop, err := NewChunksCache(log.NewNopLogger(), []byte(`
+type: in-memory
+config:
+  max_size: 1000
+  max_item_size: 100
+`), nil)
+	testutil.Ok(t, err)
+	inmem, ok := c.(*InMemoryChunksCache)
+	testutil.Assert(t, ok, "expected in-memory cache, got %T", c)
+	testutil.Equals(t, uint64(100), inmem.maxItemSizeBytes)
+	stop()
+
+	_, _, err = NewChunksCache(log.NewNopLogger(), []byte(`
+type: IN-MEMORY
+config:
+  max_size: 100
+  max_item_size: 1000
+`), nil)
+	testutil.NotOk(t, err)
+
+	_, _, err = NewChunksCache(log.NewNopLogger(), []byte(`type: REDIS`), nil)
+	testutil.NotOk(t, err)
+}
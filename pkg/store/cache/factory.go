@@ -0,0 +1,129 @@
+package storecache
+
+import (
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/thanos/pkg/cacheutil"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type CacheProvider string
+
+const (
+	INMEMORY  CacheProvider = "IN-MEMORY"
+	MEMCACHED CacheProvider = "MEMCACHED"
+)
+
+// CacheConfig specifies the config of an index or chunks cache.
+type CacheConfig struct {
+	Type   CacheProvider `yaml:"type"`
+	Config interface{}   `yaml:"config"`
+}
+
+// InMemoryCacheConfig is the config of the IN-MEMORY cache.
+type InMemoryCacheConfig struct {
+	// MaxSize is the overall maximum number of bytes the cache can contain.
+	MaxSize uint64 `yaml:"max_size"`
+	// MaxItemSize is the maximum size of a single item in bytes.
+	MaxItemSize uint64 `yaml:"max_item_size"`
+}
+
+// parseCacheConfig returns the provider type and the YAML config of the backend.
+func parseCacheConfig(confContentYaml []byte) (CacheProvider, []byte, error) {
+	cacheConfig := &CacheConfig{}
+	if err := yaml.UnmarshalStrict(confContentYaml, cacheConfig); err != nil {
+		return "", nil, errors.Wrap(err, "parsing config YAML file")
+	}
+
+	backendConfig, err := yaml.Marshal(cacheConfig.Config)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "marshal content of cache backend configuration")
+	}
+	return CacheProvider(strings.ToUpper(string(cacheConfig.Type))), backendConfig, nil
+}
+
+func parseInMemoryCacheConfig(conf []byte) (Opts, error) {
+	var config InMemoryCacheConfig
+	if err := yaml.UnmarshalStrict(conf, &config); err != nil {
+		return Opts{}, errors.Wrap(err, "parsing in-memory cache configuration")
+	}
+	return Opts{
+		MaxSizeBytes:     config.MaxSize,
+		MaxItemSizeBytes: config.MaxItemSize,
+	}, nil
+}
+
+func noopStop() {}
+
+// NewIndexCache initializes and returns a new index cache from the given YAML config. The returned function
+// stops the cache backend, e.g. closes the memcached connections, once the cache is no longer used.
+func NewIndexCache(logger log.Logger, confContentYaml []byte, reg prometheus.Registerer) (IndexCache, func(), error) {
+	provider, backendConfig, err := parseCacheConfig(confContentYaml)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch provider {
+	case INMEMORY:
+		opts, err := parseInMemoryCacheConfig(backendConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		c, err := NewInMemoryIndexCache(logger, reg, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		return c, noopStop, nil
+	case MEMCACHED:
+		memcached, err := cacheutil.NewMemcachedClient(logger, "index-cache", backendConfig, reg)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "create memcached client")
+		}
+		c, err := NewMemcachedIndexCache(logger, memcached, reg)
+		if err != nil {
+			memcached.Stop()
+			return nil, nil, err
+		}
+		return c, memcached.Stop, nil
+	default:
+		return nil, nil, errors.Errorf("index cache with type %s is not supported", provider)
+	}
+}
+
+// NewChunksCache initializes and returns a new chunks cache from the given YAML config. The returned function
+// stops the cache backend, e.g. closes the memcached connections, once the cache is no longer used.
+func NewChunksCache(logger log.Logger, confContentYaml []byte, reg prometheus.Registerer) (ChunksCache, func(), error) {
+	provider, backendConfig, err := parseCacheConfig(confContentYaml)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch provider {
+	case INMEMORY:
+		opts, err := parseInMemoryCacheConfig(backendConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		c, err := NewInMemoryChunksCache(logger, reg, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		return c, noopStop, nil
+	case MEMCACHED:
+		memcached, err := cacheutil.NewMemcachedClient(logger, "chunks-cache", backendConfig, reg)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "create memcached client")
+		}
+		c, err := NewMemcachedChunksCache(logger, memcached, reg)
+		if err != nil {
+			memcached.Stop()
+			return nil, nil, err
+		}
+		return c, memcached.Stop, nil
+	default:
+		return nil, nil, errors.Errorf("chunks cache with type %s is not supported", provider)
+	}
+}
@@ -0,0 +1,244 @@
+package storecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/cacheutil"
+)
+
+const (
+	memcachedDefaultTTL = 24 * time.Hour
+)
+
+// MemcachedIndexCache is a memcached-based index cache. Store gateways using the same memcached
+// servers share the cache, so it is warm for all of them and survives restarts.
+type MemcachedIndexCache struct {
+	logger    log.Logger
+	memcached cacheutil.MemcachedClient
+
+	requests *prometheus.CounterVec
+	hits     *prometheus.CounterVec
+}
+
+// NewMemcachedIndexCache makes a new MemcachedIndexCache.
+func NewMemcachedIndexCache(logger log.Logger, memcached cacheutil.MemcachedClient, reg prometheus.Registerer) (*MemcachedIndexCache, error) {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	c := &MemcachedIndexCache{
+		logger:    logger,
+		memcached: memcached,
+	}
+
+	c.requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_store_index_cache_requests_total",
+		Help: "Total number of requests to the cache.",
+	}, []string{"item_type"})
+	c.requests.WithLabelValues(cacheTypePostings)
+	c.requests.WithLabelValues(cacheTypeSeries)
+
+	c.hits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "thanos_store_index_cache_hits_total",
+		Help: "Total number of requests to the cache that were a hit.",
+	}, []string{"item_type"})
+	c.hits.WithLabelValues(cacheTypePostings)
+	c.hits.WithLabelValues(cacheTypeSeries)
+
+	if reg != nil {
+		reg.MustRegister(c.requests, c.hits)
+	}
+
+	level.Info(logger).Log("msg", "created memcached index cache")
+	return c, nil
+}
+
+// SetPostings sets the postings identified by the ulid and label to the value v.
+// The function enqueues the request and returns immediately: the entry will be
+// asynchronously stored in the cache.
+func (c *MemcachedIndexCache) SetPostings(b ulid.ULID, l labels.Label, v []byte) {
+	key := postingsKey(b, l)
+
+	if err := c.memcached.SetAsync(key, v, memcachedDefaultTTL); err != nil {
+		level.Debug(c.logger).Log("msg", "failed to cache postings in memcached", "err", err)
+	}
+}
+
+// FetchMultiPostings fetches multiple postings - each identified by a label -
+// and returns a map containing cache hits, along with a list of missing keys.
+// In case of error, it logs and return an empty cache hits map.
+func (c *MemcachedIndexCache) FetchMultiPostings(ctx context.Context, b ulid.ULID, lbls []labels.Label) (hits map[labels.Label][]byte, misses []labels.Label) {
+	keys := make([]string, 0, len(lbls))
+	for _, lbl := range lbls {
+		keys = append(keys, postingsKey(b, lbl))
+	}
+
+	// Fetch the keys from memcached in a single request.
+	c.requests.WithLabelValues(cacheTypePostings).Add(float64(len(keys)))
+	results := c.memcached.GetMulti(ctx, keys)
+	if len(results) == 0 {
+		return nil, lbls
+	}
+
+	// Construct the resulting hits map and list of missing keys. We iterate on the input
+	// list of labels to be able to easily create the list of ones in a single iteration.
+	hits = map[labels.Label][]byte{}
+	for i, lbl := range lbls {
+		value, ok := results[keys[i]]
+		if !ok {
+			misses = append(misses, lbl)
+			continue
+		}
+		hits[lbl] = value
+	}
+
+	c.hits.WithLabelValues(cacheTypePostings).Add(float64(len(hits)))
+	return hits, misses
+}
+
+// SetSeries sets the series identified by the ulid and id to the value v.
+// The function enqueues the request and returns immediately: the entry will be
+// asynchronously stored in the cache.
+func (c *MemcachedIndexCache) SetSeries(b ulid.ULID, id uint64, v []byte) {
+	key := seriesKey(b, id)
+
+	if err := c.memcached.SetAsync(key, v, memcachedDefaultTTL); err != nil {
+		level.Debug(c.logger).Log("msg", "failed to cache series in memcached", "err", err)
+	}
+}
+
+// FetchMultiSeries fetches multiple series - each identified by ID - from the cache
+// and returns a map containing cache hits, along with a list of missing IDs.
+// In case of error, it logs and return an empty cache hits map.
+func (c *MemcachedIndexCache) FetchMultiSeries(ctx context.Context, b ulid.ULID, ids []uint64) (hits map[uint64][]byte, misses []uint64) {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, seriesKey(b, id))
+	}
+
+	// Fetch the keys from memcached in a single request.
+	c.requests.WithLabelValues(cacheTypeSeries).Add(float64(len(ids)))
+	results := c.memcached.GetMulti(ctx, keys)
+	if len(results) == 0 {
+		return nil, ids
+	}
+
+	// Construct the resulting hits map and list of missing keys. We iterate on the input
+	// list of ids to be able to easily create the list of ones in a single iteration.
+	hits = map[uint64][]byte{}
+	for i, id := range ids {
+		value, ok := results[keys[i]]
+		if !ok {
+			misses = append(misses, id)
+			continue
+		}
+		hits[id] = value
+	}
+
+	c.hits.WithLabelValues(cacheTypeSeries).Add(float64(len(hits)))
+	return hits, misses
+}
+
+// postingsKey returns the memcached key of postings. Label names and values can be of any length
+// and contain characters memcached does not accept in keys, so they are hashed.
+func postingsKey(b ulid.ULID, l labels.Label) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(l.Name))
+	_, _ = h.Write([]byte{0xff})
+	_, _ = h.Write([]byte(l.Value))
+
+	return "P:" + b.String() + ":" + base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func seriesKey(b ulid.ULID, id uint64) string {
+	return "S:" + b.String() + ":" + strconv.FormatUint(id, 10)
+}
+
+func chunkKey(b ulid.ULID, ref uint64) string {
+	return "C:" + b.String() + ":" + strconv.FormatUint(ref, 10)
+}
+
+// MemcachedChunksCache is a memcached-based chunks cache.
+type MemcachedChunksCache struct {
+	logger    log.Logger
+	memcached cacheutil.MemcachedClient
+
+	requests prometheus.Counter
+	hits     prometheus.Counter
+}
+
+// NewMemcachedChunksCache makes a new MemcachedChunksCache.
+func NewMemcachedChunksCache(logger log.Logger, memcached cacheutil.MemcachedClient, reg prometheus.Registerer) (*MemcachedChunksCache, error) {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	c := &MemcachedChunksCache{
+		logger:    logger,
+		memcached: memcached,
+	}
+
+	c.requests = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_chunks_cache_requests_total",
+		Help: "Total number of chunks requested from the cache.",
+	})
+	c.hits = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "thanos_store_chunks_cache_hits_total",
+		Help: "Total number of chunks requested from the cache that were a hit.",
+	})
+
+	if reg != nil {
+		reg.MustRegister(c.requests, c.hits)
+	}
+
+	level.Info(logger).Log("msg", "created memcached chunks cache")
+	return c, nil
+}
+
+// SetChunk sets the chunk identified by the ulid and ref to the value v.
+// The function enqueues the request and returns immediately: the entry will be
+// asynchronously stored in the cache.
+func (c *MemcachedChunksCache) SetChunk(b ulid.ULID, ref uint64, v []byte) {
+	// The value is stored asynchronously, while chunk bytes come from pooled buffers reused by the caller.
+	cv := make([]byte, len(v))
+	copy(cv, v)
+
+	if err := c.memcached.SetAsync(chunkKey(b, ref), cv, memcachedDefaultTTL); err != nil {
+		level.Debug(c.logger).Log("msg", "failed to cache chunk in memcached", "err", err)
+	}
+}
+
+// FetchMultiChunks fetches multiple chunks - each identified by its reference -
+// and returns a map containing cache hits, along with a list of missing references.
+func (c *MemcachedChunksCache) FetchMultiChunks(ctx context.Context, b ulid.ULID, refs []uint64) (hits map[uint64][]byte, misses []uint64) {
+	keys := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		keys = append(keys, chunkKey(b, ref))
+	}
+
+	c.requests.Add(float64(len(refs)))
+	results := c.memcached.GetMulti(ctx, keys)
+	if len(results) == 0 {
+		return nil, refs
+	}
+
+	hits = map[uint64][]byte{}
+	for i, ref := range refs {
+		value, ok := results[keys[i]]
+		if !ok {
+			misses = append(misses, ref)
+			continue
+		}
+		hits[ref] = value
+	}
+
+	c.hits.Add(float64(len(hits)))
+	return hits, misses
+}
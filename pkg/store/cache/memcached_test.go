@@ -0,0 +1,111 @@
+package storecache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fortytw2/leaktest"
+	"github.com/go-kit/kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/cacheutil"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestMemcachedIndexCache(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	ctx := context.Background()
+	server, err := testutil.NewFakeMemcachedServer()
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, server.Close()) }()
+
+	memcached, err := cacheutil.NewMemcachedClient(log.NewNopLogger(), "test", []byte("addresses: ["+server.Addr()+"]"), nil)
+	testutil.Ok(t, err)
+
+	c, err := NewMemcachedIndexCache(log.NewNopLogger(), memcached, prometheus.NewRegistry())
+	testutil.Ok(t, err)
+
+	var (
+		b1 = ulid.MustNew(1, nil)
+		b2 = ulid.MustNew(2, nil)
+		l1 = labels.Label{Name: "instance", Value: "a b\nc"}
+		l2 = labels.Label{Name: "job", Value: string(make([]byte, 500))}
+	)
+	c.SetPostings(b1, l1, []byte("postings 1"))
+	c.SetPostings(b1, l2, []byte("postings 2"))
+	c.SetSeries(b1, 1234, []byte("series 1"))
+	c.SetSeries(b2, 1234, []byte("series 2"))
+
+	// Wait for the asynchronous sets.
+	memcached.Stop()
+	testutil.Equals(t, 4, len(server.Items()))
+
+	hits, misses := c.FetchMultiPostings(ctx, b1, []labels.Label{l1, {Name: "job", Value: "missing"}, l2})
+	testutil.Equals(t, map[labels.Label][]byte{l1: []byte("postings 1"), l2: []byte("postings 2")}, hits)
+	testutil.Equals(t, []labels.Label{{Name: "job", Value: "missing"}}, misses)
+
+	// Items are per block.
+	hits, misses = c.FetchMultiPostings(ctx, b2, []labels.Label{l1})
+	testutil.Equals(t, 0, len(hits))
+	testutil.Equals(t, []labels.Label{l1}, misses)
+
+	seriesHits, seriesMisses := c.FetchMultiSeries(ctx, b2, []uint64{1, 1234})
+	testutil.Equals(t, map[uint64][]byte{1234: []byte("series 2")}, seriesHits)
+	testutil.Equals(t, []uint64{1}, seriesMisses)
+
+	testutil.Equals(t, float64(4), promtest.ToFloat64(c.requests.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(2), promtest.ToFloat64(c.hits.WithLabelValues(cacheTypePostings)))
+	testutil.Equals(t, float64(2), promtest.ToFloat64(c.requests.WithLabelValues(cacheTypeSeries)))
+	testutil.Equals(t, float64(1), promtest.ToFloat64(c.hits.WithLabelValues(cacheTypeSeries)))
+
+	// Unavailable memcached results in misses only.
+	testutil.Ok(t, server.Close())
+	hits, misses = c.FetchMultiPostings(ctx, b1, []labels.Label{l1})
+	testutil.Equals(t, 0, len(hits))
+	testutil.Equals(t, []labels.Label{l1}, misses)
+}
+
+func TestNewIndexCache(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	c, stop, err := NewIndexCache(log.NewNopLogger(), []byte(`
+type: IN-MEMORY
+config:
+  max_size: 1000
+  max_item_size: 100
+`), nil)
+	testutil.Ok(t, err)
+	inmem, ok := c.(*InMemoryIndexCache)
+	testutil.Assert(t, ok, "expected in-memory cache, got %T", c)
+	testutil.Equals(t, uint64(1000), inmem.maxSizeBytes)
+	stop()
+
+	server, err := testutil.NewFakeMemcachedServer()
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, server.Close()) }()
+
+	// Stopping the cache stops the workers of the memcached client.
+	c, stop, err = NewIndexCache(log.NewNopLogger(), []byte(`
+type: MEMCACHED
+config:
+  addresses: [`+server.Addr()+`]
+`), nil)
+	testutil.Ok(t, err)
+	_, ok = c.(*MemcachedIndexCache)
+	testutil.Assert(t, ok, "expected memcached cache, got %T", c)
+	stop()
+
+	_, _, err = NewIndexCache(log.NewNopLogger(), []byte(`
+type: MEMCACHED
+config:
+  timeout: 1s
+`), nil)
+	testutil.NotOk(t, err)
+
+	_, _, err = NewIndexCache(log.NewNopLogger(), []byte(`type: REDIS`), nil)
+	testutil.NotOk(t, err)
+}
@@ -1,10 +1,32 @@
 package store
 
 import (
+	"fmt"
+	"sync/atomic"
+
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
 )
 
+// limitExceededError is returned when a limit is exceeded. Series calls failing with it are reported with
+// the ResourceExhausted gRPC code.
+type limitExceededError struct {
+	limit, got uint64
+}
+
+func (e limitExceededError) Error() string {
+	return fmt.Sprintf("limit %v violated (got %v)", e.limit, e.got)
+}
+
+// limitsErrorCode returns the ResourceExhausted code if err is caused by an exceeded limit and def otherwise.
+func limitsErrorCode(err error, def codes.Code) codes.Code {
+	if _, ok := errors.Cause(err).(limitExceededError); ok {
+		return codes.ResourceExhausted
+	}
+	return def
+}
+
 // Limiter is a simple mechanism for checking if something has passed a certain threshold.
 type Limiter struct {
 	limit uint64
@@ -25,7 +47,48 @@ func (l *Limiter) Check(num uint64) error {
 	}
 	if num > l.limit {
 		l.failedCounter.Inc()
-		return errors.Errorf("limit %v violated (got %v)", l.limit, num)
+		return limitExceededError{limit: l.limit, got: num}
 	}
 	return nil
 }
+
+// AccumulatingLimiter checks if the sum of all reserved amounts has passed a certain threshold.
+// It is meant to be used for a single query and is safe for concurrent use.
+type AccumulatingLimiter struct {
+	limit    uint64
+	reserved uint64
+
+	// Counter metric which we will increase once when Reserve() fails for the first time.
+	failedCounter prometheus.Counter
+}
+
+// NewAccumulatingLimiter returns a new accumulating limiter with a specified limit. 0 disables the limit.
+func NewAccumulatingLimiter(limit uint64, ctr prometheus.Counter) *AccumulatingLimiter {
+	return &AccumulatingLimiter{limit: limit, failedCounter: ctr}
+}
+
+// Reserve adds the passed number to the reserved amount and checks if it exceeds the limit or not.
+func (l *AccumulatingLimiter) Reserve(num uint64) error {
+	if l.limit == 0 {
+		return nil
+	}
+	reserved := atomic.AddUint64(&l.reserved, num)
+	if reserved <= l.limit {
+		return nil
+	}
+	// Count the query only once, even if it reserves more after exceeding the limit.
+	if reserved-num <= l.limit {
+		l.failedCounter.Inc()
+	}
+	return limitExceededError{limit: l.limit, got: reserved}
+}
+
+// SeriesLimits are the limits applied to a single Series call. 0 disables a limit.
+type SeriesLimits struct {
+	// MaxSeries is the maximum number of series matched in all queried blocks.
+	MaxSeries uint64
+	// MaxPostingsBytes is the maximum number of bytes of postings fetched from the bucket.
+	MaxPostingsBytes uint64
+	// MaxChunkBytes is the maximum number of bytes of chunks fetched from the bucket.
+	MaxChunkBytes uint64
+}
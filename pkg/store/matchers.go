@@ -1,11 +1,21 @@
 package store
 
 import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 )
 
+// maxSetMatches is the maximum number of values a regex matcher can be expanded to in order
+// to be resolved as a set of equality matches.
+const maxSetMatches = 256
+
 func translateMatcher(m storepb.LabelMatcher) (labels.Matcher, error) {
 	switch m.Type {
 	case storepb.LabelMatcher_EQ:
@@ -15,10 +25,10 @@ func translateMatcher(m storepb.LabelMatcher) (labels.Matcher, error) {
 		return labels.Not(labels.NewEqualMatcher(m.Name, m.Value)), nil
 
 	case storepb.LabelMatcher_RE:
-		return labels.NewRegexpMatcher(m.Name, "^(?:"+m.Value+")$")
+		return newRegexpMatcher(m.Name, m.Value)
 
 	case storepb.LabelMatcher_NRE:
-		m, err := labels.NewRegexpMatcher(m.Name, "^(?:"+m.Value+")$")
+		m, err := newRegexpMatcher(m.Name, m.Value)
 		if err != nil {
 			return nil, err
 		}
@@ -37,3 +47,164 @@ func translateMatchers(ms []storepb.LabelMatcher) (res []labels.Matcher, err err
 	}
 	return res, nil
 }
+
+// newRegexpMatcher returns a matcher for the given anchored regex. Regexes matching a small finite set
+// of values, like `a|b|c`, are turned into a setMatcher and regexes with a literal prefix into a prefixMatcher,
+// which allow to look up postings directly or to narrow down the scanned label values.
+func newRegexpMatcher(name, value string) (labels.Matcher, error) {
+	pattern := "^(?:" + value + ")$"
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if vals := findSetMatches(value); vals != nil {
+		return newSetMatcher(name, pattern, vals), nil
+	}
+	if prefix, _ := re.LiteralPrefix(); prefix != "" {
+		return &prefixMatcher{name: name, prefix: prefix, re: re}, nil
+	}
+	return labels.NewRegexpMatcher(name, pattern)
+}
+
+// setMatcher matches a finite set of values. It is equivalent to a regex matcher with
+// an alternation of literals.
+type setMatcher struct {
+	name    string
+	pattern string
+	values  []string
+	set     map[string]struct{}
+}
+
+func newSetMatcher(name, pattern string, values []string) *setMatcher {
+	m := &setMatcher{name: name, pattern: pattern, values: values, set: make(map[string]struct{}, len(values))}
+	for _, v := range values {
+		m.set[v] = struct{}{}
+	}
+	return m
+}
+
+// Name implements labels.Matcher interface.
+func (m *setMatcher) Name() string { return m.name }
+
+// Matches implements labels.Matcher interface.
+func (m *setMatcher) Matches(v string) bool {
+	_, ok := m.set[v]
+	return ok
+}
+
+// String implements labels.Matcher interface.
+func (m *setMatcher) String() string { return fmt.Sprintf("%s=~%q", m.name, m.pattern) }
+
+// Values returns the sorted matched values.
+func (m *setMatcher) Values() []string { return m.values }
+
+// prefixMatcher is a regex matcher which only matches values with a literal prefix.
+type prefixMatcher struct {
+	name   string
+	prefix string
+	re     *regexp.Regexp
+}
+
+// Name implements labels.Matcher interface.
+func (m *prefixMatcher) Name() string { return m.name }
+
+// Matches implements labels.Matcher interface.
+func (m *prefixMatcher) Matches(v string) bool {
+	return strings.HasPrefix(v, m.prefix) && m.re.MatchString(v)
+}
+
+// String implements labels.Matcher interface.
+func (m *prefixMatcher) String() string { return fmt.Sprintf("%s=~%q", m.name, m.re.String()) }
+
+// Prefix returns the literal prefix of all matched values.
+func (m *prefixMatcher) Prefix() string { return m.prefix }
+
+// findSetMatches returns the sorted values matched by the given regex if they are
+// a finite set of at most maxSetMatches values, nil otherwise.
+func findSetMatches(value string) []string {
+	re, err := syntax.Parse(value, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	vals, ok := expandRegexp(re.Simplify())
+	if !ok {
+		return nil
+	}
+	sort.Strings(vals)
+
+	res := vals[:0]
+	for i, v := range vals {
+		if i > 0 && v == vals[i-1] {
+			continue
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
+// expandRegexp returns all strings matched by the parsed regex. It fails for regexes matching
+// more than maxSetMatches strings, which includes all regexes with repetitions or wildcards.
+func expandRegexp(re *syntax.Regexp) ([]string, bool) {
+	switch re.Op {
+	case syntax.OpEmptyMatch:
+		return []string{""}, true
+
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil, false
+		}
+		return []string{string(re.Rune)}, true
+
+	case syntax.OpCharClass:
+		var res []string
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			lo, hi := re.Rune[i], re.Rune[i+1]
+			if len(res)+int(hi-lo)+1 > maxSetMatches {
+				return nil, false
+			}
+			for r := lo; r <= hi; r++ {
+				res = append(res, string(r))
+			}
+		}
+		return res, true
+
+	case syntax.OpCapture:
+		return expandRegexp(re.Sub[0])
+
+	case syntax.OpQuest:
+		res, ok := expandRegexp(re.Sub[0])
+		if !ok || len(res)+1 > maxSetMatches {
+			return nil, false
+		}
+		return append(res, ""), true
+
+	case syntax.OpAlternate:
+		var res []string
+		for _, sub := range re.Sub {
+			vals, ok := expandRegexp(sub)
+			if !ok || len(res)+len(vals) > maxSetMatches {
+				return nil, false
+			}
+			res = append(res, vals...)
+		}
+		return res, true
+
+	case syntax.OpConcat:
+		res := []string{""}
+		for _, sub := range re.Sub {
+			vals, ok := expandRegexp(sub)
+			if !ok || len(res)*len(vals) > maxSetMatches {
+				return nil, false
+			}
+			next := make([]string, 0, len(res)*len(vals))
+			for _, prefix := range res {
+				for _, v := range vals {
+					next = append(next, prefix+v)
+				}
+			}
+			res = next
+		}
+		return res, true
+	}
+	return nil, false
+}
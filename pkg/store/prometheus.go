@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"path"
 	"sort"
+	"strconv"
 	"sync"
 
 	"github.com/go-kit/kit/log"
@@ -30,6 +31,9 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// maxChunkedReadFrameBytes is the maximum size of a single frame of streamed remote read responses.
+const maxChunkedReadFrameBytes = 50 * 1024 * 1024
+
 var statusToCode = map[int]codes.Code{
 	http.StatusBadRequest:          codes.InvalidArgument,
 	http.StatusNotFound:            codes.NotFound,
@@ -47,11 +51,16 @@ type PrometheusStore struct {
 	component      component.StoreAPI
 	externalLabels func() labels.Labels
 	timestamps     func() (mint int64, maxt int64)
+	// Maximum size of the chunks sent in a single series frame. Bigger series are split across multiple frames.
+	maxFrameBytes int
+	// Persisted blocks of Prometheus served while it is unavailable. Nil disables the fallback.
+	localBlocks TSDBReader
 }
 
 // NewPrometheusStore returns a new PrometheusStore that uses the given HTTP client
 // to talk to Prometheus.
-// It attaches the provided external labels to all results.
+// It attaches the provided external labels to all results. If localBlocks is not nil, requests are
+// served from them while Prometheus is unavailable.
 func NewPrometheusStore(
 	logger log.Logger,
 	client *http.Client,
@@ -59,6 +68,7 @@ func NewPrometheusStore(
 	component component.StoreAPI,
 	externalLabels func() labels.Labels,
 	timestamps func() (mint int64, maxt int64),
+	localBlocks TSDBReader,
 ) (*PrometheusStore, error) {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -75,6 +85,8 @@ func NewPrometheusStore(
 		component:      component,
 		externalLabels: externalLabels,
 		timestamps:     timestamps,
+		maxFrameBytes:  storepb.MaxSeriesFrameBytes,
+		localBlocks:    localBlocks,
 	}
 	return p, nil
 }
@@ -140,6 +152,14 @@ func (p *PrometheusStore) Series(r *storepb.SeriesRequest, s storepb.Store_Serie
 		return status.Error(codes.InvalidArgument, errors.New("no matchers specified (excluding external labels)").Error())
 	}
 
+	if r.SkipChunks {
+		err := p.seriesWithoutChunks(s, newMatchers, r.MinTime, r.MaxTime, externalLabels)
+		if p.useLocalBlocks(err) {
+			return p.seriesFromLocalBlocks(r, s, err)
+		}
+		return err
+	}
+
 	q := prompb.Query{StartTimestampMs: r.MinTime, EndTimestampMs: r.MaxTime}
 
 	// TODO(fabxc): import common definitions from prompb once we have a stable gRPC
@@ -162,7 +182,30 @@ func (p *PrometheusStore) Series(r *storepb.SeriesRequest, s storepb.Store_Serie
 		q.Matchers = append(q.Matchers, pm)
 	}
 
-	resp, err := p.promSeries(s.Context(), q)
+	presp, err := p.startPromRemoteRead(s.Context(), q)
+	if p.useLocalBlocks(err) {
+		return p.seriesFromLocalBlocks(r, s, err)
+	}
+	if err != nil {
+		return errors.Wrap(err, "query Prometheus")
+	}
+	defer runutil.ExhaustCloseWithLogOnErr(p.logger, presp.Body, "prom series request body")
+
+	// Series are only split if the client reassembles them.
+	maxFrameBytes := 0
+	if r.AllowSplitSeries {
+		maxFrameBytes = p.maxFrameBytes
+	}
+
+	// Prometheus versions supporting streamed remote read respond with raw chunks, older ones with samples.
+	if presp.Header.Get("Content-Type") == prompb.ChunkedReadResponseContentType {
+		return p.handleStreamedPrometheusResponse(s, presp, externalLabels, maxFrameBytes)
+	}
+	return p.handleSampledPrometheusResponse(s, presp, externalLabels, maxFrameBytes)
+}
+
+func (p *PrometheusStore) handleSampledPrometheusResponse(s storepb.Store_SeriesServer, presp *http.Response, externalLabels labels.Labels, maxFrameBytes int) error {
+	resp, err := p.fetchSampledResponse(s.Context(), presp)
 	if err != nil {
 		return errors.Wrap(err, "query Prometheus")
 	}
@@ -195,11 +238,87 @@ func (p *PrometheusStore) Series(r *storepb.SeriesRequest, s storepb.Store_Serie
 			return err
 		}
 
-		resp := storepb.NewSeriesResponse(&storepb.Series{
-			Labels: lset,
-			Chunks: aggregatedChunks,
-		})
-		if err := s.Send(resp); err != nil {
+		if err := sendSeries(s, storepb.Series{Labels: lset, Chunks: aggregatedChunks}, maxFrameBytes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleStreamedPrometheusResponse passes the chunks of the streamed response through as they are read,
+// without buffering the whole response or re-encoding any samples. Prometheus may split a series across
+// multiple frames as well. If series must not be split (maxFrameBytes is not positive), these frames are
+// reassembled before the series is sent.
+func (p *PrometheusStore) handleStreamedPrometheusResponse(s storepb.Store_SeriesServer, presp *http.Response, externalLabels labels.Labels, maxFrameBytes int) error {
+	span, _ := tracing.StartSpan(s.Context(), "transform_and_respond")
+	defer span.Finish()
+
+	b := p.getBuffer()
+	defer p.putBuffer(b)
+
+	var (
+		seriesCount int
+		pending     *storepb.Series
+	)
+	stream := prompb.NewChunkedReader(presp.Body, maxChunkedReadFrameBytes, *b)
+	for {
+		// Unmarshalling copies the chunks, so the frame buffer can be reused for the next frame.
+		var res prompb.ChunkedReadResponse
+		if err := stream.NextProto(&res); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return errors.Wrap(err, "read streamed response")
+		}
+
+		for _, series := range res.ChunkedSeries {
+			seriesCount++
+			chks := make([]storepb.AggrChunk, 0, len(series.Chunks))
+			for _, chk := range series.Chunks {
+				if chk.Type != prompb.Chunk_XOR {
+					return errors.Errorf("unsupported chunk encoding %v", chk.Type)
+				}
+				chks = append(chks, storepb.AggrChunk{
+					MinTime: chk.MinTimeMs,
+					MaxTime: chk.MaxTimeMs,
+					Raw:     &storepb.Chunk{Type: storepb.Chunk_XOR, Data: chk.Data},
+				})
+			}
+
+			lset := p.translateAndExtendLabels(series.Labels, externalLabels)
+			if maxFrameBytes > 0 {
+				if err := sendSeries(s, storepb.Series{Labels: lset, Chunks: chks}, maxFrameBytes); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if pending != nil && storepb.CompareLabels(pending.Labels, lset) == 0 {
+				pending.Chunks = append(pending.Chunks, chks...)
+				continue
+			}
+			if pending != nil {
+				if err := s.Send(storepb.NewSeriesResponse(pending)); err != nil {
+					return err
+				}
+			}
+			pending = &storepb.Series{Labels: lset, Chunks: chks}
+		}
+	}
+	if pending != nil {
+		if err := s.Send(storepb.NewSeriesResponse(pending)); err != nil {
+			return err
+		}
+	}
+	span.SetTag("series_count", seriesCount)
+	return nil
+}
+
+// sendSeries sends the series split across frames holding at most maxFrameBytes of chunks each.
+func sendSeries(s storepb.Store_SeriesServer, series storepb.Series, maxFrameBytes int) error {
+	frames := storepb.SplitSeries(series, maxFrameBytes)
+	for i := range frames {
+		if err := s.Send(storepb.NewSeriesResponse(&frames[i])); err != nil {
 			return err
 		}
 	}
@@ -232,11 +351,17 @@ func (p *PrometheusStore) chunkSamples(series prompb.TimeSeries, maxSamplesPerCh
 	return chks, nil
 }
 
-func (p *PrometheusStore) promSeries(ctx context.Context, q prompb.Query) (*prompb.ReadResponse, error) {
+// startPromRemoteRead sends a remote read request for the query to Prometheus, accepting streamed chunks
+// if it supports them. The caller has to close the body of the returned response.
+func (p *PrometheusStore) startPromRemoteRead(ctx context.Context, q prompb.Query) (*http.Response, error) {
 	span, ctx := tracing.StartSpan(ctx, "query_prometheus")
 	defer span.Finish()
 
-	reqb, err := proto.Marshal(&prompb.ReadRequest{Queries: []prompb.Query{q}})
+	reqb, err := proto.Marshal(&prompb.ReadRequest{
+		Queries: []prompb.Query{q},
+		// Older Prometheus versions ignore the accepted response types and respond with samples.
+		AcceptedResponseTypes: []prompb.ReadRequest_ResponseType{prompb.ReadRequest_STREAMED_XOR_CHUNKS, prompb.ReadRequest_SAMPLES},
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "marshal read request")
 	}
@@ -255,15 +380,24 @@ func (p *PrometheusStore) promSeries(ctx context.Context, q prompb.Query) (*prom
 	preq = preq.WithContext(ctx)
 	presp, err := p.client.Do(preq)
 	if err != nil {
-		return nil, errors.Wrap(err, "send request")
+		if ctx.Err() != nil {
+			return nil, errors.Wrap(err, "send request")
+		}
+		return nil, errors.Wrap(status.Error(codes.Unavailable, err.Error()), "send request")
 	}
 	spanReqDo.Finish()
-	defer runutil.ExhaustCloseWithLogOnErr(p.logger, presp.Body, "prom series request body")
 
 	if presp.StatusCode/100 != 2 {
+		runutil.ExhaustCloseWithLogOnErr(p.logger, presp.Body, "prom series request body")
+		if presp.StatusCode == http.StatusServiceUnavailable {
+			return nil, errors.Wrap(status.Error(codes.Unavailable, presp.Status), "request failed")
+		}
 		return nil, errors.Errorf("request failed with code %s", presp.Status)
 	}
+	return presp, nil
+}
 
+func (p *PrometheusStore) fetchSampledResponse(ctx context.Context, presp *http.Response) (*prompb.ReadResponse, error) {
 	c := p.getBuffer()
 	buf := bytes.NewBuffer(*c)
 	defer p.putBuffer(c)
@@ -338,6 +472,40 @@ func (p *PrometheusStore) encodeChunk(ss []prompb.Sample) (storepb.Chunk_Encodin
 	return storepb.Chunk_XOR, c.Bytes(), nil
 }
 
+// seriesWithoutChunks responds with the label sets of the matching series only. They are looked up with the
+// Prometheus series API, which is much cheaper than a remote read as no samples have to be read and encoded.
+func (p *PrometheusStore) seriesWithoutChunks(
+	s storepb.Store_SeriesServer,
+	ms []storepb.LabelMatcher,
+	mint, maxt int64,
+	externalLabels labels.Labels,
+) error {
+	var lsets [][]storepb.Label
+	if err := p.seriesLabelSets(s.Context(), ms, mint, maxt, func(m map[string]string) {
+		lset := make([]storepb.Label, 0, len(m)+len(externalLabels))
+		for n, v := range m {
+			if externalLabels.Get(n) != "" {
+				continue
+			}
+			lset = append(lset, storepb.Label{Name: n, Value: v})
+		}
+		lsets = append(lsets, extendLset(lset, externalLabels))
+	}); err != nil {
+		return err
+	}
+
+	// Series have to be sent in order, which is not guaranteed after attaching the external labels.
+	sort.Slice(lsets, func(i, j int) bool {
+		return storepb.CompareLabels(lsets[i], lsets[j]) < 0
+	})
+	for _, lset := range lsets {
+		if err := s.Send(storepb.NewSeriesResponse(&storepb.Series{Labels: lset})); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // translateAndExtendLabels transforms a metrics into a protobuf label set. It additionally
 // attaches the given labels to it, overwriting existing ones on colllision.
 func (p *PrometheusStore) translateAndExtendLabels(m []prompb.Label, extend labels.Labels) []storepb.Label {
@@ -370,117 +538,242 @@ func extendLset(lset []storepb.Label, extend labels.Labels) []storepb.Label {
 }
 
 // LabelNames returns all known label names.
-func (p *PrometheusStore) LabelNames(ctx context.Context, _ *storepb.LabelNamesRequest) (
+func (p *PrometheusStore) LabelNames(ctx context.Context, r *storepb.LabelNamesRequest) (
 	*storepb.LabelNamesResponse, error,
 ) {
-	u := *p.base
-	u.Path = path.Join(u.Path, "/api/v1/labels")
+	res, err := p.labelNames(ctx, r)
+	if !p.useLocalBlocks(err) {
+		return res, err
+	}
+	level.Debug(p.logger).Log("msg", "Prometheus unavailable, serving label names from persisted blocks", "err", err)
+	res, lerr := p.localStore().LabelNames(ctx, r)
+	if lerr != nil {
+		return nil, lerr
+	}
+	res.Warnings = append(res.Warnings, localBlocksWarning(err).Error())
+	return res, nil
+}
 
-	req, err := http.NewRequest("GET", u.String(), nil)
+func (p *PrometheusStore) labelNames(ctx context.Context, r *storepb.LabelNamesRequest) (
+	*storepb.LabelNamesResponse, error,
+) {
+	match, newMatchers, err := matchesExternalLabels(r.Matchers, p.externalLabels())
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !match {
+		return &storepb.LabelNamesResponse{Names: []string{}}, nil
 	}
 
 	span, ctx := tracing.StartSpan(ctx, "/prom_label_names HTTP[client]")
 	defer span.Finish()
 
-	resp, err := p.client.Do(req.WithContext(ctx))
-	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+	if len(newMatchers) > 0 {
+		names := map[string]struct{}{}
+		if err := p.seriesLabelSets(ctx, newMatchers, r.Start, r.End, func(lset map[string]string) {
+			for n := range lset {
+				names[n] = struct{}{}
+			}
+		}); err != nil {
+			return nil, err
+		}
+		res := make([]string, 0, len(names))
+		for n := range names {
+			res = append(res, n)
+		}
+		sort.Strings(res)
+		return &storepb.LabelNamesResponse{Names: res}, nil
 	}
-	defer runutil.ExhaustCloseWithLogOnErr(p.logger, resp.Body, "label names request body")
 
-	if resp.StatusCode/100 != 2 {
-		return nil, status.Error(codes.Internal, fmt.Sprintf("request Prometheus server failed, code %s", resp.Status))
-	}
+	u := *p.base
+	u.Path = path.Join(u.Path, "/api/v1/labels")
+	u.RawQuery = timeRangeParams(r.Start, r.End).Encode()
 
-	if resp.StatusCode == http.StatusNoContent {
-		return &storepb.LabelNamesResponse{Names: []string{}}, nil
+	names := []string{}
+	if err := p.promAPIRequest(ctx, &u, &names); err != nil {
+		return nil, err
 	}
+	return &storepb.LabelNamesResponse{Names: names}, nil
+}
 
-	var m struct {
-		Data   []string `json:"data"`
-		Status string   `json:"status"`
-		Error  string   `json:"error"`
+// LabelValues returns all known label values for a given label name.
+func (p *PrometheusStore) LabelValues(ctx context.Context, r *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
+	res, err := p.labelValues(ctx, r)
+	if !p.useLocalBlocks(err) {
+		return res, err
 	}
+	level.Debug(p.logger).Log("msg", "Prometheus unavailable, serving label values from persisted blocks", "err", err)
+	res, lerr := p.localStore().LabelValues(ctx, r)
+	if lerr != nil {
+		return nil, lerr
+	}
+	res.Warnings = append(res.Warnings, localBlocksWarning(err).Error())
+	return res, nil
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
+func (p *PrometheusStore) labelValues(ctx context.Context, r *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
+	externalLset := p.externalLabels()
+
+	match, newMatchers, err := matchesExternalLabels(r.Matchers, externalLset)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !match {
+		return &storepb.LabelValuesResponse{Values: []string{}}, nil
 	}
 
-	if err = json.Unmarshal(body, &m); err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+	// First check for matching external label which has priority.
+	if l := externalLset.Get(r.Label); l != "" {
+		return &storepb.LabelValuesResponse{Values: []string{l}}, nil
 	}
 
-	if m.Status != "success" {
-		code, exists := statusToCode[resp.StatusCode]
-		if !exists {
-			return nil, status.Error(codes.Internal, m.Error)
+	span, ctx := tracing.StartSpan(ctx, "/prom_label_values HTTP[client]")
+	defer span.Finish()
+
+	if len(newMatchers) > 0 {
+		vals := map[string]struct{}{}
+		if err := p.seriesLabelSets(ctx, newMatchers, r.Start, r.End, func(lset map[string]string) {
+			if v := lset[r.Label]; v != "" {
+				vals[v] = struct{}{}
+			}
+		}); err != nil {
+			return nil, err
+		}
+		res := make([]string, 0, len(vals))
+		for v := range vals {
+			res = append(res, v)
 		}
-		return nil, status.Error(code, m.Error)
+		sort.Strings(res)
+		return &storepb.LabelValuesResponse{Values: res}, nil
+	}
+
+	u := *p.base
+	u.Path = path.Join(u.Path, "/api/v1/label/", r.Label, "/values")
+	u.RawQuery = timeRangeParams(r.Start, r.End).Encode()
+
+	vals := []string{}
+	if err := p.promAPIRequest(ctx, &u, &vals); err != nil {
+		return nil, err
 	}
+	sort.Strings(vals)
+	return &storepb.LabelValuesResponse{Values: vals}, nil
+}
 
-	return &storepb.LabelNamesResponse{Names: m.Data}, nil
+// useLocalBlocks returns true if a request failed because Prometheus is unavailable and the local blocks
+// can be served instead. The local blocks are never merged with the responses of Prometheus, as those
+// already include the data of all persisted blocks.
+func (p *PrometheusStore) useLocalBlocks(err error) bool {
+	return err != nil && p.localBlocks != nil && status.Code(errors.Cause(err)) == codes.Unavailable
 }
 
-// LabelValues returns all known label values for a given label name.
-func (p *PrometheusStore) LabelValues(ctx context.Context, r *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
-	externalLset := p.externalLabels()
+// localStore returns a store reading the persisted blocks of Prometheus. It is only used to serve data.
+func (p *PrometheusStore) localStore() *TSDBStore {
+	return &TSDBStore{
+		logger:         p.logger,
+		db:             p.localBlocks,
+		externalLabels: p.externalLabels(),
+		maxFrameBytes:  p.maxFrameBytes,
+	}
+}
 
-	// First check for matching external label which has priority.
-	if l := externalLset.Get(r.Label); l != "" {
-		return &storepb.LabelValuesResponse{Values: []string{l}}, nil
+// localBlocksWarning is the warning returned with responses served from the local blocks, as they lack the
+// data not persisted by Prometheus yet.
+func localBlocksWarning(promErr error) error {
+	return errors.Wrap(promErr, "Prometheus unavailable, only data of persisted blocks is returned")
+}
+
+func (p *PrometheusStore) seriesFromLocalBlocks(r *storepb.SeriesRequest, s storepb.Store_SeriesServer, promErr error) error {
+	level.Debug(p.logger).Log("msg", "Prometheus unavailable, serving series from persisted blocks", "err", promErr)
+	if err := s.Send(storepb.NewWarnSeriesResponse(localBlocksWarning(promErr))); err != nil {
+		return err
 	}
+	return p.localStore().Series(r, s)
+}
 
+// seriesLabelSets calls f with the label sets of all series matching the matchers within the given time range,
+// as returned by the Prometheus series API.
+func (p *PrometheusStore) seriesLabelSets(ctx context.Context, ms []storepb.LabelMatcher, start, end int64, f func(lset map[string]string)) error {
 	u := *p.base
-	u.Path = path.Join(u.Path, "/api/v1/label/", r.Label, "/values")
+	u.Path = path.Join(u.Path, "/api/v1/series")
+	q := timeRangeParams(start, end)
+	q.Set("match[]", storepb.MatchersToString(ms...))
+	u.RawQuery = q.Encode()
 
+	var lsets []map[string]string
+	if err := p.promAPIRequest(ctx, &u, &lsets); err != nil {
+		return err
+	}
+	for _, lset := range lsets {
+		f(lset)
+	}
+	return nil
+}
+
+// promAPIRequest requests the given Prometheus HTTP API endpoint and unmarshals the data of the response into v.
+// The returned errors are gRPC status errors.
+func (p *PrometheusStore) promAPIRequest(ctx context.Context, u *url.URL, v interface{}) error {
 	req, err := http.NewRequest("GET", u.String(), nil)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return status.Error(codes.Internal, err.Error())
 	}
 
-	span, ctx := tracing.StartSpan(ctx, "/prom_label_values HTTP[client]")
-	defer span.Finish()
-
 	resp, err := p.client.Do(req.WithContext(ctx))
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		if ctx.Err() != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		return status.Error(codes.Unavailable, err.Error())
 	}
-	defer runutil.ExhaustCloseWithLogOnErr(p.logger, resp.Body, "label values request body")
+	defer runutil.ExhaustCloseWithLogOnErr(p.logger, resp.Body, "prometheus API request body")
 
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return status.Error(codes.Unavailable, fmt.Sprintf("request Prometheus server failed, code %s", resp.Status))
+	}
 	if resp.StatusCode/100 != 2 {
-		return nil, status.Error(codes.Internal, fmt.Sprintf("request Prometheus server failed, code %s", resp.Status))
+		return status.Error(codes.Internal, fmt.Sprintf("request Prometheus server failed, code %s", resp.Status))
 	}
 
 	if resp.StatusCode == http.StatusNoContent {
-		return &storepb.LabelValuesResponse{Values: []string{}}, nil
+		return nil
 	}
 
 	var m struct {
-		Data   []string `json:"data"`
-		Status string   `json:"status"`
-		Error  string   `json:"error"`
+		Data   json.RawMessage `json:"data"`
+		Status string          `json:"status"`
+		Error  string          `json:"error"`
 	}
+
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return status.Error(codes.Internal, err.Error())
 	}
 
 	if err = json.Unmarshal(body, &m); err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return status.Error(codes.Internal, err.Error())
 	}
 
-	sort.Strings(m.Data)
-
 	if m.Status != "success" {
 		code, exists := statusToCode[resp.StatusCode]
 		if !exists {
-			return nil, status.Error(codes.Internal, m.Error)
+			return status.Error(codes.Internal, m.Error)
 		}
-		return nil, status.Error(code, m.Error)
+		return status.Error(code, m.Error)
+	}
+
+	if err = json.Unmarshal(m.Data, v); err != nil {
+		return status.Error(codes.Internal, err.Error())
 	}
+	return nil
+}
 
-	return &storepb.LabelValuesResponse{Values: m.Data}, nil
+// timeRangeParams returns the Prometheus HTTP API parameters for the given time range in milliseconds.
+// No parameters are set if neither start nor end are set.
+func timeRangeParams(start, end int64) url.Values {
+	q := url.Values{}
+	if start == 0 && end == 0 {
+		return q
+	}
+	q.Set("start", strconv.FormatFloat(float64(start)/1000, 'f', -1, 64))
+	q.Set("end", strconv.FormatFloat(float64(end)/1000, 'f', -1, 64))
+	return q
 }
@@ -1,19 +1,28 @@
 package store
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
 	"math"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/fortytw2/leaktest"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
 	"github.com/prometheus/prometheus/pkg/timestamp"
 	"github.com/prometheus/tsdb"
 	"github.com/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/store/prompb"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/thanos-io/thanos/pkg/testutil"
 )
@@ -58,7 +67,7 @@ func testPrometheusStoreSeriesE2e(t *testing.T, prefix string) {
 	proxy, err := NewPrometheusStore(nil, nil, u, component.Sidecar,
 		func() labels.Labels {
 			return labels.FromStrings("region", "eu-west")
-		}, nil)
+		}, nil, nil)
 	testutil.Ok(t, err)
 
 	{
@@ -153,7 +162,7 @@ func TestPrometheusStore_LabelValues_e2e(t *testing.T) {
 	u, err := url.Parse(fmt.Sprintf("http://%s", p.Addr()))
 	testutil.Ok(t, err)
 
-	proxy, err := NewPrometheusStore(nil, nil, u, component.Sidecar, getExternalLabels, nil)
+	proxy, err := NewPrometheusStore(nil, nil, u, component.Sidecar, getExternalLabels, nil, nil)
 	testutil.Ok(t, err)
 
 	resp, err := proxy.LabelValues(ctx, &storepb.LabelValuesRequest{
@@ -164,6 +173,90 @@ func TestPrometheusStore_LabelValues_e2e(t *testing.T) {
 	testutil.Equals(t, []string{"a", "b", "c"}, resp.Values)
 }
 
+func TestPrometheusStore_LabelNamesAndValues_Matchers_e2e(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	p, err := testutil.NewPrometheus()
+	testutil.Ok(t, err)
+
+	a := p.Appender()
+	_, err = a.Add(labels.FromStrings("a", "b", "b", "1"), 0, 1)
+	testutil.Ok(t, err)
+	_, err = a.Add(labels.FromStrings("a", "c", "c", "1"), 0, 1)
+	testutil.Ok(t, err)
+	testutil.Ok(t, a.Commit())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	testutil.Ok(t, p.Start())
+	defer func() { testutil.Ok(t, p.Stop()) }()
+
+	u, err := url.Parse(fmt.Sprintf("http://%s", p.Addr()))
+	testutil.Ok(t, err)
+
+	proxy, err := NewPrometheusStore(nil, nil, u, component.Sidecar, getExternalLabels, nil, nil)
+	testutil.Ok(t, err)
+
+	matchers := []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "b", Value: "1"}}
+
+	vals, err := proxy.LabelValues(ctx, &storepb.LabelValuesRequest{Label: "a", Matchers: matchers})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"b"}, vals.Values)
+
+	names, err := proxy.LabelNames(ctx, &storepb.LabelNamesRequest{Start: 0, End: 1000, Matchers: matchers})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"a", "b"}, names.Names)
+
+	// Non matching external labels exclude all series.
+	vals, err = proxy.LabelValues(ctx, &storepb.LabelValuesRequest{
+		Label:    "a",
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "ext_a", Value: "x"}},
+	})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{}, vals.Values)
+}
+
+func TestPrometheusStore_Series_SkipChunks_e2e(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	p, err := testutil.NewPrometheus()
+	testutil.Ok(t, err)
+
+	a := p.Appender()
+	_, err = a.Add(labels.FromStrings("a", "c", "b", "1"), 0, 1)
+	testutil.Ok(t, err)
+	_, err = a.Add(labels.FromStrings("a", "b"), 0, 1)
+	testutil.Ok(t, err)
+	testutil.Ok(t, a.Commit())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	testutil.Ok(t, p.Start())
+	defer func() { testutil.Ok(t, p.Stop()) }()
+
+	u, err := url.Parse(fmt.Sprintf("http://%s", p.Addr()))
+	testutil.Ok(t, err)
+
+	proxy, err := NewPrometheusStore(nil, nil, u, component.Sidecar, getExternalLabels, nil, nil)
+	testutil.Ok(t, err)
+
+	srv := newStoreSeriesServer(ctx)
+	testutil.Ok(t, proxy.Series(&storepb.SeriesRequest{
+		MinTime:    0,
+		MaxTime:    1000,
+		Matchers:   []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: "b|c"}},
+		SkipChunks: true,
+	}, srv))
+
+	// Series are sent sorted, with external labels and without chunks.
+	testutil.Equals(t, []storepb.Series{
+		{Labels: []storepb.Label{{Name: "a", Value: "b"}, {Name: "ext_a", Value: "a"}, {Name: "ext_b", Value: "a"}}},
+		{Labels: []storepb.Label{{Name: "a", Value: "c"}, {Name: "b", Value: "1"}, {Name: "ext_a", Value: "a"}, {Name: "ext_b", Value: "a"}}},
+	}, srv.SeriesSet)
+}
+
 // Test to check external label values retrieve.
 func TestPrometheusStore_ExternalLabelValues_e2e(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
@@ -187,7 +280,7 @@ func TestPrometheusStore_ExternalLabelValues_e2e(t *testing.T) {
 	u, err := url.Parse(fmt.Sprintf("http://%s", p.Addr()))
 	testutil.Ok(t, err)
 
-	proxy, err := NewPrometheusStore(nil, nil, u, component.Sidecar, getExternalLabels, nil)
+	proxy, err := NewPrometheusStore(nil, nil, u, component.Sidecar, getExternalLabels, nil, nil)
 	testutil.Ok(t, err)
 
 	resp, err := proxy.LabelValues(ctx, &storepb.LabelValuesRequest{
@@ -234,7 +327,7 @@ func TestPrometheusStore_Series_MatchExternalLabel_e2e(t *testing.T) {
 	proxy, err := NewPrometheusStore(nil, nil, u, component.Sidecar,
 		func() labels.Labels {
 			return labels.FromStrings("region", "eu-west")
-		}, nil)
+		}, nil, nil)
 	testutil.Ok(t, err)
 	srv := newStoreSeriesServer(ctx)
 
@@ -283,7 +376,7 @@ func TestPrometheusStore_Info(t *testing.T) {
 		},
 		func() (int64, int64) {
 			return 123, 456
-		})
+		}, nil)
 	testutil.Ok(t, err)
 
 	resp, err := proxy.Info(ctx, &storepb.InfoRequest{})
@@ -295,6 +388,301 @@ func TestPrometheusStore_Info(t *testing.T) {
 	testutil.Equals(t, int64(456), resp.MaxTime)
 }
 
+// newRemoteReadServer starts a fake Prometheus passing decoded remote read requests to the handler.
+func newRemoteReadServer(t *testing.T, handle func(w http.ResponseWriter, req *prompb.ReadRequest)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.Equals(t, "/api/v1/read", r.URL.Path)
+
+		b, err := ioutil.ReadAll(r.Body)
+		testutil.Ok(t, err)
+		b, err = snappy.Decode(nil, b)
+		testutil.Ok(t, err)
+
+		var req prompb.ReadRequest
+		testutil.Ok(t, proto.Unmarshal(b, &req))
+		handle(w, &req)
+	}))
+}
+
+func xorChunk(t *testing.T, samples ...sample) prompb.Chunk {
+	c := chunkenc.NewXORChunk()
+	a, err := c.Appender()
+	testutil.Ok(t, err)
+	for _, s := range samples {
+		a.Append(s.t, s.v)
+	}
+	return prompb.Chunk{
+		MinTimeMs: samples[0].t,
+		MaxTimeMs: samples[len(samples)-1].t,
+		Type:      prompb.Chunk_XOR,
+		Data:      c.Bytes(),
+	}
+}
+
+func TestPrometheusStore_Series_StreamedChunks(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	var (
+		chk1 = xorChunk(t, sample{1, 1}, sample{2, 2})
+		chk2 = xorChunk(t, sample{3, 3}, sample{4, 4})
+		chk3 = xorChunk(t, sample{5, 5})
+		chk4 = xorChunk(t, sample{1, 1})
+	)
+	srv := newRemoteReadServer(t, func(w http.ResponseWriter, req *prompb.ReadRequest) {
+		testutil.Equals(t, []prompb.ReadRequest_ResponseType{prompb.ReadRequest_STREAMED_XOR_CHUNKS, prompb.ReadRequest_SAMPLES}, req.AcceptedResponseTypes)
+		testutil.Equals(t, []prompb.LabelMatcher{{Type: prompb.LabelMatcher_RE, Name: "a", Value: ".+"}}, req.Queries[0].Matchers)
+
+		w.Header().Set("Content-Type", prompb.ChunkedReadResponseContentType)
+		cw := prompb.NewChunkedWriter(w, w.(http.Flusher))
+		for _, res := range []prompb.ChunkedReadResponse{
+			{ChunkedSeries: []prompb.ChunkedSeries{
+				{Labels: []prompb.Label{{Name: "a", Value: "b"}}, Chunks: []prompb.Chunk{chk1, chk2}},
+			}},
+			// The remaining chunks of the first series are sent in the next frame.
+			{ChunkedSeries: []prompb.ChunkedSeries{
+				{Labels: []prompb.Label{{Name: "a", Value: "b"}}, Chunks: []prompb.Chunk{chk3}},
+				{Labels: []prompb.Label{{Name: "a", Value: "c"}, {Name: "region", Value: "local"}}, Chunks: []prompb.Chunk{chk4}},
+			}},
+		} {
+			b, err := proto.Marshal(&res)
+			testutil.Ok(t, err)
+			_, err = cw.Write(b)
+			testutil.Ok(t, err)
+		}
+	})
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	testutil.Ok(t, err)
+
+	proxy, err := NewPrometheusStore(nil, nil, u, component.Sidecar,
+		func() labels.Labels {
+			return labels.FromStrings("region", "eu-west")
+		}, nil, nil)
+	testutil.Ok(t, err)
+
+	req := &storepb.SeriesRequest{
+		MinTime:          1,
+		MaxTime:          5,
+		Matchers:         []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: ".+"}},
+		AllowSplitSeries: true,
+	}
+	s := newStoreSeriesServer(context.Background())
+	testutil.Ok(t, proxy.Series(req, s))
+
+	aggr := func(c prompb.Chunk) storepb.AggrChunk {
+		return storepb.AggrChunk{MinTime: c.MinTimeMs, MaxTime: c.MaxTimeMs, Raw: &storepb.Chunk{Type: storepb.Chunk_XOR, Data: c.Data}}
+	}
+	// Chunks are passed through without re-encoding.
+	testutil.Equals(t, []storepb.Series{
+		{
+			Labels: []storepb.Label{{Name: "a", Value: "b"}, {Name: "region", Value: "eu-west"}},
+			Chunks: []storepb.AggrChunk{aggr(chk1), aggr(chk2)},
+		},
+		{
+			Labels: []storepb.Label{{Name: "a", Value: "b"}, {Name: "region", Value: "eu-west"}},
+			Chunks: []storepb.AggrChunk{aggr(chk3)},
+		},
+		{
+			Labels: []storepb.Label{{Name: "a", Value: "c"}, {Name: "region", Value: "eu-west"}},
+			Chunks: []storepb.AggrChunk{aggr(chk4)},
+		},
+	}, s.SeriesSet)
+
+	// Series split by Prometheus are reassembled if the client does not allow splitting.
+	req.AllowSplitSeries = false
+	s = newStoreSeriesServer(context.Background())
+	testutil.Ok(t, proxy.Series(req, s))
+	testutil.Equals(t, []storepb.Series{
+		{
+			Labels: []storepb.Label{{Name: "a", Value: "b"}, {Name: "region", Value: "eu-west"}},
+			Chunks: []storepb.AggrChunk{aggr(chk1), aggr(chk2), aggr(chk3)},
+		},
+		{
+			Labels: []storepb.Label{{Name: "a", Value: "c"}, {Name: "region", Value: "eu-west"}},
+			Chunks: []storepb.AggrChunk{aggr(chk4)},
+		},
+	}, s.SeriesSet)
+}
+
+func TestPrometheusStore_Series_StreamedChunks_Corrupted(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	srv := newRemoteReadServer(t, func(w http.ResponseWriter, req *prompb.ReadRequest) {
+		w.Header().Set("Content-Type", prompb.ChunkedReadResponseContentType)
+		b, err := proto.Marshal(&prompb.ChunkedReadResponse{ChunkedSeries: []prompb.ChunkedSeries{
+			{Labels: []prompb.Label{{Name: "a", Value: "b"}}, Chunks: []prompb.Chunk{xorChunk(t, sample{1, 1})}},
+		}})
+		testutil.Ok(t, err)
+
+		var buf bytes.Buffer
+		_, err = prompb.NewChunkedWriter(&buf, w.(http.Flusher)).Write(b)
+		testutil.Ok(t, err)
+		// Flip a bit of the frame's data.
+		frame := buf.Bytes()
+		frame[len(frame)-1] ^= 1
+		_, err = w.Write(frame)
+		testutil.Ok(t, err)
+	})
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	testutil.Ok(t, err)
+
+	proxy, err := NewPrometheusStore(nil, nil, u, component.Sidecar, func() labels.Labels { return nil }, nil, nil)
+	testutil.Ok(t, err)
+
+	err = proxy.Series(&storepb.SeriesRequest{
+		MinTime:  1,
+		MaxTime:  5,
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "b"}},
+	}, newStoreSeriesServer(context.Background()))
+	testutil.NotOk(t, err)
+	testutil.Assert(t, strings.Contains(err.Error(), "checksum mismatch"), "unexpected error: %v", err)
+}
+
+// Prometheus versions without streamed remote read ignore the accepted response types and respond with samples.
+func TestPrometheusStore_Series_SampledFallback(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	srv := newRemoteReadServer(t, func(w http.ResponseWriter, req *prompb.ReadRequest) {
+		b, err := proto.Marshal(&prompb.ReadResponse{Results: []prompb.QueryResult{{Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "a", Value: "b"}},
+				Samples: []prompb.Sample{{Timestamp: 1, Value: 1}, {Timestamp: 2, Value: 2}},
+			},
+		}}}})
+		testutil.Ok(t, err)
+
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Header().Set("Content-Encoding", "snappy")
+		_, err = w.Write(snappy.Encode(nil, b))
+		testutil.Ok(t, err)
+	})
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	testutil.Ok(t, err)
+
+	proxy, err := NewPrometheusStore(nil, nil, u, component.Sidecar,
+		func() labels.Labels {
+			return labels.FromStrings("region", "eu-west")
+		}, nil, nil)
+	testutil.Ok(t, err)
+
+	s := newStoreSeriesServer(context.Background())
+	testutil.Ok(t, proxy.Series(&storepb.SeriesRequest{
+		MinTime:  1,
+		MaxTime:  2,
+		Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "b"}},
+	}, s))
+
+	testutil.Equals(t, 1, len(s.SeriesSet))
+	testutil.Equals(t, []storepb.Label{{Name: "a", Value: "b"}, {Name: "region", Value: "eu-west"}}, s.SeriesSet[0].Labels)
+	testutil.Equals(t, 1, len(s.SeriesSet[0].Chunks))
+
+	chk, err := chunkenc.FromData(chunkenc.EncXOR, s.SeriesSet[0].Chunks[0].Raw.Data)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []sample{{1, 1}, {2, 2}}, expandChunk(chk.Iterator()))
+}
+
+func TestPrometheusStore_LocalBlocksFallback(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "prometheus-store-local-blocks-test")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	_, err = testutil.CreateBlock(ctx, dir, []labels.Labels{labels.FromStrings("a", "1"), labels.FromStrings("a", "2")}, 10, 0, 1000, nil, 0)
+	testutil.Ok(t, err)
+
+	blocks := NewTSDBBlocks(nil, dir)
+	defer func() { testutil.Ok(t, blocks.Close()) }()
+	testutil.Ok(t, blocks.Sync())
+
+	// A stopped Prometheus refuses connections.
+	stopped := httptest.NewServer(http.NotFoundHandler())
+	stopped.Close()
+	// A starting Prometheus is not ready yet.
+	starting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer starting.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+
+	for _, tcase := range []struct {
+		name        string
+		url         string
+		localBlocks TSDBReader
+		fallback    bool
+	}{
+		{name: "stopped", url: stopped.URL, localBlocks: blocks, fallback: true},
+		{name: "starting", url: starting.URL, localBlocks: blocks, fallback: true},
+		{name: "stopped without local blocks", url: stopped.URL},
+		{name: "bad request", url: failing.URL, localBlocks: blocks},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			u, err := url.Parse(tcase.url)
+			testutil.Ok(t, err)
+
+			proxy, err := NewPrometheusStore(nil, nil, u, component.Sidecar,
+				func() labels.Labels {
+					return labels.FromStrings("region", "eu-west")
+				}, nil, tcase.localBlocks)
+			testutil.Ok(t, err)
+
+			for _, skipChunks := range []bool{false, true} {
+				srv := newStoreSeriesServer(ctx)
+				err = proxy.Series(&storepb.SeriesRequest{
+					MinTime:    0,
+					MaxTime:    1000,
+					Matchers:   []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: ".+"}},
+					SkipChunks: skipChunks,
+				}, srv)
+				if !tcase.fallback {
+					testutil.NotOk(t, err)
+					continue
+				}
+				testutil.Ok(t, err)
+
+				// Responses lacking the data of Prometheus' head are flagged with a warning.
+				testutil.Equals(t, 1, len(srv.Warnings))
+				testutil.Assert(t, strings.Contains(srv.Warnings[0], "Prometheus unavailable"), "unexpected warning: %v", srv.Warnings[0])
+
+				testutil.Equals(t, 2, len(srv.SeriesSet))
+				for i, s := range srv.SeriesSet {
+					testutil.Equals(t, []storepb.Label{{Name: "a", Value: fmt.Sprint(i + 1)}, {Name: "region", Value: "eu-west"}}, s.Labels)
+					if skipChunks {
+						testutil.Equals(t, 0, len(s.Chunks))
+						continue
+					}
+					testutil.Equals(t, 1, len(s.Chunks))
+				}
+			}
+
+			names, err := proxy.LabelNames(ctx, &storepb.LabelNamesRequest{})
+			values, verr := proxy.LabelValues(ctx, &storepb.LabelValuesRequest{Label: "a"})
+			if !tcase.fallback {
+				testutil.NotOk(t, err)
+				testutil.NotOk(t, verr)
+				return
+			}
+			testutil.Ok(t, err)
+			testutil.Ok(t, verr)
+			testutil.Equals(t, []string{"a"}, names.Names)
+			testutil.Equals(t, 1, len(names.Warnings))
+			testutil.Equals(t, []string{"1", "2"}, values.Values)
+			testutil.Equals(t, 1, len(values.Warnings))
+		})
+	}
+}
+
 func testSeries_SplitSamplesIntoChunksWithMaxSizeOfUint16_e2e(t *testing.T, appender tsdb.Appender, newStore func() storepb.StoreServer) {
 	baseT := timestamp.FromTime(time.Now().AddDate(0, 0, -2)) / 1000 * 1000
 
@@ -362,7 +750,7 @@ func TestPrometheusStore_Series_SplitSamplesIntoChunksWithMaxSizeOfUint16_e2e(t
 		proxy, err := NewPrometheusStore(nil, nil, u, component.Sidecar,
 			func() labels.Labels {
 				return labels.FromStrings("region", "eu-west")
-			}, nil)
+			}, nil, nil)
 		testutil.Ok(t, err)
 
 		return proxy
@@ -0,0 +1,133 @@
+package prompb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// NOTE: this follows the streamed remote read framing of prometheus/prometheus/storage/remote, which is not
+// part of the Prometheus version we depend on.
+
+// ChunkedReadResponseContentType is the content type of streamed remote read responses with ChunkedReadResponse frames.
+const ChunkedReadResponseContentType = "application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse"
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ChunkedWriter writes frames prefixed with their uvarint size and a big endian CRC32 Castagnoli checksum.
+type ChunkedWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+
+	crc32 hash.Hash32
+}
+
+// NewChunkedWriter returns a ChunkedWriter writing frames to w, flushing f after each of them.
+func NewChunkedWriter(w io.Writer, f http.Flusher) *ChunkedWriter {
+	return &ChunkedWriter{w: w, flusher: f, crc32: crc32.New(castagnoliTable)}
+}
+
+// Write writes b as a single frame.
+func (w *ChunkedWriter) Write(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	var buf [binary.MaxVarintLen64]byte
+	v := binary.PutUvarint(buf[:], uint64(len(b)))
+	nv, err := w.w.Write(buf[:v])
+	if err != nil {
+		return nv, err
+	}
+
+	w.crc32.Reset()
+	if _, err := w.crc32.Write(b); err != nil {
+		return nv, err
+	}
+	if err := binary.Write(w.w, binary.BigEndian, w.crc32.Sum32()); err != nil {
+		return nv, err
+	}
+
+	n, err := w.w.Write(b)
+	if err != nil {
+		return n + nv + 4, err
+	}
+	w.flusher.Flush()
+	return n + nv + 4, nil
+}
+
+// ChunkedReader is a buffered reader of frames written by a ChunkedWriter. It allocates at most as much as
+// the biggest frame on top of the buffered reader.
+type ChunkedReader struct {
+	b         *bufio.Reader
+	data      []byte
+	sizeLimit uint64
+	crc32     hash.Hash32
+}
+
+// NewChunkedReader returns a ChunkedReader reading frames of at most sizeLimit bytes from r.
+// The given data buffer is reused for the frames if it is big enough.
+func NewChunkedReader(r io.Reader, sizeLimit uint64, data []byte) *ChunkedReader {
+	return &ChunkedReader{
+		b:         bufio.NewReader(r),
+		sizeLimit: sizeLimit,
+		data:      data,
+		crc32:     crc32.New(castagnoliTable),
+	}
+}
+
+// Next returns the next frame and verifies its checksum. It returns io.EOF if there are no more frames and
+// io.ErrUnexpectedEOF if a frame is shorter than its size.
+// The returned slice is only valid until the next call of Next.
+func (r *ChunkedReader) Next() ([]byte, error) {
+	size, err := binary.ReadUvarint(r.b)
+	if err != nil {
+		return nil, err
+	}
+	if size > r.sizeLimit {
+		return nil, errors.Errorf("frame size %v exceeds the limit of %v bytes", size, r.sizeLimit)
+	}
+
+	if uint64(cap(r.data)) < size {
+		r.data = make([]byte, size)
+	} else {
+		r.data = r.data[:size]
+	}
+
+	var checksum uint32
+	if err := binary.Read(r.b, binary.BigEndian, &checksum); err != nil {
+		return nil, noEOF(err)
+	}
+
+	r.crc32.Reset()
+	if _, err := io.ReadFull(io.TeeReader(r.b, r.crc32), r.data); err != nil {
+		return nil, noEOF(err)
+	}
+	if r.crc32.Sum32() != checksum {
+		return nil, errors.New("corrupted frame: checksum mismatch")
+	}
+	return r.data, nil
+}
+
+// NextProto unmarshals the next frame into pb.
+func (r *ChunkedReader) NextProto(pb proto.Message) error {
+	data, err := r.Next()
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, pb)
+}
+
+// noEOF reports an EOF in the middle of a frame as io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
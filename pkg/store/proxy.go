@@ -49,6 +49,8 @@ type ProxyStore struct {
 	selectorLabels labels.Labels
 
 	responseTimeout time.Duration
+	// Maximum size of the chunks sent in a single series frame. Bigger series are split across multiple frames.
+	maxFrameBytes int
 }
 
 // NewProxyStore returns a new ProxyStore that uses the given clients that implements storeAPI to fan-in all series to the client.
@@ -70,6 +72,7 @@ func NewProxyStore(
 		component:       component,
 		selectorLabels:  selectorLabels,
 		responseTimeout: responseTimeout,
+		maxFrameBytes:   storepb.MaxSeriesFrameBytes,
 	}
 	return s
 }
@@ -212,12 +215,20 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 				Aggregates:              r.Aggregates,
 				MaxResolutionWindow:     r.MaxResolutionWindow,
 				PartialResponseDisabled: r.PartialResponseDisabled,
+				QueryStats:              r.QueryStats,
+				SkipChunks:              r.SkipChunks,
+				AllowSplitSeries:        r.AllowSplitSeries,
 			}
-			wg = &sync.WaitGroup{}
+			wg    = &sync.WaitGroup{}
+			stats = &statsAggregator{}
 		)
 
 		defer func() {
 			wg.Wait()
+			// Statistics of all stores are merged into a single frame sent after all series.
+			if r.QueryStats {
+				respSender.send(storepb.NewStatsSeriesResponse(stats.get()))
+			}
 			closeFn()
 		}()
 
@@ -259,7 +270,7 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 			// Schedule streamSeriesSet that translates gRPC streamed response
 			// into seriesSet (if series) or respCh if warnings.
 			seriesSet = append(seriesSet, startStreamSeriesSet(seriesCtx, s.logger, closeSeries,
-				wg, sc, respSender, st.String(), !r.PartialResponseDisabled, s.responseTimeout))
+				wg, sc, respSender, stats, st.String(), !r.PartialResponseDisabled, s.responseTimeout))
 		}
 
 		level.Debug(s.logger).Log("msg", strings.Join(storeDebugMsgs, ";"))
@@ -271,11 +282,21 @@ func (s *ProxyStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSe
 			return nil
 		}
 
+		// Series split across multiple frames by a store are merged frame by frame, so the merged set may split
+		// them as well. Series merged from multiple stores are split again if they are too big for a single frame.
+		// Stores only split series if the client allows it, as the request is passed on as it is.
+		maxFrameBytes := 0
+		if r.AllowSplitSeries {
+			maxFrameBytes = s.maxFrameBytes
+		}
 		mergedSet := storepb.MergeSeriesSets(seriesSet...)
 		for mergedSet.Next() {
 			var series storepb.Series
 			series.Labels, series.Chunks = mergedSet.At()
-			respSender.send(storepb.NewSeriesResponse(&series))
+			frames := storepb.SplitSeries(series, maxFrameBytes)
+			for i := range frames {
+				respSender.send(storepb.NewSeriesResponse(&frames[i]))
+			}
 		}
 		return mergedSet.Err()
 	})
@@ -297,6 +318,25 @@ type warnSender interface {
 	send(*storepb.SeriesResponse)
 }
 
+// statsAggregator merges the query statistics received from multiple stores.
+type statsAggregator struct {
+	mtx   sync.Mutex
+	stats storepb.SeriesStats
+}
+
+func (a *statsAggregator) merge(s *storepb.SeriesStats) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.stats.Merge(s)
+}
+
+func (a *statsAggregator) get() *storepb.SeriesStats {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	s := a.stats
+	return &s
+}
+
 // streamSeriesSet iterates over incoming stream of series.
 // All errors are sent out of band via warning channel.
 type streamSeriesSet struct {
@@ -305,6 +345,7 @@ type streamSeriesSet struct {
 
 	stream storepb.Store_SeriesClient
 	warnCh warnSender
+	stats  *statsAggregator
 
 	currSeries *storepb.Series
 	recvCh     chan *storepb.Series
@@ -326,6 +367,7 @@ func startStreamSeriesSet(
 	wg *sync.WaitGroup,
 	stream storepb.Store_SeriesClient,
 	warnCh warnSender,
+	stats *statsAggregator,
 	name string,
 	partialResponse bool,
 	responseTimeout time.Duration,
@@ -336,6 +378,7 @@ func startStreamSeriesSet(
 		closeSeries:     closeSeries,
 		stream:          stream,
 		warnCh:          warnCh,
+		stats:           stats,
 		recvCh:          make(chan *storepb.Series, 10),
 		name:            name,
 		partialResponse: partialResponse,
@@ -372,6 +415,11 @@ func startStreamSeriesSet(
 				continue
 			}
 
+			if st := r.GetStats(); st != nil {
+				s.stats.merge(st)
+				continue
+			}
+
 			select {
 			case s.recvCh <- r.GetSeries():
 				continue
@@ -484,18 +532,35 @@ func labelSetMatches(ls storepb.LabelSet, matchers []storepb.LabelMatcher) (bool
 func (s *ProxyStore) LabelNames(ctx context.Context, r *storepb.LabelNamesRequest) (
 	*storepb.LabelNamesResponse, error,
 ) {
+	match, newMatchers, err := matchesExternalLabels(r.Matchers, s.selectorLabels)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !match {
+		return &storepb.LabelNamesResponse{Names: []string{}}, nil
+	}
+
 	var (
-		warnings []string
-		names    [][]string
-		mtx      sync.Mutex
-		g, gctx  = errgroup.WithContext(ctx)
+		warnings   []string
+		names      [][]string
+		mtx        sync.Mutex
+		g, gctx    = errgroup.WithContext(ctx)
+		mint, maxt = r.TimeRange()
 	)
 
 	for _, st := range s.stores() {
 		st := st
+
+		// Skip the stores which cannot have series matching the request.
+		if ok, _ := storeMatches(st, mint, maxt, newMatchers...); !ok {
+			continue
+		}
 		g.Go(func() error {
 			resp, err := st.LabelNames(gctx, &storepb.LabelNamesRequest{
 				PartialResponseDisabled: r.PartialResponseDisabled,
+				Start:                   r.Start,
+				End:                     r.End,
+				Matchers:                newMatchers,
 			})
 			if err != nil {
 				err = errors.Wrapf(err, "fetch label names from store %s", st)
@@ -532,19 +597,36 @@ func (s *ProxyStore) LabelNames(ctx context.Context, r *storepb.LabelNamesReques
 func (s *ProxyStore) LabelValues(ctx context.Context, r *storepb.LabelValuesRequest) (
 	*storepb.LabelValuesResponse, error,
 ) {
+	match, newMatchers, err := matchesExternalLabels(r.Matchers, s.selectorLabels)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !match {
+		return &storepb.LabelValuesResponse{Values: []string{}}, nil
+	}
+
 	var (
-		warnings []string
-		all      [][]string
-		mtx      sync.Mutex
-		g, gctx  = errgroup.WithContext(ctx)
+		warnings   []string
+		all        [][]string
+		mtx        sync.Mutex
+		g, gctx    = errgroup.WithContext(ctx)
+		mint, maxt = r.TimeRange()
 	)
 
 	for _, st := range s.stores() {
 		store := st
+
+		// Skip the stores which cannot have series matching the request.
+		if ok, _ := storeMatches(store, mint, maxt, newMatchers...); !ok {
+			continue
+		}
 		g.Go(func() error {
 			resp, err := store.LabelValues(gctx, &storepb.LabelValuesRequest{
 				Label:                   r.Label,
 				PartialResponseDisabled: r.PartialResponseDisabled,
+				Start:                   r.Start,
+				End:                     r.End,
+				Matchers:                newMatchers,
 			})
 			if err != nil {
 				err = errors.Wrapf(err, "fetch label values from store %s", store)
@@ -591,12 +591,179 @@ func TestProxyStore_Series_RequestParamsProxied(t *testing.T) {
 			storepb.Aggr_COUNT,
 		},
 		MaxResolutionWindow: 1234,
+		SkipChunks:          true,
 	}
 	testutil.Ok(t, q.Series(req, s))
 
 	testutil.Assert(t, proto.Equal(req, m.LastSeriesReq), "request was not proxied properly to underlying storeAPI: %s vs %s", req, m.LastSeriesReq)
 }
 
+func TestProxyStore_Series_QueryStats(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	cls := []Client{
+		&testClient{
+			StoreClient: &mockedStoreAPI{
+				RespSeries: []*storepb.SeriesResponse{
+					storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{0, 0}, {2, 1}}),
+					storepb.NewStatsSeriesResponse(&storepb.SeriesStats{BlocksQueried: 1, SeriesFetched: 2}),
+				},
+			},
+			minTime: 1,
+			maxTime: 300,
+		},
+		&testClient{
+			StoreClient: &mockedStoreAPI{
+				RespSeries: []*storepb.SeriesResponse{
+					storeSeriesResponse(t, labels.FromStrings("a", "b"), []sample{{0, 0}, {2, 1}}),
+					storepb.NewStatsSeriesResponse(&storepb.SeriesStats{BlocksQueried: 2, SeriesFetched: 3}),
+				},
+			},
+			minTime: 1,
+			maxTime: 300,
+		},
+	}
+	q := NewProxyStore(nil,
+		func() []Client { return cls },
+		component.Query,
+		nil,
+		0*time.Second,
+	)
+
+	s := newStoreSeriesServer(context.Background())
+	testutil.Ok(t, q.Series(&storepb.SeriesRequest{
+		MinTime:    1,
+		MaxTime:    300,
+		Matchers:   []storepb.LabelMatcher{{Name: "a", Value: ".*", Type: storepb.LabelMatcher_RE}},
+		QueryStats: true,
+	}, s))
+
+	testutil.Equals(t, 2, len(s.SeriesSet))
+	testutil.Equals(t, &storepb.SeriesStats{BlocksQueried: 3, SeriesFetched: 5}, s.Stats)
+}
+
+func TestProxyStore_Series_SplitFrames(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	cls := []Client{
+		&testClient{
+			StoreClient: &mockedStoreAPI{
+				RespSeries: []*storepb.SeriesResponse{
+					storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{0, 0}, {1, 1}}),
+					storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{2, 2}, {3, 3}}),
+					storeSeriesResponse(t, labels.FromStrings("a", "b"), []sample{{0, 0}, {1, 1}}),
+				},
+			},
+			minTime: 1,
+			maxTime: 300,
+		},
+		&testClient{
+			StoreClient: &mockedStoreAPI{
+				RespSeries: []*storepb.SeriesResponse{
+					storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{4, 4}, {5, 5}}),
+					storeSeriesResponse(t, labels.FromStrings("a", "c"), []sample{{0, 0}, {1, 1}}),
+					storeSeriesResponse(t, labels.FromStrings("a", "c"), []sample{{2, 2}, {3, 3}}),
+				},
+			},
+			minTime: 1,
+			maxTime: 300,
+		},
+	}
+	q := NewProxyStore(nil,
+		func() []Client { return cls },
+		component.Query,
+		nil,
+		0*time.Second,
+	)
+	req := &storepb.SeriesRequest{
+		MinTime:          1,
+		MaxTime:          300,
+		Matchers:         []storepb.LabelMatcher{{Name: "a", Value: ".*", Type: storepb.LabelMatcher_RE}},
+		AllowSplitSeries: true,
+	}
+	expected := []rawSeries{
+		{
+			lset:    []storepb.Label{{Name: "a", Value: "a"}},
+			samples: []sample{{0, 0}, {1, 1}, {2, 2}, {3, 3}, {4, 4}, {5, 5}},
+		},
+		{
+			lset:    []storepb.Label{{Name: "a", Value: "b"}},
+			samples: []sample{{0, 0}, {1, 1}},
+		},
+		{
+			lset:    []storepb.Label{{Name: "a", Value: "c"}},
+			samples: []sample{{0, 0}, {1, 1}, {2, 2}, {3, 3}},
+		},
+	}
+
+	s := newStoreSeriesServer(context.Background())
+	testutil.Ok(t, q.Series(req, s))
+	seriesEqual(t, expected, reassembleSeries(s.SeriesSet))
+	for _, c := range cls {
+		testutil.Assert(t, c.(*testClient).StoreClient.(*mockedStoreAPI).LastSeriesReq.AllowSplitSeries, "expected splitting to be allowed for stores")
+	}
+
+	// Merged series are split again if they do not fit into a single frame.
+	q.maxFrameBytes = 1
+	s = newStoreSeriesServer(context.Background())
+	testutil.Ok(t, q.Series(req, s))
+	testutil.Equals(t, 6, len(s.SeriesSet))
+	for _, series := range s.SeriesSet {
+		testutil.Equals(t, 1, len(series.Chunks))
+	}
+	seriesEqual(t, expected, reassembleSeries(s.SeriesSet))
+
+	// Neither stores nor the proxy split series if the client does not reassemble them.
+	req.AllowSplitSeries = false
+	s = newStoreSeriesServer(context.Background())
+	testutil.Ok(t, q.Series(req, s))
+	for _, c := range cls {
+		testutil.Assert(t, !c.(*testClient).StoreClient.(*mockedStoreAPI).LastSeriesReq.AllowSplitSeries, "expected splitting not to be allowed for stores")
+	}
+}
+
+func TestProxyStore_Series_Unsplit(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	cls := []Client{
+		&testClient{
+			StoreClient: &mockedStoreAPI{
+				RespSeries: []*storepb.SeriesResponse{
+					storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{0, 0}, {1, 1}}),
+				},
+			},
+			minTime: 1,
+			maxTime: 300,
+		},
+		&testClient{
+			StoreClient: &mockedStoreAPI{
+				RespSeries: []*storepb.SeriesResponse{
+					storeSeriesResponse(t, labels.FromStrings("a", "a"), []sample{{4, 4}, {5, 5}}),
+				},
+			},
+			minTime: 1,
+			maxTime: 300,
+		},
+	}
+	q := NewProxyStore(nil,
+		func() []Client { return cls },
+		component.Query,
+		nil,
+		0*time.Second,
+	)
+	q.maxFrameBytes = 1
+
+	s := newStoreSeriesServer(context.Background())
+	testutil.Ok(t, q.Series(&storepb.SeriesRequest{
+		MinTime:  1,
+		MaxTime:  300,
+		Matchers: []storepb.LabelMatcher{{Name: "a", Value: ".*", Type: storepb.LabelMatcher_RE}},
+	}, s))
+	// The series merged from both stores does not fit into a single frame, but splitting is not allowed.
+	testutil.Equals(t, 1, len(s.SeriesSet))
+	testutil.Equals(t, 2, len(s.SeriesSet[0].Chunks))
+}
+
 func TestProxyStore_Series_RegressionFillResponseChannel(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
@@ -688,6 +855,74 @@ func TestProxyStore_LabelValues(t *testing.T) {
 	testutil.Equals(t, 1, len(resp.Warnings))
 }
 
+func TestProxyStore_LabelValues_FilterStores(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	m1 := &mockedStoreAPI{
+		RespLabelValues: &storepb.LabelValuesResponse{Values: []string{"1"}},
+		RespLabelNames:  &storepb.LabelNamesResponse{Names: []string{"a"}},
+	}
+	m2 := &mockedStoreAPI{
+		RespLabelValues: &storepb.LabelValuesResponse{Values: []string{"2"}},
+		RespLabelNames:  &storepb.LabelNamesResponse{Names: []string{"a"}},
+	}
+	m3 := &mockedStoreAPI{
+		RespLabelValues: &storepb.LabelValuesResponse{Values: []string{"3"}},
+		RespLabelNames:  &storepb.LabelNamesResponse{Names: []string{"a"}},
+	}
+	cls := []Client{
+		&testClient{
+			StoreClient: m1,
+			labelSets:   []storepb.LabelSet{{Labels: []storepb.Label{{Name: "ext", Value: "1"}}}},
+			minTime:     0,
+			maxTime:     100,
+		},
+		&testClient{
+			StoreClient: m2,
+			labelSets:   []storepb.LabelSet{{Labels: []storepb.Label{{Name: "ext", Value: "1"}}}},
+			minTime:     200,
+			maxTime:     300,
+		},
+		&testClient{
+			StoreClient: m3,
+			labelSets:   []storepb.LabelSet{{Labels: []storepb.Label{{Name: "ext", Value: "2"}}}},
+			minTime:     0,
+			maxTime:     300,
+		},
+	}
+	q := NewProxyStore(nil,
+		func() []Client { return cls },
+		component.Query,
+		nil,
+		0*time.Second,
+	)
+
+	ctx := context.Background()
+	req := &storepb.LabelValuesRequest{
+		Label:    "a",
+		Start:    150,
+		End:      300,
+		Matchers: []storepb.LabelMatcher{{Name: "ext", Value: "1", Type: storepb.LabelMatcher_EQ}},
+	}
+	resp, err := q.LabelValues(ctx, req)
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"2"}, resp.Values)
+
+	// Only the store matching both the time range and external labels is queried, with the request proxied as is.
+	testutil.Assert(t, m1.LastLabelValuesReq == nil, "store outside of the time range was queried")
+	testutil.Assert(t, m3.LastLabelValuesReq == nil, "store with non matching external labels was queried")
+	testutil.Assert(t, proto.Equal(req, m2.LastLabelValuesReq), "request was not proxied properly to underlying storeAPI: %s vs %s", req, m2.LastLabelValuesReq)
+
+	// Without time range all stores with matching external labels are queried.
+	namesResp, err := q.LabelNames(ctx, &storepb.LabelNamesRequest{
+		Matchers: []storepb.LabelMatcher{{Name: "ext", Value: "1", Type: storepb.LabelMatcher_EQ}},
+	})
+	testutil.Ok(t, err)
+	testutil.Assert(t, m1.LastLabelNamesReq != nil && m2.LastLabelNamesReq != nil, "stores with matching external labels were not queried")
+	testutil.Assert(t, m3.LastLabelNamesReq == nil, "store with non matching external labels was queried")
+	testutil.Equals(t, 0, len(namesResp.Warnings))
+}
+
 func TestProxyStore_LabelNames(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
@@ -825,6 +1060,23 @@ func seriesEqual(t *testing.T, expected []rawSeries, got []storepb.Series) {
 	}
 }
 
+// reassembleSeries merges consecutive frames of the same series and sorts their chunks by time.
+func reassembleSeries(frames []storepb.Series) []storepb.Series {
+	var res []storepb.Series
+	for _, f := range frames {
+		if len(res) > 0 && storepb.CompareLabels(res[len(res)-1].Labels, f.Labels) == 0 {
+			res[len(res)-1].Chunks = append(res[len(res)-1].Chunks, f.Chunks...)
+			continue
+		}
+		res = append(res, storepb.Series{Labels: f.Labels, Chunks: append([]storepb.AggrChunk(nil), f.Chunks...)})
+	}
+	for _, s := range res {
+		chks := s.Chunks
+		sort.Slice(chks, func(i, j int) bool { return chks[i].MinTime < chks[j].MinTime })
+	}
+	return res
+}
+
 func TestStoreMatches(t *testing.T) {
 	defer leaktest.CheckTimeout(t, 10*time.Second)()
 
@@ -944,6 +1196,7 @@ type storeSeriesServer struct {
 
 	SeriesSet []storepb.Series
 	Warnings  []string
+	Stats     *storepb.SeriesStats
 }
 
 func newStoreSeriesServer(ctx context.Context) *storeSeriesServer {
@@ -956,6 +1209,11 @@ func (s *storeSeriesServer) Send(r *storepb.SeriesResponse) error {
 		return nil
 	}
 
+	if r.GetStats() != nil {
+		s.Stats = r.GetStats()
+		return nil
+	}
+
 	if r.GetSeries() == nil {
 		return errors.New("no seriesSet")
 	}
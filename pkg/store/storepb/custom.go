@@ -1,6 +1,8 @@
 package storepb
 
 import (
+	"fmt"
+	"math"
 	"strings"
 
 	"github.com/prometheus/prometheus/pkg/labels"
@@ -30,6 +32,46 @@ func NewSeriesResponse(series *Series) *SeriesResponse {
 	}
 }
 
+func NewStatsSeriesResponse(stats *SeriesStats) *SeriesResponse {
+	return &SeriesResponse{
+		Result: &SeriesResponse_Stats{
+			Stats: stats,
+		},
+	}
+}
+
+// Merge adds the statistics of o to s.
+func (s *SeriesStats) Merge(o *SeriesStats) {
+	s.BlocksQueried += o.BlocksQueried
+
+	s.PostingsTouched += o.PostingsTouched
+	s.PostingsTouchedSizeBytes += o.PostingsTouchedSizeBytes
+	s.PostingsFetched += o.PostingsFetched
+	s.PostingsFetchedSizeBytes += o.PostingsFetchedSizeBytes
+	s.PostingsFetchCount += o.PostingsFetchCount
+	s.PostingsFetchDurationNs += o.PostingsFetchDurationNs
+
+	s.SeriesTouched += o.SeriesTouched
+	s.SeriesTouchedSizeBytes += o.SeriesTouchedSizeBytes
+	s.SeriesFetched += o.SeriesFetched
+	s.SeriesFetchedSizeBytes += o.SeriesFetchedSizeBytes
+	s.SeriesFetchCount += o.SeriesFetchCount
+	s.SeriesFetchDurationNs += o.SeriesFetchDurationNs
+
+	s.ChunksTouched += o.ChunksTouched
+	s.ChunksTouchedSizeBytes += o.ChunksTouchedSizeBytes
+	s.ChunksFetched += o.ChunksFetched
+	s.ChunksFetchedSizeBytes += o.ChunksFetchedSizeBytes
+	s.ChunksFetchCount += o.ChunksFetchCount
+	s.ChunksFetchDurationNs += o.ChunksFetchDurationNs
+	s.ChunksCacheHits += o.ChunksCacheHits
+
+	s.GetAllDurationNs += o.GetAllDurationNs
+	s.MergedSeriesCount += o.MergedSeriesCount
+	s.MergedChunksCount += o.MergedChunksCount
+	s.MergeDurationNs += o.MergeDurationNs
+}
+
 // CompareLabels compares two sets of labels.
 func CompareLabels(a, b []Label) int {
 	l := len(a)
@@ -77,6 +119,7 @@ func MergeSeriesSets(all ...SeriesSet) SeriesSet {
 
 // SeriesSet is a set of series and their corresponding chunks.
 // The set is sorted by the label sets. Chunks may be overlapping or out of order.
+// The chunks of a series may be split across consecutive elements with the same labels.
 type SeriesSet interface {
 	Next() bool
 	At() ([]Label, []AggrChunk)
@@ -161,6 +204,30 @@ func (s *mergedSeriesSet) Next() bool {
 	return true
 }
 
+// MaxSeriesFrameBytes is the default maximum size of the chunks sent in a single series frame.
+const MaxSeriesFrameBytes = 1024 * 1024
+
+// SplitSeries splits the chunks of the series across frames with its labels, each holding at most maxBytes
+// of chunks or a single bigger chunk. The series is returned as a single frame if maxBytes is not positive.
+func SplitSeries(s Series, maxBytes int) []Series {
+	if maxBytes <= 0 || len(s.Chunks) < 2 {
+		return []Series{s}
+	}
+	var (
+		frames      []Series
+		start, size int
+	)
+	for i, c := range s.Chunks {
+		cs := c.Size()
+		if i > start && size+cs > maxBytes {
+			frames = append(frames, Series{Labels: s.Labels, Chunks: s.Chunks[start:i]})
+			start, size = i, 0
+		}
+		size += cs
+	}
+	return append(frames, Series{Labels: s.Labels, Chunks: s.Chunks[start:]})
+}
+
 func LabelsToPromLabels(lset []Label) labels.Labels {
 	ret := make(labels.Labels, len(lset))
 	for i, l := range lset {
@@ -185,3 +252,37 @@ func LabelSetsToString(lsets []LabelSet) string {
 	}
 	return strings.Join(s, "")
 }
+
+// MatchersToString returns the PromQL selector matching the same series as the given matchers.
+func MatchersToString(ms ...LabelMatcher) string {
+	var s []string
+	for _, m := range ms {
+		var op string
+		switch m.Type {
+		case LabelMatcher_EQ:
+			op = "="
+		case LabelMatcher_NEQ:
+			op = "!="
+		case LabelMatcher_RE:
+			op = "=~"
+		case LabelMatcher_NRE:
+			op = "!~"
+		}
+		s = append(s, fmt.Sprintf("%s%s%q", m.Name, op, m.Value))
+	}
+	return "{" + strings.Join(s, ",") + "}"
+}
+
+// timeRange returns the requested time range, which is unbounded if neither start nor end are set.
+func timeRange(start, end int64) (int64, int64) {
+	if start == 0 && end == 0 {
+		return math.MinInt64, math.MaxInt64
+	}
+	return start, end
+}
+
+// TimeRange returns the time range of the request. It is unbounded if neither start nor end are set.
+func (r *LabelNamesRequest) TimeRange() (mint, maxt int64) { return timeRange(r.Start, r.End) }
+
+// TimeRange returns the time range of the request. It is unbounded if neither start nor end are set.
+func (r *LabelValuesRequest) TimeRange() (mint, maxt int64) { return timeRange(r.Start, r.End) }
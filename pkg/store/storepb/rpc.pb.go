@@ -268,9 +268,18 @@ type SeriesRequest struct {
 	PartialResponseDisabled bool `protobuf:"varint,6,opt,name=partial_response_disabled,json=partialResponseDisabled,proto3" json:"partial_response_disabled,omitempty"`
 	// TODO(bwplotka): Move Thanos components to use strategy instead. Inlcuding QueryAPI.
 	PartialResponseStrategy PartialResponseStrategy `protobuf:"varint,7,opt,name=partial_response_strategy,json=partialResponseStrategy,proto3,enum=thanos.PartialResponseStrategy" json:"partial_response_strategy,omitempty"`
-	XXX_NoUnkeyedLiteral    struct{}                `json:"-"`
-	XXX_unrecognized        []byte                  `json:"-"`
-	XXX_sizecache           int32                   `json:"-"`
+	/// query_stats requests statistics of the query execution, sent in the last frame of the response.
+	/// Stores that do not track statistics ignore it.
+	QueryStats bool `protobuf:"varint,8,opt,name=query_stats,json=queryStats,proto3" json:"query_stats,omitempty"`
+	/// skip_chunks hints that only the labels of the matching series are needed. Stores supporting it respond with
+	/// series without chunks, others can ignore it.
+	SkipChunks bool `protobuf:"varint,9,opt,name=skip_chunks,json=skipChunks,proto3" json:"skip_chunks,omitempty"`
+	/// allow_split_series announces that the client reassembles series whose chunks are split across consecutive
+	/// frames with the same labels. Stores only split series if it is set.
+	AllowSplitSeries     bool     `protobuf:"varint,10,opt,name=allow_split_series,json=allowSplitSeries,proto3" json:"allow_split_series,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *SeriesRequest) Reset()         { *m = SeriesRequest{} }
@@ -310,6 +319,7 @@ type SeriesResponse struct {
 	// Types that are valid to be assigned to Result:
 	//	*SeriesResponse_Series
 	//	*SeriesResponse_Warning
+	//	*SeriesResponse_Stats
 	Result               isSeriesResponse_Result `protobuf_oneof:"result"`
 	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
 	XXX_unrecognized     []byte                  `json:"-"`
@@ -361,9 +371,13 @@ type SeriesResponse_Series struct {
 type SeriesResponse_Warning struct {
 	Warning string `protobuf:"bytes,2,opt,name=warning,proto3,oneof"`
 }
+type SeriesResponse_Stats struct {
+	Stats *SeriesStats `protobuf:"bytes,3,opt,name=stats,proto3,oneof"`
+}
 
 func (*SeriesResponse_Series) isSeriesResponse_Result()  {}
 func (*SeriesResponse_Warning) isSeriesResponse_Result() {}
+func (*SeriesResponse_Stats) isSeriesResponse_Result()   {}
 
 func (m *SeriesResponse) GetResult() isSeriesResponse_Result {
 	if m != nil {
@@ -386,11 +400,19 @@ func (m *SeriesResponse) GetWarning() string {
 	return ""
 }
 
+func (m *SeriesResponse) GetStats() *SeriesStats {
+	if x, ok := m.GetResult().(*SeriesResponse_Stats); ok {
+		return x.Stats
+	}
+	return nil
+}
+
 // XXX_OneofFuncs is for the internal use of the proto package.
 func (*SeriesResponse) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error, func(msg proto.Message, tag, wire int, b *proto.Buffer) (bool, error), func(msg proto.Message) (n int), []interface{}) {
 	return _SeriesResponse_OneofMarshaler, _SeriesResponse_OneofUnmarshaler, _SeriesResponse_OneofSizer, []interface{}{
 		(*SeriesResponse_Series)(nil),
 		(*SeriesResponse_Warning)(nil),
+		(*SeriesResponse_Stats)(nil),
 	}
 }
 
@@ -406,6 +428,11 @@ func _SeriesResponse_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 	case *SeriesResponse_Warning:
 		_ = b.EncodeVarint(2<<3 | proto.WireBytes)
 		_ = b.EncodeStringBytes(x.Warning)
+	case *SeriesResponse_Stats:
+		_ = b.EncodeVarint(3<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.Stats); err != nil {
+			return err
+		}
 	case nil:
 	default:
 		return fmt.Errorf("SeriesResponse.Result has unexpected type %T", x)
@@ -431,6 +458,14 @@ func _SeriesResponse_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto
 		x, err := b.DecodeStringBytes()
 		m.Result = &SeriesResponse_Warning{x}
 		return true, err
+	case 3: // result.stats
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(SeriesStats)
+		err := b.DecodeMessage(msg)
+		m.Result = &SeriesResponse_Stats{msg}
+		return true, err
 	default:
 		return false, nil
 	}
@@ -449,6 +484,11 @@ func _SeriesResponse_OneofSizer(msg proto.Message) (n int) {
 		n += 1 // tag and wire
 		n += proto.SizeVarint(uint64(len(x.Warning)))
 		n += len(x.Warning)
+	case *SeriesResponse_Stats:
+		s := proto.Size(x.Stats)
+		n += 1 // tag and wire
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case nil:
 	default:
 		panic(fmt.Sprintf("proto: unexpected type %T in oneof", x))
@@ -456,20 +496,91 @@ func _SeriesResponse_OneofSizer(msg proto.Message) (n int) {
 	return n
 }
 
+/// SeriesStats are statistics of the data touched and fetched by a Series call. Durations are in nanoseconds
+/// and summed over all concurrent operations.
+type SeriesStats struct {
+	BlocksQueried            int64    `protobuf:"varint,1,opt,name=blocks_queried,json=blocksQueried,proto3" json:"blocks_queried,omitempty"`
+	PostingsTouched          int64    `protobuf:"varint,2,opt,name=postings_touched,json=postingsTouched,proto3" json:"postings_touched,omitempty"`
+	PostingsTouchedSizeBytes int64    `protobuf:"varint,3,opt,name=postings_touched_size_bytes,json=postingsTouchedSizeBytes,proto3" json:"postings_touched_size_bytes,omitempty"`
+	PostingsFetched          int64    `protobuf:"varint,4,opt,name=postings_fetched,json=postingsFetched,proto3" json:"postings_fetched,omitempty"`
+	PostingsFetchedSizeBytes int64    `protobuf:"varint,5,opt,name=postings_fetched_size_bytes,json=postingsFetchedSizeBytes,proto3" json:"postings_fetched_size_bytes,omitempty"`
+	PostingsFetchCount       int64    `protobuf:"varint,6,opt,name=postings_fetch_count,json=postingsFetchCount,proto3" json:"postings_fetch_count,omitempty"`
+	PostingsFetchDurationNs  int64    `protobuf:"varint,7,opt,name=postings_fetch_duration_ns,json=postingsFetchDurationNs,proto3" json:"postings_fetch_duration_ns,omitempty"`
+	SeriesTouched            int64    `protobuf:"varint,8,opt,name=series_touched,json=seriesTouched,proto3" json:"series_touched,omitempty"`
+	SeriesTouchedSizeBytes   int64    `protobuf:"varint,9,opt,name=series_touched_size_bytes,json=seriesTouchedSizeBytes,proto3" json:"series_touched_size_bytes,omitempty"`
+	SeriesFetched            int64    `protobuf:"varint,10,opt,name=series_fetched,json=seriesFetched,proto3" json:"series_fetched,omitempty"`
+	SeriesFetchedSizeBytes   int64    `protobuf:"varint,11,opt,name=series_fetched_size_bytes,json=seriesFetchedSizeBytes,proto3" json:"series_fetched_size_bytes,omitempty"`
+	SeriesFetchCount         int64    `protobuf:"varint,12,opt,name=series_fetch_count,json=seriesFetchCount,proto3" json:"series_fetch_count,omitempty"`
+	SeriesFetchDurationNs    int64    `protobuf:"varint,13,opt,name=series_fetch_duration_ns,json=seriesFetchDurationNs,proto3" json:"series_fetch_duration_ns,omitempty"`
+	ChunksTouched            int64    `protobuf:"varint,14,opt,name=chunks_touched,json=chunksTouched,proto3" json:"chunks_touched,omitempty"`
+	ChunksTouchedSizeBytes   int64    `protobuf:"varint,15,opt,name=chunks_touched_size_bytes,json=chunksTouchedSizeBytes,proto3" json:"chunks_touched_size_bytes,omitempty"`
+	ChunksFetched            int64    `protobuf:"varint,16,opt,name=chunks_fetched,json=chunksFetched,proto3" json:"chunks_fetched,omitempty"`
+	ChunksFetchedSizeBytes   int64    `protobuf:"varint,17,opt,name=chunks_fetched_size_bytes,json=chunksFetchedSizeBytes,proto3" json:"chunks_fetched_size_bytes,omitempty"`
+	ChunksFetchCount         int64    `protobuf:"varint,18,opt,name=chunks_fetch_count,json=chunksFetchCount,proto3" json:"chunks_fetch_count,omitempty"`
+	ChunksFetchDurationNs    int64    `protobuf:"varint,19,opt,name=chunks_fetch_duration_ns,json=chunksFetchDurationNs,proto3" json:"chunks_fetch_duration_ns,omitempty"`
+	ChunksCacheHits          int64    `protobuf:"varint,20,opt,name=chunks_cache_hits,json=chunksCacheHits,proto3" json:"chunks_cache_hits,omitempty"`
+	GetAllDurationNs         int64    `protobuf:"varint,21,opt,name=get_all_duration_ns,json=getAllDurationNs,proto3" json:"get_all_duration_ns,omitempty"`
+	MergedSeriesCount        int64    `protobuf:"varint,22,opt,name=merged_series_count,json=mergedSeriesCount,proto3" json:"merged_series_count,omitempty"`
+	MergedChunksCount        int64    `protobuf:"varint,23,opt,name=merged_chunks_count,json=mergedChunksCount,proto3" json:"merged_chunks_count,omitempty"`
+	MergeDurationNs          int64    `protobuf:"varint,24,opt,name=merge_duration_ns,json=mergeDurationNs,proto3" json:"merge_duration_ns,omitempty"`
+	XXX_NoUnkeyedLiteral     struct{} `json:"-"`
+	XXX_unrecognized         []byte   `json:"-"`
+	XXX_sizecache            int32    `json:"-"`
+}
+
+func (m *SeriesStats) Reset()         { *m = SeriesStats{} }
+func (m *SeriesStats) String() string { return proto.CompactTextString(m) }
+func (*SeriesStats) ProtoMessage()    {}
+func (*SeriesStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{5}
+}
+func (m *SeriesStats) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SeriesStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SeriesStats.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SeriesStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SeriesStats.Merge(m, src)
+}
+func (m *SeriesStats) XXX_Size() int {
+	return m.Size()
+}
+func (m *SeriesStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_SeriesStats.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SeriesStats proto.InternalMessageInfo
+
 type LabelNamesRequest struct {
 	PartialResponseDisabled bool `protobuf:"varint,1,opt,name=partial_response_disabled,json=partialResponseDisabled,proto3" json:"partial_response_disabled,omitempty"`
 	// TODO(bwplotka): Move Thanos components to use strategy instead. Inlcuding QueryAPI.
 	PartialResponseStrategy PartialResponseStrategy `protobuf:"varint,2,opt,name=partial_response_strategy,json=partialResponseStrategy,proto3,enum=thanos.PartialResponseStrategy" json:"partial_response_strategy,omitempty"`
-	XXX_NoUnkeyedLiteral    struct{}                `json:"-"`
-	XXX_unrecognized        []byte                  `json:"-"`
-	XXX_sizecache           int32                   `json:"-"`
+	/// start and end restrict the result to the names of series with data in the given time range (in milliseconds).
+	/// If both are zero, e.g. for requests of older clients, data of any time is considered.
+	Start int64 `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"`
+	End   int64 `protobuf:"varint,4,opt,name=end,proto3" json:"end,omitempty"`
+	/// matchers restrict the result to the names of series matching all of them.
+	Matchers             []LabelMatcher `protobuf:"bytes,5,rep,name=matchers,proto3" json:"matchers"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
 }
 
 func (m *LabelNamesRequest) Reset()         { *m = LabelNamesRequest{} }
 func (m *LabelNamesRequest) String() string { return proto.CompactTextString(m) }
 func (*LabelNamesRequest) ProtoMessage()    {}
 func (*LabelNamesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{5}
+	return fileDescriptor_77a6da22d6a3feb1, []int{6}
 }
 func (m *LabelNamesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -510,7 +621,7 @@ func (m *LabelNamesResponse) Reset()         { *m = LabelNamesResponse{} }
 func (m *LabelNamesResponse) String() string { return proto.CompactTextString(m) }
 func (*LabelNamesResponse) ProtoMessage()    {}
 func (*LabelNamesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{6}
+	return fileDescriptor_77a6da22d6a3feb1, []int{7}
 }
 func (m *LabelNamesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -544,16 +655,22 @@ type LabelValuesRequest struct {
 	PartialResponseDisabled bool   `protobuf:"varint,2,opt,name=partial_response_disabled,json=partialResponseDisabled,proto3" json:"partial_response_disabled,omitempty"`
 	// TODO(bwplotka): Move Thanos components to use strategy instead. Inlcuding QueryAPI.
 	PartialResponseStrategy PartialResponseStrategy `protobuf:"varint,3,opt,name=partial_response_strategy,json=partialResponseStrategy,proto3,enum=thanos.PartialResponseStrategy" json:"partial_response_strategy,omitempty"`
-	XXX_NoUnkeyedLiteral    struct{}                `json:"-"`
-	XXX_unrecognized        []byte                  `json:"-"`
-	XXX_sizecache           int32                   `json:"-"`
+	/// start and end restrict the result to the values of series with data in the given time range (in milliseconds).
+	/// If both are zero, e.g. for requests of older clients, data of any time is considered.
+	Start int64 `protobuf:"varint,4,opt,name=start,proto3" json:"start,omitempty"`
+	End   int64 `protobuf:"varint,5,opt,name=end,proto3" json:"end,omitempty"`
+	/// matchers restrict the result to the values of series matching all of them.
+	Matchers             []LabelMatcher `protobuf:"bytes,6,rep,name=matchers,proto3" json:"matchers"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
 }
 
 func (m *LabelValuesRequest) Reset()         { *m = LabelValuesRequest{} }
 func (m *LabelValuesRequest) String() string { return proto.CompactTextString(m) }
 func (*LabelValuesRequest) ProtoMessage()    {}
 func (*LabelValuesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{7}
+	return fileDescriptor_77a6da22d6a3feb1, []int{8}
 }
 func (m *LabelValuesRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -594,7 +711,7 @@ func (m *LabelValuesResponse) Reset()         { *m = LabelValuesResponse{} }
 func (m *LabelValuesResponse) String() string { return proto.CompactTextString(m) }
 func (*LabelValuesResponse) ProtoMessage()    {}
 func (*LabelValuesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{8}
+	return fileDescriptor_77a6da22d6a3feb1, []int{9}
 }
 func (m *LabelValuesResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -632,6 +749,7 @@ func init() {
 	proto.RegisterType((*LabelSet)(nil), "thanos.LabelSet")
 	proto.RegisterType((*SeriesRequest)(nil), "thanos.SeriesRequest")
 	proto.RegisterType((*SeriesResponse)(nil), "thanos.SeriesResponse")
+	proto.RegisterType((*SeriesStats)(nil), "thanos.SeriesStats")
 	proto.RegisterType((*LabelNamesRequest)(nil), "thanos.LabelNamesRequest")
 	proto.RegisterType((*LabelNamesResponse)(nil), "thanos.LabelNamesResponse")
 	proto.RegisterType((*LabelValuesRequest)(nil), "thanos.LabelValuesRequest")
@@ -641,56 +759,86 @@ func init() {
 func init() { proto.RegisterFile("rpc.proto", fileDescriptor_77a6da22d6a3feb1) }
 
 var fileDescriptor_77a6da22d6a3feb1 = []byte{
-	// 772 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0x4f, 0x6f, 0xfa, 0x46,
-	0x10, 0x65, 0x6d, 0x30, 0x78, 0x48, 0x90, 0xb3, 0x21, 0x89, 0x71, 0x25, 0x82, 0x38, 0xa1, 0xb4,
-	0x22, 0x2d, 0x55, 0x5b, 0xb5, 0x37, 0x20, 0x8e, 0x82, 0x9a, 0x40, 0xbb, 0x40, 0xe8, 0x9f, 0x03,
-	0x35, 0xc9, 0xd6, 0xb1, 0x64, 0x6c, 0xea, 0x35, 0x4d, 0x72, 0xed, 0xe7, 0xe9, 0xb7, 0xe8, 0x25,
-	0xc7, 0x5e, 0x7b, 0xa9, 0xda, 0x7c, 0x8a, 0x1e, 0x2b, 0xaf, 0xd7, 0x80, 0xdb, 0x24, 0xd2, 0x4f,
-	0xdc, 0x76, 0xde, 0x1b, 0xcf, 0xec, 0x7b, 0x3b, 0xbb, 0x06, 0x35, 0x58, 0xdc, 0x34, 0x17, 0x81,
-	0x1f, 0xfa, 0x58, 0x09, 0xef, 0x2c, 0xcf, 0x67, 0x46, 0x31, 0x7c, 0x5c, 0x50, 0x16, 0x83, 0x46,
-	0xd9, 0xf6, 0x6d, 0x9f, 0x2f, 0x4f, 0xa3, 0x55, 0x8c, 0xd6, 0x77, 0xa1, 0xd8, 0xf3, 0x7e, 0xf4,
-	0x09, 0xfd, 0x69, 0x49, 0x59, 0x58, 0xff, 0x03, 0xc1, 0x4e, 0x1c, 0xb3, 0x85, 0xef, 0x31, 0x8a,
-	0xdf, 0x07, 0xc5, 0xb5, 0x66, 0xd4, 0x65, 0x3a, 0xaa, 0xc9, 0x8d, 0x62, 0x6b, 0xb7, 0x19, 0xd7,
-	0x6e, 0x5e, 0x46, 0x68, 0x27, 0xfb, 0xf4, 0xe7, 0x71, 0x86, 0x88, 0x14, 0x5c, 0x81, 0xc2, 0xdc,
-	0xf1, 0xa6, 0xa1, 0x33, 0xa7, 0xba, 0x54, 0x43, 0x0d, 0x99, 0xe4, 0xe7, 0x8e, 0x37, 0x72, 0xe6,
-	0x94, 0x53, 0xd6, 0x43, 0x4c, 0xc9, 0x82, 0xb2, 0x1e, 0x38, 0x75, 0x0a, 0x2a, 0x0b, 0xfd, 0x80,
-	0x8e, 0x1e, 0x17, 0x54, 0xcf, 0xd6, 0x50, 0xa3, 0xd4, 0xda, 0x4b, 0xba, 0x0c, 0x13, 0x82, 0xac,
-	0x73, 0xf0, 0x27, 0x00, 0xbc, 0xe1, 0x94, 0xd1, 0x90, 0xe9, 0x39, 0xbe, 0x2f, 0x2d, 0xb5, 0xaf,
-	0x21, 0x0d, 0xc5, 0xd6, 0x54, 0x57, 0xc4, 0xac, 0xfe, 0x19, 0x14, 0x12, 0xf2, 0x9d, 0x64, 0xd5,
-	0xff, 0x91, 0x60, 0x77, 0x48, 0x03, 0x87, 0x32, 0x61, 0x53, 0x4a, 0x28, 0x7a, 0x5d, 0xa8, 0x94,
-	0x16, 0xfa, 0x69, 0x44, 0x85, 0x37, 0x77, 0x34, 0x60, 0xba, 0xcc, 0xdb, 0x96, 0x53, 0x6d, 0xaf,
-	0x62, 0x52, 0x74, 0x5f, 0xe5, 0xe2, 0x16, 0x1c, 0x44, 0x25, 0x03, 0xca, 0x7c, 0x77, 0x19, 0x3a,
-	0xbe, 0x37, 0xbd, 0x77, 0xbc, 0x5b, 0xff, 0x9e, 0x9b, 0x25, 0x93, 0xfd, 0xb9, 0xf5, 0x40, 0x56,
-	0xdc, 0x84, 0x53, 0xf8, 0x03, 0x00, 0xcb, 0xb6, 0x03, 0x6a, 0x5b, 0x21, 0x8d, 0x3d, 0x2a, 0xb5,
-	0x76, 0x92, 0x6e, 0x6d, 0xdb, 0x0e, 0xc8, 0x06, 0x8f, 0xbf, 0x80, 0xca, 0xc2, 0x0a, 0x42, 0xc7,
-	0x72, 0xa3, 0x2e, 0xfc, 0xe4, 0xa7, 0xb7, 0x0e, 0xb3, 0x66, 0x2e, 0xbd, 0xd5, 0x95, 0x1a, 0x6a,
-	0x14, 0xc8, 0x91, 0x48, 0x48, 0x26, 0xe3, 0x4c, 0xd0, 0xf8, 0xfb, 0x17, 0xbe, 0x65, 0x61, 0x60,
-	0x85, 0xd4, 0x7e, 0xd4, 0xf3, 0xfc, 0x38, 0x8f, 0x93, 0xc6, 0x5f, 0xa5, 0x6b, 0x0c, 0x45, 0xda,
-	0xff, 0x8a, 0x27, 0x44, 0xfd, 0x07, 0x28, 0x25, 0xce, 0x8b, 0x81, 0x6c, 0x80, 0xc2, 0x38, 0xc2,
-	0x8d, 0x2f, 0xb6, 0x4a, 0xab, 0x51, 0xe1, 0xe8, 0x45, 0x86, 0x08, 0x1e, 0x1b, 0x90, 0xbf, 0xb7,
-	0x02, 0xcf, 0xf1, 0x6c, 0x7e, 0x10, 0xea, 0x45, 0x86, 0x24, 0x40, 0xa7, 0x00, 0x4a, 0x40, 0xd9,
-	0xd2, 0x0d, 0xeb, 0xbf, 0x22, 0xd8, 0xe3, 0xee, 0xf7, 0xad, 0xf9, 0xfa, 0x80, 0xdf, 0x34, 0x04,
-	0x6d, 0x61, 0x88, 0xb4, 0xa5, 0x21, 0xe7, 0x80, 0x37, 0x77, 0x2b, 0x4c, 0x29, 0x43, 0xce, 0x8b,
-	0x00, 0x3e, 0xcd, 0x2a, 0x89, 0x03, 0x6c, 0x40, 0x41, 0xe8, 0x65, 0xba, 0xc4, 0x89, 0x55, 0x5c,
-	0xff, 0x0d, 0x89, 0x42, 0xd7, 0x96, 0xbb, 0x5c, 0xeb, 0x2e, 0x43, 0x8e, 0x0f, 0x3d, 0xd7, 0xa8,
-	0x92, 0x38, 0x78, 0xdb, 0x0d, 0x69, 0x0b, 0x37, 0xe4, 0x2d, 0xdd, 0xe8, 0xc1, 0x7e, 0x4a, 0x84,
-	0xb0, 0xe3, 0x10, 0x94, 0x9f, 0x39, 0x22, 0xfc, 0x10, 0xd1, 0x5b, 0x86, 0x9c, 0x10, 0x50, 0x57,
-	0x8f, 0x0d, 0x2e, 0x42, 0x7e, 0xdc, 0xff, 0xb2, 0x3f, 0x98, 0xf4, 0xb5, 0x0c, 0x56, 0x21, 0xf7,
-	0xf5, 0xd8, 0x24, 0xdf, 0x6a, 0x08, 0x17, 0x20, 0x4b, 0xc6, 0x97, 0xa6, 0x26, 0x45, 0x19, 0xc3,
-	0xde, 0x99, 0xd9, 0x6d, 0x13, 0x4d, 0x8e, 0x32, 0x86, 0xa3, 0x01, 0x31, 0xb5, 0x6c, 0x84, 0x13,
-	0xb3, 0x6b, 0xf6, 0xae, 0x4d, 0x2d, 0x77, 0xd2, 0x84, 0xa3, 0x57, 0x24, 0x45, 0x95, 0x26, 0x6d,
-	0x22, 0xca, 0xb7, 0x3b, 0x03, 0x32, 0xd2, 0xd0, 0x49, 0x07, 0xb2, 0xd1, 0xd5, 0xc4, 0x79, 0x90,
-	0x49, 0x7b, 0x12, 0x73, 0xdd, 0xc1, 0xb8, 0x3f, 0xd2, 0x50, 0x84, 0x0d, 0xc7, 0x57, 0x9a, 0x14,
-	0x2d, 0xae, 0x7a, 0x7d, 0x4d, 0xe6, 0x8b, 0xf6, 0x37, 0x71, 0x4f, 0x9e, 0x65, 0x12, 0x2d, 0xd7,
-	0xfa, 0x45, 0x82, 0x1c, 0x17, 0x82, 0x3f, 0x82, 0x6c, 0xf4, 0x94, 0xe3, 0xfd, 0xc4, 0xde, 0x8d,
-	0x87, 0xde, 0x28, 0xa7, 0x41, 0x61, 0xdc, 0xe7, 0xa0, 0xc4, 0xd7, 0x08, 0x1f, 0xa4, 0xaf, 0x55,
-	0xf2, 0xd9, 0xe1, 0x7f, 0xe1, 0xf8, 0xc3, 0x0f, 0x11, 0xee, 0x02, 0xac, 0x07, 0x13, 0x57, 0x52,
-	0x0f, 0xdb, 0xe6, 0xd5, 0x32, 0x8c, 0x97, 0x28, 0xd1, 0xff, 0x1c, 0x8a, 0x1b, 0xe7, 0x89, 0xd3,
-	0xa9, 0xa9, 0x49, 0x35, 0xde, 0x7b, 0x91, 0x8b, 0xeb, 0x74, 0x2a, 0x4f, 0x7f, 0x57, 0x33, 0x4f,
-	0xcf, 0x55, 0xf4, 0xfb, 0x73, 0x15, 0xfd, 0xf5, 0x5c, 0x45, 0xdf, 0xe5, 0xf9, 0xef, 0x63, 0x31,
-	0x9b, 0x29, 0xfc, 0xbf, 0xf7, 0xf1, 0xbf, 0x01, 0x00, 0x00, 0xff, 0xff, 0xe8, 0xcb, 0x18, 0x01,
-	0x2f, 0x07, 0x00, 0x00,
+	// 1257 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x57, 0xcd, 0x6e, 0x1b, 0x37,
+	0x17, 0xf5, 0x68, 0x24, 0x59, 0xba, 0x8a, 0x9d, 0x31, 0x2d, 0xdb, 0x63, 0x05, 0x70, 0x0c, 0x01,
+	0x1f, 0xe0, 0xcf, 0x49, 0x9d, 0xd4, 0x45, 0x1b, 0xa4, 0x45, 0x17, 0x92, 0xa2, 0xc0, 0x46, 0x13,
+	0xb9, 0xa1, 0xe4, 0xb8, 0x3f, 0x8b, 0xc1, 0x48, 0x62, 0x46, 0x83, 0x8c, 0x66, 0x94, 0x21, 0x55,
+	0xc7, 0x59, 0x76, 0xdd, 0x3e, 0x45, 0x5f, 0xa1, 0x0f, 0x91, 0x65, 0xb7, 0xdd, 0x14, 0x6d, 0x56,
+	0x7d, 0x8c, 0x82, 0x3f, 0x23, 0x91, 0x8e, 0x12, 0x34, 0x70, 0x77, 0xe2, 0x3d, 0xe7, 0xde, 0xc3,
+	0x7b, 0xc8, 0x21, 0x29, 0x28, 0xa7, 0x93, 0xc1, 0xc1, 0x24, 0x4d, 0x58, 0x82, 0x8a, 0x6c, 0xe4,
+	0xc7, 0x09, 0xad, 0x55, 0xd8, 0xc5, 0x84, 0x50, 0x19, 0xac, 0x55, 0x83, 0x24, 0x48, 0xc4, 0xcf,
+	0x3b, 0xfc, 0x97, 0x8c, 0xd6, 0x57, 0xa0, 0x72, 0x1c, 0x3f, 0x4b, 0x30, 0x79, 0x31, 0x25, 0x94,
+	0xd5, 0x7f, 0xb7, 0xe0, 0x9a, 0x1c, 0xd3, 0x49, 0x12, 0x53, 0x82, 0x6e, 0x41, 0x31, 0xf2, 0xfb,
+	0x24, 0xa2, 0xae, 0xb5, 0x6b, 0xef, 0x55, 0x0e, 0x57, 0x0e, 0x64, 0xed, 0x83, 0x47, 0x3c, 0xda,
+	0xcc, 0xbf, 0xfe, 0xe3, 0xe6, 0x12, 0x56, 0x14, 0xb4, 0x0d, 0xa5, 0x71, 0x18, 0x7b, 0x2c, 0x1c,
+	0x13, 0x37, 0xb7, 0x6b, 0xed, 0xd9, 0x78, 0x79, 0x1c, 0xc6, 0xbd, 0x70, 0x4c, 0x04, 0xe4, 0xbf,
+	0x94, 0x90, 0xad, 0x20, 0xff, 0xa5, 0x80, 0xee, 0x40, 0x99, 0xb2, 0x24, 0x25, 0xbd, 0x8b, 0x09,
+	0x71, 0xf3, 0xbb, 0xd6, 0xde, 0xea, 0xe1, 0x5a, 0xa6, 0xd2, 0xcd, 0x00, 0x3c, 0xe7, 0xa0, 0x4f,
+	0x01, 0x84, 0xa0, 0x47, 0x09, 0xa3, 0x6e, 0x41, 0xcc, 0xcb, 0x31, 0xe6, 0xd5, 0x25, 0x4c, 0x4d,
+	0xad, 0x1c, 0xa9, 0x31, 0xad, 0xdf, 0x83, 0x52, 0x06, 0x7e, 0x50, 0x5b, 0xf5, 0xbf, 0x6d, 0x58,
+	0xe9, 0x92, 0x34, 0x24, 0x54, 0xd9, 0x64, 0x34, 0x6a, 0xbd, 0xbb, 0xd1, 0x9c, 0xd9, 0xe8, 0x67,
+	0x1c, 0x62, 0x83, 0x11, 0x49, 0xa9, 0x6b, 0x0b, 0xd9, 0xaa, 0x21, 0xfb, 0x58, 0x82, 0x4a, 0x7d,
+	0xc6, 0x45, 0x87, 0xb0, 0xc1, 0x4b, 0xa6, 0x84, 0x26, 0xd1, 0x94, 0x85, 0x49, 0xec, 0x9d, 0x87,
+	0xf1, 0x30, 0x39, 0x17, 0x66, 0xd9, 0x78, 0x7d, 0xec, 0xbf, 0xc4, 0x33, 0xec, 0x4c, 0x40, 0xe8,
+	0x36, 0x80, 0x1f, 0x04, 0x29, 0x09, 0x7c, 0x46, 0xa4, 0x47, 0xab, 0x87, 0xd7, 0x32, 0xb5, 0x46,
+	0x10, 0xa4, 0x58, 0xc3, 0xd1, 0xe7, 0xb0, 0x3d, 0xf1, 0x53, 0x16, 0xfa, 0x91, 0x97, 0xaa, 0x95,
+	0xf7, 0x86, 0x21, 0xf5, 0xfb, 0x11, 0x19, 0xba, 0xc5, 0x5d, 0x6b, 0xaf, 0x84, 0xb7, 0x14, 0x21,
+	0xdb, 0x19, 0x0f, 0x14, 0x8c, 0xbe, 0x5f, 0x90, 0x4b, 0x59, 0xea, 0x33, 0x12, 0x5c, 0xb8, 0xcb,
+	0x62, 0x39, 0x6f, 0x66, 0xc2, 0x5f, 0x9b, 0x35, 0xba, 0x8a, 0xf6, 0x56, 0xf1, 0x0c, 0x40, 0x37,
+	0xa1, 0xf2, 0x62, 0x4a, 0xd2, 0x0b, 0x8f, 0x32, 0x9f, 0x51, 0xb7, 0x24, 0xa6, 0x02, 0x22, 0xd4,
+	0xe5, 0x11, 0x4e, 0xa0, 0xcf, 0xc3, 0x89, 0x37, 0x18, 0x4d, 0xe3, 0xe7, 0xd4, 0x2d, 0x4b, 0x02,
+	0x0f, 0xb5, 0x44, 0x04, 0xdd, 0x06, 0xe4, 0x47, 0x51, 0x72, 0xee, 0xd1, 0x49, 0x14, 0x32, 0x8f,
+	0x8a, 0x75, 0x74, 0x41, 0xf0, 0x1c, 0x81, 0x74, 0x39, 0x20, 0xd7, 0xb7, 0xfe, 0xb3, 0x05, 0xab,
+	0xd9, 0x52, 0xab, 0x2f, 0x60, 0x0f, 0x8a, 0x2a, 0x89, 0xaf, 0x74, 0xe5, 0x70, 0x75, 0xb6, 0x37,
+	0x45, 0xf4, 0x68, 0x09, 0x2b, 0x1c, 0xd5, 0x60, 0xf9, 0xdc, 0x4f, 0xe3, 0x30, 0x0e, 0xc4, 0xca,
+	0x97, 0x8f, 0x96, 0x70, 0x16, 0x40, 0xb7, 0xa0, 0x20, 0x5b, 0xb0, 0x45, 0x91, 0x75, 0xb3, 0x88,
+	0xe8, 0xe5, 0x68, 0x09, 0x4b, 0x4e, 0xb3, 0x04, 0xc5, 0x94, 0xd0, 0x69, 0xc4, 0xea, 0xbf, 0x96,
+	0xa1, 0xa2, 0x51, 0xd0, 0xff, 0x60, 0xb5, 0x1f, 0x25, 0x83, 0xe7, 0xd4, 0xe3, 0x1e, 0x84, 0x64,
+	0xa8, 0xb6, 0xdf, 0x8a, 0x8c, 0x3e, 0x91, 0x41, 0xf4, 0x7f, 0x70, 0x26, 0x09, 0x65, 0x61, 0x1c,
+	0x50, 0x8f, 0x25, 0xd3, 0xc1, 0x88, 0x0c, 0xd5, 0x66, 0xbc, 0x9e, 0xc5, 0x7b, 0x32, 0x8c, 0xbe,
+	0x84, 0x1b, 0x97, 0xa9, 0x1e, 0x0d, 0x5f, 0x11, 0xaf, 0x7f, 0xc1, 0x88, 0x9c, 0xae, 0x8d, 0xdd,
+	0x4b, 0x59, 0xdd, 0xf0, 0x15, 0x69, 0x72, 0xdc, 0x50, 0x7a, 0x46, 0x98, 0x50, 0xca, 0x9b, 0x4a,
+	0x0f, 0x09, 0x7b, 0x4b, 0x49, 0x51, 0x75, 0xa5, 0x82, 0xa9, 0xa4, 0xb2, 0xe6, 0x4a, 0x77, 0xa1,
+	0x6a, 0xa6, 0x7b, 0x83, 0x64, 0x1a, 0x33, 0xb1, 0x3d, 0x6d, 0x8c, 0x8c, 0xbc, 0x16, 0x47, 0xd0,
+	0x17, 0x50, 0xbb, 0x94, 0x31, 0x9c, 0xa6, 0xbe, 0xf8, 0x80, 0x62, 0x2a, 0xb6, 0xa6, 0x8d, 0xb7,
+	0x8c, 0xbc, 0x07, 0x0a, 0xef, 0x08, 0xa7, 0xe5, 0xb2, 0xce, 0x0c, 0x2c, 0x49, 0xa7, 0x65, 0x34,
+	0xb3, 0xef, 0x3e, 0x6c, 0x9b, 0x34, 0xbd, 0xa5, 0xb2, 0xc8, 0xd8, 0x34, 0x32, 0xe6, 0x0d, 0xcd,
+	0x15, 0x32, 0xe3, 0x40, 0x57, 0xc8, 0x6c, 0x9b, 0x2b, 0x2c, 0x30, 0xad, 0xa2, 0x2b, 0xbc, 0x65,
+	0xd9, 0x6d, 0x40, 0x7a, 0xaa, 0x32, 0xec, 0x9a, 0xc8, 0x71, 0xb4, 0x1c, 0x69, 0xd7, 0x3d, 0x70,
+	0x0d, 0xb6, 0x6e, 0xd6, 0x8a, 0xc8, 0xd9, 0xd0, 0x72, 0x4c, 0xab, 0xe4, 0xe7, 0x37, 0xb3, 0x6a,
+	0x55, 0x36, 0x22, 0xa3, 0x9a, 0x55, 0x26, 0x4d, 0x6f, 0xe4, 0xba, 0x6c, 0xc4, 0xc8, 0x30, 0xac,
+	0x52, 0xa9, 0x99, 0x55, 0x8e, 0xae, 0xa0, 0x59, 0x65, 0xd2, 0x74, 0x85, 0x35, 0x5d, 0x61, 0x91,
+	0x55, 0x7a, 0xaa, 0xb2, 0x0a, 0x49, 0xab, 0xb4, 0x9c, 0x99, 0x55, 0x06, 0x5b, 0xb7, 0x6a, 0x5d,
+	0x5a, 0xa5, 0xe5, 0x68, 0x56, 0xed, 0xc3, 0x9a, 0x4a, 0x1c, 0xf8, 0x83, 0x11, 0xf1, 0x46, 0x21,
+	0xa3, 0x6e, 0x55, 0x7e, 0x2f, 0x12, 0x68, 0xf1, 0xf8, 0x51, 0xc8, 0x28, 0xfa, 0x08, 0xd6, 0x03,
+	0xc2, 0x3c, 0x3f, 0x8a, 0x8c, 0xfa, 0x1b, 0x72, 0x4e, 0x01, 0x61, 0x8d, 0x28, 0xd2, 0x4a, 0x1f,
+	0xc0, 0xfa, 0x98, 0xa4, 0x01, 0x6f, 0x5a, 0xae, 0xa2, 0x6c, 0x61, 0x53, 0xd0, 0xd7, 0x24, 0x24,
+	0x8f, 0x12, 0xd9, 0xc3, 0x9c, 0x9f, 0xcd, 0x48, 0xf0, 0xb7, 0x74, 0xbe, 0x3c, 0x43, 0x25, 0x7f,
+	0x1f, 0x64, 0xd0, 0x98, 0x8c, 0x2b, 0xa7, 0x2e, 0x80, 0xf9, 0x5c, 0xea, 0x3f, 0xe5, 0x60, 0x4d,
+	0x5c, 0x69, 0x1d, 0x7f, 0x3c, 0xbf, 0x35, 0xdf, 0x7b, 0xcb, 0x58, 0x57, 0xb8, 0x65, 0x72, 0x57,
+	0xbc, 0x65, 0xaa, 0xe2, 0x70, 0x4e, 0x99, 0x3a, 0xed, 0xe4, 0x00, 0x39, 0x60, 0x93, 0x38, 0x3b,
+	0xcd, 0xf8, 0x4f, 0xe3, 0x02, 0x2f, 0xfc, 0xfb, 0x0b, 0xbc, 0xfe, 0x10, 0x90, 0xee, 0x86, 0xba,
+	0x58, 0xaa, 0x50, 0x88, 0x79, 0x40, 0x3c, 0x41, 0xca, 0x58, 0x0e, 0x50, 0x0d, 0x4a, 0xea, 0xce,
+	0xa0, 0x6e, 0x4e, 0x00, 0xb3, 0x71, 0xfd, 0x97, 0x9c, 0x2a, 0xf4, 0xd4, 0x8f, 0xa6, 0x73, 0x5f,
+	0xab, 0x50, 0x10, 0x2f, 0x15, 0xe1, 0x61, 0x19, 0xcb, 0xc1, 0xfb, 0xdd, 0xce, 0x5d, 0xc1, 0x6d,
+	0xfb, 0xbf, 0x72, 0x3b, 0xbf, 0xc0, 0xed, 0xc2, 0x62, 0xb7, 0x8b, 0x1f, 0xe0, 0xf6, 0x31, 0xac,
+	0x1b, 0x26, 0x29, 0xbb, 0x37, 0xa1, 0xf8, 0x83, 0x88, 0x28, 0xbf, 0xd5, 0xe8, 0x7d, 0x86, 0xef,
+	0x63, 0x28, 0xcf, 0x5e, 0xa0, 0xa8, 0x02, 0xcb, 0xa7, 0x9d, 0xaf, 0x3a, 0x27, 0x67, 0x1d, 0x67,
+	0x09, 0x95, 0xa1, 0xf0, 0xe4, 0xb4, 0x8d, 0xbf, 0x75, 0x2c, 0x54, 0x82, 0x3c, 0x3e, 0x7d, 0xd4,
+	0x76, 0x72, 0x9c, 0xd1, 0x3d, 0x7e, 0xd0, 0x6e, 0x35, 0xb0, 0x63, 0x73, 0x46, 0xb7, 0x77, 0x82,
+	0xdb, 0x4e, 0x9e, 0xc7, 0x71, 0xbb, 0xd5, 0x3e, 0x7e, 0xda, 0x76, 0x0a, 0xfb, 0x07, 0xb0, 0xf5,
+	0x0e, 0xcb, 0x78, 0xa5, 0xb3, 0x06, 0x56, 0xe5, 0x1b, 0xcd, 0x13, 0xdc, 0x73, 0xac, 0xfd, 0x26,
+	0xe4, 0xf9, 0x7b, 0x0d, 0x2d, 0x83, 0x8d, 0x1b, 0x67, 0x12, 0x6b, 0x9d, 0x9c, 0x76, 0x7a, 0x8e,
+	0xc5, 0x63, 0xdd, 0xd3, 0xc7, 0x4e, 0x8e, 0xff, 0x78, 0x7c, 0xdc, 0x71, 0x6c, 0xf1, 0xa3, 0xf1,
+	0x8d, 0xd4, 0x14, 0xac, 0x36, 0x76, 0x0a, 0x87, 0x3f, 0xe6, 0xa0, 0x20, 0x1a, 0x41, 0x1f, 0x43,
+	0x9e, 0xbf, 0xef, 0xd1, 0xec, 0x01, 0xa2, 0xbd, 0xfe, 0x6b, 0x55, 0x33, 0xa8, 0x8c, 0xbb, 0x0f,
+	0x45, 0x79, 0x6e, 0xa0, 0x0d, 0xf3, 0xd5, 0x92, 0xa5, 0x6d, 0x5e, 0x0e, 0xcb, 0xc4, 0xbb, 0x16,
+	0x6a, 0x01, 0xcc, 0x37, 0x3e, 0xda, 0x36, 0x96, 0x4f, 0x3f, 0x1a, 0x6a, 0xb5, 0x45, 0x90, 0xd2,
+	0x7f, 0x08, 0x15, 0x6d, 0x3d, 0x91, 0x49, 0x35, 0xbe, 0x84, 0xda, 0x8d, 0x85, 0x98, 0xac, 0xd3,
+	0xdc, 0x7e, 0xfd, 0xd7, 0xce, 0xd2, 0xeb, 0x37, 0x3b, 0xd6, 0x6f, 0x6f, 0x76, 0xac, 0x3f, 0xdf,
+	0xec, 0x58, 0xdf, 0x2d, 0x8b, 0xff, 0x14, 0x93, 0x7e, 0xbf, 0x28, 0xfe, 0x0c, 0x7d, 0xf2, 0xcf,
+	0x00, 0x7e, 0xda, 0x9c, 0x30, 0x44, 0x0d, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1092,6 +1240,36 @@ func (m *SeriesRequest) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintRpc(dAtA, i, uint64(m.PartialResponseStrategy))
 	}
+	if m.QueryStats {
+		dAtA[i] = 0x40
+		i++
+		if m.QueryStats {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.SkipChunks {
+		dAtA[i] = 0x48
+		i++
+		if m.SkipChunks {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.AllowSplitSeries {
+		dAtA[i] = 0x50
+		i++
+		if m.AllowSplitSeries {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -1148,6 +1326,179 @@ func (m *SeriesResponse_Warning) MarshalTo(dAtA []byte) (int, error) {
 	i += copy(dAtA[i:], m.Warning)
 	return i, nil
 }
+func (m *SeriesResponse_Stats) MarshalTo(dAtA []byte) (int, error) {
+	i := 0
+	if m.Stats != nil {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.Stats.Size()))
+		n5, err := m.Stats.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n5
+	}
+	return i, nil
+}
+func (m *SeriesStats) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SeriesStats) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.BlocksQueried != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.BlocksQueried))
+	}
+	if m.PostingsTouched != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.PostingsTouched))
+	}
+	if m.PostingsTouchedSizeBytes != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.PostingsTouchedSizeBytes))
+	}
+	if m.PostingsFetched != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.PostingsFetched))
+	}
+	if m.PostingsFetchedSizeBytes != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.PostingsFetchedSizeBytes))
+	}
+	if m.PostingsFetchCount != 0 {
+		dAtA[i] = 0x30
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.PostingsFetchCount))
+	}
+	if m.PostingsFetchDurationNs != 0 {
+		dAtA[i] = 0x38
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.PostingsFetchDurationNs))
+	}
+	if m.SeriesTouched != 0 {
+		dAtA[i] = 0x40
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.SeriesTouched))
+	}
+	if m.SeriesTouchedSizeBytes != 0 {
+		dAtA[i] = 0x48
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.SeriesTouchedSizeBytes))
+	}
+	if m.SeriesFetched != 0 {
+		dAtA[i] = 0x50
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.SeriesFetched))
+	}
+	if m.SeriesFetchedSizeBytes != 0 {
+		dAtA[i] = 0x58
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.SeriesFetchedSizeBytes))
+	}
+	if m.SeriesFetchCount != 0 {
+		dAtA[i] = 0x60
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.SeriesFetchCount))
+	}
+	if m.SeriesFetchDurationNs != 0 {
+		dAtA[i] = 0x68
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.SeriesFetchDurationNs))
+	}
+	if m.ChunksTouched != 0 {
+		dAtA[i] = 0x70
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.ChunksTouched))
+	}
+	if m.ChunksTouchedSizeBytes != 0 {
+		dAtA[i] = 0x78
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.ChunksTouchedSizeBytes))
+	}
+	if m.ChunksFetched != 0 {
+		dAtA[i] = 0x80
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.ChunksFetched))
+	}
+	if m.ChunksFetchedSizeBytes != 0 {
+		dAtA[i] = 0x88
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.ChunksFetchedSizeBytes))
+	}
+	if m.ChunksFetchCount != 0 {
+		dAtA[i] = 0x90
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.ChunksFetchCount))
+	}
+	if m.ChunksFetchDurationNs != 0 {
+		dAtA[i] = 0x98
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.ChunksFetchDurationNs))
+	}
+	if m.ChunksCacheHits != 0 {
+		dAtA[i] = 0xa0
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.ChunksCacheHits))
+	}
+	if m.GetAllDurationNs != 0 {
+		dAtA[i] = 0xa8
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.GetAllDurationNs))
+	}
+	if m.MergedSeriesCount != 0 {
+		dAtA[i] = 0xb0
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.MergedSeriesCount))
+	}
+	if m.MergedChunksCount != 0 {
+		dAtA[i] = 0xb8
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.MergedChunksCount))
+	}
+	if m.MergeDurationNs != 0 {
+		dAtA[i] = 0xc0
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.MergeDurationNs))
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
 func (m *LabelNamesRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -1178,6 +1529,28 @@ func (m *LabelNamesRequest) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintRpc(dAtA, i, uint64(m.PartialResponseStrategy))
 	}
+	if m.Start != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.Start))
+	}
+	if m.End != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.End))
+	}
+	if len(m.Matchers) > 0 {
+		for _, msg := range m.Matchers {
+			dAtA[i] = 0x2a
+			i++
+			i = encodeVarintRpc(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -1271,6 +1644,28 @@ func (m *LabelValuesRequest) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintRpc(dAtA, i, uint64(m.PartialResponseStrategy))
 	}
+	if m.Start != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.Start))
+	}
+	if m.End != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintRpc(dAtA, i, uint64(m.End))
+	}
+	if len(m.Matchers) > 0 {
+		for _, msg := range m.Matchers {
+			dAtA[i] = 0x32
+			i++
+			i = encodeVarintRpc(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		i += copy(dAtA[i:], m.XXX_unrecognized)
 	}
@@ -1434,6 +1829,15 @@ func (m *SeriesRequest) Size() (n int) {
 	if m.PartialResponseStrategy != 0 {
 		n += 1 + sovRpc(uint64(m.PartialResponseStrategy))
 	}
+	if m.QueryStats {
+		n += 2
+	}
+	if m.SkipChunks {
+		n += 2
+	}
+	if m.AllowSplitSeries {
+		n += 2
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1477,6 +1881,102 @@ func (m *SeriesResponse_Warning) Size() (n int) {
 	n += 1 + l + sovRpc(uint64(l))
 	return n
 }
+func (m *SeriesResponse_Stats) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Stats != nil {
+		l = m.Stats.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+func (m *SeriesStats) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.BlocksQueried != 0 {
+		n += 1 + sovRpc(uint64(m.BlocksQueried))
+	}
+	if m.PostingsTouched != 0 {
+		n += 1 + sovRpc(uint64(m.PostingsTouched))
+	}
+	if m.PostingsTouchedSizeBytes != 0 {
+		n += 1 + sovRpc(uint64(m.PostingsTouchedSizeBytes))
+	}
+	if m.PostingsFetched != 0 {
+		n += 1 + sovRpc(uint64(m.PostingsFetched))
+	}
+	if m.PostingsFetchedSizeBytes != 0 {
+		n += 1 + sovRpc(uint64(m.PostingsFetchedSizeBytes))
+	}
+	if m.PostingsFetchCount != 0 {
+		n += 1 + sovRpc(uint64(m.PostingsFetchCount))
+	}
+	if m.PostingsFetchDurationNs != 0 {
+		n += 1 + sovRpc(uint64(m.PostingsFetchDurationNs))
+	}
+	if m.SeriesTouched != 0 {
+		n += 1 + sovRpc(uint64(m.SeriesTouched))
+	}
+	if m.SeriesTouchedSizeBytes != 0 {
+		n += 1 + sovRpc(uint64(m.SeriesTouchedSizeBytes))
+	}
+	if m.SeriesFetched != 0 {
+		n += 1 + sovRpc(uint64(m.SeriesFetched))
+	}
+	if m.SeriesFetchedSizeBytes != 0 {
+		n += 1 + sovRpc(uint64(m.SeriesFetchedSizeBytes))
+	}
+	if m.SeriesFetchCount != 0 {
+		n += 1 + sovRpc(uint64(m.SeriesFetchCount))
+	}
+	if m.SeriesFetchDurationNs != 0 {
+		n += 1 + sovRpc(uint64(m.SeriesFetchDurationNs))
+	}
+	if m.ChunksTouched != 0 {
+		n += 1 + sovRpc(uint64(m.ChunksTouched))
+	}
+	if m.ChunksTouchedSizeBytes != 0 {
+		n += 1 + sovRpc(uint64(m.ChunksTouchedSizeBytes))
+	}
+	if m.ChunksFetched != 0 {
+		n += 2 + sovRpc(uint64(m.ChunksFetched))
+	}
+	if m.ChunksFetchedSizeBytes != 0 {
+		n += 2 + sovRpc(uint64(m.ChunksFetchedSizeBytes))
+	}
+	if m.ChunksFetchCount != 0 {
+		n += 2 + sovRpc(uint64(m.ChunksFetchCount))
+	}
+	if m.ChunksFetchDurationNs != 0 {
+		n += 2 + sovRpc(uint64(m.ChunksFetchDurationNs))
+	}
+	if m.ChunksCacheHits != 0 {
+		n += 2 + sovRpc(uint64(m.ChunksCacheHits))
+	}
+	if m.GetAllDurationNs != 0 {
+		n += 2 + sovRpc(uint64(m.GetAllDurationNs))
+	}
+	if m.MergedSeriesCount != 0 {
+		n += 2 + sovRpc(uint64(m.MergedSeriesCount))
+	}
+	if m.MergedChunksCount != 0 {
+		n += 2 + sovRpc(uint64(m.MergedChunksCount))
+	}
+	if m.MergeDurationNs != 0 {
+		n += 2 + sovRpc(uint64(m.MergeDurationNs))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func (m *LabelNamesRequest) Size() (n int) {
 	if m == nil {
 		return 0
@@ -1489,6 +1989,18 @@ func (m *LabelNamesRequest) Size() (n int) {
 	if m.PartialResponseStrategy != 0 {
 		n += 1 + sovRpc(uint64(m.PartialResponseStrategy))
 	}
+	if m.Start != 0 {
+		n += 1 + sovRpc(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovRpc(uint64(m.End))
+	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1535,13 +2047,25 @@ func (m *LabelValuesRequest) Size() (n int) {
 	if m.PartialResponseStrategy != 0 {
 		n += 1 + sovRpc(uint64(m.PartialResponseStrategy))
 	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+	if m.Start != 0 {
+		n += 1 + sovRpc(uint64(m.Start))
 	}
-	return n
-}
-
-func (m *LabelValuesResponse) Size() (n int) {
+	if m.End != 0 {
+		n += 1 + sovRpc(uint64(m.End))
+	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *LabelValuesResponse) Size() (n int) {
 	if m == nil {
 		return 0
 	}
@@ -2127,6 +2651,66 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryStats", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.QueryStats = bool(v != 0)
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SkipChunks", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.SkipChunks = bool(v != 0)
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AllowSplitSeries", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.AllowSplitSeries = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRpc(dAtA[iNdEx:])
@@ -2248,6 +2832,41 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 			}
 			m.Result = &SeriesResponse_Warning{string(dAtA[iNdEx:postIndex])}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &SeriesStats{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_Stats{v}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRpc(dAtA[iNdEx:])
@@ -2273,7 +2892,7 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
+func (m *SeriesStats) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2296,17 +2915,17 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelNamesRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: SeriesStats: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelNamesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SeriesStats: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PartialResponseDisabled", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field BlocksQueried", wireType)
 			}
-			var v int
+			m.BlocksQueried = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2316,17 +2935,16 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= int(b&0x7F) << shift
+				m.BlocksQueried |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.PartialResponseDisabled = bool(v != 0)
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PartialResponseStrategy", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field PostingsTouched", wireType)
 			}
-			m.PartialResponseStrategy = 0
+			m.PostingsTouched = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2336,70 +2954,73 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.PartialResponseStrategy |= PartialResponseStrategy(b&0x7F) << shift
+				m.PostingsTouched |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		default:
-			iNdEx = preIndex
-			skippy, err := skipRpc(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthRpc
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PostingsTouchedSizeBytes", wireType)
 			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthRpc
+			m.PostingsTouchedSizeBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PostingsTouchedSizeBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PostingsFetched", wireType)
 			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowRpc
+			m.PostingsFetched = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PostingsFetched |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PostingsFetchedSizeBytes", wireType)
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+			m.PostingsFetchedSizeBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PostingsFetchedSizeBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: LabelNamesResponse: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelNamesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Names", wireType)
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PostingsFetchCount", wireType)
 			}
-			var stringLen uint64
+			m.PostingsFetchCount = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2409,29 +3030,35 @@ func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.PostingsFetchCount |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthRpc
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthRpc
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PostingsFetchDurationNs", wireType)
 			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+			m.PostingsFetchDurationNs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PostingsFetchDurationNs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			m.Names = append(m.Names, string(dAtA[iNdEx:postIndex]))
-			iNdEx = postIndex
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Warnings", wireType)
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesTouched", wireType)
 			}
-			var stringLen uint64
+			m.SeriesTouched = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2441,21 +3068,595 @@ func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.SeriesTouched |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthRpc
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesTouchedSizeBytes", wireType)
 			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthRpc
+			m.SeriesTouchedSizeBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SeriesTouchedSizeBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesFetched", wireType)
+			}
+			m.SeriesFetched = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SeriesFetched |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesFetchedSizeBytes", wireType)
+			}
+			m.SeriesFetchedSizeBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SeriesFetchedSizeBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesFetchCount", wireType)
+			}
+			m.SeriesFetchCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SeriesFetchCount |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 13:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesFetchDurationNs", wireType)
+			}
+			m.SeriesFetchDurationNs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SeriesFetchDurationNs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 14:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksTouched", wireType)
+			}
+			m.ChunksTouched = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksTouched |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 15:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksTouchedSizeBytes", wireType)
+			}
+			m.ChunksTouchedSizeBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksTouchedSizeBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 16:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksFetched", wireType)
+			}
+			m.ChunksFetched = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksFetched |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 17:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksFetchedSizeBytes", wireType)
+			}
+			m.ChunksFetchedSizeBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksFetchedSizeBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 18:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksFetchCount", wireType)
+			}
+			m.ChunksFetchCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksFetchCount |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 19:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksFetchDurationNs", wireType)
+			}
+			m.ChunksFetchDurationNs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksFetchDurationNs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 20:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunksCacheHits", wireType)
+			}
+			m.ChunksCacheHits = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunksCacheHits |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 21:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GetAllDurationNs", wireType)
+			}
+			m.GetAllDurationNs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.GetAllDurationNs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 22:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MergedSeriesCount", wireType)
+			}
+			m.MergedSeriesCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MergedSeriesCount |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 23:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MergedChunksCount", wireType)
+			}
+			m.MergedChunksCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MergedChunksCount |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 24:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MergeDurationNs", wireType)
+			}
+			m.MergeDurationNs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MergeDurationNs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelNamesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelNamesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PartialResponseDisabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.PartialResponseDisabled = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PartialResponseStrategy", wireType)
+			}
+			m.PartialResponseStrategy = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PartialResponseStrategy |= PartialResponseStrategy(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			m.Start = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Start |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			m.End = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.End |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelNamesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelNamesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Names", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Names = append(m.Names, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Warnings", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
 			}
 			m.Warnings = append(m.Warnings, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
@@ -2584,6 +3785,78 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			m.Start = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Start |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			m.End = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.End |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRpc(dAtA[iNdEx:])
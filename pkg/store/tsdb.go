@@ -18,18 +18,28 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// TSDBReader provides read access to the data of a TSDB.
+type TSDBReader interface {
+	// Querier returns a querier over the data of the given time range.
+	Querier(mint, maxt int64) (tsdb.Querier, error)
+	// Blocks returns the persisted blocks sorted by time.
+	Blocks() []*tsdb.Block
+}
+
 // TSDBStore implements the store API against a local TSDB instance.
 // It attaches the provided external labels to all results. It only responds with raw data
 // and does not support downsampling.
 type TSDBStore struct {
 	logger         log.Logger
-	db             *tsdb.DB
+	db             TSDBReader
 	component      component.SourceStoreAPI
 	externalLabels labels.Labels
+	// Maximum size of the chunks sent in a single series frame. Bigger series are split across multiple frames.
+	maxFrameBytes int
 }
 
 // NewTSDBStore creates a new TSDBStore.
-func NewTSDBStore(logger log.Logger, _ prometheus.Registerer, db *tsdb.DB, component component.SourceStoreAPI, externalLabels labels.Labels) *TSDBStore {
+func NewTSDBStore(logger log.Logger, _ prometheus.Registerer, db TSDBReader, component component.SourceStoreAPI, externalLabels labels.Labels) *TSDBStore {
 	if logger == nil {
 		logger = log.NewNopLogger()
 	}
@@ -38,6 +48,7 @@ func NewTSDBStore(logger log.Logger, _ prometheus.Registerer, db *tsdb.DB, compo
 		db:             db,
 		component:      component,
 		externalLabels: externalLabels,
+		maxFrameBytes:  storepb.MaxSeriesFrameBytes,
 	}
 }
 
@@ -97,15 +108,29 @@ func (s *TSDBStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSer
 	}
 	defer runutil.CloseWithLogOnErr(s.logger, q, "close tsdb querier series")
 
-	set, err := q.Select(matchers...)
+	set, err := selectSeries(q, matchers)
 	if err != nil {
 		return status.Error(codes.Internal, err.Error())
 	}
 
+	// Series are only split if the client reassembles them.
+	maxFrameBytes := 0
+	if r.AllowSplitSeries {
+		maxFrameBytes = s.maxFrameBytes
+	}
+
 	var respSeries storepb.Series
 
 	for set.Next() {
 		series := set.At()
+		respSeries.Labels = s.translateAndExtendLabels(series.Labels(), s.externalLabels)
+
+		if r.SkipChunks {
+			if err := srv.Send(storepb.NewSeriesResponse(&respSeries)); err != nil {
+				return status.Error(codes.Aborted, err.Error())
+			}
+			continue
+		}
 
 		// TODO(fabxc): An improvement over this trivial approach would be to directly
 		// use the chunks provided by TSDB in the response.
@@ -119,11 +144,13 @@ func (s *TSDBStore) Series(r *storepb.SeriesRequest, srv storepb.Store_SeriesSer
 			return status.Errorf(codes.Internal, "encode chunk: %s", err)
 		}
 
-		respSeries.Labels = s.translateAndExtendLabels(series.Labels(), s.externalLabels)
-		respSeries.Chunks = append(respSeries.Chunks[:0], c...)
+		respSeries.Chunks = c
 
-		if err := srv.Send(storepb.NewSeriesResponse(&respSeries)); err != nil {
-			return status.Error(codes.Aborted, err.Error())
+		frames := storepb.SplitSeries(respSeries, maxFrameBytes)
+		for i := range frames {
+			if err := srv.Send(storepb.NewSeriesResponse(&frames[i])); err != nil {
+				return status.Error(codes.Aborted, err.Error())
+			}
 		}
 	}
 	return nil
@@ -198,19 +225,44 @@ func (s *TSDBStore) translateAndExtendLabels(m, extend labels.Labels) []storepb.
 }
 
 // LabelNames returns all known label names.
-func (s *TSDBStore) LabelNames(ctx context.Context, _ *storepb.LabelNamesRequest) (
+func (s *TSDBStore) LabelNames(ctx context.Context, r *storepb.LabelNamesRequest) (
 	*storepb.LabelNamesResponse, error,
 ) {
-	q, err := s.db.Querier(math.MinInt64, math.MaxInt64)
+	match, matchers, err := s.labelsMatchers(r.Matchers)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !match {
+		return &storepb.LabelNamesResponse{Names: []string{}}, nil
+	}
+
+	q, err := s.db.Querier(r.TimeRange())
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	defer runutil.CloseWithLogOnErr(s.logger, q, "close tsdb querier label names")
 
-	res, err := q.LabelNames()
-	if err != nil {
+	if len(matchers) == 0 {
+		res, err := q.LabelNames()
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return &storepb.LabelNamesResponse{Names: res}, nil
+	}
+
+	names := map[string]struct{}{}
+	if err := selectLabelSets(q, matchers, func(lset labels.Labels) {
+		for _, l := range lset {
+			names[l.Name] = struct{}{}
+		}
+	}); err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
+	res := make([]string, 0, len(names))
+	for n := range names {
+		res = append(res, n)
+	}
+	sort.Strings(res)
 	return &storepb.LabelNamesResponse{Names: res}, nil
 }
 
@@ -218,15 +270,111 @@ func (s *TSDBStore) LabelNames(ctx context.Context, _ *storepb.LabelNamesRequest
 func (s *TSDBStore) LabelValues(ctx context.Context, r *storepb.LabelValuesRequest) (
 	*storepb.LabelValuesResponse, error,
 ) {
-	q, err := s.db.Querier(math.MinInt64, math.MaxInt64)
+	match, matchers, err := s.labelsMatchers(r.Matchers)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !match {
+		return &storepb.LabelValuesResponse{Values: []string{}}, nil
+	}
+
+	q, err := s.db.Querier(r.TimeRange())
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	defer runutil.CloseWithLogOnErr(s.logger, q, "close tsdb querier label values")
 
-	res, err := q.LabelValues(r.Label)
-	if err != nil {
+	if len(matchers) == 0 {
+		res, err := q.LabelValues(r.Label)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return &storepb.LabelValuesResponse{Values: res}, nil
+	}
+
+	vals := map[string]struct{}{}
+	if err := selectLabelSets(q, matchers, func(lset labels.Labels) {
+		if v := lset.Get(r.Label); v != "" {
+			vals[v] = struct{}{}
+		}
+	}); err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
+	res := make([]string, 0, len(vals))
+	for v := range vals {
+		res = append(res, v)
+	}
+	sort.Strings(res)
 	return &storepb.LabelValuesResponse{Values: res}, nil
 }
+
+// labelsMatchers returns the matchers of a label names or values request without the ones
+// on external labels. It returns false if the external labels do not match.
+func (s *TSDBStore) labelsMatchers(ms []storepb.LabelMatcher) (bool, []labels.Matcher, error) {
+	match, newMatchers, err := matchesExternalLabels(ms, s.externalLabels)
+	if err != nil || !match {
+		return false, nil, err
+	}
+	matchers, err := translateMatchers(newMatchers)
+	if err != nil {
+		return false, nil, err
+	}
+	return true, matchers, nil
+}
+
+// selectLabelSets calls f with the labels of all series selected by the matchers.
+func selectLabelSets(q tsdb.Querier, matchers []labels.Matcher, f func(lset labels.Labels)) error {
+	set, err := selectSeries(q, matchers)
+	if err != nil {
+		return err
+	}
+	for set.Next() {
+		f(set.At().Labels())
+	}
+	return set.Err()
+}
+
+// selectSeries selects the series matching the matchers from q. TSDB only looks up the postings of
+// equality matchers directly and scans all values of the label for any other matcher, so set matchers
+// are rewritten into one Select per matched value with an equality matcher instead. The results
+// of those Selects are disjoint and sorted, so they are merged into a single sorted set.
+func selectSeries(q tsdb.Querier, matchers []labels.Matcher) (tsdb.SeriesSet, error) {
+	return selectSetMatches(q, matchers, maxSetMatches)
+}
+
+// selectSetMatches rewrites set matchers as long as the total number of Selects stays within maxSelects.
+func selectSetMatches(q tsdb.Querier, matchers []labels.Matcher, maxSelects int) (tsdb.SeriesSet, error) {
+	for i, m := range matchers {
+		sm, ok := m.(*setMatcher)
+		// An empty value also matches series without the label, which an equality lookup does not cover.
+		if !ok || len(sm.Values()) == 0 || len(sm.Values()) > maxSelects || sm.Matches("") {
+			continue
+		}
+
+		sets := make([]tsdb.SeriesSet, 0, len(sm.Values()))
+		for _, v := range sm.Values() {
+			ms := make([]labels.Matcher, len(matchers))
+			copy(ms, matchers)
+			ms[i] = labels.NewEqualMatcher(sm.Name(), v)
+
+			set, err := selectSetMatches(q, ms, maxSelects/len(sm.Values()))
+			if err != nil {
+				return nil, err
+			}
+			sets = append(sets, set)
+		}
+		return mergeSeriesSets(sets...), nil
+	}
+	return q.Select(matchers...)
+}
+
+func mergeSeriesSets(sets ...tsdb.SeriesSet) tsdb.SeriesSet {
+	switch len(sets) {
+	case 0:
+		return tsdb.EmptySeriesSet()
+	case 1:
+		return sets[0]
+	}
+	h := len(sets) / 2
+	return tsdb.NewMergedSeriesSet(mergeSeriesSets(sets[:h]...), mergeSeriesSets(sets[h:]...))
+}
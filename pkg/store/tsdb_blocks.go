@@ -0,0 +1,205 @@
+package store
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/tsdb"
+	tsdberrors "github.com/prometheus/tsdb/errors"
+	"github.com/prometheus/tsdb/labels"
+)
+
+// TSDBBlocks provides read access to the persisted blocks of a TSDB directory owned by another process,
+// like Prometheus. It does not lock the directory nor read the head and WAL, so it can be used while the
+// directory is written to.
+type TSDBBlocks struct {
+	logger log.Logger
+	dir    string
+
+	mtx sync.RWMutex
+	// Blocks sorted by min time.
+	blocks []*tsdb.Block
+}
+
+// NewTSDBBlocks returns TSDBBlocks for the given TSDB directory. No blocks are read before the first Sync.
+func NewTSDBBlocks(logger log.Logger, dir string) *TSDBBlocks {
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+	return &TSDBBlocks{
+		logger: logger,
+		dir:    dir,
+	}
+}
+
+// Sync opens the blocks added to the directory and closes the ones removed from it.
+// It must not be called concurrently.
+func (b *TSDBBlocks) Sync() error {
+	fis, err := ioutil.ReadDir(b.dir)
+	if err != nil {
+		return errors.Wrap(err, "read TSDB dir")
+	}
+
+	removed := map[ulid.ULID]*tsdb.Block{}
+	for _, blk := range b.Blocks() {
+		removed[blk.Meta().ULID] = blk
+	}
+
+	var blocks []*tsdb.Block
+	for _, fi := range fis {
+		// Blocks still being written by the TSDB have a temporary directory name.
+		id, err := ulid.Parse(fi.Name())
+		if err != nil || !fi.IsDir() {
+			continue
+		}
+		if blk, ok := removed[id]; ok {
+			blocks = append(blocks, blk)
+			delete(removed, id)
+			continue
+		}
+		blk, err := tsdb.OpenBlock(b.logger, filepath.Join(b.dir, fi.Name()), nil)
+		if err != nil {
+			level.Warn(b.logger).Log("msg", "failed to open block", "block", id, "err", err)
+			continue
+		}
+		blocks = append(blocks, blk)
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Meta().MinTime < blocks[j].Meta().MinTime
+	})
+
+	b.mtx.Lock()
+	b.blocks = blocks
+	b.mtx.Unlock()
+
+	// Closing waits for the queries still reading the removed blocks.
+	var merr tsdberrors.MultiError
+	for _, blk := range removed {
+		merr.Add(errors.Wrapf(blk.Close(), "close block %s", blk))
+	}
+	return merr.Err()
+}
+
+// Blocks returns the opened blocks sorted by min time.
+func (b *TSDBBlocks) Blocks() []*tsdb.Block {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	return append([]*tsdb.Block(nil), b.blocks...)
+}
+
+// Querier returns a querier over the blocks overlapping the given time range.
+func (b *TSDBBlocks) Querier(mint, maxt int64) (tsdb.Querier, error) {
+	// Queriers are opened under the lock, so the blocks cannot be closed by a concurrent Sync before.
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	var (
+		q     = &blocksQuerier{}
+		metas []tsdb.BlockMeta
+	)
+	for _, blk := range b.blocks {
+		if !blk.OverlapsClosedInterval(mint, maxt) {
+			continue
+		}
+		bq, err := tsdb.NewBlockQuerier(blk, mint, maxt)
+		if err != nil {
+			var merr tsdberrors.MultiError
+			merr.Add(errors.Wrapf(err, "open querier for block %s", blk))
+			merr.Add(q.Close())
+			return nil, merr.Err()
+		}
+		q.queriers = append(q.queriers, bq)
+		metas = append(metas, blk.Meta())
+	}
+	q.overlapping = len(tsdb.OverlappingBlocks(metas)) > 0
+	return q, nil
+}
+
+// Close closes all blocks.
+func (b *TSDBBlocks) Close() error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	var merr tsdberrors.MultiError
+	for _, blk := range b.blocks {
+		merr.Add(blk.Close())
+	}
+	b.blocks = nil
+	return merr.Err()
+}
+
+// blocksQuerier merges the results of the queriers of blocks sorted by min time.
+type blocksQuerier struct {
+	queriers []tsdb.Querier
+	// Whether the time ranges of the blocks overlap.
+	overlapping bool
+}
+
+func (q *blocksQuerier) Select(ms ...labels.Matcher) (tsdb.SeriesSet, error) {
+	if len(q.queriers) == 0 {
+		return tsdb.EmptySeriesSet(), nil
+	}
+	set, err := q.queriers[0].Select(ms...)
+	if err != nil {
+		return nil, err
+	}
+	for _, bq := range q.queriers[1:] {
+		s, err := bq.Select(ms...)
+		if err != nil {
+			return nil, err
+		}
+		if q.overlapping {
+			set = tsdb.NewMergedVerticalSeriesSet(set, s)
+		} else {
+			set = tsdb.NewMergedSeriesSet(set, s)
+		}
+	}
+	return set, nil
+}
+
+func (q *blocksQuerier) LabelValues(name string) ([]string, error) {
+	return q.mergeStrings(func(bq tsdb.Querier) ([]string, error) { return bq.LabelValues(name) })
+}
+
+func (q *blocksQuerier) LabelValuesFor(string, labels.Label) ([]string, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (q *blocksQuerier) LabelNames() ([]string, error) {
+	return q.mergeStrings(func(bq tsdb.Querier) ([]string, error) { return bq.LabelNames() })
+}
+
+// mergeStrings returns the sorted union of the strings returned by f for all block queriers.
+func (q *blocksQuerier) mergeStrings(f func(bq tsdb.Querier) ([]string, error)) ([]string, error) {
+	set := map[string]struct{}{}
+	for _, bq := range q.queriers {
+		vals, err := f(bq)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vals {
+			set[v] = struct{}{}
+		}
+	}
+	res := make([]string, 0, len(set))
+	for v := range set {
+		res = append(res, v)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func (q *blocksQuerier) Close() error {
+	var merr tsdberrors.MultiError
+	for _, bq := range q.queriers {
+		merr.Add(bq.Close())
+	}
+	return merr.Err()
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fortytw2/leaktest"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/tsdb/labels"
+	"github.com/thanos-io/thanos/pkg/component"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"github.com/thanos-io/thanos/pkg/testutil"
+)
+
+func TestTSDBBlocks(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "tsdb-blocks-test")
+	testutil.Ok(t, err)
+	defer func() { testutil.Ok(t, os.RemoveAll(dir)) }()
+
+	createBlock := func(series []labels.Labels, mint, maxt int64) ulid.ULID {
+		id, err := testutil.CreateBlock(ctx, dir, series, 10, mint, maxt, nil, 0)
+		testutil.Ok(t, err)
+		return id
+	}
+	id1 := createBlock([]labels.Labels{labels.FromStrings("a", "1"), labels.FromStrings("a", "2")}, 0, 1000)
+	createBlock([]labels.Labels{labels.FromStrings("a", "2"), labels.FromStrings("a", "3")}, 1000, 2000)
+
+	// Blocks still being written and other files are ignored.
+	testutil.Ok(t, os.Mkdir(filepath.Join(dir, ulid.MustNew(1, nil).String()+".tmp"), os.ModePerm))
+	testutil.Ok(t, ioutil.WriteFile(filepath.Join(dir, "lock"), nil, os.ModePerm))
+
+	blocks := NewTSDBBlocks(nil, dir)
+	defer func() { testutil.Ok(t, blocks.Close()) }()
+
+	store := NewTSDBStore(nil, nil, blocks, component.Sidecar, labels.FromStrings("region", "eu-west"))
+	// seriesSamples returns the number of samples of each series within the time range by their value of label a.
+	seriesSamples := func(mint, maxt int64) map[string]int {
+		srv := newStoreSeriesServer(ctx)
+		testutil.Ok(t, store.Series(&storepb.SeriesRequest{
+			MinTime:  mint,
+			MaxTime:  maxt,
+			Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: ".+"}},
+		}, srv))
+
+		res := map[string]int{}
+		for _, s := range srv.SeriesSet {
+			testutil.Equals(t, "eu-west", s.Labels[1].Value)
+			for _, c := range s.Chunks {
+				chk, err := chunkenc.FromData(chunkenc.EncXOR, c.Raw.Data)
+				testutil.Ok(t, err)
+				res[s.Labels[0].Value] += chk.NumSamples()
+			}
+		}
+		return res
+	}
+
+	// Nothing is served before the first sync.
+	testutil.Equals(t, map[string]int{}, seriesSamples(0, 2000))
+
+	testutil.Ok(t, blocks.Sync())
+	testutil.Equals(t, 2, len(blocks.Blocks()))
+	testutil.Equals(t, map[string]int{"1": 10, "2": 20, "3": 10}, seriesSamples(0, 2000))
+	testutil.Equals(t, map[string]int{"2": 10, "3": 10}, seriesSamples(1000, 2000))
+
+	vals, err := store.LabelValues(ctx, &storepb.LabelValuesRequest{Label: "a"})
+	testutil.Ok(t, err)
+	testutil.Equals(t, []string{"1", "2", "3"}, vals.Values)
+
+	// Samples of overlapping blocks are merged.
+	createBlock([]labels.Labels{labels.FromStrings("a", "2")}, 500, 1500)
+	testutil.Ok(t, blocks.Sync())
+	testutil.Equals(t, 3, len(blocks.Blocks()))
+	testutil.Equals(t, map[string]int{"1": 10, "2": 30, "3": 10}, seriesSamples(0, 2000))
+
+	// Blocks removed from the directory are not served anymore.
+	testutil.Ok(t, os.RemoveAll(filepath.Join(dir, id1.String())))
+	testutil.Ok(t, blocks.Sync())
+	testutil.Equals(t, 2, len(blocks.Blocks()))
+	testutil.Equals(t, map[string]int{"2": 20, "3": 10}, seriesSamples(0, 2000))
+}
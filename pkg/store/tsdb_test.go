@@ -2,11 +2,13 @@ package store
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"testing"
 	"time"
 
 	"github.com/fortytw2/leaktest"
+	"github.com/prometheus/tsdb"
 	"github.com/prometheus/tsdb/labels"
 	"github.com/thanos-io/thanos/pkg/component"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
@@ -48,3 +50,290 @@ func TestTSDBStore_Series_SplitSamplesIntoChunksWithMaxSizeOfUint16_e2e(t *testi
 		return tsdbStore
 	})
 }
+
+func TestTSDBStore_LabelNamesAndValues(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := testutil.NewTSDB()
+	defer func() { testutil.Ok(t, db.Close()) }()
+	testutil.Ok(t, err)
+
+	app := db.Appender()
+	for _, s := range []struct {
+		lset labels.Labels
+		t    int64
+	}{
+		{lset: labels.FromStrings("a", "1", "b", "1"), t: 100},
+		{lset: labels.FromStrings("a", "2", "c", "1"), t: 200},
+		{lset: labels.FromStrings("a", "3", "d", "1"), t: 1500},
+	} {
+		_, err := app.Add(s.lset, s.t, 1)
+		testutil.Ok(t, err)
+	}
+	testutil.Ok(t, app.Commit())
+
+	tsdbStore := NewTSDBStore(nil, nil, db, component.Rule, labels.FromStrings("region", "eu-west"))
+
+	for _, tcase := range []struct {
+		name           string
+		start, end     int64
+		matchers       []storepb.LabelMatcher
+		expectedNames  []string
+		expectedValues []string
+	}{
+		{
+			name:           "no filters",
+			expectedNames:  []string{"a", "b", "c", "d"},
+			expectedValues: []string{"1", "2", "3"},
+		},
+		{
+			name:           "matchers",
+			matchers:       []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: "1|3"}},
+			expectedNames:  []string{"a", "b", "d"},
+			expectedValues: []string{"1", "3"},
+		},
+		{
+			name:           "time range and matchers",
+			start:          1000,
+			end:            2000,
+			matchers:       []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: "1|3"}},
+			expectedNames:  []string{"a", "d"},
+			expectedValues: []string{"3"},
+		},
+		{
+			name:           "external label matchers",
+			matchers:       []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "region", Value: "eu-west"}},
+			expectedNames:  []string{"a", "b", "c", "d"},
+			expectedValues: []string{"1", "2", "3"},
+		},
+		{
+			name:           "no match",
+			matchers:       []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "region", Value: "us-east"}},
+			expectedNames:  []string{},
+			expectedValues: []string{},
+		},
+	} {
+		t.Run(tcase.name, func(t *testing.T) {
+			names, err := tsdbStore.LabelNames(ctx, &storepb.LabelNamesRequest{Start: tcase.start, End: tcase.end, Matchers: tcase.matchers})
+			testutil.Ok(t, err)
+			testutil.Equals(t, tcase.expectedNames, names.Names)
+
+			vals, err := tsdbStore.LabelValues(ctx, &storepb.LabelValuesRequest{Label: "a", Start: tcase.start, End: tcase.end, Matchers: tcase.matchers})
+			testutil.Ok(t, err)
+			testutil.Equals(t, tcase.expectedValues, vals.Values)
+		})
+	}
+}
+
+func TestTSDBStore_Series_SkipChunks(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := testutil.NewTSDB()
+	defer func() { testutil.Ok(t, db.Close()) }()
+	testutil.Ok(t, err)
+
+	app := db.Appender()
+	for _, s := range []struct {
+		lset labels.Labels
+		t    int64
+	}{
+		{lset: labels.FromStrings("a", "1"), t: 100},
+		{lset: labels.FromStrings("a", "2"), t: 1500},
+	} {
+		_, err := app.Add(s.lset, s.t, 1)
+		testutil.Ok(t, err)
+	}
+	testutil.Ok(t, app.Commit())
+
+	tsdbStore := NewTSDBStore(nil, nil, db, component.Rule, labels.FromStrings("region", "eu-west"))
+
+	srv := newStoreSeriesServer(ctx)
+	testutil.Ok(t, tsdbStore.Series(&storepb.SeriesRequest{
+		MinTime:    1000,
+		MaxTime:    2000,
+		Matchers:   []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: ".+"}},
+		SkipChunks: true,
+	}, srv))
+	testutil.Equals(t, []storepb.Series{
+		{Labels: []storepb.Label{{Name: "a", Value: "2"}, {Name: "region", Value: "eu-west"}}},
+	}, srv.SeriesSet)
+}
+
+type selectRecordingQuerier struct {
+	tsdb.Querier
+	selects [][]labels.Matcher
+}
+
+func (q *selectRecordingQuerier) Select(ms ...labels.Matcher) (tsdb.SeriesSet, error) {
+	q.selects = append(q.selects, ms)
+	return q.Querier.Select(ms...)
+}
+
+func TestSelectSeries_SetMatchers(t *testing.T) {
+	defer leaktest.CheckTimeout(t, 10*time.Second)()
+
+	db, err := testutil.NewTSDB()
+	defer func() { testutil.Ok(t, db.Close()) }()
+	testutil.Ok(t, err)
+
+	app := db.Appender()
+	for i := 0; i < 20; i++ {
+		for _, lset := range []labels.Labels{
+			labels.FromStrings("a", fmt.Sprintf("%d", i), "b", "x"),
+			labels.FromStrings("a", fmt.Sprintf("%d", i), "b", "y"),
+			labels.FromStrings("b", fmt.Sprintf("%d", i)),
+		} {
+			_, err := app.Add(lset, 1, 1)
+			testutil.Ok(t, err)
+		}
+	}
+	testutil.Ok(t, app.Commit())
+
+	for _, c := range []struct {
+		name          string
+		matchers      []storepb.LabelMatcher
+		equalSelects  int
+		rewrittenOnly bool
+	}{
+		{
+			name:          "set",
+			matchers:      []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: "1|13|7|99"}},
+			equalSelects:  4,
+			rewrittenOnly: true,
+		},
+		{
+			name: "two sets",
+			matchers: []storepb.LabelMatcher{
+				{Type: storepb.LabelMatcher_RE, Name: "a", Value: "1[0-2]"},
+				{Type: storepb.LabelMatcher_RE, Name: "b", Value: "x|y"},
+			},
+			equalSelects:  6,
+			rewrittenOnly: true,
+		},
+		{
+			name: "set and not equal",
+			matchers: []storepb.LabelMatcher{
+				{Type: storepb.LabelMatcher_RE, Name: "a", Value: "1|2"},
+				{Type: storepb.LabelMatcher_NEQ, Name: "b", Value: "x"},
+			},
+			equalSelects: 2,
+		},
+		{
+			// Also matches series without the label, which cannot be looked up by value.
+			name:     "set matching empty",
+			matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: "|1"}},
+		},
+		{
+			name:     "negated set",
+			matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_NRE, Name: "a", Value: "1|2"}},
+		},
+		{
+			name:     "too many values",
+			matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: "a", Value: "[0-9][0-9][0-9]"}},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			matchers, err := translateMatchers(c.matchers)
+			testutil.Ok(t, err)
+
+			var plain []labels.Matcher
+			for _, m := range c.matchers {
+				pm, err := labels.NewRegexpMatcher(m.Name, "^(?:"+m.Value+")$")
+				testutil.Ok(t, err)
+				switch m.Type {
+				case storepb.LabelMatcher_NEQ:
+					plain = append(plain, labels.Not(labels.NewEqualMatcher(m.Name, m.Value)))
+				case storepb.LabelMatcher_NRE:
+					plain = append(plain, labels.Not(pm))
+				default:
+					plain = append(plain, pm)
+				}
+			}
+
+			q, err := db.Querier(0, 10)
+			testutil.Ok(t, err)
+			defer func() { testutil.Ok(t, q.Close()) }()
+
+			rq := &selectRecordingQuerier{Querier: q}
+			set, err := selectSeries(rq, matchers)
+			testutil.Ok(t, err)
+			expectedSet, err := q.Select(plain...)
+			testutil.Ok(t, err)
+
+			var got, expected []labels.Labels
+			for set.Next() {
+				got = append(got, set.At().Labels())
+			}
+			testutil.Ok(t, set.Err())
+			for expectedSet.Next() {
+				expected = append(expected, expectedSet.At().Labels())
+			}
+			testutil.Ok(t, expectedSet.Err())
+			testutil.Equals(t, expected, got)
+
+			if c.equalSelects == 0 {
+				testutil.Equals(t, [][]labels.Matcher{matchers}, rq.selects)
+				return
+			}
+			testutil.Equals(t, c.equalSelects, len(rq.selects))
+			if !c.rewrittenOnly {
+				return
+			}
+			for _, ms := range rq.selects {
+				for _, m := range ms {
+					_, ok := m.(*labels.EqualMatcher)
+					testutil.Assert(t, ok, "expected only equality matchers, got %v", m)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSelectSeries_SetMatchers(b *testing.B) {
+	db, err := testutil.NewTSDB()
+	defer func() { testutil.Ok(b, db.Close()) }()
+	testutil.Ok(b, err)
+
+	app := db.Appender()
+	for i := 0; i < 100000; i++ {
+		_, err := app.Add(labels.FromStrings("n", fmt.Sprintf("value-%06d", i)), 1, 1)
+		testutil.Ok(b, err)
+	}
+	testutil.Ok(b, app.Commit())
+
+	q, err := db.Querier(0, 10)
+	testutil.Ok(b, err)
+	defer func() { testutil.Ok(b, q.Close()) }()
+
+	const value = "value-000001|value-050000|value-099999"
+	optimized, err := translateMatcher(storepb.LabelMatcher{Type: storepb.LabelMatcher_RE, Name: "n", Value: value})
+	testutil.Ok(b, err)
+	plain, err := labels.NewRegexpMatcher("n", "^(?:"+value+")$")
+	testutil.Ok(b, err)
+
+	run := func(b *testing.B, sel func() (tsdb.SeriesSet, error)) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			set, err := sel()
+			testutil.Ok(b, err)
+			n := 0
+			for set.Next() {
+				n++
+			}
+			testutil.Ok(b, set.Err())
+			testutil.Equals(b, 3, n)
+		}
+	}
+	b.Run("regexp", func(b *testing.B) {
+		run(b, func() (tsdb.SeriesSet, error) { return q.Select(plain) })
+	})
+	b.Run("optimized", func(b *testing.B) {
+		run(b, func() (tsdb.SeriesSet, error) { return selectSeries(q, []labels.Matcher{optimized}) })
+	})
+}
@@ -0,0 +1,181 @@
+package testutil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// FakeMemcachedServer is an in-process server implementing the subset of the memcached text protocol
+// used by cacheutil.MemcachedClient. Items never expire.
+type FakeMemcachedServer struct {
+	l         net.Listener
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
+
+	mtx    sync.Mutex
+	items  map[string][]byte
+	conns  map[net.Conn]struct{}
+	gets   int
+	closed bool
+}
+
+// NewFakeMemcachedServer starts a new fake memcached server listening on a random local port.
+func NewFakeMemcachedServer() (*FakeMemcachedServer, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.Wrap(err, "listen")
+	}
+	s := &FakeMemcachedServer{
+		l:     l,
+		items: map[string][]byte{},
+		conns: map[net.Conn]struct{}{},
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.mtx.Lock()
+			if s.closed {
+				s.mtx.Unlock()
+				_ = c.Close()
+				return
+			}
+			s.conns[c] = struct{}{}
+			s.mtx.Unlock()
+
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serve(c)
+			}()
+		}
+	}()
+	return s, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *FakeMemcachedServer) Addr() string {
+	return s.l.Addr().String()
+}
+
+// Items returns a copy of all stored items.
+func (s *FakeMemcachedServer) Items() map[string][]byte {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	items := make(map[string][]byte, len(s.items))
+	for k, v := range s.items {
+		items[k] = v
+	}
+	return items
+}
+
+// Gets returns the number of get commands received so far.
+func (s *FakeMemcachedServer) Gets() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.gets
+}
+
+// Close stops the server and closes all open connections. It is safe to call it multiple times.
+func (s *FakeMemcachedServer) Close() error {
+	s.closeOnce.Do(func() {
+		s.closeErr = s.l.Close()
+
+		s.mtx.Lock()
+		s.closed = true
+		for c := range s.conns {
+			_ = c.Close()
+		}
+		s.mtx.Unlock()
+
+		s.wg.Wait()
+	})
+	return s.closeErr
+}
+
+func (s *FakeMemcachedServer) serve(c net.Conn) {
+	defer func() {
+		s.mtx.Lock()
+		delete(s.conns, c)
+		s.mtx.Unlock()
+		_ = c.Close()
+	}()
+
+	rw := bufio.NewReadWriter(bufio.NewReader(c), bufio.NewWriter(c))
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			fmt.Fprint(rw, "ERROR\r\n")
+		} else {
+			switch fields[0] {
+			case "get", "gets":
+				s.get(rw, fields[1:])
+			case "set":
+				if err := s.set(rw, fields[1:]); err != nil {
+					return
+				}
+			default:
+				fmt.Fprint(rw, "ERROR\r\n")
+			}
+		}
+		if err := rw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *FakeMemcachedServer) get(w io.Writer, keys []string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.gets++
+	for _, k := range keys {
+		if v, ok := s.items[k]; ok {
+			fmt.Fprintf(w, "VALUE %s 0 %d\r\n%s\r\n", k, len(v), v)
+		}
+	}
+	fmt.Fprint(w, "END\r\n")
+}
+
+func (s *FakeMemcachedServer) set(rw *bufio.ReadWriter, args []string) error {
+	// set <key> <flags> <exptime> <bytes>
+	if len(args) < 4 {
+		fmt.Fprint(rw, "CLIENT_ERROR bad command line format\r\n")
+		return nil
+	}
+	size, err := strconv.Atoi(args[3])
+	if err != nil {
+		fmt.Fprint(rw, "CLIENT_ERROR bad command line format\r\n")
+		return nil
+	}
+	value := make([]byte, size+2)
+	if _, err := io.ReadFull(rw, value); err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	s.items[args[0]] = value[:size]
+	s.mtx.Unlock()
+
+	fmt.Fprint(rw, "STORED\r\n")
+	return nil
+}
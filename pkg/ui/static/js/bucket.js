@@ -52,7 +52,7 @@ function draw() {
                     }
                 }();
 
-                label = `l: ${d.compaction.level}, res: ${d.thanos.downsample.resolution}`;
+                label = `l: ${d.compaction.level}, res: ${d.thanos.downsample.resolution}, size: ${humanizeBytes(d.size)}`;
                 return [title, label, new Date(d.minTime), new Date(d.maxTime)];
             }));
 
@@ -69,6 +69,16 @@ function draw() {
     }
 }
 
+function humanizeBytes(bytes) {
+    var units = ["B", "KiB", "MiB", "GiB", "TiB", "PiB"];
+    var i = 0;
+    while (bytes >= 1024 && i < units.length - 1) {
+        bytes /= 1024;
+        i++;
+    }
+    return i == 0 ? `${bytes} ${units[i]}` : `${bytes.toFixed(1)} ${units[i]}`;
+}
+
 function stringify(map) {
     var t = "";
     for (let [key, value] of Object.entries(map)) {
@@ -0,0 +1,21 @@
+$(function () {
+    $(".block-action").click(function () {
+        var block = $(this).data("block");
+        var action = $(this).data("action");
+        if (action === "drop" && !confirm("Drop block " + block + "? It is not synced again until it is resynced.")) {
+            return;
+        }
+
+        $(".block-action").prop("disabled", true);
+        $.ajax({
+            method: "POST",
+            url: PATH_PREFIX + "/api/v1/blocks/" + block + "/" + action,
+        }).done(function () {
+            location.reload();
+        }).fail(function (xhr) {
+            var msg = xhr.responseJSON ? xhr.responseJSON.error : xhr.statusText;
+            $("#err").text("Failed to " + action + " block " + block + ": " + msg).show();
+            $(".block-action").prop("disabled", false);
+        });
+    });
+});
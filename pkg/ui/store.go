@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/common/route"
+	extpromhttp "github.com/thanos-io/thanos/pkg/extprom/http"
+	"github.com/thanos-io/thanos/pkg/store"
+)
+
+// BlocksLister lists the blocks served by a store.
+type BlocksLister interface {
+	Blocks() []store.BlockInfo
+}
+
+// Store is a web UI listing the blocks loaded by a store gateway.
+type Store struct {
+	*BaseUI
+
+	blocks      BlocksLister
+	enableAdmin bool
+}
+
+// NewStoreUI returns the store UI. The actions to drop and resync blocks are only shown if enableAdmin is true.
+func NewStoreUI(logger log.Logger, blocks BlocksLister, enableAdmin bool) *Store {
+	return &Store{
+		BaseUI:      NewBaseUI(logger, "store_menu.html", storeTmplFuncs()),
+		blocks:      blocks,
+		enableAdmin: enableAdmin,
+	}
+}
+
+func storeTmplFuncs() template.FuncMap {
+	funcs := queryTmplFuncs()
+	funcs["humanizeBytes"] = humanizeBytes
+	funcs["formatTime"] = func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format(time.RFC3339)
+	}
+	return funcs
+}
+
+// humanizeBytes formats the number of bytes with a binary unit prefix.
+func humanizeBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+// Register registers http routes for the store UI.
+func (s *Store) Register(r *route.Router, ins extpromhttp.InstrumentationMiddleware) {
+	instrf := func(name string, next func(w http.ResponseWriter, r *http.Request)) http.HandlerFunc {
+		return ins.NewHandler(name, http.HandlerFunc(next))
+	}
+
+	r.Get("/", instrf("root", s.root))
+	r.Get("/blocks", instrf("blocks", s.blocksPage))
+	r.Get("/static/*filepath", instrf("static", s.serveStaticAsset))
+}
+
+// root redirects / requests to /blocks.
+func (s *Store) root(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, path.Join(r.URL.Path, "blocks"), http.StatusFound)
+}
+
+func (s *Store) blocksPage(w http.ResponseWriter, r *http.Request) {
+	s.executeTemplate(w, "store.html", "", struct {
+		Blocks      []store.BlockInfo
+		EnableAdmin bool
+	}{
+		Blocks:      s.blocks.Blocks(),
+		EnableAdmin: s.enableAdmin,
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/objstore/azure"
 	"github.com/thanos-io/thanos/pkg/objstore/client"
 	"github.com/thanos-io/thanos/pkg/objstore/cos"
+	"github.com/thanos-io/thanos/pkg/objstore/filesystem"
 	"github.com/thanos-io/thanos/pkg/objstore/gcs"
 	"github.com/thanos-io/thanos/pkg/objstore/s3"
 	"github.com/thanos-io/thanos/pkg/objstore/swift"
@@ -24,11 +25,12 @@ import (
 
 var (
 	configs = map[client.ObjProvider]interface{}{
-		client.AZURE: azure.Config{},
-		client.GCS:   gcs.Config{},
-		client.S3:    s3.Config{},
-		client.SWIFT: swift.SwiftConfig{},
-		client.COS:   cos.Config{},
+		client.AZURE:      azure.Config{},
+		client.GCS:        gcs.Config{},
+		client.S3:         s3.Config{},
+		client.SWIFT:      swift.SwiftConfig{},
+		client.COS:        cos.Config{},
+		client.FILESYSTEM: filesystem.Config{},
 	}
 )
 